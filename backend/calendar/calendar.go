@@ -0,0 +1,62 @@
+// backend/calendar/calendar.go
+
+// Package calendar counts business days for time-based policies and writes
+// iCalendar feeds.
+package calendar
+
+import "time"
+
+// Calendar tells business days from days off.
+type Calendar struct {
+	// Weekend lists the weekdays that are not business days.
+	Weekend []time.Weekday `json:"weekend"`
+	// Holidays are further days off, as dates in the form 2006-01-02.
+	Holidays []string `json:"holidays"`
+	// Location is the time zone the days are counted in; nil means UTC.
+	Location *time.Location `json:"-"`
+}
+
+// Default returns a calendar with Saturday and Sunday off.
+func Default() Calendar {
+	return Calendar{
+		Weekend: []time.Weekday{time.Saturday, time.Sunday},
+	}
+}
+
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.UTC
+	}
+	return c.Location
+}
+
+// IsBusinessDay reports whether the day t falls on is a business day.
+func (c Calendar) IsBusinessDay(t time.Time) bool {
+	t = t.In(c.location())
+	for _, d := range c.Weekend {
+		if t.Weekday() == d {
+			return false
+		}
+	}
+	date := t.Format("2006-01-02")
+	for _, h := range c.Holidays {
+		if h == date {
+			return false
+		}
+	}
+	return true
+}
+
+// AddBusinessDays returns the time n business days after t, at the same
+// time of day. Days off in between are skipped, so two business days after
+// Friday noon is Tuesday noon with the default calendar.
+func (c Calendar) AddBusinessDays(t time.Time, n int) time.Time {
+	t = t.In(c.location())
+	for n > 0 {
+		t = t.AddDate(0, 0, 1)
+		if c.IsBusinessDay(t) {
+			n--
+		}
+	}
+	return t
+}
@@ -0,0 +1,102 @@
+// backend/calendar/ical.go
+
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Statuses of an Event, as iCalendar names them.
+const (
+	EventTentative = "TENTATIVE"
+	EventConfirmed = "CONFIRMED"
+	EventCancelled = "CANCELLED"
+)
+
+// Event is an entry of an iCalendar feed. UID must stay the same for the
+// same entry across feeds so calendar clients update it in place.
+type Event struct {
+	UID         string
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	URL         string
+	Status      string
+	Updated     time.Time
+}
+
+// WriteICal writes events as an iCalendar (RFC 5545) feed named name.
+func WriteICal(w io.Writer, name string, events []Event) error {
+	b := &icalBuilder{}
+	b.line("BEGIN:VCALENDAR")
+	b.line("VERSION:2.0")
+	b.line("PRODID:-//shuttlersit//service-desk//EN")
+	b.line("CALSCALE:GREGORIAN")
+	b.line("METHOD:PUBLISH")
+	b.property("X-WR-CALNAME", name)
+	for _, e := range events {
+		b.line("BEGIN:VEVENT")
+		b.property("UID", e.UID)
+		b.line("DTSTAMP:" + icalTime(e.Updated))
+		b.line("DTSTART:" + icalTime(e.Start))
+		b.line("DTEND:" + icalTime(e.End))
+		b.property("SUMMARY", e.Summary)
+		if e.Description != "" {
+			b.property("DESCRIPTION", e.Description)
+		}
+		if e.Location != "" {
+			b.property("LOCATION", e.Location)
+		}
+		if e.URL != "" {
+			b.line("URL:" + e.URL)
+		}
+		if e.Status != "" {
+			b.line("STATUS:" + e.Status)
+		}
+		b.line("END:VEVENT")
+	}
+	b.line("END:VCALENDAR")
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func icalTime(t time.Time) string {
+	if t.IsZero() {
+		t = time.Now()
+	}
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalEscaper escapes text property values.
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`)
+
+type icalBuilder struct {
+	strings.Builder
+}
+
+func (b *icalBuilder) property(name, value string) {
+	b.line(fmt.Sprintf("%s:%s", name, icalEscaper.Replace(value)))
+}
+
+// line writes a content line, folded so that no line is longer than 75
+// octets, without splitting a UTF-8 character.
+func (b *icalBuilder) line(s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
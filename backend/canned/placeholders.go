@@ -0,0 +1,83 @@
+// backend/canned/placeholders.go
+
+// Package canned fills in the placeholders of canned responses, such as
+// {{user.first_name}} or {{ticket.number}}. Unlike the Go templates of
+// notifications and rules, placeholders are plain names that agents can
+// type without knowing the data model.
+package canned
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+var placeholder = regexp.MustCompile(`\{\{\s*([a-z_]+\.[a-z_]+)\s*\}\}`)
+
+// Data is what placeholders are filled in from. Agent is the agent sending
+// the response, not necessarily the one assigned to the ticket.
+type Data struct {
+	Ticket *models.Ticket
+	User   models.Users
+	Agent  models.Agents
+}
+
+var values = map[string]func(d Data) string{
+	"ticket.number":    func(d Data) string { return strconv.FormatUint(uint64(d.Ticket.ID), 10) },
+	"ticket.subject":   func(d Data) string { return d.Ticket.Subject },
+	"ticket.status":    func(d Data) string { return d.Ticket.Status.StatusName },
+	"ticket.priority":  func(d Data) string { return d.Ticket.Priority.Name },
+	"ticket.category":  func(d Data) string { return d.Ticket.Category.CategoryName },
+	"user.first_name":  func(d Data) string { return d.User.FirstName },
+	"user.last_name":   func(d Data) string { return d.User.LastName },
+	"user.full_name":   func(d Data) string { return strings.TrimSpace(d.User.FirstName + " " + d.User.LastName) },
+	"user.email":       func(d Data) string { return d.User.Email },
+	"agent.first_name": func(d Data) string { return d.Agent.FirstName },
+	"agent.last_name":  func(d Data) string { return d.Agent.LastName },
+	"agent.full_name":  func(d Data) string { return strings.TrimSpace(d.Agent.FirstName + " " + d.Agent.LastName) },
+	"agent.email":      func(d Data) string { return d.Agent.AgentEmail },
+	"agent.unit":       func(d Data) string { return d.Agent.Unit.UnitName },
+}
+
+// Placeholders lists the supported placeholder names.
+func Placeholders() []string {
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ErrUnknownPlaceholder is returned by Validate.
+var ErrUnknownPlaceholder = errors.New("unknown placeholder")
+
+// Validate checks that text only uses supported placeholders.
+func Validate(text string) error {
+	for _, match := range placeholder.FindAllStringSubmatch(text, -1) {
+		if _, ok := values[match[1]]; !ok {
+			return fmt.Errorf("%w {{%s}}", ErrUnknownPlaceholder, match[1])
+		}
+	}
+	return nil
+}
+
+// Render fills in the placeholders of text. Unknown placeholders are left
+// as they are.
+func Render(text string, data Data) string {
+	if data.Ticket == nil {
+		data.Ticket = &models.Ticket{}
+	}
+	return placeholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := placeholder.FindStringSubmatch(match)[1]
+		if value, ok := values[name]; ok {
+			return value(data)
+		}
+		return match
+	})
+}
@@ -0,0 +1,194 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"github.com/shuttlersit/service-desk/backend/storage"
+	"gorm.io/gorm"
+)
+
+type ApprovalController struct {
+	ApprovalService *services.DefaultApprovalService
+}
+
+func NewApprovalController(approvalService *services.DefaultApprovalService) *ApprovalController {
+	return &ApprovalController{
+		ApprovalService: approvalService,
+	}
+}
+
+// approvalDecision is the body of a decision on an approval.
+type approvalDecision struct {
+	Comment string `json:"comment"`
+}
+
+// GetPendingApprovals handles GET /approvals, the approvals awaiting the
+// decision of the signed-in user.
+func (ac *ApprovalController) GetPendingApprovals(ctx *gin.Context) {
+	approvals, err := ac.ApprovalService.GetPendingApprovals(ctx.GetUint("userID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve approvals"})
+		return
+	}
+	ctx.JSON(http.StatusOK, approvals)
+}
+
+// GetPrompt handles GET /approvals/:id/:decision?expires=&signature=, the
+// approval an emailed link is about. It does not decide, so that opening
+// the link is safe; the decision is confirmed with a POST to the same URL.
+func (ac *ApprovalController) GetPrompt(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	prompt, err := ac.ApprovalService.GetPrompt(uint(id), ctx.Param("decision"), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		approvalError(ctx, err, "Failed to retrieve approval")
+		return
+	}
+	ctx.JSON(http.StatusOK, prompt)
+}
+
+// Decide handles POST /approvals/:id/approve and /approvals/:id/reject with
+// an optional comment. The decision is taken through the signed link of the
+// approval email when it has a signature, for the signed-in user otherwise.
+func (ac *ApprovalController) Decide(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var body approvalDecision
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&body); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	decision := ctx.Param("decision")
+	var request *models.ApprovalRequest
+	if signature := ctx.Query("signature"); signature != "" {
+		request, err = ac.ApprovalService.DecideByLink(uint(id), decision, ctx.Query("expires"), signature, body.Comment)
+	} else {
+		request, err = ac.ApprovalService.Decide(ctx.GetUint("userID"), uint(id), decision, body.Comment)
+	}
+	if err != nil {
+		approvalError(ctx, err, "Failed to record decision")
+		return
+	}
+	ctx.JSON(http.StatusOK, request)
+}
+
+// GetTicketApproval handles GET /tickets/:id/approval, the progress of the
+// approval of a ticket.
+func (ac *ApprovalController) GetTicketApproval(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	request, err := ac.ApprovalService.GetTicketApproval(uint(id))
+	if err != nil {
+		approvalError(ctx, err, "Failed to retrieve approval")
+		return
+	}
+	ctx.JSON(http.StatusOK, request)
+}
+
+// GetChains handles GET /admin/approval-chains.
+func (ac *ApprovalController) GetChains(ctx *gin.Context) {
+	chains, err := ac.ApprovalService.GetChains()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve approval chains"})
+		return
+	}
+	ctx.JSON(http.StatusOK, chains)
+}
+
+// CreateChain handles POST /admin/approval-chains.
+func (ac *ApprovalController) CreateChain(ctx *gin.Context) {
+	var chain models.ApprovalChain
+	if err := ctx.ShouldBindJSON(&chain); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := ac.ApprovalService.CreateChain(&chain); err != nil {
+		approvalError(ctx, err, "Failed to create approval chain")
+		return
+	}
+	ctx.JSON(http.StatusCreated, chain)
+}
+
+// GetChain handles GET /admin/approval-chains/:id.
+func (ac *ApprovalController) GetChain(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	chain, err := ac.ApprovalService.GetChain(uint(id))
+	if err != nil {
+		approvalError(ctx, err, "Failed to retrieve approval chain")
+		return
+	}
+	ctx.JSON(http.StatusOK, chain)
+}
+
+// UpdateChain handles PUT /admin/approval-chains/:id.
+func (ac *ApprovalController) UpdateChain(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var chain models.ApprovalChain
+	if err := ctx.ShouldBindJSON(&chain); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	chain.ID = uint(id)
+	updated, err := ac.ApprovalService.UpdateChain(&chain)
+	if err != nil {
+		approvalError(ctx, err, "Failed to update approval chain")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteChain handles DELETE /admin/approval-chains/:id.
+func (ac *ApprovalController) DeleteChain(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := ac.ApprovalService.DeleteChain(uint(id))
+	if err != nil {
+		approvalError(ctx, err, "Failed to delete approval chain")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+func approvalError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidApprovalChain), errors.Is(err, services.ErrInvalidDecision):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrApprovalDecided):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNotApprover), errors.Is(err, storage.ErrInvalidSignature):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrLinkExpired):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"github.com/shuttlersit/service-desk/backend/storage"
+	"gorm.io/gorm"
+)
+
+type AttachmentController struct {
+	AttachmentService *services.DefaultAttachmentService
+}
+
+func NewAttachmentController(attachmentService *services.DefaultAttachmentService) *AttachmentController {
+	return &AttachmentController{
+		AttachmentService: attachmentService,
+	}
+}
+
+// UploadAttachments handles POST /tickets/:id/attachments. The request is a
+// multipart form with one or more "file" parts and optional caption, alt_text
+// and is_primary fields applied to every file.
+func (pc *AttachmentController) UploadAttachments(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, pc.AttachmentService.Config.MaxSize*10+(1<<20))
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+	isPrimary, _ := strconv.ParseBool(ctx.PostForm("is_primary"))
+
+	status := http.StatusOK
+	var attachments []*models.TicketMediaAttachment
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		attachment, created, err := pc.AttachmentService.UploadAttachment(ctx.Request.Context(), uint(ticketID), &services.AttachmentUpload{
+			FileName:   fh.Filename,
+			Reader:     f,
+			Caption:    ctx.PostForm("caption"),
+			AltText:    ctx.PostForm("alt_text"),
+			IsPrimary:  isPrimary,
+			UploadedBy: ctx.GetUint("userID"),
+		})
+		f.Close()
+		if err != nil {
+			ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error(), "file": fh.Filename})
+			return
+		}
+		if created {
+			status = http.StatusCreated
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	ctx.JSON(status, attachments)
+}
+
+// GetAttachments handles GET /tickets/:id/attachments.
+func (pc *AttachmentController) GetAttachments(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	attachments, err := pc.AttachmentService.GetAttachmentsByTicket(uint(ticketID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, attachments)
+}
+
+// DeleteAttachment handles DELETE /tickets/:id/attachments/:attachment_id.
+func (pc *AttachmentController) DeleteAttachment(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	id, err := strconv.ParseUint(ctx.Param("attachment_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+	if err := pc.AttachmentService.DeleteAttachment(ctx.Request.Context(), uint(ticketID), uint(id)); err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// DownloadAttachment handles GET /attachments/:id/download using a signed link.
+func (pc *AttachmentController) DownloadAttachment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	attachment, body, err := pc.AttachmentService.OpenAttachment(ctx.Request.Context(), uint(id), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	disposition := "inline"
+	if attachment.Type == "document" {
+		disposition = "attachment"
+	}
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	})
+}
+
+// DownloadVariant handles GET /attachments/:id/variants/:name using a signed link.
+func (pc *AttachmentController) DownloadVariant(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	variant, body, err := pc.AttachmentService.OpenVariant(ctx.Request.Context(), uint(id), ctx.Param("name"), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	ctx.DataFromReader(http.StatusOK, variant.Size, variant.ContentType, body, map[string]string{
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	})
+}
+
+// GetQuarantinedAttachments handles GET /admin/attachments/quarantine.
+func (pc *AttachmentController) GetQuarantinedAttachments(ctx *gin.Context) {
+	attachments, err := pc.AttachmentService.GetQuarantinedAttachments()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, attachments)
+}
+
+// ReleaseAttachment handles POST /admin/attachments/:id/release.
+func (pc *AttachmentController) ReleaseAttachment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	attachment, err := pc.AttachmentService.ReleaseAttachment(ctx.Request.Context(), uint(id), ctx.GetUint("agentID"))
+	if err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, attachment)
+}
+
+// PurgeAttachment handles DELETE /admin/attachments/:id.
+func (pc *AttachmentController) PurgeAttachment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := pc.AttachmentService.PurgeAttachment(ctx.Request.Context(), uint(id)); err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// attachmentErrorStatus maps attachment service errors to HTTP status codes.
+func attachmentErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound), errors.Is(err, storage.ErrBlobNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrAttachmentTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, services.ErrAttachmentTypeNotAllowed), errors.Is(err, services.ErrAttachmentExtensionBlocked):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, services.ErrAttachmentQuarantined):
+		return http.StatusForbidden
+	case errors.Is(err, services.ErrAttachmentNotQuarantined):
+		return http.StatusConflict
+	case errors.Is(err, storage.ErrInvalidSignature):
+		return http.StatusForbidden
+	case errors.Is(err, storage.ErrLinkExpired):
+		return http.StatusGone
+	default:
+		return http.StatusInternalServerError
+	}
+}
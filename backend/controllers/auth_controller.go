@@ -1,9 +1,11 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/middleware"
 	"github.com/shuttlersit/service-desk/backend/models"
 	"github.com/shuttlersit/service-desk/backend/services"
 )
@@ -30,21 +32,40 @@ func (a *AuthController) Registration(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
+	if err := middleware.SetUserSession(c, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
 	c.JSON(http.StatusCreated, gin.H{"message": "User registered successfully", "token": token, "loggedInUser": newUser})
 }
 
 // User login
 func (a *AuthController) Login(c *gin.Context) {
-	var loginInfo *services.LoginInfo
-	loginInfo.Email = c.PostForm("email")
-	loginInfo.Password = c.PostForm("secret")
-	if err := c.BindJSON(&loginInfo); err != nil {
+	a.login(c, a.AuthService.Login, middleware.SetUserSession)
+}
+
+// AgentLogin signs an agent in on the session.
+func (a *AuthController) AgentLogin(c *gin.Context) {
+	a.login(c, a.AuthService.AgentLogin, middleware.SetAgentSession)
+}
+
+func (a *AuthController) login(c *gin.Context, login func(*services.LoginInfo) (string, error), setSession func(*gin.Context, string) error) {
+	var loginInfo services.LoginInfo
+	if err := c.ShouldBindJSON(&loginInfo); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	token, err := a.AuthService.Login(loginInfo)
+	token, err := login(&loginInfo)
+	if errors.Is(err, services.ErrInvalidLogin) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid email or password"})
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
+		return
+	}
+	if err := setSession(c, token); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to sign in"})
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"token": token})
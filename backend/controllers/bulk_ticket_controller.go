@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type BulkTicketController struct {
+	BulkTicketService *services.DefaultBulkTicketService
+}
+
+func NewBulkTicketController(bulkTicketService *services.DefaultBulkTicketService) *BulkTicketController {
+	return &BulkTicketController{
+		BulkTicketService: bulkTicketService,
+	}
+}
+
+// SubmitBulkJob handles POST /tickets/bulk. The operations run in the
+// background; the response is the queued job to poll.
+func (bc *BulkTicketController) SubmitBulkJob(ctx *gin.Context) {
+	var request services.BulkTicketRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	job, err := bc.BulkTicketService.Submit(ctx.GetUint("agentID"), request)
+	if err != nil {
+		bulkError(ctx, err, "Failed to queue bulk operation")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+// GetBulkJobs handles GET /tickets/bulk.
+func (bc *BulkTicketController) GetBulkJobs(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	jobs, err := bc.BulkTicketService.GetBulkJobs(limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+// GetBulkJob handles GET /tickets/bulk/:id.
+func (bc *BulkTicketController) GetBulkJob(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	job, err := bc.BulkTicketService.GetBulkJob(uint(id))
+	if err != nil {
+		bulkError(ctx, err, "Failed to retrieve bulk operation")
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// GetBulkResults handles GET /tickets/bulk/:id/results, optionally filtered
+// by ?status=succeeded|failed|skipped.
+func (bc *BulkTicketController) GetBulkResults(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	results, err := bc.BulkTicketService.GetResults(uint(id), ctx.Query("status"))
+	if err != nil {
+		bulkError(ctx, err, "Failed to retrieve bulk results")
+		return
+	}
+	ctx.JSON(http.StatusOK, results)
+}
+
+func bulkError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Bulk operation not found"})
+	case errors.Is(err, services.ErrInvalidBulkRequest):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrJobsDisabled):
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/canned"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type CannedResponseController struct {
+	CannedResponseService *services.DefaultCannedResponseService
+}
+
+func NewCannedResponseController(cannedResponseService *services.DefaultCannedResponseService) *CannedResponseController {
+	return &CannedResponseController{
+		CannedResponseService: cannedResponseService,
+	}
+}
+
+type applyMacroRequest struct {
+	TicketIDs []uint `json:"ticket_ids" binding:"required"`
+}
+
+// CreateResponse handles POST /canned-responses.
+func (cc *CannedResponseController) CreateResponse(ctx *gin.Context) {
+	var response models.CannedResponse
+	if err := ctx.ShouldBindJSON(&response); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := cc.CannedResponseService.CreateResponse(ctx.GetUint("agentID"), &response); err != nil {
+		cannedError(ctx, err, "Failed to create canned response")
+		return
+	}
+	ctx.JSON(http.StatusCreated, response)
+}
+
+// GetResponses handles GET /canned-responses?q=.
+func (cc *CannedResponseController) GetResponses(ctx *gin.Context) {
+	responses, err := cc.CannedResponseService.GetResponses(ctx.GetUint("agentID"), ctx.Query("q"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, responses)
+}
+
+// GetPlaceholders handles GET /canned-responses/placeholders.
+func (cc *CannedResponseController) GetPlaceholders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, canned.Placeholders())
+}
+
+// GetResponseByID handles GET /canned-responses/:id.
+func (cc *CannedResponseController) GetResponseByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	response, err := cc.CannedResponseService.GetResponse(ctx.GetUint("agentID"), uint(id))
+	if err != nil {
+		cannedError(ctx, err, "Failed to retrieve canned response")
+		return
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+// UpdateResponse handles PUT /canned-responses/:id.
+func (cc *CannedResponseController) UpdateResponse(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var response models.CannedResponse
+	if err := ctx.ShouldBindJSON(&response); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	response.ID = uint(id)
+	updated, err := cc.CannedResponseService.UpdateResponse(ctx.GetUint("agentID"), &response)
+	if err != nil {
+		cannedError(ctx, err, "Failed to update canned response")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteResponse handles DELETE /canned-responses/:id.
+func (cc *CannedResponseController) DeleteResponse(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := cc.CannedResponseService.DeleteResponse(ctx.GetUint("agentID"), uint(id))
+	if err != nil {
+		cannedError(ctx, err, "Failed to delete canned response")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// RenderResponse handles GET /canned-responses/:id/render?ticket_id=.
+func (cc *CannedResponseController) RenderResponse(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	ticketID, err := strconv.ParseUint(ctx.Query("ticket_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	body, err := cc.CannedResponseService.RenderResponse(ctx.GetUint("agentID"), uint(id), uint(ticketID))
+	if err != nil {
+		cannedError(ctx, err, "Failed to render canned response")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"body": body})
+}
+
+// CreateMacro handles POST /macros.
+func (cc *CannedResponseController) CreateMacro(ctx *gin.Context) {
+	var macro models.Macro
+	if err := ctx.ShouldBindJSON(&macro); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := cc.CannedResponseService.CreateMacro(ctx.GetUint("agentID"), &macro); err != nil {
+		cannedError(ctx, err, "Failed to create macro")
+		return
+	}
+	ctx.JSON(http.StatusCreated, macro)
+}
+
+// GetMacros handles GET /macros.
+func (cc *CannedResponseController) GetMacros(ctx *gin.Context) {
+	macros, err := cc.CannedResponseService.GetMacros(ctx.GetUint("agentID"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, macros)
+}
+
+// GetMacroByID handles GET /macros/:id.
+func (cc *CannedResponseController) GetMacroByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	macro, err := cc.CannedResponseService.GetMacro(ctx.GetUint("agentID"), uint(id))
+	if err != nil {
+		cannedError(ctx, err, "Failed to retrieve macro")
+		return
+	}
+	ctx.JSON(http.StatusOK, macro)
+}
+
+// UpdateMacro handles PUT /macros/:id.
+func (cc *CannedResponseController) UpdateMacro(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var macro models.Macro
+	if err := ctx.ShouldBindJSON(&macro); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	macro.ID = uint(id)
+	updated, err := cc.CannedResponseService.UpdateMacro(ctx.GetUint("agentID"), &macro)
+	if err != nil {
+		cannedError(ctx, err, "Failed to update macro")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteMacro handles DELETE /macros/:id.
+func (cc *CannedResponseController) DeleteMacro(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := cc.CannedResponseService.DeleteMacro(ctx.GetUint("agentID"), uint(id))
+	if err != nil {
+		cannedError(ctx, err, "Failed to delete macro")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// ApplyMacro handles POST /macros/:id/apply with the tickets to apply the
+// macro to. It answers with the outcome for each ticket.
+func (cc *CannedResponseController) ApplyMacro(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var request applyMacroRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	results, err := cc.CannedResponseService.ApplyMacro(ctx.GetUint("agentID"), uint(id), request.TicketIDs)
+	if err != nil {
+		cannedError(ctx, err, "Failed to apply macro")
+		return
+	}
+	ctx.JSON(http.StatusOK, results)
+}
+
+func cannedError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidCannedResponse), errors.Is(err, services.ErrInvalidMacro):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,311 @@
+package controllers
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"github.com/shuttlersit/service-desk/backend/storage"
+	"gorm.io/gorm"
+)
+
+type ChangeController struct {
+	ChangeService *services.DefaultChangeService
+}
+
+func NewChangeController(changeService *services.DefaultChangeService) *ChangeController {
+	return &ChangeController{
+		ChangeService: changeService,
+	}
+}
+
+// GetChanges handles GET /changes?status=.
+func (cc *ChangeController) GetChanges(ctx *gin.Context) {
+	changes, err := cc.ChangeService.GetChanges(ctx.Query("status"))
+	if err != nil {
+		changeError(ctx, err, "Failed to retrieve changes")
+		return
+	}
+	ctx.JSON(http.StatusOK, changes)
+}
+
+// CreateChange handles POST /changes, which records a draft change.
+func (cc *ChangeController) CreateChange(ctx *gin.Context) {
+	var change models.ChangeRequest
+	if err := ctx.ShouldBindJSON(&change); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := cc.ChangeService.CreateChange(ctx.GetUint("agentID"), &change); err != nil {
+		changeError(ctx, err, "Failed to create change")
+		return
+	}
+	ctx.JSON(http.StatusCreated, change)
+}
+
+// GetChange handles GET /changes/:id.
+func (cc *ChangeController) GetChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	change, err := cc.ChangeService.GetChange(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to retrieve change")
+		return
+	}
+	ctx.JSON(http.StatusOK, change)
+}
+
+// UpdateChange handles PUT /changes/:id.
+func (cc *ChangeController) UpdateChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var change models.ChangeRequest
+	if err := ctx.ShouldBindJSON(&change); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	change.ID = uint(id)
+	updated, err := cc.ChangeService.UpdateChange(&change)
+	if err != nil {
+		changeError(ctx, err, "Failed to update change")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteChange handles DELETE /changes/:id.
+func (cc *ChangeController) DeleteChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := cc.ChangeService.DeleteChange(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to delete change")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// SubmitChange handles POST /changes/:id/submit.
+func (cc *ChangeController) SubmitChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	change, err := cc.ChangeService.SubmitChange(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to submit change")
+		return
+	}
+	ctx.JSON(http.StatusOK, change)
+}
+
+// StartChange handles POST /changes/:id/start.
+func (cc *ChangeController) StartChange(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	change, err := cc.ChangeService.StartChange(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to start change")
+		return
+	}
+	ctx.JSON(http.StatusOK, change)
+}
+
+// CompleteChange handles POST /changes/:id/complete.
+func (cc *ChangeController) CompleteChange(ctx *gin.Context) {
+	cc.transition(ctx, cc.ChangeService.CompleteChange, "Failed to complete change")
+}
+
+// FailChange handles POST /changes/:id/fail, for a change that was backed
+// out.
+func (cc *ChangeController) FailChange(ctx *gin.Context) {
+	cc.transition(ctx, cc.ChangeService.FailChange, "Failed to fail change")
+}
+
+// CancelChange handles POST /changes/:id/cancel.
+func (cc *ChangeController) CancelChange(ctx *gin.Context) {
+	cc.transition(ctx, cc.ChangeService.CancelChange, "Failed to cancel change")
+}
+
+func (cc *ChangeController) transition(ctx *gin.Context, apply func(uint, *services.ChangeTransition) (*models.ChangeRequest, error), message string) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var transition services.ChangeTransition
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&transition); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	change, err := apply(uint(id), &transition)
+	if err != nil {
+		changeError(ctx, err, message)
+		return
+	}
+	ctx.JSON(http.StatusOK, change)
+}
+
+// GetConflicts handles GET /changes/:id/conflicts, the active changes
+// scheduled on the same assets during the window of a change.
+func (cc *ChangeController) GetConflicts(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	conflicts, err := cc.ChangeService.GetConflicts(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to retrieve conflicts")
+		return
+	}
+	ctx.JSON(http.StatusOK, conflicts)
+}
+
+// GetChangeApproval handles GET /changes/:id/approval, the progress of the
+// CAB approval of a change.
+func (cc *ChangeController) GetChangeApproval(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if cc.ChangeService.Approvals == nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+	request, err := cc.ChangeService.Approvals.GetChangeApproval(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to retrieve approval")
+		return
+	}
+	ctx.JSON(http.StatusOK, request)
+}
+
+// GetCalendarLink handles GET /changes/calendar/link, the signed URL of the
+// change calendar to subscribe to.
+func (cc *ChangeController) GetCalendarLink(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"url": cc.ChangeService.GetCalendarLink()})
+}
+
+// GetCalendar handles GET /changes/calendar.ics?expires=&signature= using a
+// signed link, with optional from and to dates (YYYY-MM-DD). It covers the
+// last 30 days and the coming year by default.
+func (cc *ChangeController) GetCalendar(ctx *gin.Context) {
+	now := time.Now()
+	from, to := now.AddDate(0, 0, -30), now.AddDate(1, 0, 0)
+	for param, value := range map[string]*time.Time{"from": &from, "to": &to} {
+		if raw := ctx.Query(param); raw != "" {
+			parsed, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+			if err != nil {
+				ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " date"})
+				return
+			}
+			*value = parsed
+		}
+	}
+	var feed bytes.Buffer
+	if err := cc.ChangeService.WriteCalendar(&feed, ctx.Query("expires"), ctx.Query("signature"), from, to); err != nil {
+		changeError(ctx, err, "Failed to write calendar")
+		return
+	}
+	ctx.Header("Content-Disposition", `inline; filename="changes.ics"`)
+	ctx.Data(http.StatusOK, "text/calendar; charset=utf-8", feed.Bytes())
+}
+
+// GetQuestions handles GET /admin/change-risk-questions.
+func (cc *ChangeController) GetQuestions(ctx *gin.Context) {
+	questions, err := cc.ChangeService.GetQuestions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve risk questions"})
+		return
+	}
+	ctx.JSON(http.StatusOK, questions)
+}
+
+// CreateQuestion handles POST /admin/change-risk-questions.
+func (cc *ChangeController) CreateQuestion(ctx *gin.Context) {
+	var question models.RiskQuestion
+	if err := ctx.ShouldBindJSON(&question); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := cc.ChangeService.CreateQuestion(&question); err != nil {
+		changeError(ctx, err, "Failed to create risk question")
+		return
+	}
+	ctx.JSON(http.StatusCreated, question)
+}
+
+// UpdateQuestion handles PUT /admin/change-risk-questions/:id.
+func (cc *ChangeController) UpdateQuestion(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var question models.RiskQuestion
+	if err := ctx.ShouldBindJSON(&question); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	question.ID = uint(id)
+	updated, err := cc.ChangeService.UpdateQuestion(&question)
+	if err != nil {
+		changeError(ctx, err, "Failed to update risk question")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteQuestion handles DELETE /admin/change-risk-questions/:id.
+func (cc *ChangeController) DeleteQuestion(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := cc.ChangeService.DeleteQuestion(uint(id))
+	if err != nil {
+		changeError(ctx, err, "Failed to delete risk question")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+func changeError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidChange), errors.Is(err, services.ErrInvalidRiskQuestion), errors.Is(err, services.ErrNoApprover):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrChangeStatus), errors.Is(err, services.ErrChangeConflict), errors.Is(err, services.ErrNoChangeChain):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrInvalidSignature):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, storage.ErrLinkExpired):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
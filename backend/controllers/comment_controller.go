@@ -0,0 +1,85 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type CommentController struct {
+	CommentService *services.DefaultCommentService
+}
+
+func NewCommentController(commentService *services.DefaultCommentService) *CommentController {
+	return &CommentController{
+		CommentService: commentService,
+	}
+}
+
+// CreateComment handles POST /tickets/:id/comments.
+func (pc *CommentController) CreateComment(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var comment models.TicketComment
+	if err := ctx.ShouldBindJSON(&comment); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	comment.TicketID = uint(ticketID)
+	comment.Source = models.CommentSourceWeb
+	// The author is who is signed in, never what the body claims; only
+	// agents write internal notes.
+	comment.UserID, comment.AgentID = 0, ctx.GetUint("agentID")
+	if comment.AgentID == 0 {
+		comment.UserID = ctx.GetUint("userID")
+		comment.IsInternal = false
+	}
+
+	if err := pc.CommentService.CreateComment(&comment); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create comment"})
+		return
+	}
+	ctx.JSON(http.StatusCreated, comment)
+}
+
+// GetComments handles GET /tickets/:id/comments.
+func (pc *CommentController) GetComments(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	comments, err := pc.CommentService.GetCommentsByTicket(uint(ticketID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, comments)
+}
+
+// DeleteComment handles DELETE /tickets/:id/comments/:comment_id.
+func (pc *CommentController) DeleteComment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("comment_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := pc.CommentService.DeleteComment(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type CustomFieldController struct {
+	CustomFieldService *services.DefaultCustomFieldService
+}
+
+func NewCustomFieldController(customFieldService *services.DefaultCustomFieldService) *CustomFieldController {
+	return &CustomFieldController{
+		CustomFieldService: customFieldService,
+	}
+}
+
+// CreateField handles POST /admin/custom-fields.
+func (fc *CustomFieldController) CreateField(ctx *gin.Context) {
+	var definition models.CustomFieldDefinition
+	if err := ctx.ShouldBindJSON(&definition); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := fc.CustomFieldService.CreateDefinition(&definition); err != nil {
+		customFieldError(ctx, err, "Failed to create custom field")
+		return
+	}
+	ctx.JSON(http.StatusCreated, definition)
+}
+
+// GetFields handles GET /admin/custom-fields.
+func (fc *CustomFieldController) GetFields(ctx *gin.Context) {
+	definitions, err := fc.CustomFieldService.GetDefinitions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve custom fields"})
+		return
+	}
+	ctx.JSON(http.StatusOK, definitions)
+}
+
+// GetField handles GET /admin/custom-fields/:id.
+func (fc *CustomFieldController) GetField(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	definition, err := fc.CustomFieldService.GetDefinition(uint(id))
+	if err != nil {
+		customFieldError(ctx, err, "Failed to retrieve custom field")
+		return
+	}
+	ctx.JSON(http.StatusOK, definition)
+}
+
+// UpdateField handles PUT /admin/custom-fields/:id.
+func (fc *CustomFieldController) UpdateField(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var definition models.CustomFieldDefinition
+	if err := ctx.ShouldBindJSON(&definition); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	definition.ID = uint(id)
+	updated, err := fc.CustomFieldService.UpdateDefinition(&definition)
+	if err != nil {
+		customFieldError(ctx, err, "Failed to update custom field")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteField handles DELETE /admin/custom-fields/:id. The values of the
+// field on tickets are deleted with it.
+func (fc *CustomFieldController) DeleteField(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := fc.CustomFieldService.DeleteDefinition(uint(id))
+	if err != nil {
+		customFieldError(ctx, err, "Failed to delete custom field")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetForm handles GET /custom-fields/form?category=&sub_category=, the
+// fields to show on the ticket form of a category.
+func (fc *CustomFieldController) GetForm(ctx *gin.Context) {
+	form, err := fc.CustomFieldService.GetForm(ctx.Query("category"), ctx.Query("sub_category"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve form"})
+		return
+	}
+	ctx.JSON(http.StatusOK, form)
+}
+
+func customFieldError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidCustomField), errors.Is(err, services.ErrInvalidCustomFieldValue):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
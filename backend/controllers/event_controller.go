@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type EventController struct {
+	EventService *services.DefaultEventService
+}
+
+func NewEventController(eventService *services.DefaultEventService) *EventController {
+	return &EventController{
+		EventService: eventService,
+	}
+}
+
+// GetEvents handles GET /admin/events, optionally filtered by ?status=.
+func (pc *EventController) GetEvents(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	events, err := pc.EventService.GetEvents(ctx.Query("status"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, events)
+}
+
+// RetryEvent handles POST /admin/events/:id/retry.
+func (pc *EventController) RetryEvent(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	event, err := pc.EventService.RetryEvent(uint(id))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retry event"})
+		return
+	}
+	ctx.JSON(http.StatusAccepted, event)
+}
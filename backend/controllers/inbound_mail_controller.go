@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/inbound"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+type InboundMailController struct {
+	InboundMailService *services.DefaultInboundMailService
+}
+
+func NewInboundMailController(inboundMailService *services.DefaultInboundMailService) *InboundMailController {
+	return &InboundMailController{
+		InboundMailService: inboundMailService,
+	}
+}
+
+// ReceiveEmail handles POST /inbound/email. The raw MIME message is sent
+// either as the request body (Content-Type message/rfc822) or, as most mail
+// providers do, in an "email" form field or a "message" file of a multipart
+// form. The shared token is passed in the X-Inbound-Token header or the
+// token query parameter.
+func (pc *InboundMailController) ReceiveEmail(ctx *gin.Context) {
+	token := ctx.GetHeader("X-Inbound-Token")
+	if token == "" {
+		token = ctx.Query("token")
+	}
+	expected := pc.InboundMailService.Config.WebhookToken
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	raw, err := readRawMessage(ctx)
+	if err != nil || len(raw) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Missing raw message"})
+		return
+	}
+
+	record, err := pc.InboundMailService.ProcessMessage(ctx.Request.Context(), "webhook", raw)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, record)
+}
+
+// GetInboundEmails handles GET /admin/inbound/emails.
+func (pc *InboundMailController) GetInboundEmails(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	emails, err := pc.InboundMailService.GetInboundEmails(limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, emails)
+}
+
+func readRawMessage(ctx *gin.Context) ([]byte, error) {
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, inbound.MaxMessageSize)
+	if !strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		return io.ReadAll(body)
+	}
+	ctx.Request.Body = body
+	if raw := ctx.PostForm("email"); raw != "" {
+		return []byte(raw), nil
+	}
+	fh, err := ctx.FormFile("message")
+	if err != nil {
+		return nil, err
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type JobController struct {
+	JobService *services.DefaultJobService
+}
+
+func NewJobController(jobService *services.DefaultJobService) *JobController {
+	return &JobController{
+		JobService: jobService,
+	}
+}
+
+// GetJobs handles GET /admin/jobs, optionally filtered by ?status= and ?kind=.
+func (pc *JobController) GetJobs(ctx *gin.Context) {
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	jobs, err := pc.JobService.GetJobs(ctx.Query("status"), ctx.Query("kind"), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, jobs)
+}
+
+// GetJobByID handles GET /admin/jobs/:id.
+func (pc *JobController) GetJobByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	job, err := pc.JobService.GetJobByID(uint(id))
+	if err != nil {
+		jobError(ctx, err, "Failed to retrieve job")
+		return
+	}
+	ctx.JSON(http.StatusOK, job)
+}
+
+// RetryJob handles POST /admin/jobs/:id/retry.
+func (pc *JobController) RetryJob(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	job, err := pc.JobService.RetryJob(uint(id))
+	if err != nil {
+		jobError(ctx, err, "Failed to retry job")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+// CancelJob handles POST /admin/jobs/:id/cancel. A running job is stopped
+// by its worker shortly after.
+func (pc *JobController) CancelJob(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	job, err := pc.JobService.CancelJob(uint(id))
+	if err != nil {
+		jobError(ctx, err, "Failed to cancel job")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, job)
+}
+
+// GetSchedules handles GET /admin/jobs/schedules.
+func (pc *JobController) GetSchedules(ctx *gin.Context) {
+	schedules, err := pc.JobService.GetSchedules()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, schedules)
+}
+
+func jobError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Job not found"})
+	case errors.Is(err, services.ErrJobNotRetryable), errors.Is(err, services.ErrJobFinished):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
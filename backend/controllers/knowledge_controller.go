@@ -0,0 +1,436 @@
+package controllers
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type KnowledgeController struct {
+	KnowledgeService *services.DefaultKnowledgeService
+}
+
+func NewKnowledgeController(knowledgeService *services.DefaultKnowledgeService) *KnowledgeController {
+	return &KnowledgeController{
+		KnowledgeService: knowledgeService,
+	}
+}
+
+// articleTicketLink is the body of POST /knowledge/articles/:id/tickets.
+type articleTicketLink struct {
+	TicketID uint `json:"ticket_id"`
+	Resolved bool `json:"resolved"`
+}
+
+// articleVote is the body of the vote endpoints.
+type articleVote struct {
+	Helpful *bool `json:"helpful"`
+}
+
+// articleFilter reads the q, category, sub_category and status query
+// parameters.
+func articleFilter(ctx *gin.Context) models.ArticleFilter {
+	return models.ArticleFilter{
+		Query:       ctx.Query("q"),
+		Category:    ctx.Query("category"),
+		SubCategory: ctx.Query("sub_category"),
+		Status:      ctx.Query("status"),
+	}
+}
+
+// GetArticles handles GET /knowledge/articles?q=&category=&sub_category=&status=.
+func (kc *KnowledgeController) GetArticles(ctx *gin.Context) {
+	articles, err := kc.KnowledgeService.GetArticles(articleFilter(ctx))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve articles")
+		return
+	}
+	ctx.JSON(http.StatusOK, articles)
+}
+
+// CreateArticle handles POST /knowledge/articles.
+func (kc *KnowledgeController) CreateArticle(ctx *gin.Context) {
+	var draft services.ArticleDraft
+	if err := ctx.ShouldBindJSON(&draft); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	article, err := kc.KnowledgeService.CreateArticle(ctx.GetUint("agentID"), &draft)
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to create article")
+		return
+	}
+	ctx.JSON(http.StatusCreated, article)
+}
+
+// GetArticle handles GET /knowledge/articles/:id.
+func (kc *KnowledgeController) GetArticle(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	article, err := kc.KnowledgeService.GetArticle(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve article")
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// UpdateArticle handles PUT /knowledge/articles/:id.
+func (kc *KnowledgeController) UpdateArticle(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var draft services.ArticleDraft
+	if err := ctx.ShouldBindJSON(&draft); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	article, err := kc.KnowledgeService.UpdateArticle(ctx.GetUint("agentID"), uint(id), &draft)
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to update article")
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// DeleteArticle handles DELETE /knowledge/articles/:id.
+func (kc *KnowledgeController) DeleteArticle(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := kc.KnowledgeService.DeleteArticle(ctx.Request.Context(), uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to delete article")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetVersions handles GET /knowledge/articles/:id/versions.
+func (kc *KnowledgeController) GetVersions(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	versions, err := kc.KnowledgeService.GetVersions(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve versions")
+		return
+	}
+	ctx.JSON(http.StatusOK, versions)
+}
+
+// GetVersion handles GET /knowledge/articles/:id/versions/:version.
+func (kc *KnowledgeController) GetVersion(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	version, err := strconv.Atoi(ctx.Param("version"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid version"})
+		return
+	}
+	v, err := kc.KnowledgeService.GetVersion(uint(id), version)
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve version")
+		return
+	}
+	ctx.JSON(http.StatusOK, v)
+}
+
+// SubmitArticle handles POST /knowledge/articles/:id/submit.
+func (kc *KnowledgeController) SubmitArticle(ctx *gin.Context) {
+	kc.transition(ctx, "Failed to submit article", func(id uint) (*models.KnowledgeArticle, error) {
+		return kc.KnowledgeService.SubmitArticle(id)
+	})
+}
+
+// PublishArticle handles POST /knowledge/articles/:id/publish.
+func (kc *KnowledgeController) PublishArticle(ctx *gin.Context) {
+	kc.transition(ctx, "Failed to publish article", func(id uint) (*models.KnowledgeArticle, error) {
+		return kc.KnowledgeService.PublishArticle(ctx.GetUint("agentID"), id)
+	})
+}
+
+// RejectArticle handles POST /knowledge/articles/:id/reject.
+func (kc *KnowledgeController) RejectArticle(ctx *gin.Context) {
+	kc.transition(ctx, "Failed to reject article", func(id uint) (*models.KnowledgeArticle, error) {
+		return kc.KnowledgeService.RejectArticle(id)
+	})
+}
+
+// ArchiveArticle handles POST /knowledge/articles/:id/archive.
+func (kc *KnowledgeController) ArchiveArticle(ctx *gin.Context) {
+	kc.transition(ctx, "Failed to archive article", func(id uint) (*models.KnowledgeArticle, error) {
+		return kc.KnowledgeService.ArchiveArticle(id)
+	})
+}
+
+func (kc *KnowledgeController) transition(ctx *gin.Context, message string, apply func(id uint) (*models.KnowledgeArticle, error)) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	article, err := apply(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, message)
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// UploadAttachments handles POST /knowledge/articles/:id/attachments, a
+// multipart form with one or more "file" parts.
+func (kc *KnowledgeController) UploadAttachments(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, kc.KnowledgeService.Attachments.Config.MaxSize*10+(1<<20))
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid multipart form"})
+		return
+	}
+	files := form.File["file"]
+	if len(files) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		return
+	}
+
+	status := http.StatusOK
+	var attachments []*models.ArticleAttachment
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+			return
+		}
+		attachment, created, err := kc.KnowledgeService.UploadAttachment(ctx.Request.Context(), uint(id), &services.AttachmentUpload{
+			FileName:   fh.Filename,
+			Reader:     f,
+			UploadedBy: ctx.GetUint("agentID"),
+		})
+		f.Close()
+		if err != nil {
+			ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error(), "file": fh.Filename})
+			return
+		}
+		if created {
+			status = http.StatusCreated
+		}
+		attachments = append(attachments, attachment)
+	}
+
+	ctx.JSON(status, attachments)
+}
+
+// DeleteAttachment handles DELETE /knowledge/articles/:id/attachments/:attachment_id.
+func (kc *KnowledgeController) DeleteAttachment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	attachmentID, err := strconv.ParseUint(ctx.Param("attachment_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		return
+	}
+	if err := kc.KnowledgeService.DeleteAttachment(ctx.Request.Context(), uint(id), uint(attachmentID)); err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	ctx.Status(http.StatusNoContent)
+}
+
+// DownloadAttachment handles GET /kb/attachments/:id/download using a
+// signed link.
+func (kc *KnowledgeController) DownloadAttachment(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	attachment, body, err := kc.KnowledgeService.OpenAttachment(ctx.Request.Context(), uint(id), ctx.Query("expires"), ctx.Query("signature"))
+	if err != nil {
+		ctx.JSON(attachmentErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+	defer body.Close()
+
+	disposition := "inline"
+	if attachment.Type == "document" {
+		disposition = "attachment"
+	}
+	ctx.DataFromReader(http.StatusOK, attachment.Size, attachment.ContentType, body, map[string]string{
+		"Content-Disposition":    mime.FormatMediaType(disposition, map[string]string{"filename": attachment.FileName}),
+		"X-Content-Type-Options": "nosniff",
+		"Cache-Control":          "private, max-age=300",
+	})
+}
+
+// LinkTicket handles POST /knowledge/articles/:id/tickets.
+func (kc *KnowledgeController) LinkTicket(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var link articleTicketLink
+	if err := ctx.ShouldBindJSON(&link); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	article, err := kc.KnowledgeService.LinkTicket(ctx.GetUint("agentID"), uint(id), link.TicketID, link.Resolved)
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to link ticket")
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// UnlinkTicket handles DELETE /knowledge/articles/:id/tickets/:ticketID.
+func (kc *KnowledgeController) UnlinkTicket(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	ticketID, err := strconv.ParseUint(ctx.Param("ticketID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	article, err := kc.KnowledgeService.UnlinkTicket(ctx.GetUint("agentID"), uint(id), uint(ticketID))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to unlink ticket")
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// GetTicketArticles handles GET /tickets/:id/articles.
+func (kc *KnowledgeController) GetTicketArticles(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	articles, err := kc.KnowledgeService.GetTicketArticles(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve articles")
+		return
+	}
+	ctx.JSON(http.StatusOK, articles)
+}
+
+// SuggestArticles handles GET /tickets/:id/articles/suggested, the
+// published articles in the category of the ticket.
+func (kc *KnowledgeController) SuggestArticles(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	articles, err := kc.KnowledgeService.SuggestArticles(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to suggest articles")
+		return
+	}
+	ctx.JSON(http.StatusOK, articles)
+}
+
+// Vote handles POST /knowledge/articles/:id/vote, an agent voting on any
+// published article.
+func (kc *KnowledgeController) Vote(ctx *gin.Context) {
+	kc.vote(ctx, false)
+}
+
+// GetPublicArticles handles GET /kb/articles?q=&category=&sub_category=,
+// the published public articles.
+func (kc *KnowledgeController) GetPublicArticles(ctx *gin.Context) {
+	articles, err := kc.KnowledgeService.GetPublicArticles(articleFilter(ctx))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve articles")
+		return
+	}
+	ctx.JSON(http.StatusOK, articles)
+}
+
+// GetPublicArticle handles GET /kb/articles/:id.
+func (kc *KnowledgeController) GetPublicArticle(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	article, err := kc.KnowledgeService.GetPublicArticle(uint(id))
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to retrieve article")
+		return
+	}
+	ctx.JSON(http.StatusOK, article)
+}
+
+// PublicVote handles POST /kb/articles/:id/vote. Anonymous readers are
+// told apart by their address.
+func (kc *KnowledgeController) PublicVote(ctx *gin.Context) {
+	kc.vote(ctx, true)
+}
+
+func (kc *KnowledgeController) vote(ctx *gin.Context, publicOnly bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var vote articleVote
+	if err := ctx.ShouldBindJSON(&vote); err != nil || vote.Helpful == nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	voter := services.ArticleVoter{UserID: ctx.GetUint("userID"), AgentID: ctx.GetUint("agentID"), Address: ctx.ClientIP()}
+	article, err := kc.KnowledgeService.Vote(uint(id), voter, *vote.Helpful, publicOnly)
+	if err != nil {
+		knowledgeError(ctx, err, "Failed to record vote")
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{
+		"article_id":        article.ID,
+		"helpful_count":     article.HelpfulCount,
+		"not_helpful_count": article.NotHelpfulCount,
+	})
+}
+
+func knowledgeError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidArticle):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrArticleStatus):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,356 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type LookupController struct {
+	LookupService *services.DefaultLookupService
+}
+
+func NewLookupController(lookupService *services.DefaultLookupService) *LookupController {
+	return &LookupController{
+		LookupService: lookupService,
+	}
+}
+
+// GetCategoryTree handles GET /categories/tree, the categories and
+// sub-categories of the request form.
+func (lc *LookupController) GetCategoryTree(ctx *gin.Context) {
+	tree, err := lc.LookupService.GetCategoryTree()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+	ctx.JSON(http.StatusOK, tree)
+}
+
+// GetCategories handles GET /admin/categories.
+func (lc *LookupController) GetCategories(ctx *gin.Context) {
+	categories, err := lc.LookupService.GetCategories()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve categories"})
+		return
+	}
+	ctx.JSON(http.StatusOK, categories)
+}
+
+// CreateCategory handles POST /admin/categories.
+func (lc *LookupController) CreateCategory(ctx *gin.Context) {
+	var category models.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := lc.LookupService.CreateCategory(&category); err != nil {
+		lookupError(ctx, err, "Failed to create category")
+		return
+	}
+	ctx.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory handles PUT /admin/categories/:id.
+func (lc *LookupController) UpdateCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var category models.Category
+	if err := ctx.ShouldBindJSON(&category); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	category.ID = id
+	updated, err := lc.LookupService.UpdateCategory(&category)
+	if err != nil {
+		lookupError(ctx, err, "Failed to update category")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteCategory handles DELETE /admin/categories/:id. A category that
+// tickets still have is retired rather than deleted.
+func (lc *LookupController) DeleteCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	retired, err := lc.LookupService.DeleteCategory(id)
+	if err != nil {
+		lookupError(ctx, err, "Failed to delete category")
+		return
+	}
+	if retired {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Category is in use and was retired"})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, true)
+}
+
+// GetSubCategories handles GET /admin/sub-categories.
+func (lc *LookupController) GetSubCategories(ctx *gin.Context) {
+	subCategories, err := lc.LookupService.GetSubCategories()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sub-categories"})
+		return
+	}
+	ctx.JSON(http.StatusOK, subCategories)
+}
+
+// CreateSubCategory handles POST /admin/sub-categories.
+func (lc *LookupController) CreateSubCategory(ctx *gin.Context) {
+	var subCategory models.SubCategory
+	if err := ctx.ShouldBindJSON(&subCategory); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := lc.LookupService.CreateSubCategory(&subCategory); err != nil {
+		lookupError(ctx, err, "Failed to create sub-category")
+		return
+	}
+	ctx.JSON(http.StatusCreated, subCategory)
+}
+
+// UpdateSubCategory handles PUT /admin/sub-categories/:id.
+func (lc *LookupController) UpdateSubCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var subCategory models.SubCategory
+	if err := ctx.ShouldBindJSON(&subCategory); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	subCategory.SubCategoryID = id
+	updated, err := lc.LookupService.UpdateSubCategory(&subCategory)
+	if err != nil {
+		lookupError(ctx, err, "Failed to update sub-category")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteSubCategory handles DELETE /admin/sub-categories/:id. A
+// sub-category that tickets still have is retired rather than deleted.
+func (lc *LookupController) DeleteSubCategory(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	retired, err := lc.LookupService.DeleteSubCategory(id)
+	if err != nil {
+		lookupError(ctx, err, "Failed to delete sub-category")
+		return
+	}
+	if retired {
+		ctx.JSON(http.StatusOK, gin.H{"message": "Sub-category is in use and was retired"})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, true)
+}
+
+// GetPriorities handles GET /admin/priorities.
+func (lc *LookupController) GetPriorities(ctx *gin.Context) {
+	priorities, err := lc.LookupService.GetPriorities()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve priorities"})
+		return
+	}
+	ctx.JSON(http.StatusOK, priorities)
+}
+
+// CreatePriority handles POST /admin/priorities.
+func (lc *LookupController) CreatePriority(ctx *gin.Context) {
+	var priority models.Priority
+	if err := ctx.ShouldBindJSON(&priority); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := lc.LookupService.CreatePriority(&priority); err != nil {
+		lookupError(ctx, err, "Failed to create priority")
+		return
+	}
+	ctx.JSON(http.StatusCreated, priority)
+}
+
+// UpdatePriority handles PUT /admin/priorities/:id.
+func (lc *LookupController) UpdatePriority(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var priority models.Priority
+	if err := ctx.ShouldBindJSON(&priority); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	priority.PriorityID = id
+	updated, err := lc.LookupService.UpdatePriority(&priority)
+	if err != nil {
+		lookupError(ctx, err, "Failed to update priority")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeletePriority handles DELETE /admin/priorities/:id.
+func (lc *LookupController) DeletePriority(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := lc.LookupService.DeletePriority(id)
+	if err != nil {
+		lookupError(ctx, err, "Failed to delete priority")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetStatuses handles GET /admin/statuses.
+func (lc *LookupController) GetStatuses(ctx *gin.Context) {
+	statuses, err := lc.LookupService.GetStatuses()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve statuses"})
+		return
+	}
+	ctx.JSON(http.StatusOK, statuses)
+}
+
+// CreateStatus handles POST /admin/statuses.
+func (lc *LookupController) CreateStatus(ctx *gin.Context) {
+	var status models.Status
+	if err := ctx.ShouldBindJSON(&status); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := lc.LookupService.CreateStatus(&status); err != nil {
+		lookupError(ctx, err, "Failed to create status")
+		return
+	}
+	ctx.JSON(http.StatusCreated, status)
+}
+
+// UpdateStatus handles PUT /admin/statuses/:id.
+func (lc *LookupController) UpdateStatus(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var status models.Status
+	if err := ctx.ShouldBindJSON(&status); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	status.StatusID = id
+	updated, err := lc.LookupService.UpdateStatus(&status)
+	if err != nil {
+		lookupError(ctx, err, "Failed to update status")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteStatus handles DELETE /admin/statuses/:id.
+func (lc *LookupController) DeleteStatus(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := lc.LookupService.DeleteStatus(id)
+	if err != nil {
+		lookupError(ctx, err, "Failed to delete status")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetSlas handles GET /admin/slas.
+func (lc *LookupController) GetSlas(ctx *gin.Context) {
+	slas, err := lc.LookupService.GetSlas()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve SLAs"})
+		return
+	}
+	ctx.JSON(http.StatusOK, slas)
+}
+
+// CreateSla handles POST /admin/slas.
+func (lc *LookupController) CreateSla(ctx *gin.Context) {
+	var sla models.Sla
+	if err := ctx.ShouldBindJSON(&sla); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := lc.LookupService.CreateSla(&sla); err != nil {
+		lookupError(ctx, err, "Failed to create SLA")
+		return
+	}
+	ctx.JSON(http.StatusCreated, sla)
+}
+
+// UpdateSla handles PUT /admin/slas/:id.
+func (lc *LookupController) UpdateSla(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var sla models.Sla
+	if err := ctx.ShouldBindJSON(&sla); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	sla.SlaID = id
+	updated, err := lc.LookupService.UpdateSla(&sla)
+	if err != nil {
+		lookupError(ctx, err, "Failed to update SLA")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteSla handles DELETE /admin/slas/:id.
+func (lc *LookupController) DeleteSla(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := lc.LookupService.DeleteSla(id)
+	if err != nil {
+		lookupError(ctx, err, "Failed to delete SLA")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+func lookupError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrLookupInUse):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidLookup):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,187 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type MajorIncidentController struct {
+	MajorIncidentService *services.DefaultMajorIncidentService
+}
+
+func NewMajorIncidentController(majorIncidentService *services.DefaultMajorIncidentService) *MajorIncidentController {
+	return &MajorIncidentController{
+		MajorIncidentService: majorIncidentService,
+	}
+}
+
+// majorIncidentLinks is the body of POST /major-incidents/:id/tickets.
+type majorIncidentLinks struct {
+	TicketIDs []uint `json:"ticket_ids"`
+}
+
+// GetIncidents handles GET /major-incidents?active=true.
+func (mc *MajorIncidentController) GetIncidents(ctx *gin.Context) {
+	incidents, err := mc.MajorIncidentService.GetIncidents(ctx.Query("active") == "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve major incidents"})
+		return
+	}
+	ctx.JSON(http.StatusOK, incidents)
+}
+
+// DeclareIncident handles POST /major-incidents, which declares the
+// ticket in ticket_id a major incident.
+func (mc *MajorIncidentController) DeclareIncident(ctx *gin.Context) {
+	var incident models.MajorIncident
+	if err := ctx.ShouldBindJSON(&incident); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := mc.MajorIncidentService.Declare(ctx.GetUint("agentID"), &incident); err != nil {
+		majorIncidentError(ctx, err, "Failed to declare major incident")
+		return
+	}
+	ctx.JSON(http.StatusCreated, incident)
+}
+
+// GetIncident handles GET /major-incidents/:id.
+func (mc *MajorIncidentController) GetIncident(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	incident, err := mc.MajorIncidentService.GetIncident(uint(id))
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to retrieve major incident")
+		return
+	}
+	ctx.JSON(http.StatusOK, incident)
+}
+
+// UpdateIncident handles PUT /major-incidents/:id.
+func (mc *MajorIncidentController) UpdateIncident(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var incident models.MajorIncident
+	if err := ctx.ShouldBindJSON(&incident); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	incident.ID = uint(id)
+	updated, err := mc.MajorIncidentService.UpdateIncident(&incident)
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to update major incident")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// LinkTickets handles POST /major-incidents/:id/tickets.
+func (mc *MajorIncidentController) LinkTickets(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var links majorIncidentLinks
+	if err := ctx.ShouldBindJSON(&links); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	incident, err := mc.MajorIncidentService.LinkTickets(ctx.GetUint("agentID"), uint(id), links.TicketIDs)
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to link tickets")
+		return
+	}
+	ctx.JSON(http.StatusOK, incident)
+}
+
+// UnlinkTicket handles DELETE /major-incidents/:id/tickets/:ticketID.
+func (mc *MajorIncidentController) UnlinkTicket(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	ticketID, err := strconv.ParseUint(ctx.Param("ticketID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	incident, err := mc.MajorIncidentService.UnlinkTicket(ctx.GetUint("agentID"), uint(id), uint(ticketID))
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to unlink ticket")
+		return
+	}
+	ctx.JSON(http.StatusOK, incident)
+}
+
+// Broadcast handles POST /major-incidents/:id/updates, which emails a
+// status update to the requesters of the linked tickets.
+func (mc *MajorIncidentController) Broadcast(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var broadcast services.MajorIncidentBroadcast
+	if err := ctx.ShouldBindJSON(&broadcast); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	update, err := mc.MajorIncidentService.Broadcast(ctx.GetUint("agentID"), uint(id), &broadcast)
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to broadcast update")
+		return
+	}
+	ctx.JSON(http.StatusCreated, update)
+}
+
+// GetTicketIncident handles GET /tickets/:id/major-incident.
+func (mc *MajorIncidentController) GetTicketIncident(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	incident, err := mc.MajorIncidentService.GetTicketIncident(uint(id))
+	if err != nil {
+		majorIncidentError(ctx, err, "Failed to retrieve major incident")
+		return
+	}
+	ctx.JSON(http.StatusOK, incident)
+}
+
+// GetStatus handles GET /status?site=, the public list of active major
+// incidents by site.
+func (mc *MajorIncidentController) GetStatus(ctx *gin.Context) {
+	sites, err := mc.MajorIncidentService.GetStatus(ctx.Query("site"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status"})
+		return
+	}
+	ctx.Header("Cache-Control", "public, max-age=60")
+	ctx.JSON(http.StatusOK, sites)
+}
+
+func majorIncidentError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidMajorIncident):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	NotificationService *services.DefaultNotificationService
+}
+
+func NewNotificationController(notificationService *services.DefaultNotificationService) *NotificationController {
+	return &NotificationController{
+		NotificationService: notificationService,
+	}
+}
+
+// GetUserPreferences handles GET /users/:id/notification-preferences.
+func (pc *NotificationController) GetUserPreferences(ctx *gin.Context) {
+	pc.getPreferences(ctx, models.RecipientUser)
+}
+
+// UpdateUserPreferences handles PUT /users/:id/notification-preferences.
+func (pc *NotificationController) UpdateUserPreferences(ctx *gin.Context) {
+	pc.updatePreferences(ctx, models.RecipientUser)
+}
+
+// GetAgentPreferences handles GET /agents/:id/notification-preferences.
+func (pc *NotificationController) GetAgentPreferences(ctx *gin.Context) {
+	pc.getPreferences(ctx, models.RecipientAgent)
+}
+
+// UpdateAgentPreferences handles PUT /agents/:id/notification-preferences.
+func (pc *NotificationController) UpdateAgentPreferences(ctx *gin.Context) {
+	pc.updatePreferences(ctx, models.RecipientAgent)
+}
+
+// ownRecipientID returns the :id of a preferences route when it is the
+// signed-in user or agent. Nobody may read or change the preferences of
+// someone else.
+func ownRecipientID(ctx *gin.Context, recipientType string) (uint, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return 0, false
+	}
+	signedIn := ctx.GetUint("userID")
+	if recipientType == models.RecipientAgent {
+		signedIn = ctx.GetUint("agentID")
+	}
+	if signedIn == 0 || uint(id) != signedIn {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Not your notification preferences"})
+		return 0, false
+	}
+	return uint(id), true
+}
+
+func (pc *NotificationController) getPreferences(ctx *gin.Context, recipientType string) {
+	id, ok := ownRecipientID(ctx, recipientType)
+	if !ok {
+		return
+	}
+	preferences, err := pc.NotificationService.GetPreferences(recipientType, id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, preferences)
+}
+
+func (pc *NotificationController) updatePreferences(ctx *gin.Context, recipientType string) {
+	id, ok := ownRecipientID(ctx, recipientType)
+	if !ok {
+		return
+	}
+	var preferences []models.NotificationPreference
+	if err := ctx.ShouldBindJSON(&preferences); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	updated, err := pc.NotificationService.UpdatePreferences(recipientType, id, preferences)
+	if err != nil {
+		if errors.Is(err, services.ErrUnknownNotificationEvent) || errors.Is(err, services.ErrUnknownNotificationChannel) {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification preferences"})
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// GetTicketNotifications handles GET /tickets/:id/notifications.
+func (pc *NotificationController) GetTicketNotifications(ctx *gin.Context) {
+	ticketID, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	notifications, err := pc.NotificationService.GetNotificationsByTicket(uint(ticketID))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, notifications)
+}
+
+type deliveryReceipt struct {
+	ID     string `json:"id" form:"id" binding:"required"`
+	Status string `json:"status" form:"status" binding:"required"`
+	Error  string `json:"error" form:"error"`
+}
+
+// ReceiveReceipt handles POST /notifications/receipts/:channel, the
+// delivery receipt callback of messaging gateways. The gateway passes the
+// receipt token in the X-Receipt-Token header or the token query parameter.
+func (pc *NotificationController) ReceiveReceipt(ctx *gin.Context) {
+	token := ctx.GetHeader("X-Receipt-Token")
+	if token == "" {
+		token = ctx.Query("token")
+	}
+	expected := pc.NotificationService.Config.ReceiptToken
+	if expected == "" || subtle.ConstantTimeCompare([]byte(token), []byte(expected)) != 1 {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrInvalidReceiptToken.Error()})
+		return
+	}
+	var receipt deliveryReceipt
+	if err := ctx.ShouldBind(&receipt); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	notification, err := pc.NotificationService.RecordReceipt(ctx.Param("channel"), receipt.ID, receipt.Status, receipt.Error)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record delivery receipt"})
+		return
+	}
+	ctx.JSON(http.StatusOK, notification)
+}
+
+// GetReceipts handles GET /notifications/:id/receipts.
+func (pc *NotificationController) GetReceipts(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	receipts, err := pc.NotificationService.GetReceipts(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, receipts)
+}
@@ -0,0 +1,204 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type PriorityMatrixController struct {
+	PriorityMatrixService *services.DefaultPriorityMatrixService
+}
+
+func NewPriorityMatrixController(priorityMatrixService *services.DefaultPriorityMatrixService) *PriorityMatrixController {
+	return &PriorityMatrixController{
+		PriorityMatrixService: priorityMatrixService,
+	}
+}
+
+type overridePriorityRequest struct {
+	PriorityID    int    `json:"priority_id" binding:"required"`
+	Justification string `json:"justification" binding:"required"`
+}
+
+// GetMatrix handles GET /admin/priority-matrix.
+func (mc *PriorityMatrixController) GetMatrix(ctx *gin.Context) {
+	matrix, err := mc.PriorityMatrixService.GetMatrix()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve priority matrix"})
+		return
+	}
+	ctx.JSON(http.StatusOK, matrix)
+}
+
+// SetCells handles PUT /admin/priority-matrix with the cells to set.
+func (mc *PriorityMatrixController) SetCells(ctx *gin.Context) {
+	var cells []models.PriorityMatrixCell
+	if err := ctx.ShouldBindJSON(&cells); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	matrix, err := mc.PriorityMatrixService.SetCells(cells)
+	if err != nil {
+		matrixError(ctx, err, "Failed to update priority matrix")
+		return
+	}
+	ctx.JSON(http.StatusOK, matrix)
+}
+
+// GetImpacts handles GET /admin/priority-matrix/impacts.
+func (mc *PriorityMatrixController) GetImpacts(ctx *gin.Context) {
+	impacts, err := mc.PriorityMatrixService.GetImpacts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve impacts"})
+		return
+	}
+	ctx.JSON(http.StatusOK, impacts)
+}
+
+// CreateImpact handles POST /admin/priority-matrix/impacts.
+func (mc *PriorityMatrixController) CreateImpact(ctx *gin.Context) {
+	var impact models.Impact
+	if err := ctx.ShouldBindJSON(&impact); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := mc.PriorityMatrixService.CreateImpact(&impact); err != nil {
+		matrixError(ctx, err, "Failed to create impact")
+		return
+	}
+	ctx.JSON(http.StatusCreated, impact)
+}
+
+// UpdateImpact handles PUT /admin/priority-matrix/impacts/:id.
+func (mc *PriorityMatrixController) UpdateImpact(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var impact models.Impact
+	if err := ctx.ShouldBindJSON(&impact); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	impact.ID = uint(id)
+	updated, err := mc.PriorityMatrixService.UpdateImpact(&impact)
+	if err != nil {
+		matrixError(ctx, err, "Failed to update impact")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteImpact handles DELETE /admin/priority-matrix/impacts/:id.
+func (mc *PriorityMatrixController) DeleteImpact(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := mc.PriorityMatrixService.DeleteImpact(uint(id))
+	if err != nil {
+		matrixError(ctx, err, "Failed to delete impact")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetUrgencies handles GET /admin/priority-matrix/urgencies.
+func (mc *PriorityMatrixController) GetUrgencies(ctx *gin.Context) {
+	urgencies, err := mc.PriorityMatrixService.GetUrgencies()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve urgencies"})
+		return
+	}
+	ctx.JSON(http.StatusOK, urgencies)
+}
+
+// CreateUrgency handles POST /admin/priority-matrix/urgencies.
+func (mc *PriorityMatrixController) CreateUrgency(ctx *gin.Context) {
+	var urgency models.Urgency
+	if err := ctx.ShouldBindJSON(&urgency); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := mc.PriorityMatrixService.CreateUrgency(&urgency); err != nil {
+		matrixError(ctx, err, "Failed to create urgency")
+		return
+	}
+	ctx.JSON(http.StatusCreated, urgency)
+}
+
+// UpdateUrgency handles PUT /admin/priority-matrix/urgencies/:id.
+func (mc *PriorityMatrixController) UpdateUrgency(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var urgency models.Urgency
+	if err := ctx.ShouldBindJSON(&urgency); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	urgency.ID = uint(id)
+	updated, err := mc.PriorityMatrixService.UpdateUrgency(&urgency)
+	if err != nil {
+		matrixError(ctx, err, "Failed to update urgency")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteUrgency handles DELETE /admin/priority-matrix/urgencies/:id.
+func (mc *PriorityMatrixController) DeleteUrgency(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := mc.PriorityMatrixService.DeleteUrgency(uint(id))
+	if err != nil {
+		matrixError(ctx, err, "Failed to delete urgency")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// OverridePriority handles PUT /tickets/:id/priority with the priority an
+// agent chooses instead of the matrix and the justification.
+func (mc *PriorityMatrixController) OverridePriority(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	var request overridePriorityRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	ticket, err := mc.PriorityMatrixService.OverridePriority(uint(id), ctx.GetUint("agentID"), request.PriorityID, request.Justification)
+	if err != nil {
+		matrixError(ctx, err, "Failed to override priority")
+		return
+	}
+	ctx.JSON(http.StatusOK, ticket)
+}
+
+func matrixError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidPriorityMatrix), errors.Is(err, services.ErrInvalidOverride):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,191 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type ProblemController struct {
+	ProblemService *services.DefaultProblemService
+}
+
+func NewProblemController(problemService *services.DefaultProblemService) *ProblemController {
+	return &ProblemController{
+		ProblemService: problemService,
+	}
+}
+
+// incidentLinks is the body of POST /problems/:id/incidents.
+type incidentLinks struct {
+	TicketIDs []uint `json:"ticket_ids"`
+}
+
+// GetProblems handles GET /problems?status=, e.g. status=known_error for
+// the known errors.
+func (pc *ProblemController) GetProblems(ctx *gin.Context) {
+	problems, err := pc.ProblemService.GetProblems(ctx.Query("status"))
+	if err != nil {
+		problemError(ctx, err, "Failed to retrieve problems")
+		return
+	}
+	ctx.JSON(http.StatusOK, problems)
+}
+
+// CreateProblem handles POST /problems.
+func (pc *ProblemController) CreateProblem(ctx *gin.Context) {
+	var problem models.Problem
+	if err := ctx.ShouldBindJSON(&problem); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := pc.ProblemService.CreateProblem(ctx.GetUint("agentID"), &problem); err != nil {
+		problemError(ctx, err, "Failed to create problem")
+		return
+	}
+	ctx.JSON(http.StatusCreated, problem)
+}
+
+// GetProblem handles GET /problems/:id.
+func (pc *ProblemController) GetProblem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	problem, err := pc.ProblemService.GetProblem(uint(id))
+	if err != nil {
+		problemError(ctx, err, "Failed to retrieve problem")
+		return
+	}
+	ctx.JSON(http.StatusOK, problem)
+}
+
+// UpdateProblem handles PUT /problems/:id.
+func (pc *ProblemController) UpdateProblem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var problem models.Problem
+	if err := ctx.ShouldBindJSON(&problem); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	problem.ID = uint(id)
+	updated, err := pc.ProblemService.UpdateProblem(&problem)
+	if err != nil {
+		problemError(ctx, err, "Failed to update problem")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteProblem handles DELETE /problems/:id.
+func (pc *ProblemController) DeleteProblem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := pc.ProblemService.DeleteProblem(uint(id))
+	if err != nil {
+		problemError(ctx, err, "Failed to delete problem")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// LinkIncidents handles POST /problems/:id/incidents.
+func (pc *ProblemController) LinkIncidents(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var links incidentLinks
+	if err := ctx.ShouldBindJSON(&links); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	problem, err := pc.ProblemService.LinkIncidents(ctx.GetUint("agentID"), uint(id), links.TicketIDs)
+	if err != nil {
+		problemError(ctx, err, "Failed to link incidents")
+		return
+	}
+	ctx.JSON(http.StatusOK, problem)
+}
+
+// UnlinkIncident handles DELETE /problems/:id/incidents/:ticketID.
+func (pc *ProblemController) UnlinkIncident(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	ticketID, err := strconv.ParseUint(ctx.Param("ticketID"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	problem, err := pc.ProblemService.UnlinkIncident(ctx.GetUint("agentID"), uint(id), uint(ticketID))
+	if err != nil {
+		problemError(ctx, err, "Failed to unlink incident")
+		return
+	}
+	ctx.JSON(http.StatusOK, problem)
+}
+
+// ResolveProblem handles POST /problems/:id/resolve, which with
+// resolve_incidents also resolves the linked incidents, or retries those
+// left open of a resolved problem.
+func (pc *ProblemController) ResolveProblem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var resolution services.ProblemResolution
+	if err := ctx.ShouldBindJSON(&resolution); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	result, err := pc.ProblemService.Resolve(ctx.GetUint("agentID"), uint(id), &resolution)
+	if err != nil {
+		problemError(ctx, err, "Failed to resolve problem")
+		return
+	}
+	ctx.JSON(http.StatusOK, result)
+}
+
+// GetTicketProblems handles GET /tickets/:id/problems.
+func (pc *ProblemController) GetTicketProblems(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	problems, err := pc.ProblemService.GetTicketProblems(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve problems"})
+		return
+	}
+	ctx.JSON(http.StatusOK, problems)
+}
+
+func problemError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidProblem):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,214 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/rules"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type RuleController struct {
+	RuleService *services.DefaultRuleService
+}
+
+func NewRuleController(ruleService *services.DefaultRuleService) *RuleController {
+	return &RuleController{
+		RuleService: ruleService,
+	}
+}
+
+// ruleRequest is the body of rule create and update requests. Active
+// defaults to true.
+type ruleRequest struct {
+	Name           string                 `json:"name" binding:"required"`
+	Description    string                 `json:"description"`
+	Events         []string               `json:"events" binding:"required"`
+	Match          string                 `json:"match"`
+	Conditions     []models.RuleCondition `json:"conditions"`
+	Actions        []models.RuleAction    `json:"actions" binding:"required"`
+	Position       int                    `json:"position"`
+	Active         *bool                  `json:"active"`
+	StopProcessing bool                   `json:"stop_processing"`
+}
+
+func (r *ruleRequest) rule() *models.Rule {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &models.Rule{
+		Name:           r.Name,
+		Description:    r.Description,
+		Events:         r.Events,
+		Match:          r.Match,
+		Conditions:     r.Conditions,
+		Actions:        r.Actions,
+		Position:       r.Position,
+		Active:         active,
+		StopProcessing: r.StopProcessing,
+	}
+}
+
+// dryRunRequest is the body of dry run requests. Rule is only read when
+// no saved rule is given in the path.
+type dryRunRequest struct {
+	TicketID uint         `json:"ticket_id" binding:"required"`
+	Event    string       `json:"event"`
+	Rule     *ruleRequest `json:"rule"`
+}
+
+// CreateRule handles POST /admin/rules.
+func (pc *RuleController) CreateRule(ctx *gin.Context) {
+	var req ruleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	rule := req.rule()
+	rule.CreatedBy = ctx.GetUint("agentID")
+	if err := pc.RuleService.CreateRule(rule); err != nil {
+		ruleError(ctx, err, "Failed to create rule")
+		return
+	}
+	ctx.JSON(http.StatusCreated, rule)
+}
+
+// GetRules handles GET /admin/rules, in the order the rules run.
+func (pc *RuleController) GetRules(ctx *gin.Context) {
+	rules, err := pc.RuleService.GetAllRules()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, rules)
+}
+
+// GetRuleByID handles GET /admin/rules/:id.
+func (pc *RuleController) GetRuleByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	rule, err := pc.RuleService.GetRuleByID(uint(id))
+	if err != nil {
+		ruleError(ctx, err, "Failed to retrieve rule")
+		return
+	}
+	ctx.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule handles PUT /admin/rules/:id.
+func (pc *RuleController) UpdateRule(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req ruleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	rule := req.rule()
+	rule.ID = uint(id)
+	updated, err := pc.RuleService.UpdateRule(rule)
+	if err != nil {
+		ruleError(ctx, err, "Failed to update rule")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteRule handles DELETE /admin/rules/:id.
+func (pc *RuleController) DeleteRule(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := pc.RuleService.DeleteRule(uint(id))
+	if err != nil {
+		ruleError(ctx, err, "Failed to delete rule")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// DryRunRule handles POST /admin/rules/:id/dry-run and, for a rule that is
+// not saved yet, POST /admin/rules/dry-run. Nothing is changed.
+func (pc *RuleController) DryRunRule(ctx *gin.Context) {
+	var req dryRunRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	var rule *models.Rule
+	if ctx.Param("id") != "" {
+		id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+		if rule, err = pc.RuleService.GetRuleByID(uint(id)); err != nil {
+			ruleError(ctx, err, "Failed to retrieve rule")
+			return
+		}
+	} else if req.Rule != nil {
+		rule = req.Rule.rule()
+	} else {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A rule is required"})
+		return
+	}
+	evaluation, err := pc.RuleService.DryRun(rule, req.TicketID, req.Event)
+	if err != nil {
+		ruleError(ctx, err, "Failed to evaluate rule")
+		return
+	}
+	ctx.JSON(http.StatusOK, evaluation)
+}
+
+// GetExecutions handles GET /admin/rules/executions, optionally filtered by
+// ?ticket_id=, and GET /admin/rules/:id/executions.
+func (pc *RuleController) GetExecutions(ctx *gin.Context) {
+	var ruleID, ticketID uint64
+	var err error
+	if ctx.Param("id") != "" {
+		if ruleID, err = strconv.ParseUint(ctx.Param("id"), 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+			return
+		}
+	}
+	if ctx.Query("ticket_id") != "" {
+		if ticketID, err = strconv.ParseUint(ctx.Query("ticket_id"), 10, 64); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+			return
+		}
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	executions, err := pc.RuleService.GetExecutions(uint(ruleID), uint(ticketID), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, executions)
+}
+
+func ruleError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, rules.ErrInvalidRule):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,206 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type ServiceCatalogController struct {
+	ServiceCatalogService *services.DefaultServiceCatalogService
+}
+
+func NewServiceCatalogController(serviceCatalogService *services.DefaultServiceCatalogService) *ServiceCatalogController {
+	return &ServiceCatalogController{
+		ServiceCatalogService: serviceCatalogService,
+	}
+}
+
+// GetCatalog handles GET /catalog, the items that can be requested.
+func (sc *ServiceCatalogController) GetCatalog(ctx *gin.Context) {
+	items, err := sc.ServiceCatalogService.GetItems(true)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve catalog"})
+		return
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+// GetRequestForm handles GET /catalog/:id, an item with its request form.
+func (sc *ServiceCatalogController) GetRequestForm(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	form, err := sc.ServiceCatalogService.GetRequestForm(uint(id))
+	if err != nil {
+		catalogError(ctx, err, "Failed to retrieve request form")
+		return
+	}
+	ctx.JSON(http.StatusOK, form)
+}
+
+// SubmitRequest handles POST /catalog/:id/requests, creating the ticket of
+// the request of the signed-in user and its fulfilment tasks.
+func (sc *ServiceCatalogController) SubmitRequest(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var request services.ServiceRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	ticket, err := sc.ServiceCatalogService.Submit(ctx.GetUint("userID"), uint(id), &request)
+	if err != nil {
+		catalogError(ctx, err, "Failed to submit request")
+		return
+	}
+	ctx.JSON(http.StatusCreated, ticket)
+}
+
+// GetItems handles GET /admin/catalog, inactive items included.
+func (sc *ServiceCatalogController) GetItems(ctx *gin.Context) {
+	items, err := sc.ServiceCatalogService.GetItems(false)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve catalog"})
+		return
+	}
+	ctx.JSON(http.StatusOK, items)
+}
+
+// CreateItem handles POST /admin/catalog.
+func (sc *ServiceCatalogController) CreateItem(ctx *gin.Context) {
+	var item models.CatalogItem
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := sc.ServiceCatalogService.CreateItem(&item); err != nil {
+		catalogError(ctx, err, "Failed to create catalog item")
+		return
+	}
+	ctx.JSON(http.StatusCreated, item)
+}
+
+// GetItem handles GET /admin/catalog/:id.
+func (sc *ServiceCatalogController) GetItem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	item, err := sc.ServiceCatalogService.GetItem(uint(id))
+	if err != nil {
+		catalogError(ctx, err, "Failed to retrieve catalog item")
+		return
+	}
+	ctx.JSON(http.StatusOK, item)
+}
+
+// UpdateItem handles PUT /admin/catalog/:id.
+func (sc *ServiceCatalogController) UpdateItem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var item models.CatalogItem
+	if err := ctx.ShouldBindJSON(&item); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	item.ID = uint(id)
+	updated, err := sc.ServiceCatalogService.UpdateItem(&item)
+	if err != nil {
+		catalogError(ctx, err, "Failed to update catalog item")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteItem handles DELETE /admin/catalog/:id.
+func (sc *ServiceCatalogController) DeleteItem(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := sc.ServiceCatalogService.DeleteItem(uint(id))
+	if err != nil {
+		catalogError(ctx, err, "Failed to delete catalog item")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetTicketTasks handles GET /tickets/:id/tasks.
+func (sc *ServiceCatalogController) GetTicketTasks(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	tasks, err := sc.ServiceCatalogService.GetTicketTasks(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// GetUnitTasks handles GET /fulfilment-tasks?unit=&all=, the open tasks of
+// a unit, or all of them with all=true.
+func (sc *ServiceCatalogController) GetUnitTasks(ctx *gin.Context) {
+	unit := ctx.Query("unit")
+	if unit == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A unit is required"})
+		return
+	}
+	tasks, err := sc.ServiceCatalogService.GetUnitTasks(unit, ctx.Query("all") != "true")
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve tasks"})
+		return
+	}
+	ctx.JSON(http.StatusOK, tasks)
+}
+
+// UpdateTask handles PUT /fulfilment-tasks/:id.
+func (sc *ServiceCatalogController) UpdateTask(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var update services.TaskUpdate
+	if err := ctx.ShouldBindJSON(&update); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	task, err := sc.ServiceCatalogService.UpdateTask(ctx.GetUint("agentID"), uint(id), &update)
+	if err != nil {
+		catalogError(ctx, err, "Failed to update task")
+		return
+	}
+	ctx.JSON(http.StatusOK, task)
+}
+
+func catalogError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidCatalogItem), errors.Is(err, services.ErrInvalidServiceRequest),
+		errors.Is(err, services.ErrInvalidTaskUpdate), invalidTicket(err):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
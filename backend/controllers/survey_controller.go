@@ -0,0 +1,181 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type SurveyController struct {
+	SurveyService *services.DefaultSurveyService
+}
+
+func NewSurveyController(surveyService *services.DefaultSurveyService) *SurveyController {
+	return &SurveyController{
+		SurveyService: surveyService,
+	}
+}
+
+type surveyResponse struct {
+	Survey  *models.SatisfactionSurvey `json:"survey"`
+	Ratings *[]models.Satisfaction     `json:"ratings"`
+	// Selected is the rating picked by the link the survey was opened
+	// with, preselected in the form.
+	Selected int `json:"selected_rating,omitempty"`
+}
+
+type surveyRequest struct {
+	Rating   int    `json:"rating"`
+	Feedback string `json:"feedback"`
+}
+
+// GetSurvey handles GET /surveys/:token. The rating links of survey emails
+// add ?rating=<satisfaction_id>, which is preselected in the form. It does
+// not record the rating, so that opening the link is safe; the rating is
+// recorded with a POST to the same URL.
+func (sc *SurveyController) GetSurvey(ctx *gin.Context) {
+	rating, ok := queryRating(ctx)
+	if !ok {
+		return
+	}
+	survey, err := sc.SurveyService.GetSurvey(ctx.Param("token"))
+	if err != nil {
+		surveyError(ctx, err, "Failed to retrieve survey")
+		return
+	}
+	sc.respond(ctx, survey, rating)
+}
+
+// RespondToSurvey handles POST /surveys/:token. The body is optional; the
+// rating defaults to the one of the link.
+func (sc *SurveyController) RespondToSurvey(ctx *gin.Context) {
+	rating, ok := queryRating(ctx)
+	if !ok {
+		return
+	}
+	var request surveyRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&request); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	if request.Rating == 0 {
+		request.Rating = rating
+	}
+	survey, err := sc.SurveyService.Respond(ctx.Param("token"), request.Rating, request.Feedback)
+	if err != nil {
+		surveyError(ctx, err, "Failed to record response")
+		return
+	}
+	sc.respond(ctx, survey, 0)
+}
+
+// queryRating reads the optional ?rating= of a survey link.
+func queryRating(ctx *gin.Context) (int, bool) {
+	value := ctx.Query("rating")
+	if value == "" {
+		return 0, true
+	}
+	rating, err := strconv.Atoi(value)
+	if err != nil || rating < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rating"})
+		return 0, false
+	}
+	return rating, true
+}
+
+func (sc *SurveyController) respond(ctx *gin.Context, survey *models.SatisfactionSurvey, selected int) {
+	levels, err := sc.SurveyService.GetLevels()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve ratings"})
+		return
+	}
+	ctx.JSON(http.StatusOK, surveyResponse{Survey: survey, Ratings: levels, Selected: selected})
+}
+
+// GetTicketSurveys handles GET /tickets/:id/surveys.
+func (sc *SurveyController) GetTicketSurveys(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	surveys, err := sc.SurveyService.GetSurveysByTicket(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve surveys"})
+		return
+	}
+	ctx.JSON(http.StatusOK, surveys)
+}
+
+// GetStats handles GET /admin/csat?group_by=agent|unit|category&from=&to=.
+// Dates are RFC 3339 or YYYY-MM-DD, in which case to is inclusive; the
+// default is the last 30 days.
+func (sc *SurveyController) GetStats(ctx *gin.Context) {
+	from, to, ok := statsPeriod(ctx)
+	if !ok {
+		return
+	}
+	stats, err := sc.SurveyService.GetStats(ctx.DefaultQuery("group_by", models.SurveyGroupAgent), from, to)
+	if err != nil {
+		surveyError(ctx, err, "Failed to retrieve satisfaction statistics")
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
+
+// statsPeriod reads the from and to query parameters of statistics, the
+// last 30 days by default. It answers 400 and reports false when they are
+// invalid.
+func statsPeriod(ctx *gin.Context) (time.Time, time.Time, bool) {
+	to := time.Now()
+	if value := ctx.Query("to"); value != "" {
+		parsed, dateOnly, err := parseStatsDate(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date"})
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+	}
+	from := to.AddDate(0, 0, -30)
+	if value := ctx.Query("from"); value != "" {
+		parsed, _, err := parseStatsDate(value)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date"})
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	return from, to, true
+}
+
+func parseStatsDate(value string) (time.Time, bool, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+func surveyError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Survey not found"})
+	case errors.Is(err, services.ErrSurveyExpired):
+		ctx.JSON(http.StatusGone, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidRating), errors.Is(err, services.ErrInvalidGrouping):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/shuttlersit/service-desk/backend/models"
 	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
 )
 
 type TicketController struct {
@@ -29,8 +31,13 @@ func (pc *TicketController) CreateTicket(ctx *gin.Context) {
 	}
 
 	err := pc.TicketService.CreateTicket(&newTicket)
+	if invalidTicket(err) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create ticket"})
+		return
 	}
 
 	ctx.JSON(http.StatusCreated, gin.H{"message": "Ticket created successfully"})
@@ -64,6 +71,18 @@ func (pc *TicketController) UpdateTicket(ctx *gin.Context) {
 	ad.ID = uint(id)
 
 	updatedAd, err := pc.TicketService.UpdateTicket(&ad)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Ticket not found"})
+		return
+	}
+	if invalidTicket(err) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrApprovalPending) {
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -98,3 +117,13 @@ func (pc *TicketController) GetAllTickets(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, tickets)
 }
+
+// invalidTicket reports whether err rejects the ticket sent rather than
+// failing to store it.
+func invalidTicket(err error) bool {
+	return errors.Is(err, services.ErrInvalidImpactUrgency) ||
+		errors.Is(err, services.ErrInvalidCustomFieldValue) ||
+		errors.Is(err, services.ErrInvalidTicketReference) ||
+		errors.Is(err, services.ErrNoApprover) ||
+		errors.Is(err, services.ErrPriorityFromMatrix)
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type TicketPolicyController struct {
+	TicketPolicyService  *services.DefaultTicketPolicyService
+	TicketHistoryService *services.DefaultTicketHistoryService
+}
+
+func NewTicketPolicyController(ticketPolicyService *services.DefaultTicketPolicyService, ticketHistoryService *services.DefaultTicketHistoryService) *TicketPolicyController {
+	return &TicketPolicyController{
+		TicketPolicyService:  ticketPolicyService,
+		TicketHistoryService: ticketHistoryService,
+	}
+}
+
+// CreatePolicy handles POST /admin/ticket-policies.
+func (pc *TicketPolicyController) CreatePolicy(ctx *gin.Context) {
+	var policy models.TicketPolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := pc.TicketPolicyService.CreatePolicy(&policy); err != nil {
+		policyError(ctx, err, "Failed to create policy")
+		return
+	}
+	ctx.JSON(http.StatusCreated, policy)
+}
+
+// GetPolicies handles GET /admin/ticket-policies.
+func (pc *TicketPolicyController) GetPolicies(ctx *gin.Context) {
+	policies, err := pc.TicketPolicyService.GetAllPolicies()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, policies)
+}
+
+// GetPolicyByID handles GET /admin/ticket-policies/:id.
+func (pc *TicketPolicyController) GetPolicyByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	policy, err := pc.TicketPolicyService.GetPolicyByID(uint(id))
+	if err != nil {
+		policyError(ctx, err, "Failed to retrieve policy")
+		return
+	}
+	ctx.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy handles PUT /admin/ticket-policies/:id.
+func (pc *TicketPolicyController) UpdatePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var policy models.TicketPolicy
+	if err := ctx.ShouldBindJSON(&policy); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	policy.ID = uint(id)
+	updated, err := pc.TicketPolicyService.UpdatePolicy(&policy)
+	if err != nil {
+		policyError(ctx, err, "Failed to update policy")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeletePolicy handles DELETE /admin/ticket-policies/:id.
+func (pc *TicketPolicyController) DeletePolicy(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := pc.TicketPolicyService.DeletePolicy(uint(id))
+	if err != nil {
+		policyError(ctx, err, "Failed to delete policy")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetTicketHistory handles GET /tickets/:id/history.
+func (pc *TicketPolicyController) GetTicketHistory(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	history, err := pc.TicketHistoryService.GetHistory(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, history)
+}
+
+func policyError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Policy not found"})
+	case errors.Is(err, services.ErrInvalidTicketPolicy):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type TimeTrackingController struct {
+	TimeTrackingService *services.DefaultTimeTrackingService
+}
+
+func NewTimeTrackingController(timeTrackingService *services.DefaultTimeTrackingService) *TimeTrackingController {
+	return &TimeTrackingController{
+		TimeTrackingService: timeTrackingService,
+	}
+}
+
+// GetTimeEntries handles GET /tickets/:id/time.
+func (tc *TimeTrackingController) GetTimeEntries(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	entries, err := tc.TimeTrackingService.GetEntries(uint(id))
+	if err != nil {
+		timeError(ctx, err, "Failed to retrieve time entries")
+		return
+	}
+	ctx.JSON(http.StatusOK, entries)
+}
+
+// LogTime handles POST /tickets/:id/time with the minutes worked, the
+// activity type, whether it is billable and a note.
+func (tc *TimeTrackingController) LogTime(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	var entry models.TimeEntry
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	if err := tc.TimeTrackingService.LogTime(ctx.GetUint("agentID"), uint(id), &entry); err != nil {
+		timeError(ctx, err, "Failed to log time")
+		return
+	}
+	ctx.JSON(http.StatusCreated, entry)
+}
+
+// UpdateTimeEntry handles PUT /tickets/:id/time/:entry_id.
+func (tc *TimeTrackingController) UpdateTimeEntry(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	entryID, err := strconv.ParseUint(ctx.Param("entry_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time entry ID"})
+		return
+	}
+	var entry models.TimeEntry
+	if err := ctx.ShouldBindJSON(&entry); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	entry.ID = uint(entryID)
+	updated, err := tc.TimeTrackingService.UpdateEntry(uint(id), &entry)
+	if err != nil {
+		timeError(ctx, err, "Failed to update time entry")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteTimeEntry handles DELETE /tickets/:id/time/:entry_id.
+func (tc *TimeTrackingController) DeleteTimeEntry(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	entryID, err := strconv.ParseUint(ctx.Param("entry_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid time entry ID"})
+		return
+	}
+	status, err := tc.TimeTrackingService.DeleteEntry(uint(id), uint(entryID))
+	if err != nil {
+		timeError(ctx, err, "Failed to delete time entry")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// StartTimer handles POST /tickets/:id/time/timer/start. The body is
+// optional.
+func (tc *TimeTrackingController) StartTimer(ctx *gin.Context) {
+	tc.timer(ctx, tc.TimeTrackingService.StartTimer, http.StatusCreated, "Failed to start timer")
+}
+
+// StopTimer handles POST /tickets/:id/time/timer/stop. The body is optional.
+func (tc *TimeTrackingController) StopTimer(ctx *gin.Context) {
+	tc.timer(ctx, tc.TimeTrackingService.StopTimer, http.StatusOK, "Failed to stop timer")
+}
+
+func (tc *TimeTrackingController) timer(ctx *gin.Context, action func(uint, uint, services.TimerOptions) (*models.TimeEntry, error), status int, message string) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	var options services.TimerOptions
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&options); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+			return
+		}
+	}
+	entry, err := action(ctx.GetUint("agentID"), uint(id), options)
+	if err != nil {
+		timeError(ctx, err, message)
+		return
+	}
+	ctx.JSON(status, entry)
+}
+
+// GetTimeReport handles GET
+// /admin/time-reports?group_by=agent|unit|department|category&from=&to=.
+// Dates are read as for the satisfaction statistics.
+func (tc *TimeTrackingController) GetTimeReport(ctx *gin.Context) {
+	from, to, ok := statsPeriod(ctx)
+	if !ok {
+		return
+	}
+	report, err := tc.TimeTrackingService.GetReport(ctx.DefaultQuery("group_by", models.TimeGroupAgent), from, to)
+	if err != nil {
+		timeError(ctx, err, "Failed to retrieve time report")
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// GetActivityTypes handles GET /time/activity-types.
+func (tc *TimeTrackingController) GetActivityTypes(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, models.ActivityTypes)
+}
+
+func timeError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrTimerRunning), errors.Is(err, services.ErrNoTimerRunning):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidTimeEntry), errors.Is(err, services.ErrInvalidTimeGroup):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
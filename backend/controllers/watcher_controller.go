@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type WatcherController struct {
+	WatcherService *services.DefaultWatcherService
+}
+
+func NewWatcherController(watcherService *services.DefaultWatcherService) *WatcherController {
+	return &WatcherController{
+		WatcherService: watcherService,
+	}
+}
+
+// GetWatchers handles GET /tickets/:id/watchers.
+func (wc *WatcherController) GetWatchers(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	watchers, err := wc.WatcherService.GetWatchers(uint(id))
+	if err != nil {
+		watcherError(ctx, err, "Failed to retrieve watchers")
+		return
+	}
+	ctx.JSON(http.StatusOK, watchers)
+}
+
+// AddWatcher handles POST /tickets/:id/watchers with a user or agent
+// (watcher_type and recipient_id) or a CC address (watcher_type cc and
+// email). Adding an existing watcher answers 200 with it.
+func (wc *WatcherController) AddWatcher(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	var watcher models.TicketWatcher
+	if err := ctx.ShouldBindJSON(&watcher); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	created, err := wc.WatcherService.AddWatcher(uint(id), &watcher, ctx.GetUint("userID"), ctx.GetUint("agentID"))
+	if err != nil {
+		watcherError(ctx, err, "Failed to add watcher")
+		return
+	}
+	if !created {
+		ctx.JSON(http.StatusOK, watcher)
+		return
+	}
+	ctx.JSON(http.StatusCreated, watcher)
+}
+
+// RemoveWatcher handles DELETE /tickets/:id/watchers/:watcher_id.
+func (wc *WatcherController) RemoveWatcher(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ticket ID"})
+		return
+	}
+	watcherID, err := strconv.ParseUint(ctx.Param("watcher_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid watcher ID"})
+		return
+	}
+	status, err := wc.WatcherService.RemoveWatcher(uint(id), uint(watcherID))
+	if err != nil {
+		watcherError(ctx, err, "Failed to remove watcher")
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+func watcherError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidWatcher):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrWatcherForbidden):
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
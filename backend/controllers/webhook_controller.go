@@ -0,0 +1,176 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/gorm"
+)
+
+type WebhookController struct {
+	WebhookService *services.DefaultWebhookService
+}
+
+func NewWebhookController(webhookService *services.DefaultWebhookService) *WebhookController {
+	return &WebhookController{
+		WebhookService: webhookService,
+	}
+}
+
+// webhookRequest is the body of webhook create and update requests. Active
+// defaults to true.
+type webhookRequest struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+	Secret string   `json:"secret"`
+	Active *bool    `json:"active"`
+}
+
+func (r *webhookRequest) subscription() *models.WebhookSubscription {
+	active := true
+	if r.Active != nil {
+		active = *r.Active
+	}
+	return &models.WebhookSubscription{
+		Name:   r.Name,
+		URL:    r.URL,
+		Events: r.Events,
+		Secret: r.Secret,
+		Active: active,
+	}
+}
+
+// CreateWebhook handles POST /admin/webhooks. The response is the only
+// place the subscription secret is returned.
+func (pc *WebhookController) CreateWebhook(ctx *gin.Context) {
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	subscription := req.subscription()
+	subscription.CreatedBy = ctx.GetUint("agentID")
+	if err := pc.WebhookService.CreateSubscription(subscription); err != nil {
+		webhookError(ctx, err, "Failed to create webhook")
+		return
+	}
+	ctx.JSON(http.StatusCreated, subscription)
+}
+
+// GetWebhooks handles GET /admin/webhooks.
+func (pc *WebhookController) GetWebhooks(ctx *gin.Context) {
+	subscriptions, err := pc.WebhookService.GetAllSubscriptions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, subscriptions)
+}
+
+// GetWebhookByID handles GET /admin/webhooks/:id.
+func (pc *WebhookController) GetWebhookByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	subscription, err := pc.WebhookService.GetSubscriptionByID(uint(id))
+	if err != nil {
+		webhookError(ctx, err, "Failed to retrieve webhook")
+		return
+	}
+	ctx.JSON(http.StatusOK, subscription)
+}
+
+// UpdateWebhook handles PUT /admin/webhooks/:id. The secret is kept when
+// the request leaves it empty.
+func (pc *WebhookController) UpdateWebhook(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	var req webhookRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		return
+	}
+	subscription := req.subscription()
+	subscription.ID = uint(id)
+	updated, err := pc.WebhookService.UpdateSubscription(subscription)
+	if err != nil {
+		webhookError(ctx, err, "Failed to update webhook")
+		return
+	}
+	ctx.JSON(http.StatusOK, updated)
+}
+
+// DeleteWebhook handles DELETE /admin/webhooks/:id.
+func (pc *WebhookController) DeleteWebhook(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	status, err := pc.WebhookService.DeleteSubscription(uint(id))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusNoContent, status)
+}
+
+// GetDeliveries handles GET /admin/webhooks/:id/deliveries.
+func (pc *WebhookController) GetDeliveries(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	deliveries, err := pc.WebhookService.GetDeliveries(uint(id), limit)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, deliveries)
+}
+
+// ReplayDelivery handles POST /admin/webhooks/:id/deliveries/:delivery_id/replay.
+func (pc *WebhookController) ReplayDelivery(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	deliveryID, err := strconv.ParseUint(ctx.Param("delivery_id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid delivery ID"})
+		return
+	}
+	delivery, err := pc.WebhookService.ReplayDelivery(uint(id), uint(deliveryID))
+	if err != nil {
+		webhookError(ctx, err, "Failed to replay delivery")
+		return
+	}
+	ctx.JSON(http.StatusAccepted, delivery)
+}
+
+func webhookError(ctx *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+	case errors.Is(err, services.ErrInvalidWebhookURL), errors.Is(err, services.ErrUnknownWebhookEvent), errors.Is(err, services.ErrNoWebhookEvents):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
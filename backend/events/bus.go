@@ -0,0 +1,321 @@
+// backend/events/bus.go
+
+// Package events is the in-process domain event bus. Services publish events
+// inside the gorm transaction that makes the change, which writes them to an
+// outbox table; a dispatcher then hands them to the registered subscribers.
+// An event is therefore only seen once its change is committed, and is seen
+// even if the process stops right after the commit.
+//
+// Delivery is at least once: a subscriber that fails, or a dispatcher that
+// crashes while handling an event, causes the event to be delivered again.
+// Subscribers that must not act twice can use Event.ID to recognise repeats.
+package events
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+// Event is a domain event as delivered to subscribers.
+type Event struct {
+	ID         string          `json:"id"`
+	Name       string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Payload    json.RawMessage `json:"data"`
+}
+
+// Decode unmarshals the payload of the event into v.
+func (e Event) Decode(v interface{}) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+// Handler handles an event. Returning an error makes the bus retry the
+// event for this subscriber.
+type Handler func(ctx context.Context, e Event) error
+
+// Typed adapts a handler that takes the payload decoded into T.
+func Typed[T any](fn func(ctx context.Context, e Event, payload *T) error) Handler {
+	return func(ctx context.Context, e Event) error {
+		var payload T
+		if err := e.Decode(&payload); err != nil {
+			return fmt.Errorf("decoding %s payload: %w", e.Name, err)
+		}
+		return fn(ctx, e, &payload)
+	}
+}
+
+// Config configures the dispatcher.
+type Config struct {
+	// MaxAttempts is the number of dispatch attempts before an event is
+	// marked as failed.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every failed attempt.
+	RetryBackoff time.Duration
+	// BatchSize bounds the events dispatched per run.
+	BatchSize int
+	// StaleAfter is how long an event may stay claimed before another
+	// dispatcher assumes the first one crashed.
+	StaleAfter time.Duration
+}
+
+// DefaultConfig returns the default dispatcher settings.
+func DefaultConfig() Config {
+	return Config{
+		MaxAttempts:  10,
+		RetryBackoff: 10 * time.Second,
+		BatchSize:    100,
+		StaleAfter:   5 * time.Minute,
+	}
+}
+
+const maxRetryBackoff = time.Hour
+
+type subscription struct {
+	subscriber string
+	pattern    string
+	handler    Handler
+}
+
+// Bus publishes events to the outbox and dispatches them to subscribers.
+type Bus struct {
+	Outbox *models.OutboxDBModel
+	Config Config
+
+	mu            sync.RWMutex
+	subscriptions []subscription
+	wake          chan struct{}
+}
+
+// NewBus creates a new Bus.
+func NewBus(outbox *models.OutboxDBModel, config Config) *Bus {
+	defaults := DefaultConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = defaults.StaleAfter
+	}
+	return &Bus{
+		Outbox: outbox,
+		Config: config,
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// Subscribe registers a handler for the events matching pattern: an event
+// name such as "ticket.created", a prefix such as "ticket.*", or "*" for
+// every event. The subscriber name identifies the handler in the delivery
+// records and must stay the same across restarts.
+func (b *Bus) Subscribe(subscriber, pattern string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subscriptions = append(b.subscriptions, subscription{subscriber: subscriber, pattern: pattern, handler: handler})
+}
+
+func matches(pattern, name string) bool {
+	if pattern == "*" || pattern == name {
+		return true
+	}
+	return strings.HasSuffix(pattern, ".*") && strings.HasPrefix(name, strings.TrimSuffix(pattern, "*"))
+}
+
+// Publish writes an event to the outbox using tx, so it is committed or
+// rolled back together with the change it describes. With a nil tx the
+// event is written on its own.
+func (b *Bus) Publish(tx *gorm.DB, name string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	outbox := b.Outbox
+	if tx != nil {
+		outbox = models.NewOutboxDBModel(tx)
+	}
+	now := time.Now()
+	err = outbox.CreateEvent(&models.OutboxEvent{
+		EventID:       newEventID(),
+		Name:          name,
+		Payload:       string(data),
+		OccurredAt:    now.UTC(),
+		Status:        models.OutboxStatusPending,
+		NextAttemptAt: now,
+	})
+	if err != nil {
+		return err
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Dispatch hands the due events to their subscribers and returns how many
+// events were fully handled.
+func (b *Bus) Dispatch(ctx context.Context) (int, error) {
+	now := time.Now()
+	staleBefore := now.Add(-b.Config.StaleAfter)
+	due, err := b.Outbox.GetDueEvents(now, staleBefore, b.Config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	done := 0
+	for i := range *due {
+		if err := ctx.Err(); err != nil {
+			return done, err
+		}
+		row := &(*due)[i]
+		claimed, err := b.Outbox.ClaimEvent(row.ID, staleBefore)
+		if err != nil {
+			return done, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := b.dispatch(ctx, row); err != nil {
+			return done, err
+		}
+		if row.Status == models.OutboxStatusDone {
+			done++
+		}
+	}
+	return done, nil
+}
+
+// dispatch delivers one claimed event to the subscribers that have not
+// handled it yet and records the outcome.
+func (b *Bus) dispatch(ctx context.Context, row *models.OutboxEvent) error {
+	delivered, err := b.Outbox.GetDeliveredSubscribers(row.ID)
+	if err != nil {
+		return err
+	}
+	handled := map[string]bool{}
+	for _, s := range delivered {
+		handled[s] = true
+	}
+	event := Event{
+		ID:         row.EventID,
+		Name:       row.Name,
+		OccurredAt: row.OccurredAt,
+		Payload:    json.RawMessage(row.Payload),
+	}
+
+	b.mu.RLock()
+	subscriptions := append([]subscription(nil), b.subscriptions...)
+	b.mu.RUnlock()
+
+	var failures []string
+	for _, s := range subscriptions {
+		if handled[s.subscriber] || !matches(s.pattern, row.Name) {
+			continue
+		}
+		if err := call(ctx, s.handler, event); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.subscriber, err))
+			continue
+		}
+		handled[s.subscriber] = true
+		if err := b.Outbox.MarkDelivered(row.ID, s.subscriber); err != nil {
+			return err
+		}
+	}
+
+	row.Attempts++
+	if len(failures) == 0 {
+		now := time.Now()
+		row.Status = models.OutboxStatusDone
+		row.ProcessedAt = &now
+		row.LastError = ""
+	} else {
+		row.LastError = strings.Join(failures, "; ")
+		if row.Attempts >= b.Config.MaxAttempts {
+			row.Status = models.OutboxStatusFailed
+		} else {
+			row.Status = models.OutboxStatusPending
+			row.NextAttemptAt = time.Now().Add(b.backoff(row.Attempts))
+		}
+		log.Printf("events: %s %s: %s", row.Name, row.EventID, row.LastError)
+	}
+	return b.Outbox.UpdateEvent(row)
+}
+
+// call runs a handler, turning a panic into an error so one faulty
+// subscriber cannot stop the dispatcher.
+func call(ctx context.Context, h Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return h(ctx, e)
+}
+
+func (b *Bus) backoff(attempts int) time.Duration {
+	delay := b.Config.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+// Run dispatches events every interval, and soon after an event is
+// published, until ctx is cancelled.
+func (b *Bus) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := b.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("events: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.wake:
+		}
+	}
+}
+
+// Retry queues a failed event again with a fresh set of attempts.
+func (b *Bus) Retry(id uint) (*models.OutboxEvent, error) {
+	row, err := b.Outbox.GetEventByID(id)
+	if err != nil {
+		return nil, err
+	}
+	row.Status = models.OutboxStatusPending
+	row.Attempts = 0
+	row.NextAttemptAt = time.Now()
+	if err := b.Outbox.UpdateEvent(row); err != nil {
+		return nil, err
+	}
+	select {
+	case b.wake <- struct{}{}:
+	default:
+	}
+	return row, nil
+}
+
+func newEventID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
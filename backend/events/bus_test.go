@@ -0,0 +1,247 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestBus(t *testing.T, config Config) (*Bus, *gorm.DB) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.OutboxEvent{}, &models.OutboxDelivery{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewBus(models.NewOutboxDBModel(db), config), db
+}
+
+// due makes every pending event due now, skipping its backoff.
+func due(t *testing.T, db *gorm.DB) {
+	t.Helper()
+	err := db.Model(&models.OutboxEvent{}).Where("status = ?", models.OutboxStatusPending).
+		UpdateColumn("next_attempt_at", time.Now().Add(-time.Second)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func dispatch(t *testing.T, bus *Bus, want int) {
+	t.Helper()
+	done, err := bus.Dispatch(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done != want {
+		t.Fatalf("Dispatch handled %d events, want %d", done, want)
+	}
+}
+
+func onlyEvent(t *testing.T, db *gorm.DB) models.OutboxEvent {
+	t.Helper()
+	var rows []models.OutboxEvent
+	if err := db.Find(&rows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("outbox has %d events, want 1", len(rows))
+	}
+	return rows[0]
+}
+
+func TestPublishFollowsTransaction(t *testing.T) {
+	bus, db := newTestBus(t, Config{})
+	var got []string
+	bus.Subscribe("recorder", "ticket.*", func(ctx context.Context, e Event) error {
+		var payload struct{ ID int }
+		if err := e.Decode(&payload); err != nil {
+			return err
+		}
+		got = append(got, e.Name)
+		return nil
+	})
+
+	rollback := errors.New("rollback")
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := bus.Publish(tx, "ticket.created", map[string]int{"ID": 1}); err != nil {
+			return err
+		}
+		return rollback
+	})
+	if !errors.Is(err, rollback) {
+		t.Fatal(err)
+	}
+	dispatch(t, bus, 0)
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return bus.Publish(tx, "ticket.updated", map[string]int{"ID": 1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bus.Publish(nil, "change.created", map[string]int{"ID": 2}); err != nil {
+		t.Fatal(err)
+	}
+	dispatch(t, bus, 2)
+	if len(got) != 1 || got[0] != "ticket.updated" {
+		t.Fatalf("delivered %v, want only the committed ticket event", got)
+	}
+	dispatch(t, bus, 0)
+}
+
+func TestRedeliveryOnlyToFailedSubscribers(t *testing.T) {
+	bus, db := newTestBus(t, Config{RetryBackoff: time.Minute})
+	calls := map[string]int{}
+	bus.Subscribe("notifier", "*", func(ctx context.Context, e Event) error {
+		calls["notifier"]++
+		return nil
+	})
+	bus.Subscribe("webhooks", "ticket.created", func(ctx context.Context, e Event) error {
+		calls["webhooks"]++
+		if calls["webhooks"] == 1 {
+			return errors.New("endpoint down")
+		}
+		return nil
+	})
+	bus.Subscribe("flaky", "ticket.created", func(ctx context.Context, e Event) error {
+		calls["flaky"]++
+		if calls["flaky"] == 1 {
+			panic("boom")
+		}
+		return nil
+	})
+	if err := bus.Publish(nil, "ticket.created", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+
+	dispatch(t, bus, 0)
+	row := onlyEvent(t, db)
+	if row.Status != models.OutboxStatusPending || row.Attempts != 1 || row.LastError == "" {
+		t.Fatalf("after a failure: %s, %d attempts, error %q; want pending, 1 attempt and the error", row.Status, row.Attempts, row.LastError)
+	}
+	if wait := time.Until(row.NextAttemptAt); wait < 50*time.Second {
+		t.Fatalf("retried in %v, want the backoff of a minute", wait)
+	}
+	dispatch(t, bus, 0)
+	if calls["webhooks"] != 1 {
+		t.Fatalf("retried before the backoff elapsed")
+	}
+
+	due(t, db)
+	dispatch(t, bus, 1)
+	if calls["notifier"] != 1 || calls["webhooks"] != 2 || calls["flaky"] != 2 {
+		t.Fatalf("calls = %v, want notifier once and the failed subscribers twice", calls)
+	}
+	row = onlyEvent(t, db)
+	if row.Status != models.OutboxStatusDone || row.ProcessedAt == nil || row.LastError != "" {
+		t.Fatalf("after the retry: %s, processed %v, error %q; want done", row.Status, row.ProcessedAt, row.LastError)
+	}
+	var deliveries int64
+	if err := db.Model(&models.OutboxDelivery{}).Count(&deliveries).Error; err != nil {
+		t.Fatal(err)
+	}
+	if deliveries != 3 {
+		t.Fatalf("%d delivery records, want one per subscriber", deliveries)
+	}
+}
+
+func TestFailedEventIsRetried(t *testing.T) {
+	bus, db := newTestBus(t, Config{MaxAttempts: 2})
+	healthy := false
+	bus.Subscribe("webhooks", "*", func(ctx context.Context, e Event) error {
+		if !healthy {
+			return errors.New("endpoint down")
+		}
+		return nil
+	})
+	if err := bus.Publish(nil, "ticket.created", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	dispatch(t, bus, 0)
+	due(t, db)
+	dispatch(t, bus, 0)
+	row := onlyEvent(t, db)
+	if row.Status != models.OutboxStatusFailed || row.Attempts != 2 {
+		t.Fatalf("after %d attempts: %s, want failed", row.Attempts, row.Status)
+	}
+	due(t, db)
+	dispatch(t, bus, 0)
+
+	retried, err := bus.Retry(row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Status != models.OutboxStatusPending || retried.Attempts != 0 {
+		t.Fatalf("Retry = %s, %d attempts; want pending with none", retried.Status, retried.Attempts)
+	}
+	healthy = true
+	dispatch(t, bus, 1)
+}
+
+func TestStaleClaimIsReclaimed(t *testing.T) {
+	bus, db := newTestBus(t, Config{StaleAfter: time.Minute})
+	calls := 0
+	bus.Subscribe("notifier", "*", func(ctx context.Context, e Event) error {
+		calls++
+		return nil
+	})
+	if err := bus.Publish(nil, "ticket.created", struct{}{}); err != nil {
+		t.Fatal(err)
+	}
+	row := onlyEvent(t, db)
+	claimed, err := bus.Outbox.ClaimEvent(row.ID, time.Now().Add(-time.Minute))
+	if err != nil || !claimed {
+		t.Fatalf("ClaimEvent = %v, %v; want claimed", claimed, err)
+	}
+
+	// Another dispatcher holds the event: it is left alone.
+	dispatch(t, bus, 0)
+	if calls != 0 {
+		t.Fatalf("an event claimed by another dispatcher was delivered")
+	}
+
+	// That dispatcher stopped long ago: the event is taken over.
+	err = db.Model(&models.OutboxEvent{}).Where("id = ?", row.ID).UpdateColumn("updated_at", time.Now().Add(-2*time.Minute)).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+	dispatch(t, bus, 1)
+	if calls != 1 {
+		t.Fatalf("stale event delivered %d times, want once", calls)
+	}
+}
+
+func TestBackoffDoublesUpToAnHour(t *testing.T) {
+	bus := NewBus(nil, Config{RetryBackoff: 10 * time.Minute})
+	for attempts, want := range map[int]time.Duration{1: 10 * time.Minute, 2: 20 * time.Minute, 3: 40 * time.Minute, 4: time.Hour, 9: time.Hour} {
+		if got := bus.backoff(attempts); got != want {
+			t.Errorf("backoff(%d) = %v, want %v", attempts, got, want)
+		}
+	}
+}
+
+func TestMatches(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*", "ticket.created", true},
+		{"ticket.created", "ticket.created", true},
+		{"ticket.*", "ticket.created", true},
+		{"ticket.*", "tickets.created", false},
+		{"ticket.created", "ticket.updated", false},
+	}
+	for _, tt := range tests {
+		if got := matches(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matches(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+// backend/imaging/imaging.go
+
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+)
+
+// MaxPixels bounds the size of images that are decoded for variants, so a
+// small file declaring huge dimensions cannot exhaust memory. Decoded, an
+// image of MaxPixels takes up to 100MB; variants are scaled from it without
+// further full-size copies.
+const MaxPixels = 25_000_000
+
+// ErrImageTooLarge is returned for images above MaxPixels.
+var ErrImageTooLarge = errors.New("image dimensions too large")
+
+// Variant describes a derived rendition of an uploaded image.
+type Variant struct {
+	Name      string `json:"name"`
+	MaxWidth  int    `json:"max_width"`
+	MaxHeight int    `json:"max_height"`
+}
+
+// Rendition is an encoded variant ready to be stored.
+type Rendition struct {
+	Name        string
+	Data        []byte
+	ContentType string
+	Width       int
+	Height      int
+}
+
+// DefaultVariants are generated for every uploaded image.
+var DefaultVariants = []Variant{
+	{Name: "thumbnail", MaxWidth: 320, MaxHeight: 320},
+	{Name: "web", MaxWidth: 1600, MaxHeight: 1600},
+}
+
+// Supported reports whether renditions can be generated for a content type.
+func Supported(contentType string) bool {
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif":
+		return true
+	}
+	return false
+}
+
+// Dimensions returns the width and height of an encoded image without decoding it fully.
+func Dimensions(data []byte) (int, int, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, err
+	}
+	w, h := cfg.Width, cfg.Height
+	if jpegOrientation(data) >= 5 {
+		w, h = h, w
+	}
+	return w, h, nil
+}
+
+// Generate decodes an image and produces one rendition per variant. The
+// image is rotated according to its EXIF orientation and re-encoded, so no
+// metadata from the original (location, camera serial, ...) survives.
+// Images with transparency are encoded as PNG, everything else as JPEG.
+func Generate(data []byte, variants []Variant) ([]Rendition, error) {
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrImageTooLarge
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	orientation := 1
+	if format == "jpeg" {
+		orientation = jpegOrientation(data)
+	}
+	asPNG := format != "jpeg" && hasAlpha(src)
+
+	renditions := make([]Rendition, 0, len(variants))
+	for _, v := range variants {
+		// Scale first and rotate the result, which is much smaller than
+		// the original.
+		maxWidth, maxHeight := v.MaxWidth, v.MaxHeight
+		if orientation >= 5 {
+			maxWidth, maxHeight = maxHeight, maxWidth
+		}
+		img := orient(Fit(src, maxWidth, maxHeight), orientation)
+		var buf bytes.Buffer
+		r := Rendition{Name: v.Name, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+		if asPNG {
+			err = png.Encode(&buf, img)
+			r.ContentType = "image/png"
+		} else {
+			err = jpeg.Encode(&buf, flatten(img), &jpeg.Options{Quality: 85})
+			r.ContentType = "image/jpeg"
+		}
+		if err != nil {
+			return nil, err
+		}
+		r.Data = buf.Bytes()
+		renditions = append(renditions, r)
+	}
+	return renditions, nil
+}
+
+// Fit scales an image down to fit within maxWidth x maxHeight while keeping
+// its aspect ratio. Images that already fit are returned unscaled.
+func Fit(src image.Image, maxWidth, maxHeight int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxWidth && h <= maxHeight {
+		return src
+	}
+	dw, dh := maxWidth, h*maxWidth/w
+	if dh > maxHeight {
+		dw, dh = w*maxHeight/h, maxHeight
+	}
+	if dw < 1 {
+		dw = 1
+	}
+	if dh < 1 {
+		dh = 1
+	}
+	return resize(src, dw, dh)
+}
+
+// resize downsamples with a box filter: every destination pixel is the
+// average of the source pixels it covers. The source is read one row at a
+// time, so no full-size copy of it is made.
+func resize(src image.Image, dw, dh int) *image.RGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	row := image.NewRGBA(image.Rect(0, 0, sw, 1))
+	sums := make([]uint64, dw*4)
+
+	out := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for i := range sums {
+			sums[i] = 0
+		}
+		for y := y0; y < y1; y++ {
+			draw.Draw(row, row.Bounds(), src, image.Pt(b.Min.X, b.Min.Y+y), draw.Src)
+			for dx := 0; dx < dw; dx++ {
+				x0, x1 := columns(dx, sw, dw)
+				s := sums[dx*4 : dx*4+4]
+				for i := x0 * 4; i < x1*4; i += 4 {
+					s[0] += uint64(row.Pix[i])
+					s[1] += uint64(row.Pix[i+1])
+					s[2] += uint64(row.Pix[i+2])
+					s[3] += uint64(row.Pix[i+3])
+				}
+			}
+		}
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := columns(dx, sw, dw)
+			n := uint64((x1 - x0) * (y1 - y0))
+			o := out.PixOffset(dx, dy)
+			for c := 0; c < 4; c++ {
+				out.Pix[o+c] = uint8(sums[dx*4+c] / n)
+			}
+		}
+	}
+	return out
+}
+
+// columns returns the source columns covered by destination column dx.
+func columns(dx, sw, dw int) (int, int) {
+	x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+	if x1 <= x0 {
+		x1 = x0 + 1
+	}
+	return x0, x1
+}
+
+// flatten composites an image onto white so transparent areas do not turn
+// black when encoded as JPEG.
+func flatten(src image.Image) image.Image {
+	if !hasAlpha(src) {
+		return src
+	}
+	out := image.NewRGBA(src.Bounds())
+	draw.Draw(out, out.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(out, out.Bounds(), src, src.Bounds().Min, draw.Over)
+	return out
+}
+
+// hasAlpha reports whether any pixel of the image is not fully opaque.
+func hasAlpha(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return !o.Opaque()
+	}
+	return true
+}
@@ -0,0 +1,164 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// halves returns an image whose left half is red and right half blue.
+func halves(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withOrientation inserts an EXIF segment carrying an orientation right
+// after the start of a JPEG file.
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08\x00\x01")
+	entry := make([]byte, 12)
+	binary.BigEndian.PutUint16(entry, 0x0112)
+	binary.BigEndian.PutUint16(entry[2:], 3)
+	binary.BigEndian.PutUint32(entry[4:], 1)
+	binary.BigEndian.PutUint16(entry[8:], orientation)
+	tiff = append(append(tiff, entry...), 0, 0, 0, 0)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	out := append([]byte{}, data[:2]...)
+	out = append(append(out, app1...), segment...)
+	return append(out, data[2:]...)
+}
+
+func TestGenerateVariantSizes(t *testing.T) {
+	tests := []struct {
+		name        string
+		width       int
+		height      int
+		contentType string
+		want        [][2]int
+	}{
+		{"landscape", 2000, 1000, "image/jpeg", [][2]int{{320, 160}, {1600, 800}}},
+		{"portrait", 900, 1800, "image/jpeg", [][2]int{{160, 320}, {800, 1600}}},
+		{"small images are not enlarged", 100, 50, "image/jpeg", [][2]int{{100, 50}, {100, 50}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renditions, err := Generate(encodeJPEG(t, halves(tt.width, tt.height)), DefaultVariants)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(renditions) != len(tt.want) {
+				t.Fatalf("got %d renditions, want %d", len(renditions), len(tt.want))
+			}
+			for i, r := range renditions {
+				cfg, _, err := image.DecodeConfig(bytes.NewReader(r.Data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := [2]int{r.Width, r.Height}
+				if got != tt.want[i] || cfg.Width != r.Width || cfg.Height != r.Height || r.ContentType != tt.contentType {
+					t.Errorf("%s = %v (encoded %dx%d, %s), want %v %s", r.Name, got, cfg.Width, cfg.Height, r.ContentType, tt.want[i], tt.contentType)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateKeepsTransparencyAsPNG(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 800, 400))
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	renditions, err := Generate(buf.Bytes(), []Variant{{Name: "thumbnail", MaxWidth: 200, MaxHeight: 200}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := renditions[0]; r.ContentType != "image/png" || r.Width != 200 || r.Height != 100 {
+		t.Fatalf("rendition = %s %dx%d, want image/png 200x100", r.ContentType, r.Width, r.Height)
+	}
+}
+
+func TestGenerateAppliesOrientation(t *testing.T) {
+	// Orientation 6 is displayed rotated 90° clockwise: the red left half
+	// of the stored image ends up on top.
+	data := withOrientation(encodeJPEG(t, halves(400, 200)), 6)
+	if w, h, err := Dimensions(data); err != nil || w != 200 || h != 400 {
+		t.Fatalf("Dimensions = %dx%d, %v; want 200x400", w, h, err)
+	}
+	renditions, err := Generate(data, []Variant{{Name: "thumbnail", MaxWidth: 100, MaxHeight: 100}, {Name: "web", MaxWidth: 1000, MaxHeight: 1000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range [][2]int{{50, 100}, {200, 400}} {
+		r := renditions[i]
+		if r.Width != want[0] || r.Height != want[1] {
+			t.Fatalf("%s = %dx%d, want %dx%d", r.Name, r.Width, r.Height, want[0], want[1])
+		}
+		img, err := jpeg.Decode(bytes.NewReader(r.Data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bytes.Contains(r.Data, []byte("Exif")) {
+			t.Errorf("%s keeps EXIF metadata", r.Name)
+		}
+		top, _, _, _ := img.At(r.Width/2, r.Height/4).RGBA()
+		_, _, bottom, _ := img.At(r.Width/2, r.Height*3/4).RGBA()
+		if top < 0xC000 || bottom < 0xC000 {
+			t.Errorf("%s is not upright: top red %x, bottom blue %x", r.Name, top, bottom)
+		}
+	}
+}
+
+func TestGenerateRejectsTooManyPixels(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	// Declare 10000x10000 in the header; only the header is read.
+	data := buf.Bytes()
+	ihdr := data[12:29]
+	binary.BigEndian.PutUint32(ihdr[4:], 10000)
+	binary.BigEndian.PutUint32(ihdr[8:], 10000)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(ihdr))
+
+	if _, err := Generate(data, DefaultVariants); !errors.Is(err, ErrImageTooLarge) {
+		t.Fatalf("Generate = %v, want ErrImageTooLarge", err)
+	}
+}
+
+func TestResizeAveragesCoveredPixels(t *testing.T) {
+	img := resize(halves(4, 2), 2, 1)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{R: 255, A: 255}) {
+		t.Errorf("left = %v, want red", got)
+	}
+	img = resize(halves(4, 2), 1, 1)
+	if got := img.RGBAAt(0, 0); got != (color.RGBA{R: 127, B: 127, A: 255}) {
+		t.Errorf("whole = %v, want purple", got)
+	}
+}
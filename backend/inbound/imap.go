@@ -0,0 +1,218 @@
+// backend/inbound/imap.go
+
+package inbound
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// IMAPConfig holds the settings of an IMAP mailbox.
+type IMAPConfig struct {
+	Address  string `json:"address"`
+	Username string `json:"username"`
+	Password string `json:"password"`
+	Mailbox  string `json:"mailbox"`
+	// TLS uses implicit TLS (port 993). Plain connections are meant for
+	// local test servers only.
+	TLS     bool          `json:"tls"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+// IMAPSource fetches unseen messages from an IMAP mailbox and flags them
+// as seen once processed. It implements only the handful of IMAP4rev1
+// commands needed for that.
+type IMAPSource struct {
+	Config IMAPConfig
+}
+
+// NewIMAPSource creates a new IMAPSource.
+func NewIMAPSource(config IMAPConfig) *IMAPSource {
+	if config.Mailbox == "" {
+		config.Mailbox = "INBOX"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = time.Minute
+	}
+	return &IMAPSource{
+		Config: config,
+	}
+}
+
+// Name identifies the source in logs and records.
+func (is *IMAPSource) Name() string {
+	return "imap"
+}
+
+// Fetch hands every unseen message to handle.
+func (is *IMAPSource) Fetch(ctx context.Context, handle Handler) error {
+	c, err := is.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if _, err := c.cmd("LOGIN %s %s", quote(is.Config.Username), quote(is.Config.Password)); err != nil {
+		return err
+	}
+	if _, err := c.cmd("SELECT %s", quote(is.Config.Mailbox)); err != nil {
+		return err
+	}
+	resp, err := c.cmd("UID SEARCH UNSEEN")
+	if err != nil {
+		return err
+	}
+	var uids []string
+	for _, r := range resp {
+		if strings.HasPrefix(r.line, "* SEARCH") {
+			uids = append(uids, strings.Fields(strings.TrimPrefix(r.line, "* SEARCH"))...)
+		}
+	}
+
+	for _, uid := range uids {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		resp, err := c.cmd("UID FETCH %s BODY.PEEK[]", uid)
+		if err != nil {
+			return err
+		}
+		var raw []byte
+		for _, r := range resp {
+			if strings.Contains(r.line, "FETCH") && len(r.literals) > 0 {
+				raw = r.literals[0]
+			}
+		}
+		if raw == nil {
+			continue
+		}
+		if err := handle(ctx, raw); err != nil {
+			log.Printf("imap: uid %s: %v", uid, err)
+			continue
+		}
+		if _, err := c.cmd("UID STORE %s +FLAGS.SILENT (\\Seen)", uid); err != nil {
+			return err
+		}
+	}
+	_, err = c.cmd("LOGOUT")
+	return err
+}
+
+type imapConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	tag  int
+	tmo  time.Duration
+}
+
+// imapResponse is one logical response line with any literals it carried.
+type imapResponse struct {
+	line     string
+	literals [][]byte
+}
+
+func (is *IMAPSource) dial(ctx context.Context) (*imapConn, error) {
+	dialer := &net.Dialer{Timeout: is.Config.Timeout}
+	var conn net.Conn
+	var err error
+	if is.Config.TLS {
+		host, _, _ := net.SplitHostPort(is.Config.Address)
+		td := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		conn, err = td.DialContext(ctx, "tcp", is.Config.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", is.Config.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("imap: %w", err)
+	}
+	c := &imapConn{conn: conn, r: bufio.NewReader(conn), tmo: is.Config.Timeout}
+	conn.SetDeadline(time.Now().Add(c.tmo))
+	greeting, err := c.readResponse()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(greeting.line, "* OK") && !strings.HasPrefix(greeting.line, "* PREAUTH") {
+		conn.Close()
+		return nil, fmt.Errorf("imap: unexpected greeting %q", greeting.line)
+	}
+	return c, nil
+}
+
+func (c *imapConn) close() {
+	c.conn.Close()
+}
+
+// cmd sends a tagged command and collects the responses up to its completion.
+func (c *imapConn) cmd(format string, args ...interface{}) ([]imapResponse, error) {
+	c.tag++
+	tag := "a" + strconv.Itoa(c.tag)
+	c.conn.SetDeadline(time.Now().Add(c.tmo))
+	if _, err := fmt.Fprintf(c.conn, "%s %s\r\n", tag, fmt.Sprintf(format, args...)); err != nil {
+		return nil, fmt.Errorf("imap: %w", err)
+	}
+	var untagged []imapResponse
+	for {
+		resp, err := c.readResponse()
+		if err != nil {
+			return nil, err
+		}
+		if !strings.HasPrefix(resp.line, tag+" ") {
+			untagged = append(untagged, resp)
+			continue
+		}
+		status := strings.TrimPrefix(resp.line, tag+" ")
+		if !strings.HasPrefix(status, "OK") {
+			verb := strings.Fields(format)[0]
+			return nil, fmt.Errorf("imap: %s failed: %s", verb, status)
+		}
+		return untagged, nil
+	}
+}
+
+var literalPattern = regexp.MustCompile(`\{(\d+)\}$`)
+
+// readResponse reads one line, following any {n} literals it announces.
+func (c *imapConn) readResponse() (imapResponse, error) {
+	var resp imapResponse
+	var line strings.Builder
+	for {
+		part, err := c.r.ReadString('\n')
+		if err != nil {
+			return resp, fmt.Errorf("imap: %w", err)
+		}
+		part = strings.TrimRight(part, "\r\n")
+		line.WriteString(part)
+		m := literalPattern.FindStringSubmatch(part)
+		if m == nil {
+			break
+		}
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n > MaxMessageSize {
+			return resp, fmt.Errorf("imap: literal too large")
+		}
+		lit := make([]byte, n)
+		if _, err := io.ReadFull(c.r, lit); err != nil {
+			return resp, fmt.Errorf("imap: %w", err)
+		}
+		resp.literals = append(resp.literals, lit)
+	}
+	resp.line = line.String()
+	return resp, nil
+}
+
+// quote renders an IMAP quoted string.
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + s + `"`
+}
@@ -0,0 +1,108 @@
+package inbound
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeIMAP serves one session over mailbox, a map of unseen messages by
+// UID, and returns the commands it received without their tags.
+func fakeIMAP(t *testing.T, mailbox map[string]string) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	received := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var commands []string
+		defer func() { received <- commands }()
+		fmt.Fprint(conn, "* OK IMAP4rev1 ready\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			tag, cmd, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+			commands = append(commands, cmd)
+			switch {
+			case strings.HasPrefix(cmd, "LOGIN") && cmd != `LOGIN "desk" "p\"w"`:
+				fmt.Fprintf(conn, "%s NO invalid credentials\r\n", tag)
+				continue
+			case cmd == "UID SEARCH UNSEEN":
+				fmt.Fprint(conn, "* SEARCH 3 5\r\n")
+			case strings.HasPrefix(cmd, "UID FETCH "):
+				uid := strings.Fields(cmd)[2]
+				fmt.Fprintf(conn, "* 1 FETCH (UID %s BODY[] {%d}\r\n%s)\r\n", uid, len(mailbox[uid]), mailbox[uid])
+			case cmd == "LOGOUT":
+				fmt.Fprintf(conn, "* BYE\r\n%s OK LOGOUT completed\r\n", tag)
+				return
+			}
+			fmt.Fprintf(conn, "%s OK done\r\n", tag)
+		}
+	}()
+	return ln.Addr().String(), received
+}
+
+func TestIMAPFetch(t *testing.T) {
+	mailbox := map[string]string{
+		"3": "From: ann@example.com\r\nSubject: first\r\n\r\nline one\r\nline two\r\n",
+		"5": "From: bob@example.com\r\nSubject: second\r\n\r\nfails\r\n",
+	}
+	addr, received := fakeIMAP(t, mailbox)
+	src := NewIMAPSource(IMAPConfig{Address: addr, Username: "desk", Password: `p"w`, Timeout: 5 * time.Second})
+
+	var handled []string
+	err := src.Fetch(context.Background(), func(ctx context.Context, raw []byte) error {
+		handled = append(handled, string(raw))
+		if strings.Contains(string(raw), "fails") {
+			return errors.New("handler failed")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{mailbox["3"], mailbox["5"]}; !reflect.DeepEqual(handled, want) {
+		t.Fatalf("handled %q, want %q", handled, want)
+	}
+	// Only the message that was processed is flagged as seen.
+	want := []string{
+		`LOGIN "desk" "p\"w"`,
+		`SELECT "INBOX"`,
+		"UID SEARCH UNSEEN",
+		"UID FETCH 3 BODY.PEEK[]",
+		`UID STORE 3 +FLAGS.SILENT (\Seen)`,
+		"UID FETCH 5 BODY.PEEK[]",
+		"LOGOUT",
+	}
+	if got := <-received; !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands:\n%q\nwant:\n%q", got, want)
+	}
+}
+
+func TestIMAPLoginFailure(t *testing.T) {
+	addr, _ := fakeIMAP(t, nil)
+	src := NewIMAPSource(IMAPConfig{Address: addr, Username: "desk", Password: "wrong", Timeout: 5 * time.Second})
+	err := src.Fetch(context.Background(), func(ctx context.Context, raw []byte) error {
+		t.Fatal("handler called")
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "LOGIN failed") {
+		t.Fatalf("err = %v, want a LOGIN failure", err)
+	}
+}
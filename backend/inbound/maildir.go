@@ -0,0 +1,75 @@
+// backend/inbound/maildir.go
+
+package inbound
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaildirSource reads messages delivered to a Maildir directory. Processed
+// messages are moved from new/ to cur/ and flagged as seen, as a regular
+// mail client would do.
+type MaildirSource struct {
+	Dir string
+}
+
+// NewMaildirSource creates a new MaildirSource.
+func NewMaildirSource(dir string) *MaildirSource {
+	return &MaildirSource{
+		Dir: dir,
+	}
+}
+
+// Name identifies the source in logs and records.
+func (ms *MaildirSource) Name() string {
+	return "maildir"
+}
+
+// Fetch hands every message in new/ to handle, oldest first.
+func (ms *MaildirSource) Fetch(ctx context.Context, handle Handler) error {
+	newDir := filepath.Join(ms.Dir, "new")
+	entries, err := os.ReadDir(newDir)
+	if err != nil {
+		return err
+	}
+	// Maildir file names start with the delivery timestamp.
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+
+	for _, e := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(newDir, e.Name())
+		raw, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		if err := handle(ctx, raw); err != nil {
+			log.Printf("maildir: %s: %v", e.Name(), err)
+			continue
+		}
+		seen := filepath.Join(ms.Dir, "cur", e.Name()+":2,S")
+		if err := os.Rename(path, seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readFile(path string) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(io.LimitReader(f, MaxMessageSize))
+}
@@ -0,0 +1,338 @@
+// backend/inbound/message.go
+
+package inbound
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxMessageSize bounds how much of a raw message is read.
+const MaxMessageSize = 50 << 20
+
+// maxPartDepth stops maliciously nested multiparts.
+const maxPartDepth = 10
+
+// Attachment is a file part of a message.
+type Attachment struct {
+	FileName    string
+	ContentType string
+	Data        []byte
+}
+
+// Message is the parsed form of an RFC 5322 message.
+type Message struct {
+	MessageID   string
+	From        *mail.Address
+	To          []*mail.Address
+	Cc          []*mail.Address
+	Subject     string
+	Date        time.Time
+	InReplyTo   string
+	References  []string
+	TextBody    string
+	HTMLBody    string
+	Attachments []Attachment
+	// AutoSubmitted is set for bounces, out-of-office replies and other
+	// machine generated mail that must not open tickets.
+	AutoSubmitted bool
+}
+
+var wordDecoder = &mime.WordDecoder{CharsetReader: charsetReader}
+
+// Parse reads a raw RFC 5322 message, walking any MIME structure to collect
+// the text and HTML bodies and the attached files.
+func Parse(r io.Reader) (*Message, error) {
+	msg, err := mail.ReadMessage(io.LimitReader(r, MaxMessageSize))
+	if err != nil {
+		return nil, err
+	}
+	h := msg.Header
+	m := &Message{
+		MessageID: strings.Trim(strings.TrimSpace(h.Get("Message-Id")), "<>"),
+		InReplyTo: strings.Trim(strings.TrimSpace(h.Get("In-Reply-To")), "<>"),
+		Subject:   decodeHeader(h.Get("Subject")),
+	}
+	for _, ref := range strings.Fields(h.Get("References")) {
+		m.References = append(m.References, strings.Trim(ref, "<>"))
+	}
+	parser := mail.AddressParser{WordDecoder: wordDecoder}
+	if m.From, err = parser.Parse(h.Get("From")); err != nil {
+		return nil, fmt.Errorf("invalid From header: %w", err)
+	}
+	m.To, _ = parser.ParseList(h.Get("To"))
+	m.Cc, _ = parser.ParseList(h.Get("Cc"))
+	if d, err := h.Date(); err == nil {
+		m.Date = d
+	}
+	m.AutoSubmitted = isAutoSubmitted(h)
+
+	if err := m.readPart(h, msg.Body, 0); err != nil {
+		return nil, err
+	}
+	if m.TextBody == "" && m.HTMLBody != "" {
+		m.TextBody = HTMLToText(m.HTMLBody)
+	}
+	return m, nil
+}
+
+// partHeader is the subset of header access shared by mail.Header and
+// multipart part headers.
+type partHeader interface {
+	Get(string) string
+}
+
+// readPart decodes one MIME entity, recursing into multiparts.
+func (m *Message) readPart(h partHeader, body io.Reader, depth int) error {
+	if depth > maxPartDepth {
+		return fmt.Errorf("MIME structure nested too deeply")
+	}
+	contentType := h.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain; charset=us-ascii"
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType, params = "application/octet-stream", map[string]string{}
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextRawPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			if err := m.readPart(p.Header, p, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	data, err := io.ReadAll(decodeTransfer(h.Get("Content-Transfer-Encoding"), body))
+	if err != nil {
+		return err
+	}
+
+	disposition, dparams, _ := mime.ParseMediaType(h.Get("Content-Disposition"))
+	fileName := decodeHeader(dparams["filename"])
+	if fileName == "" {
+		fileName = decodeHeader(params["name"])
+	}
+	isAttachment := disposition == "attachment" || fileName != ""
+
+	switch {
+	case !isAttachment && mediaType == "text/plain" && m.TextBody == "":
+		m.TextBody = toUTF8(data, params["charset"])
+	case !isAttachment && mediaType == "text/html" && m.HTMLBody == "":
+		m.HTMLBody = toUTF8(data, params["charset"])
+	case mediaType == "message/rfc822" && fileName == "":
+		m.Attachments = append(m.Attachments, Attachment{FileName: "forwarded.eml", ContentType: mediaType, Data: data})
+	case isAttachment || !strings.HasPrefix(mediaType, "text/"):
+		if fileName == "" {
+			fileName = "attachment"
+			if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+				fileName += exts[0]
+			}
+		}
+		m.Attachments = append(m.Attachments, Attachment{FileName: fileName, ContentType: mediaType, Data: data})
+	}
+	return nil
+}
+
+// decodeTransfer undoes a Content-Transfer-Encoding.
+func decodeTransfer(encoding string, r io.Reader) io.Reader {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, &base64Cleaner{r: r})
+	case "quoted-printable":
+		return quotedprintable.NewReader(r)
+	default:
+		return r
+	}
+}
+
+// base64Cleaner drops the line breaks and stray whitespace that mail
+// transports put into base64 bodies.
+type base64Cleaner struct {
+	r io.Reader
+}
+
+func (bc *base64Cleaner) Read(p []byte) (int, error) {
+	n, err := bc.r.Read(p)
+	j := 0
+	for i := 0; i < n; i++ {
+		switch p[i] {
+		case '\r', '\n', ' ', '\t':
+		default:
+			p[j] = p[i]
+			j++
+		}
+	}
+	return j, err
+}
+
+// decodeHeader decodes RFC 2047 encoded words, leaving malformed input as is.
+func decodeHeader(v string) string {
+	d, err := wordDecoder.DecodeHeader(v)
+	if err != nil {
+		return v
+	}
+	return d
+}
+
+// charsetReader converts the single byte charsets commonly seen in mail.
+// Anything else is passed through unchanged.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return strings.NewReader(toUTF8(data, charset)), nil
+}
+
+func toUTF8(data []byte, charset string) string {
+	switch strings.ToLower(charset) {
+	case "iso-8859-1", "latin1", "windows-1252", "cp1252":
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	return string(data)
+}
+
+// isAutoSubmitted detects auto replies and bounces (RFC 3834 and common practice).
+func isAutoSubmitted(h mail.Header) bool {
+	if v := strings.ToLower(h.Get("Auto-Submitted")); v != "" && v != "no" {
+		return true
+	}
+	if h.Get("X-Autoreply") != "" || h.Get("X-Autorespond") != "" {
+		return true
+	}
+	switch strings.ToLower(h.Get("Precedence")) {
+	case "bulk", "junk", "list", "auto_reply":
+		return true
+	}
+	return strings.EqualFold(h.Get("Return-Path"), "<>")
+}
+
+var (
+	tagPattern       = regexp.MustCompile(`(?s)<[^>]*>`)
+	blockPattern     = regexp.MustCompile(`(?i)<\s*(br|/p|/div|/li|/tr|/h[1-6])\b[^>]*>`)
+	stripPattern     = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	blankLinePattern = regexp.MustCompile(`\n{3,}`)
+)
+
+// HTMLToText produces a readable plain text rendering of an HTML body.
+func HTMLToText(s string) string {
+	s = stripPattern.ReplaceAllString(s, "")
+	s = blockPattern.ReplaceAllString(s, "\n")
+	s = tagPattern.ReplaceAllString(s, "")
+	s = html.UnescapeString(s)
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimSpace(l)
+	}
+	return strings.TrimSpace(blankLinePattern.ReplaceAllString(strings.Join(lines, "\n"), "\n\n"))
+}
+
+var quoteHeaderPattern = regexp.MustCompile(`(?i)^(on .+wrote:|-+ ?original message ?-+|from: .+|sent from my .+)$`)
+
+// StripQuotedReply removes the quoted history below a reply so only the new
+// text ends up in the ticket comment.
+func StripQuotedReply(body string) string {
+	lines := strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if quoteHeaderPattern.MatchString(trimmed) || strings.HasPrefix(trimmed, ">") {
+			lines = lines[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+var subjectRefPattern = regexp.MustCompile(`(?i)\[(?:ticket\s*)?#(\d+)\]`)
+
+// TicketReference extracts a ticket number from a subject such as
+// "Re: [Ticket #42] Printer jammed".
+func TicketReference(subject string) (uint, bool) {
+	return matchID(subjectRefPattern, subject)
+}
+
+var (
+	addressRefPattern   = regexp.MustCompile(`(?i)\+ticket-(\d+)@`)
+	messageIDRefPattern = regexp.MustCompile(`(?i)^ticket-(\d+)[.@]`)
+)
+
+// TicketReferenceFromAddresses extracts a ticket number from a
+// plus-addressed recipient such as support+ticket-42@example.com, the
+// Reply-To of ticket notifications.
+func TicketReferenceFromAddresses(addrs ...[]*mail.Address) (uint, bool) {
+	for _, list := range addrs {
+		for _, a := range list {
+			if id, ok := matchID(addressRefPattern, a.Address); ok {
+				return id, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// TicketReferenceFromThread extracts a ticket number from the In-Reply-To
+// and References headers of a reply to a ticket notification.
+func TicketReferenceFromThread(m *Message) (uint, bool) {
+	for _, ref := range append([]string{m.InReplyTo}, m.References...) {
+		if id, ok := matchID(messageIDRefPattern, ref); ok {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// TicketReferenceFromMessage looks for a ticket number in the subject, the
+// recipients and the thread headers of a message, in that order.
+func TicketReferenceFromMessage(m *Message) (uint, bool) {
+	if id, ok := TicketReference(m.Subject); ok {
+		return id, true
+	}
+	if id, ok := TicketReferenceFromAddresses(m.To, m.Cc); ok {
+		return id, true
+	}
+	return TicketReferenceFromThread(m)
+}
+
+func matchID(pattern *regexp.Regexp, s string) (uint, bool) {
+	match := pattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(match[1], 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// RawMessageID returns a stable identifier for messages without a
+// Message-ID header, derived from their content.
+func RawMessageID(raw []byte) string {
+	return fmt.Sprintf("sha256-%x", sha256.Sum256(bytes.TrimSpace(raw)))
+}
@@ -0,0 +1,148 @@
+package inbound
+
+import (
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name        string
+		raw         string
+		subject     string
+		text        string
+		html        string
+		attachments []string
+		auto        bool
+	}{
+		{
+			name:    "plain text",
+			raw:     "From: Ann <ann@example.com>\r\nTo: support@example.com\r\nSubject: Printer jammed\r\nMessage-Id: <1@example.com>\r\n\r\nIt jammed again.\r\n",
+			subject: "Printer jammed",
+			text:    "It jammed again.\r\n",
+		},
+		{
+			name:    "encoded subject and latin-1 quoted-printable body",
+			raw:     "From: ann@example.com\r\nSubject: =?UTF-8?Q?Caf=C3=A9_machine?=\r\nContent-Type: text/plain; charset=iso-8859-1\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\nLe caf=E9 est froid.",
+			subject: "Café machine",
+			text:    "Le café est froid.",
+		},
+		{
+			name: "alternative bodies with an attachment",
+			raw: "From: ann@example.com\r\nSubject: Logs\r\nContent-Type: multipart/mixed; boundary=outer\r\n\r\n" +
+				"--outer\r\nContent-Type: multipart/alternative; boundary=inner\r\n\r\n" +
+				"--inner\r\nContent-Type: text/plain\r\n\r\nSee attached.\r\n" +
+				"--inner\r\nContent-Type: text/html\r\n\r\n<p>See attached.</p>\r\n" +
+				"--inner--\r\n" +
+				"--outer\r\nContent-Type: text/plain; name=\"app.log\"\r\nContent-Disposition: attachment; filename=\"app.log\"\r\nContent-Transfer-Encoding: base64\r\n\r\naGVs\r\nbG8=\r\n" +
+				"--outer--\r\n",
+			subject:     "Logs",
+			text:        "See attached.",
+			html:        "<p>See attached.</p>",
+			attachments: []string{"app.log"},
+		},
+		{
+			name:    "html only",
+			raw:     "From: ann@example.com\r\nSubject: Hi\r\nContent-Type: text/html\r\n\r\n<html><head><style>p{}</style></head><p>Line one</p><p>Line &amp; two</p></html>",
+			subject: "Hi",
+			text:    "Line one\nLine & two",
+			html:    "<html><head><style>p{}</style></head><p>Line one</p><p>Line &amp; two</p></html>",
+		},
+		{
+			name:    "out of office reply",
+			raw:     "From: ann@example.com\r\nSubject: Away\r\nAuto-Submitted: auto-replied\r\n\r\nBack Monday.",
+			subject: "Away",
+			text:    "Back Monday.",
+			auto:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse(strings.NewReader(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Subject != tt.subject {
+				t.Errorf("subject = %q, want %q", m.Subject, tt.subject)
+			}
+			if m.TextBody != tt.text {
+				t.Errorf("text = %q, want %q", m.TextBody, tt.text)
+			}
+			if strings.TrimSpace(m.HTMLBody) != tt.html {
+				t.Errorf("html = %q, want %q", m.HTMLBody, tt.html)
+			}
+			if m.AutoSubmitted != tt.auto {
+				t.Errorf("auto-submitted = %v, want %v", m.AutoSubmitted, tt.auto)
+			}
+			if len(m.Attachments) != len(tt.attachments) {
+				t.Fatalf("%d attachments, want %d", len(m.Attachments), len(tt.attachments))
+			}
+			for i, name := range tt.attachments {
+				if m.Attachments[i].FileName != name || string(m.Attachments[i].Data) != "hello" {
+					t.Errorf("attachment %d = %q %q", i, m.Attachments[i].FileName, m.Attachments[i].Data)
+				}
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalidMessages(t *testing.T) {
+	for _, raw := range []string{
+		"not a message",
+		"Subject: no sender\r\n\r\nbody",
+		"From: ann@example.com\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n" + strings.Repeat("--b\r\nContent-Type: multipart/mixed; boundary=b\r\n\r\n", maxPartDepth+2),
+	} {
+		if _, err := Parse(strings.NewReader(raw)); err == nil {
+			t.Errorf("Parse(%q) succeeded", raw)
+		}
+	}
+}
+
+func TestTicketReferenceFromMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+		id   uint
+		ok   bool
+	}{
+		{"subject", Message{Subject: "Re: [Ticket #42] Printer jammed"}, 42, true},
+		{"short subject tag", Message{Subject: "RE: [#7] VPN"}, 7, true},
+		{"plus address", Message{To: []*mail.Address{{Address: "support+ticket-12@example.com"}}}, 12, true},
+		{"cc plus address", Message{Cc: []*mail.Address{{Address: "support+TICKET-13@example.com"}}}, 13, true},
+		{"in-reply-to", Message{InReplyTo: "ticket-9.1700000000@example.com"}, 9, true},
+		{"references", Message{References: []string{"abc@example.com", "ticket-10@example.com"}}, 10, true},
+		{"subject wins", Message{Subject: "[#1]", InReplyTo: "ticket-2@example.com"}, 1, true},
+		{"ticket zero", Message{Subject: "[#0] hello"}, 0, false},
+		{"no reference", Message{Subject: "Order #42 shipped"}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := TicketReferenceFromMessage(&tt.msg)
+			if id != tt.id || ok != tt.ok {
+				t.Fatalf("got %d %v, want %d %v", id, ok, tt.id, tt.ok)
+			}
+		})
+	}
+}
+
+func TestStripQuotedReply(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"no quote", "Thanks, fixed.", "Thanks, fixed."},
+		{"quoted lines", "Still broken.\r\n\r\n> Did a restart help?\r\n> Agent", "Still broken."},
+		{"gmail header", "Works now.\n\nOn Mon, 15 Jan 2024 at 10:00, Support wrote:\n> hi", "Works now."},
+		{"outlook header", "See below.\n-----Original Message-----\nFrom: Support", "See below."},
+		{"mobile signature", "Ok\nSent from my iPhone", "Ok"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := StripQuotedReply(tt.body); got != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
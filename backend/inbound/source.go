@@ -0,0 +1,35 @@
+// backend/inbound/source.go
+
+package inbound
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Handler processes one raw message. Returning an error leaves the message
+// in the source so it is retried on the next fetch.
+type Handler func(ctx context.Context, raw []byte) error
+
+// Source is a mailbox that can be drained of unprocessed messages.
+type Source interface {
+	Name() string
+	Fetch(ctx context.Context, handle Handler) error
+}
+
+// Poll fetches from src every interval until ctx is cancelled.
+func Poll(ctx context.Context, src Source, interval time.Duration, handle Handler) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := src.Fetch(ctx, handle); err != nil && ctx.Err() == nil {
+			log.Printf("%s: fetch failed: %v", src.Name(), err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
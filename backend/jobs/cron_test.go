@@ -0,0 +1,60 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	// A Monday.
+	now := time.Date(2024, time.January, 15, 10, 7, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2024, time.January, 15, 10, 15, 0, 0, time.UTC)},
+		{"0-30/10 * * * *", time.Date(2024, time.January, 15, 10, 10, 0, 0, time.UTC)},
+		{"5,40 10 * * *", time.Date(2024, time.January, 15, 10, 40, 0, 0, time.UTC)},
+		{"0 9 * * mon-fri", time.Date(2024, time.January, 16, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{"30 8 13 * 5", time.Date(2024, time.January, 19, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1 jan *", time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2024, time.January, 15, 11, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2024, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{"@weekly", time.Date(2024, time.January, 21, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 15m", time.Date(2024, time.January, 15, 10, 15, 0, 0, time.UTC)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := s.Next(now); !got.Equal(tt.want) {
+				t.Fatalf("Next = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"* * * foo *",
+		"* * * * 8",
+		"@every 10ms",
+		"@every soon",
+	} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("ParseSchedule(%q) succeeded", spec)
+		}
+	}
+}
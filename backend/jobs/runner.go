@@ -0,0 +1,524 @@
+// backend/jobs/runner.go
+
+// Package jobs runs background work. Jobs are rows in the jobs table, so
+// they survive restarts and can be picked up by any replica: a worker
+// claims a job with a conditional update, keeps it locked while it runs and
+// releases it with the outcome. A job whose worker dies is run again once
+// its lock expires.
+//
+// Recurring jobs are described by schedules. Every replica checks them, but
+// only the one that advances a schedule's next run time enqueues the run.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+// Handler runs a job. Returning an error makes the runner retry the job
+// unless the error is permanent or the job is out of attempts. The context
+// is cancelled when the job is cancelled, another worker takes it over or
+// the runner stops.
+type Handler func(ctx context.Context, job *models.Job) error
+
+// Decode unmarshals the payload of a job into v.
+func Decode(job *models.Job, v interface{}) error {
+	if job.Payload == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(job.Payload), v)
+}
+
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth retrying; the job fails right away.
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Config configures the runner.
+type Config struct {
+	// Concurrency is the number of jobs run at the same time.
+	Concurrency int
+	// PollInterval is how often idle workers look for due jobs and the
+	// scheduler looks for due schedules.
+	PollInterval time.Duration
+	// LockTTL is how long a claimed job stays locked without a heartbeat
+	// before another worker may take it over.
+	LockTTL time.Duration
+	// MaxAttempts is the default number of attempts of a job.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every failed attempt.
+	RetryBackoff time.Duration
+	// WorkerID identifies this process in job locks. It defaults to the
+	// host name and a random suffix.
+	WorkerID string
+}
+
+// DefaultConfig returns the default runner settings.
+func DefaultConfig() Config {
+	return Config{
+		Concurrency:  4,
+		PollInterval: 5 * time.Second,
+		LockTTL:      time.Minute,
+		MaxAttempts:  5,
+		RetryBackoff: 30 * time.Second,
+	}
+}
+
+const maxRetryBackoff = 6 * time.Hour
+
+// EnqueueOptions adjusts how a job is queued.
+type EnqueueOptions struct {
+	// RunAt delays the job; the zero value runs it as soon as possible.
+	RunAt time.Time
+	// UniqueKey makes the job a singleton: while a job with the same key is
+	// pending or running, enqueueing returns that job instead.
+	UniqueKey string
+	// MaxAttempts overrides Config.MaxAttempts.
+	MaxAttempts int
+}
+
+// Runner queues jobs and runs them with a pool of workers.
+type Runner struct {
+	Jobs   *models.JobDBModel
+	Config Config
+
+	mu        sync.RWMutex
+	handlers  map[string]Handler
+	schedules map[string]Schedule
+	wake      chan struct{}
+}
+
+// NewRunner creates a new Runner.
+func NewRunner(jobDBModel *models.JobDBModel, config Config) *Runner {
+	defaults := DefaultConfig()
+	if config.Concurrency <= 0 {
+		config.Concurrency = defaults.Concurrency
+	}
+	if config.PollInterval <= 0 {
+		config.PollInterval = defaults.PollInterval
+	}
+	if config.LockTTL <= 0 {
+		config.LockTTL = defaults.LockTTL
+	}
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.WorkerID == "" {
+		config.WorkerID = newWorkerID()
+	}
+	return &Runner{
+		Jobs:      jobDBModel,
+		Config:    config,
+		handlers:  map[string]Handler{},
+		schedules: map[string]Schedule{},
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Register sets the handler of a job kind. Workers only claim jobs of the
+// kinds registered with them.
+func (r *Runner) Register(kind string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.handlers[kind] = handler
+}
+
+func (r *Runner) handler(kind string) (Handler, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	h, ok := r.handlers[kind]
+	return h, ok
+}
+
+func (r *Runner) kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kinds := make([]string, 0, len(r.handlers))
+	for kind := range r.handlers {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// Enqueue queues a job. It reports false, together with the existing job,
+// when a job with the same unique key is already pending or running.
+func (r *Runner) Enqueue(kind string, payload interface{}, opts EnqueueOptions) (*models.Job, bool, error) {
+	data, err := encodePayload(payload)
+	if err != nil {
+		return nil, false, err
+	}
+	job := &models.Job{
+		Kind:        kind,
+		Payload:     data,
+		Status:      models.JobStatusPending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if job.MaxAttempts <= 0 {
+		job.MaxAttempts = r.Config.MaxAttempts
+	}
+	if job.RunAt.IsZero() {
+		job.RunAt = time.Now()
+	}
+	if opts.UniqueKey != "" {
+		if existing, err := r.Jobs.GetJobByUniqueKey(opts.UniqueKey); err == nil {
+			return existing, false, nil
+		}
+		key := opts.UniqueKey
+		job.UniqueKey = &key
+	}
+	if err := r.Jobs.CreateJob(job); err != nil {
+		// Another replica may have queued the same singleton in between.
+		if opts.UniqueKey != "" {
+			if existing, lookupErr := r.Jobs.GetJobByUniqueKey(opts.UniqueKey); lookupErr == nil {
+				return existing, false, nil
+			}
+		}
+		return nil, false, err
+	}
+	r.notify()
+	return job, true, nil
+}
+
+func encodePayload(payload interface{}) (string, error) {
+	switch p := payload.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return p, nil
+	case json.RawMessage:
+		return string(p), nil
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Schedule registers a recurring job that enqueues a job of kind with
+// payload at the times given by spec (see ParseSchedule). The schedule is
+// stored under name; changing its spec, kind or payload takes effect on the
+// next start, while an administrator's choice to disable it is kept.
+func (r *Runner) Schedule(name, spec, kind string, payload interface{}) error {
+	sched, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+	data, err := encodePayload(payload)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	existing, err := r.Jobs.GetScheduleByName(name)
+	if err != nil {
+		err = r.Jobs.CreateSchedule(&models.JobSchedule{
+			Name:      name,
+			Spec:      spec,
+			Kind:      kind,
+			Payload:   data,
+			Enabled:   true,
+			NextRunAt: sched.Next(now),
+		})
+		// A replica starting at the same time may have created it.
+		if err != nil {
+			if _, lookupErr := r.Jobs.GetScheduleByName(name); lookupErr != nil {
+				return err
+			}
+		}
+	} else if existing.Spec != spec || existing.Kind != kind || existing.Payload != data {
+		existing.Spec = spec
+		existing.Kind = kind
+		existing.Payload = data
+		existing.NextRunAt = sched.Next(now)
+		if err := r.Jobs.UpdateSchedule(existing); err != nil {
+			return err
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schedules[name] = sched
+	return nil
+}
+
+// Run starts the workers and the scheduler and blocks until ctx is
+// cancelled and the running jobs have returned.
+func (r *Runner) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	for i := 0; i < r.Config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.work(ctx)
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		r.schedule(ctx)
+	}()
+	wg.Wait()
+}
+
+func (r *Runner) notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Runner) work(ctx context.Context) {
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+	for ctx.Err() == nil {
+		ran, err := r.RunNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("jobs: %v", err)
+		}
+		if ran {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// RunNext claims one due job and runs it. It reports false when no job
+// was due.
+func (r *Runner) RunNext(ctx context.Context) (bool, error) {
+	kinds := r.kinds()
+	if len(kinds) == 0 {
+		return false, nil
+	}
+	now := time.Now()
+	due, err := r.Jobs.GetDueJobs(now, kinds, r.Config.Concurrency)
+	if err != nil {
+		return false, err
+	}
+	for i := range *due {
+		job := &(*due)[i]
+		claimed, err := r.Jobs.ClaimJob(job.ID, r.Config.WorkerID, now, now.Add(r.Config.LockTTL))
+		if err != nil {
+			return false, err
+		}
+		if !claimed {
+			continue
+		}
+		job.Status = models.JobStatusRunning
+		job.Attempts++
+		job.LockedBy = r.Config.WorkerID
+		job.StartedAt = &now
+		return true, r.execute(ctx, job)
+	}
+	return false, nil
+}
+
+// execute runs a claimed job, keeps its lock alive meanwhile and records
+// the outcome.
+func (r *Runner) execute(ctx context.Context, job *models.Job) error {
+	h, ok := r.handler(job.Kind)
+	if !ok {
+		return fmt.Errorf("no handler for job kind %q", job.Kind)
+	}
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var cancelled, lost bool
+	var mu sync.Mutex
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(r.Config.LockTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				held, requested, err := r.Jobs.ExtendLock(job.ID, r.Config.WorkerID, time.Now().Add(r.Config.LockTTL))
+				if err != nil {
+					log.Printf("jobs: extending lock of job %d: %v", job.ID, err)
+					continue
+				}
+				// Another worker took the job over: stop the handler so the
+				// job does not run twice at the same time.
+				if !held {
+					mu.Lock()
+					lost = true
+					mu.Unlock()
+					cancel()
+					return
+				}
+				if requested {
+					mu.Lock()
+					cancelled = true
+					mu.Unlock()
+					cancel()
+				}
+			}
+		}
+	}()
+	err := call(jobCtx, h, job)
+	close(done)
+	mu.Lock()
+	wasCancelled, wasLost := cancelled, lost
+	mu.Unlock()
+
+	if wasLost {
+		return fmt.Errorf("%s job %d: lock lost to another worker", job.Kind, job.ID)
+	}
+	// The runner is shutting down: leave the job locked so another worker
+	// picks it up again once the lock expires.
+	if !wasCancelled && ctx.Err() != nil {
+		return nil
+	}
+
+	now := time.Now()
+	job.LockedBy = ""
+	job.LockedUntil = nil
+	switch {
+	case wasCancelled:
+		job.Status = models.JobStatusCancelled
+		job.LastError = "cancelled"
+	case err == nil:
+		job.Status = models.JobStatusSucceeded
+		job.LastError = ""
+	default:
+		job.LastError = err.Error()
+		if IsPermanent(err) || job.Attempts >= job.MaxAttempts {
+			job.Status = models.JobStatusFailed
+		} else {
+			job.Status = models.JobStatusPending
+			job.RunAt = now.Add(r.backoff(job.Attempts))
+		}
+		log.Printf("jobs: %s job %d attempt %d: %v", job.Kind, job.ID, job.Attempts, err)
+	}
+	if job.Status != models.JobStatusPending {
+		job.UniqueKey = nil
+		job.FinishedAt = &now
+	}
+	finished, err := r.Jobs.FinishJob(job, r.Config.WorkerID)
+	if err != nil {
+		return err
+	}
+	if !finished {
+		return fmt.Errorf("%s job %d: lock lost to another worker", job.Kind, job.ID)
+	}
+	return nil
+}
+
+// call runs a handler, turning a panic into an error so one faulty job
+// cannot stop its worker.
+func call(ctx context.Context, h Handler, job *models.Job) (err error) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			err = fmt.Errorf("panic: %v", rec)
+		}
+	}()
+	return h(ctx, job)
+}
+
+func (r *Runner) backoff(attempts int) time.Duration {
+	delay := r.Config.RetryBackoff
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+func (r *Runner) schedule(ctx context.Context) {
+	ticker := time.NewTicker(r.Config.PollInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := r.EnqueueScheduled(time.Now()); err != nil && ctx.Err() == nil {
+			log.Printf("jobs: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// EnqueueScheduled enqueues a run of every enabled schedule registered with
+// this runner that is due at now, and returns how many runs it queued.
+// Runs missed while no replica was up are collapsed into one. A run is
+// skipped when the previous run of the same schedule is still queued or
+// running.
+func (r *Runner) EnqueueScheduled(now time.Time) (int, error) {
+	schedules, err := r.Jobs.GetSchedules()
+	if err != nil {
+		return 0, err
+	}
+	queued := 0
+	for i := range *schedules {
+		s := &(*schedules)[i]
+		r.mu.RLock()
+		sched, ok := r.schedules[s.Name]
+		r.mu.RUnlock()
+		if !ok || !s.Enabled || s.NextRunAt.After(now) {
+			continue
+		}
+		won, err := r.Jobs.AdvanceSchedule(s, sched.Next(now))
+		if err != nil {
+			return queued, err
+		}
+		if !won {
+			continue
+		}
+		job, created, err := r.Enqueue(s.Kind, s.Payload, EnqueueOptions{UniqueKey: "schedule:" + s.Name})
+		if err != nil {
+			return queued, err
+		}
+		if !created {
+			continue
+		}
+		queued++
+		if err := r.Jobs.SetScheduleLastJob(s.ID, job.ID); err != nil {
+			return queued, err
+		}
+	}
+	return queued, nil
+}
+
+func newWorkerID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	rand.Read(b)
+	return fmt.Sprintf("%s-%s", host, hex.EncodeToString(b))
+}
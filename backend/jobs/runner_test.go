@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestRunner(t *testing.T, config Config) *Runner {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.Job{}, &models.JobSchedule{}); err != nil {
+		t.Fatal(err)
+	}
+	return NewRunner(models.NewJobDBModel(db), config)
+}
+
+func TestClaimJob(t *testing.T) {
+	r := newTestRunner(t, Config{})
+	now := time.Now()
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		job     models.Job
+		claimed bool
+	}{
+		{"due pending job", models.Job{Status: models.JobStatusPending, RunAt: past}, true},
+		{"pending job not due yet", models.Job{Status: models.JobStatusPending, RunAt: future}, false},
+		{"running job with a live lock", models.Job{Status: models.JobStatusRunning, RunAt: past, LockedBy: "other", LockedUntil: &future}, false},
+		{"running job with an expired lock", models.Job{Status: models.JobStatusRunning, RunAt: past, LockedBy: "other", LockedUntil: &past}, true},
+		{"finished job", models.Job{Status: models.JobStatusSucceeded, RunAt: past}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			job := tt.job
+			job.Kind = "test"
+			if err := r.Jobs.CreateJob(&job); err != nil {
+				t.Fatal(err)
+			}
+			claimed, err := r.Jobs.ClaimJob(job.ID, "worker", now, future)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if claimed != tt.claimed {
+				t.Fatalf("claimed = %v, want %v", claimed, tt.claimed)
+			}
+			if !claimed {
+				return
+			}
+			if again, _ := r.Jobs.ClaimJob(job.ID, "second", now, future); again {
+				t.Fatal("claimed twice")
+			}
+			got, err := r.Jobs.GetJobByID(job.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.LockedBy != "worker" || got.Attempts != job.Attempts+1 {
+				t.Fatalf("locked by %q after %d attempts", got.LockedBy, got.Attempts)
+			}
+		})
+	}
+}
+
+func TestFinishJobRequiresLock(t *testing.T) {
+	r := newTestRunner(t, Config{})
+	now := time.Now()
+	job := &models.Job{Kind: "test", Status: models.JobStatusPending, RunAt: now}
+	if err := r.Jobs.CreateJob(job); err != nil {
+		t.Fatal(err)
+	}
+	if claimed, err := r.Jobs.ClaimJob(job.ID, "first", now, now.Add(-time.Second)); !claimed || err != nil {
+		t.Fatalf("first claim: %v %v", claimed, err)
+	}
+	// The lock of the first worker expired and a second one took over.
+	if claimed, err := r.Jobs.ClaimJob(job.ID, "second", now, now.Add(time.Minute)); !claimed || err != nil {
+		t.Fatalf("reclaim: %v %v", claimed, err)
+	}
+
+	held, _, err := r.Jobs.ExtendLock(job.ID, "first", now.Add(time.Minute))
+	if err != nil || held {
+		t.Fatalf("first worker extended a lock it lost: %v %v", held, err)
+	}
+	stale := *job
+	stale.Status = models.JobStatusFailed
+	stale.LockedBy = ""
+	if finished, err := r.Jobs.FinishJob(&stale, "first"); finished || err != nil {
+		t.Fatalf("first worker finished a job it lost: %v %v", finished, err)
+	}
+
+	got, err := r.Jobs.GetJobByID(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.JobStatusRunning || got.LockedBy != "second" {
+		t.Fatalf("job is %s locked by %q", got.Status, got.LockedBy)
+	}
+	done := *got
+	done.Status = models.JobStatusSucceeded
+	done.LockedBy = ""
+	if finished, err := r.Jobs.FinishJob(&done, "second"); !finished || err != nil {
+		t.Fatalf("second worker could not finish: %v %v", finished, err)
+	}
+}
+
+func TestLostLockCancelsHandler(t *testing.T) {
+	r := newTestRunner(t, Config{LockTTL: 30 * time.Millisecond, WorkerID: "first"})
+	stopped := make(chan error, 1)
+	r.Register("test", func(ctx context.Context, job *models.Job) error {
+		// Another worker takes the job over while it runs.
+		err := r.Jobs.DB.Model(&models.Job{}).Where("id = ?", job.ID).Update("locked_by", "second").Error
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			stopped <- ctx.Err()
+		case <-time.After(time.Second):
+			stopped <- errors.New("handler was not cancelled")
+		}
+		return nil
+	})
+	job, _, err := r.Enqueue("test", nil, EnqueueOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ran, err := r.RunNext(context.Background())
+	if !ran || err == nil {
+		t.Fatalf("RunNext = %v, %v; want a lost lock error", ran, err)
+	}
+	if err := <-stopped; !errors.Is(err, context.Canceled) {
+		t.Fatal(err)
+	}
+	got, err := r.Jobs.GetJobByID(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.JobStatusRunning || got.LockedBy != "second" {
+		t.Fatalf("job is %s locked by %q", got.Status, got.LockedBy)
+	}
+}
+
+func TestRunNextRetriesAndSucceeds(t *testing.T) {
+	r := newTestRunner(t, Config{RetryBackoff: time.Millisecond})
+	calls := 0
+	r.Register("test", func(ctx context.Context, job *models.Job) error {
+		calls++
+		if calls == 1 {
+			return errors.New("temporary")
+		}
+		return nil
+	})
+	job, _, err := r.Enqueue("test", nil, EnqueueOptions{UniqueKey: "once"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, created, _ := r.Enqueue("test", nil, EnqueueOptions{UniqueKey: "once"}); created {
+		t.Fatal("queued a second job with the same unique key")
+	}
+
+	for _, want := range []string{models.JobStatusPending, models.JobStatusSucceeded} {
+		time.Sleep(5 * time.Millisecond)
+		if ran, err := r.RunNext(context.Background()); !ran || err != nil {
+			t.Fatalf("RunNext = %v, %v", ran, err)
+		}
+		got, err := r.Jobs.GetJobByID(job.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Status != want || got.LockedBy != "" {
+			t.Fatalf("job is %s locked by %q, want %s and unlocked", got.Status, got.LockedBy, want)
+		}
+	}
+}
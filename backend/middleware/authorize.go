@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 
@@ -9,32 +11,110 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// AuthorizeRequest is used to authorize a request for a certain end-point group.
+// Session keys holding the token of the signed-in user and agent.
+const (
+	userSessionKey  = "user-token-gen-on-server-side"
+	agentSessionKey = "agent-token-gen-on-server-side"
+)
 
+// AuthorizeRequest is used to authorize a request for a certain end-point group.
+// Both users and agents may make it: the ID of the signed-in user is set as
+// "userID" on the context, that of the signed-in agent as "agentID".
 func AuthorizeRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		session := sessions.Default(c)
-		v := session.Get("user-token-gen-on-server-side")
-		if v == nil {
+		userID, isUser := sessionUserID(session.Get(userSessionKey))
+		agentID, isAgent := sessionUserID(session.Get(agentSessionKey))
+		if !isUser && !isAgent {
+			c.HTML(http.StatusUnauthorized, "login.html", gin.H{"message": "Please login."})
+			c.Abort()
+			return
+		}
+		if isUser {
+			c.Set("userID", userID)
+		}
+		if isAgent {
+			c.Set("agentID", agentID)
+		}
+		c.Next()
+	}
+}
+
+// AuthorizeUserRequest authorizes a request of a user, not of an agent,
+// setting the ID of the signed-in user as "userID" on the context.
+func AuthorizeUserRequest() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		session := sessions.Default(c)
+		userID, ok := sessionUserID(session.Get(userSessionKey))
+		if !ok {
 			c.HTML(http.StatusUnauthorized, "login.html", gin.H{"message": "Please login."})
 			c.Abort()
+			return
 		}
+		c.Set("userID", userID)
 		c.Next()
 	}
 }
 
+// AuthorizeAdminRequest authorizes a request of an agent, setting the ID of
+// the signed-in agent as "agentID" on the context.
 func AuthorizeAdminRequest() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		adminSession := sessions.Default(c)
-		v := adminSession.Get("agent-token-gen-on-server-side")
-		if v == nil {
+		agentID, ok := sessionUserID(adminSession.Get(agentSessionKey))
+		if !ok {
 			c.HTML(http.StatusUnauthorized, "admin/login.html", gin.H{"message": "Please login."})
 			c.Abort()
+			return
 		}
+		c.Set("agentID", agentID)
 		c.Next()
 	}
 }
 
+// SetUserSession signs a user in on the session of the request.
+func SetUserSession(c *gin.Context, token string) error {
+	session := sessions.Default(c)
+	session.Set(userSessionKey, token)
+	return session.Save()
+}
+
+// SetAgentSession signs an agent in on the session of the request.
+func SetAgentSession(c *gin.Context, token string) error {
+	session := sessions.Default(c)
+	session.Set(agentSessionKey, token)
+	return session.Save()
+}
+
+// sessionUserID returns the ID carried by the token stored in a session.
+func sessionUserID(v interface{}) (uint, bool) {
+	tokenString, ok := v.(string)
+	if !ok || tokenString == "" {
+		return 0, false
+	}
+	userID, err := parseToken(tokenString)
+	return userID, err == nil
+}
+
+// parseToken validates a token and returns the ID of its user.
+func parseToken(tokenString string) (uint, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return []byte("your-secret-key"), nil
+	})
+	if err != nil || !token.Valid {
+		return 0, errors.New("invalid token")
+	}
+	claims, _ := token.Claims.(jwt.MapClaims)
+	userID, ok := claims["userID"].(float64)
+	if !ok || userID <= 0 {
+		return 0, errors.New("token carries no user")
+	}
+	return uint(userID), nil
+}
+
 // Implement middleware to check if a request is authenticated
 func authenticateMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -45,16 +125,12 @@ func authenticateMiddleware() gin.HandlerFunc {
 			return
 		}
 		tokenString = strings.Replace(tokenString, "Bearer ", "", 1)
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			return []byte("your-secret-key"), nil
-		})
-		if err != nil || !token.Valid {
+		userID, err := parseToken(tokenString)
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 			c.Abort()
 			return
 		}
-		claims, _ := token.Claims.(jwt.MapClaims)
-		userID := uint(claims["userID"].(float64))
 		c.Set("userID", userID)
 		c.Next()
 	}
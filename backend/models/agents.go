@@ -3,6 +3,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -106,6 +107,37 @@ func (as *AgentDBModel) GetAgentByID(id uint) (*Agents, error) {
 	return &agent, err
 }
 
+// GetAgentByEmail retrieves an agent by email address, ignoring case.
+func (as *AgentDBModel) GetAgentByEmail(email string) (*Agents, error) {
+	var agent Agents
+	err := as.DB.Where("LOWER(agent_email) = LOWER(?)", email).First(&agent).Error
+	return &agent, err
+}
+
+// GetAgentByHandle retrieves the agent an @mention refers to: the full
+// email address, or its part before the @ when only one agent has it.
+func (as *AgentDBModel) GetAgentByHandle(handle string) (*Agents, error) {
+	if strings.Contains(handle, "@") {
+		return as.GetAgentByEmail(handle)
+	}
+	var candidates []Agents
+	prefix := strings.ToLower(handle) + "@"
+	if err := as.DB.Where("LOWER(agent_email) LIKE ?", prefix+"%").Find(&candidates).Error; err != nil {
+		return nil, err
+	}
+	var found []Agents
+	for _, agent := range candidates {
+		// LIKE treats _ as a wildcard, so check the prefix exactly.
+		if strings.HasPrefix(strings.ToLower(agent.AgentEmail), prefix) {
+			found = append(found, agent)
+		}
+	}
+	if len(found) != 1 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &found[0], nil
+}
+
 // UpdateAgent updates the details of an existing agent.
 func (as *AgentDBModel) UpdateAgent(agent *Agents) error {
 	if err := as.DB.Save(agent).Error; err != nil {
@@ -128,3 +160,10 @@ func (as *AgentDBModel) GetAllAgents() (*[]Agents, error) {
 	err := as.DB.Find(&agents).Error
 	return &agents, err
 }
+
+// GetUnitByID retrieves a unit by its ID.
+func (as *AgentDBModel) GetUnitByID(id int) (*Unit, error) {
+	var unit Unit
+	err := as.DB.Where("id = ?", id).First(&unit).Error
+	return &unit, err
+}
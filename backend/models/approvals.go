@@ -0,0 +1,328 @@
+// backend/models/approvals.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ApprovalChain is the sign-off a ticket needs before it is worked on,
+// defined for a catalog item or for a category, optionally narrowed to a
+// sub-category, or the sign-off of the change requests of a type. Its
+// steps run one after the other in sequential mode and all at once in
+// parallel mode.
+type ApprovalChain struct {
+	gorm.Model
+	ID            uint           `gorm:"primaryKey" json:"chain_id"`
+	Name          string         `json:"name"`
+	CatalogItemID uint           `json:"catalog_item_id,omitempty" gorm:"index"`
+	Category      string         `json:"category,omitempty"`
+	SubCategory   string         `json:"sub_category,omitempty"`
+	ChangeType    string         `json:"change_type,omitempty"`
+	Mode          string         `json:"mode"`
+	Active        bool           `json:"active"`
+	Steps         []ApprovalStep `json:"steps" gorm:"foreignKey:ChainID"`
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// TableName sets the table name for the ApprovalChain model.
+func (ApprovalChain) TableName() string {
+	return "approval_chains"
+}
+
+// ApprovalStep is a step of an approval chain. With the any rule one of its
+// approvers is enough; with the all rule each of them has to approve.
+type ApprovalStep struct {
+	gorm.Model
+	ID        uint           `gorm:"primaryKey" json:"step_id"`
+	ChainID   uint           `json:"chain_id" gorm:"index"`
+	Name      string         `json:"name"`
+	Position  int            `json:"position"`
+	Rule      string         `json:"rule"`
+	Approvers []ApproverRule `json:"approvers" gorm:"serializer:json"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TableName sets the table name for the ApprovalStep model.
+func (ApprovalStep) TableName() string {
+	return "approval_steps"
+}
+
+// ApproverRule tells who approves a step: a given user, or the users
+// holding a position, such as the line manager or head of department. A
+// position is looked up in DepartmentID, or in the requester's department
+// when it is zero.
+type ApproverRule struct {
+	Type         string `json:"type"`
+	UserID       uint   `json:"user_id,omitempty"`
+	PositionID   int    `json:"position_id,omitempty"`
+	DepartmentID int    `json:"department_id,omitempty"`
+}
+
+// ApprovalRequest is the run of an approval chain for a ticket, or for a
+// change request when ChangeID is set. The chain is copied into its
+// approvals, so editing the chain does not change requests already
+// started.
+type ApprovalRequest struct {
+	gorm.Model
+	ID          uint       `gorm:"primaryKey" json:"approval_request_id"`
+	TicketID    uint       `json:"ticket_id,omitempty" gorm:"index"`
+	ChangeID    uint       `json:"change_id,omitempty" gorm:"index"`
+	ChainID     uint       `json:"chain_id"`
+	ChainName   string     `json:"chain_name"`
+	Mode        string     `json:"mode"`
+	RequesterID uint       `json:"requester_id"`
+	Status      string     `json:"status" gorm:"index"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Approvals   []Approval `json:"approvals" gorm:"foreignKey:RequestID"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the ApprovalRequest model.
+func (ApprovalRequest) TableName() string {
+	return "approval_requests"
+}
+
+// Approval is the decision of one approver on a step of an approval
+// request. It waits until its step is reached, is pending until the
+// approver decides, and is skipped when the step is settled without it.
+type Approval struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey" json:"approval_id"`
+	RequestID     uint       `json:"approval_request_id" gorm:"index"`
+	TicketID      uint       `json:"ticket_id,omitempty"`
+	ChangeID      uint       `json:"change_id,omitempty"`
+	StepPosition  int        `json:"step_position"`
+	StepName      string     `json:"step_name"`
+	Rule          string     `json:"rule"`
+	ApproverID    uint       `json:"approver_id" gorm:"index"`
+	ApproverName  string     `json:"approver_name"`
+	ApproverEmail string     `json:"approver_email"`
+	Status        string     `json:"status" gorm:"index"`
+	Comment       string     `json:"comment,omitempty"`
+	DecidedAt     *time.Time `json:"decided_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the Approval model.
+func (Approval) TableName() string {
+	return "approvals"
+}
+
+// Modes of an ApprovalChain.
+const (
+	ApprovalModeSequential = "sequential"
+	ApprovalModeParallel   = "parallel"
+)
+
+// Rules of an ApprovalStep.
+const (
+	ApprovalRuleAny = "any"
+	ApprovalRuleAll = "all"
+)
+
+// Types of an ApproverRule.
+const (
+	ApproverTypeUser     = "user"
+	ApproverTypePosition = "position"
+)
+
+// Statuses of an ApprovalRequest and of its approvals. Requests are
+// pending, approved, rejected or cancelled; approvals can also be waiting
+// or skipped.
+const (
+	ApprovalStatusWaiting   = "waiting"
+	ApprovalStatusPending   = "pending"
+	ApprovalStatusApproved  = "approved"
+	ApprovalStatusRejected  = "rejected"
+	ApprovalStatusSkipped   = "skipped"
+	ApprovalStatusCancelled = "cancelled"
+)
+
+// EventTicketApproval is the notification event of the emails asking for
+// an approval. It is not published on the event bus.
+const EventTicketApproval = "ticket.approval"
+
+type ApprovalStorage interface {
+	CreateChain(*ApprovalChain) error
+	UpdateChain(*ApprovalChain) error
+	DeleteChain(uint) error
+	GetChainByID(uint) (*ApprovalChain, error)
+	GetChains() (*[]ApprovalChain, error)
+	GetActiveChains() (*[]ApprovalChain, error)
+	CreateRequest(*ApprovalRequest) error
+	UpdateRequest(*ApprovalRequest) error
+	GetRequestByID(uint) (*ApprovalRequest, error)
+	GetTicketRequest(uint) (*ApprovalRequest, error)
+	GetChangeRequest(uint) (*ApprovalRequest, error)
+	HasPendingRequest(uint) (bool, error)
+	LockPendingRequest(uint) (bool, error)
+	DecideApproval(*Approval) (bool, error)
+	UpdateApproval(*Approval) error
+	GetApprovalByID(uint) (*Approval, error)
+	GetPendingApprovals(uint) (*[]Approval, error)
+}
+
+// ApprovalDBModel handles database operations for ApprovalChain,
+// ApprovalStep, ApprovalRequest and Approval
+type ApprovalDBModel struct {
+	DB *gorm.DB
+}
+
+// NewApprovalDBModel creates a new instance of ApprovalDBModel
+func NewApprovalDBModel(db *gorm.DB) *ApprovalDBModel {
+	return &ApprovalDBModel{
+		DB: db,
+	}
+}
+
+func orderSteps(db *gorm.DB) *gorm.DB {
+	return db.Order("position, id")
+}
+
+func orderApprovals(db *gorm.DB) *gorm.DB {
+	return db.Order("step_position, id")
+}
+
+// CreateChain creates an approval chain with its steps.
+func (as *ApprovalDBModel) CreateChain(chain *ApprovalChain) error {
+	return as.DB.Create(chain).Error
+}
+
+// UpdateChain updates an approval chain and replaces its steps.
+func (as *ApprovalDBModel) UpdateChain(chain *ApprovalChain) error {
+	if err := as.DB.Omit("Steps").Save(chain).Error; err != nil {
+		return err
+	}
+	if err := as.DB.Where("chain_id = ?", chain.ID).Delete(&ApprovalStep{}).Error; err != nil {
+		return err
+	}
+	if len(chain.Steps) == 0 {
+		return nil
+	}
+	for i := range chain.Steps {
+		chain.Steps[i].ID = 0
+		chain.Steps[i].ChainID = chain.ID
+	}
+	return as.DB.Create(&chain.Steps).Error
+}
+
+// DeleteChain deletes an approval chain and its steps. Requests already
+// started keep their approvals.
+func (as *ApprovalDBModel) DeleteChain(id uint) error {
+	if err := as.DB.Where("chain_id = ?", id).Delete(&ApprovalStep{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&ApprovalChain{}, id).Error
+}
+
+// GetChainByID retrieves an approval chain with its steps.
+func (as *ApprovalDBModel) GetChainByID(id uint) (*ApprovalChain, error) {
+	var chain ApprovalChain
+	err := as.DB.Preload("Steps", orderSteps).Where("id = ?", id).First(&chain).Error
+	return &chain, err
+}
+
+// GetChains retrieves every approval chain by name with its steps.
+func (as *ApprovalDBModel) GetChains() (*[]ApprovalChain, error) {
+	var chains []ApprovalChain
+	err := as.DB.Preload("Steps", orderSteps).Order("name, id").Find(&chains).Error
+	return &chains, err
+}
+
+// GetActiveChains retrieves the active approval chains with their steps.
+func (as *ApprovalDBModel) GetActiveChains() (*[]ApprovalChain, error) {
+	var chains []ApprovalChain
+	err := as.DB.Preload("Steps", orderSteps).Where("active = ?", true).Order("id").Find(&chains).Error
+	return &chains, err
+}
+
+// CreateRequest creates an approval request with its approvals.
+func (as *ApprovalDBModel) CreateRequest(request *ApprovalRequest) error {
+	return as.DB.Create(request).Error
+}
+
+// UpdateRequest updates an approval request, not its approvals.
+func (as *ApprovalDBModel) UpdateRequest(request *ApprovalRequest) error {
+	return as.DB.Omit("Approvals").Save(request).Error
+}
+
+// GetRequestByID retrieves an approval request with its approvals.
+func (as *ApprovalDBModel) GetRequestByID(id uint) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+	err := as.DB.Preload("Approvals", orderApprovals).Where("id = ?", id).First(&request).Error
+	return &request, err
+}
+
+// GetTicketRequest retrieves the latest approval request of a ticket with
+// its approvals.
+func (as *ApprovalDBModel) GetTicketRequest(ticketID uint) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+	err := as.DB.Preload("Approvals", orderApprovals).Where("ticket_id = ?", ticketID).Order("id DESC").First(&request).Error
+	return &request, err
+}
+
+// GetChangeRequest retrieves the latest approval request of a change
+// request with its approvals.
+func (as *ApprovalDBModel) GetChangeRequest(changeID uint) (*ApprovalRequest, error) {
+	var request ApprovalRequest
+	err := as.DB.Preload("Approvals", orderApprovals).Where("change_id = ?", changeID).Order("id DESC").First(&request).Error
+	return &request, err
+}
+
+// HasPendingRequest reports whether a ticket awaits approval.
+func (as *ApprovalDBModel) HasPendingRequest(ticketID uint) (bool, error) {
+	var count int64
+	err := as.DB.Model(&ApprovalRequest{}).Where("ticket_id = ? AND status = ?", ticketID, ApprovalStatusPending).Count(&count).Error
+	return count > 0, err
+}
+
+// LockPendingRequest takes a pending approval request for a decision,
+// reporting false when it is no longer pending. Within a transaction, the
+// decisions on the same request wait for each other from there on, so
+// that each one settles its step with the others in view.
+func (as *ApprovalDBModel) LockPendingRequest(id uint) (bool, error) {
+	res := as.DB.Model(&ApprovalRequest{}).Where("id = ? AND status = ?", id, ApprovalStatusPending).
+		Update("updated_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// DecideApproval records the status, comment and time of the decision on
+// a pending approval. It reports false when the approval is no longer
+// pending, having been decided meanwhile.
+func (as *ApprovalDBModel) DecideApproval(approval *Approval) (bool, error) {
+	res := as.DB.Model(&Approval{}).Where("id = ? AND status = ?", approval.ID, ApprovalStatusPending).
+		Updates(map[string]interface{}{
+			"status":     approval.Status,
+			"comment":    approval.Comment,
+			"decided_at": approval.DecidedAt,
+			"updated_at": time.Now(),
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// UpdateApproval updates an approval.
+func (as *ApprovalDBModel) UpdateApproval(approval *Approval) error {
+	return as.DB.Save(approval).Error
+}
+
+// GetApprovalByID retrieves an approval by its ID.
+func (as *ApprovalDBModel) GetApprovalByID(id uint) (*Approval, error) {
+	var approval Approval
+	err := as.DB.Where("id = ?", id).First(&approval).Error
+	return &approval, err
+}
+
+// GetPendingApprovals retrieves the approvals awaiting the decision of a
+// user, the oldest first.
+func (as *ApprovalDBModel) GetPendingApprovals(userID uint) (*[]Approval, error) {
+	var approvals []Approval
+	err := as.DB.Where("approver_id = ? AND status = ?", userID, ApprovalStatusPending).Order("id").Find(&approvals).Error
+	return &approvals, err
+}
@@ -126,7 +126,7 @@ func (as *AssetDBModel) CreateAsset(asset *Assets) error {
 // GetAssetsByID retrieves a user by its ID.
 func (as *AssetDBModel) GetAssetByID(id uint) (*Assets, error) {
 	var asset Assets
-	err := as.DB.Where("id = ?", id).First(&asset).Error
+	err := as.DB.Preload("Assignment").Where("id = ?", id).First(&asset).Error
 	return &asset, err
 }
 
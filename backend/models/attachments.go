@@ -0,0 +1,139 @@
+// backend/models/attachments.go
+
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// TicketAttachmentVariant is a derived rendition of an image attachment,
+// such as a thumbnail or a web sized copy.
+type TicketAttachmentVariant struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey" json:"-"`
+	AttachmentID uint   `json:"-" gorm:"index"`
+	Name         string `json:"name"`
+	URL          string `json:"url" gorm:"-"`
+	ContentType  string `json:"contentType"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Size         int64  `json:"size"`
+	Checksum     string `json:"-"`
+	StorageKey   string `json:"-"`
+}
+
+// TableName sets the table name for the TicketAttachmentVariant model.
+func (TicketAttachmentVariant) TableName() string {
+	return "ticket_attachment_variant"
+}
+
+type AttachmentStorage interface {
+	CreateAttachment(*TicketMediaAttachment) error
+	DeleteAttachment(uint) error
+	UpdateAttachment(*TicketMediaAttachment) error
+	GetAttachmentByID(uint) (*TicketMediaAttachment, error)
+	GetAttachmentsByTicketID(uint) (*[]TicketMediaAttachment, error)
+	GetAttachmentByChecksum(uint, string) (*TicketMediaAttachment, error)
+	CountAttachmentsByStorageKey(string) (int64, error)
+	CreateVariant(*TicketAttachmentVariant) error
+	GetVariant(uint, string) (*TicketAttachmentVariant, error)
+	DeleteVariantsByAttachmentID(uint) error
+	GetAttachmentsByScanStatus(string) (*[]TicketMediaAttachment, error)
+	PurgeAttachment(uint) error
+}
+
+// AttachmentDBModel handles database operations for TicketMediaAttachment
+type AttachmentDBModel struct {
+	DB *gorm.DB
+}
+
+// NewAttachmentDBModel creates a new instance of AttachmentDBModel
+func NewAttachmentDBModel(db *gorm.DB) *AttachmentDBModel {
+	return &AttachmentDBModel{
+		DB: db,
+	}
+}
+
+// CreateAttachment creates a new attachment record.
+func (as *AttachmentDBModel) CreateAttachment(attachment *TicketMediaAttachment) error {
+	return as.DB.Create(attachment).Error
+}
+
+// GetAttachmentByID retrieves an attachment by its ID.
+func (as *AttachmentDBModel) GetAttachmentByID(id uint) (*TicketMediaAttachment, error) {
+	var attachment TicketMediaAttachment
+	err := as.DB.Preload("Variants").Where("id = ?", id).First(&attachment).Error
+	return &attachment, err
+}
+
+// UpdateAttachment updates the details of an existing attachment.
+func (as *AttachmentDBModel) UpdateAttachment(attachment *TicketMediaAttachment) error {
+	return as.DB.Save(attachment).Error
+}
+
+// DeleteAttachment deletes an attachment record from the database.
+func (as *AttachmentDBModel) DeleteAttachment(id uint) error {
+	return as.DB.Delete(&TicketMediaAttachment{}, id).Error
+}
+
+// GetAttachmentsByTicketID retrieves all attachments of a ticket in display order.
+func (as *AttachmentDBModel) GetAttachmentsByTicketID(ticketID uint) (*[]TicketMediaAttachment, error) {
+	var attachments []TicketMediaAttachment
+	err := as.DB.Preload("Variants").Where("ticket_id = ?", ticketID).Order("`order`, id").Find(&attachments).Error
+	return &attachments, err
+}
+
+// GetAttachmentByChecksum retrieves the attachment of a ticket with the given content checksum.
+func (as *AttachmentDBModel) GetAttachmentByChecksum(ticketID uint, checksum string) (*TicketMediaAttachment, error) {
+	var attachment TicketMediaAttachment
+	err := as.DB.Preload("Variants").Where("ticket_id = ? AND checksum = ?", ticketID, checksum).First(&attachment).Error
+	return &attachment, err
+}
+
+// CountAttachmentsByStorageKey counts the attachment, variant and knowledge
+// article attachment records sharing a stored blob.
+func (as *AttachmentDBModel) CountAttachmentsByStorageKey(key string) (int64, error) {
+	var attachments, variants, articles int64
+	if err := as.DB.Model(&TicketMediaAttachment{}).Where("storage_key = ?", key).Count(&attachments).Error; err != nil {
+		return 0, err
+	}
+	if err := as.DB.Model(&TicketAttachmentVariant{}).Where("storage_key = ?", key).Count(&variants).Error; err != nil {
+		return 0, err
+	}
+	err := as.DB.Model(&ArticleAttachment{}).Where("storage_key = ?", key).Count(&articles).Error
+	return attachments + variants + articles, err
+}
+
+// CreateVariant creates a new attachment variant record.
+func (as *AttachmentDBModel) CreateVariant(variant *TicketAttachmentVariant) error {
+	return as.DB.Create(variant).Error
+}
+
+// GetVariant retrieves the named variant of an attachment.
+func (as *AttachmentDBModel) GetVariant(attachmentID uint, name string) (*TicketAttachmentVariant, error) {
+	var variant TicketAttachmentVariant
+	err := as.DB.Where("attachment_id = ? AND name = ?", attachmentID, name).First(&variant).Error
+	return &variant, err
+}
+
+// DeleteVariantsByAttachmentID deletes all variant records of an attachment.
+func (as *AttachmentDBModel) DeleteVariantsByAttachmentID(attachmentID uint) error {
+	return as.DB.Where("attachment_id = ?", attachmentID).Delete(&TicketAttachmentVariant{}).Error
+}
+
+// GetAttachmentsByScanStatus retrieves all attachments in the given scan state, oldest first.
+func (as *AttachmentDBModel) GetAttachmentsByScanStatus(status string) (*[]TicketMediaAttachment, error) {
+	var attachments []TicketMediaAttachment
+	err := as.DB.Where("scan_status = ?", status).Order("id").Find(&attachments).Error
+	return &attachments, err
+}
+
+// PurgeAttachment permanently deletes an attachment and its variants, bypassing soft delete.
+func (as *AttachmentDBModel) PurgeAttachment(id uint) error {
+	return as.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("attachment_id = ?", id).Delete(&TicketAttachmentVariant{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&TicketMediaAttachment{}, id).Error
+	})
+}
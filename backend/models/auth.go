@@ -84,6 +84,13 @@ func (as *AuthDBModel) GetUserCredentialsByID(id uint) (*UsersLoginCredentials,
 	return &userCredentials, err
 }
 
+// GetUserCredentialsByUserID retrieves the credentials of a user.
+func (as *AuthDBModel) GetUserCredentialsByUserID(userID uint) (*UsersLoginCredentials, error) {
+	var userCredentials UsersLoginCredentials
+	err := as.DB.Where("user_id = ?", userID).First(&userCredentials).Error
+	return &userCredentials, err
+}
+
 // UpdateUser updates the details of an existing user.
 func (as *AuthDBModel) UpdateUserCredentials(userCredentials *UsersLoginCredentials) error {
 	return as.DB.Save(userCredentials).Error
@@ -115,6 +122,13 @@ func (as *AuthDBModel) GetAgentCredentialsByID(id uint) (*AgentLoginCredentials,
 	return &agentCredentials, err
 }
 
+// GetAgentCredentialsByAgentID retrieves the credentials of an agent.
+func (as *AuthDBModel) GetAgentCredentialsByAgentID(agentID uint) (*AgentLoginCredentials, error) {
+	var agentCredentials AgentLoginCredentials
+	err := as.DB.Where("agent_id = ?", agentID).First(&agentCredentials).Error
+	return &agentCredentials, err
+}
+
 // UpdateUser updates the details of an existing user.
 func (as *AuthDBModel) UpdateAgentCredentials(agentCredentials *AgentLoginCredentials) error {
 	return as.DB.Save(agentCredentials).Error
@@ -0,0 +1,175 @@
+// backend/models/bulk_operations.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// BulkOperation is one change applied to every ticket of a bulk request.
+// Which fields apply depends on Type:
+//
+//   - assign assigns the tickets to AgentID, or queues them for Unit
+//   - set_status and set_priority set the status or priority to Value
+//   - add_tag adds the tag Value
+//   - merge_into closes the tickets as merged into TargetTicketID
+//   - delete deletes the tickets, and cannot be combined with others
+type BulkOperation struct {
+	Type           string `json:"type"`
+	AgentID        uint   `json:"agent_id,omitempty"`
+	Unit           string `json:"unit,omitempty"`
+	Value          string `json:"value,omitempty"`
+	TargetTicketID uint   `json:"target_ticket_id,omitempty"`
+}
+
+// Types of a BulkOperation.
+const (
+	BulkAssign      = "assign"
+	BulkSetStatus   = "set_status"
+	BulkSetPriority = "set_priority"
+	BulkAddTag      = "add_tag"
+	BulkMergeInto   = "merge_into"
+	BulkDelete      = "delete"
+)
+
+// BulkTicketJob is a set of operations applied to many tickets in the
+// background. The tickets are fixed when the job is submitted, from the
+// ticket IDs or the filter of the request.
+type BulkTicketJob struct {
+	gorm.Model
+	ID          uint            `gorm:"primaryKey" json:"bulk_job_id"`
+	TicketIDs   []uint          `json:"ticket_ids" gorm:"serializer:json"`
+	Filter      *TicketFilter   `json:"filter,omitempty" gorm:"serializer:json"`
+	Operations  []BulkOperation `json:"operations" gorm:"serializer:json"`
+	Status      string          `json:"status" gorm:"index"`
+	Total       int             `json:"total"`
+	Succeeded   int             `json:"succeeded"`
+	Failed      int             `json:"failed"`
+	Skipped     int             `json:"skipped"`
+	JobID       uint            `json:"job_id"`
+	RequestedBy uint            `json:"requested_by"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	FinishedAt  *time.Time      `json:"finished_at,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// TableName sets the table name for the BulkTicketJob model.
+func (BulkTicketJob) TableName() string {
+	return "bulk_ticket_jobs"
+}
+
+// Statuses of a BulkTicketJob. A job completes with errors when some of its
+// tickets failed.
+const (
+	BulkJobQueued              = "queued"
+	BulkJobRunning             = "running"
+	BulkJobCompleted           = "completed"
+	BulkJobCompletedWithErrors = "completed_with_errors"
+	BulkJobFailed              = "failed"
+)
+
+// BulkTicketResult is the outcome of a bulk job for one ticket. Changes
+// describes what was changed.
+type BulkTicketResult struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"result_id"`
+	BulkJobID uint      `json:"bulk_job_id" gorm:"index"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	Status    string    `json:"status"`
+	Changes   []string  `json:"changes,omitempty" gorm:"serializer:json"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the BulkTicketResult model.
+func (BulkTicketResult) TableName() string {
+	return "bulk_ticket_results"
+}
+
+// Outcomes of a BulkTicketResult.
+const (
+	BulkResultSucceeded = "succeeded"
+	BulkResultFailed    = "failed"
+	BulkResultSkipped   = "skipped"
+)
+
+type BulkTicketStorage interface {
+	CreateBulkJob(*BulkTicketJob) error
+	UpdateBulkJob(*BulkTicketJob) error
+	GetBulkJobByID(uint) (*BulkTicketJob, error)
+	GetBulkJobs(int) (*[]BulkTicketJob, error)
+	CreateResult(*BulkTicketResult) error
+	GetResults(uint, string) (*[]BulkTicketResult, error)
+	GetProcessedTicketIDs(uint) (map[uint]bool, error)
+}
+
+// BulkTicketDBModel handles database operations for BulkTicketJob
+type BulkTicketDBModel struct {
+	DB *gorm.DB
+}
+
+// NewBulkTicketDBModel creates a new instance of BulkTicketDBModel
+func NewBulkTicketDBModel(db *gorm.DB) *BulkTicketDBModel {
+	return &BulkTicketDBModel{
+		DB: db,
+	}
+}
+
+// CreateBulkJob creates a bulk job.
+func (as *BulkTicketDBModel) CreateBulkJob(job *BulkTicketJob) error {
+	return as.DB.Create(job).Error
+}
+
+// UpdateBulkJob updates a bulk job.
+func (as *BulkTicketDBModel) UpdateBulkJob(job *BulkTicketJob) error {
+	return as.DB.Save(job).Error
+}
+
+// GetBulkJobByID retrieves a bulk job by its ID.
+func (as *BulkTicketDBModel) GetBulkJobByID(id uint) (*BulkTicketJob, error) {
+	var job BulkTicketJob
+	err := as.DB.Where("id = ?", id).First(&job).Error
+	return &job, err
+}
+
+// GetBulkJobs retrieves the most recent bulk jobs.
+func (as *BulkTicketDBModel) GetBulkJobs(limit int) (*[]BulkTicketJob, error) {
+	var jobs []BulkTicketJob
+	err := as.DB.Order("id desc").Limit(limit).Find(&jobs).Error
+	return &jobs, err
+}
+
+// CreateResult records the outcome of a bulk job for a ticket.
+func (as *BulkTicketDBModel) CreateResult(result *BulkTicketResult) error {
+	return as.DB.Create(result).Error
+}
+
+// GetResults retrieves the results of a bulk job in the order the tickets
+// were processed, optionally only those with a status.
+func (as *BulkTicketDBModel) GetResults(bulkJobID uint, status string) (*[]BulkTicketResult, error) {
+	var results []BulkTicketResult
+	query := as.DB.Where("bulk_job_id = ?", bulkJobID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("id").Find(&results).Error
+	return &results, err
+}
+
+// GetProcessedTicketIDs retrieves the tickets a bulk job already has a
+// result for.
+func (as *BulkTicketDBModel) GetProcessedTicketIDs(bulkJobID uint) (map[uint]bool, error) {
+	var ids []uint
+	if err := as.DB.Model(&BulkTicketResult{}).Where("bulk_job_id = ?", bulkJobID).Pluck("ticket_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	processed := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		processed[id] = true
+	}
+	return processed, nil
+}
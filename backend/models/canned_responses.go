@@ -0,0 +1,159 @@
+// backend/models/canned_responses.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Scopes of canned responses and macros: personal ones are only seen by
+// their owner, unit ones by the agents of the unit and global ones by all.
+const (
+	ScopePersonal = "personal"
+	ScopeUnit     = "unit"
+	ScopeGlobal   = "global"
+)
+
+// CannedResponse is a reusable reply. Its body may contain placeholders
+// such as {{user.first_name}} that are filled in for each ticket.
+type CannedResponse struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"response_id"`
+	Title     string    `json:"title" binding:"required"`
+	Body      string    `json:"body" binding:"required"`
+	Scope     string    `json:"scope" gorm:"index"`
+	AgentID   uint      `json:"agent_id,omitempty" gorm:"index"`
+	UnitName  string    `json:"unit_name,omitempty" gorm:"index"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the CannedResponse model.
+func (CannedResponse) TableName() string {
+	return "canned_responses"
+}
+
+// Macro applies a canned response and a set of field changes to a ticket
+// in one action. Every part is optional.
+type Macro struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"macro_id"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Scope       string `json:"scope" gorm:"index"`
+	AgentID     uint   `json:"agent_id,omitempty" gorm:"index"`
+	UnitName    string `json:"unit_name,omitempty" gorm:"index"`
+	// ResponseID is the canned response added to the ticket, as an
+	// internal note when Internal is set.
+	ResponseID uint      `json:"response_id,omitempty"`
+	Internal   bool      `json:"internal"`
+	Status     string    `json:"status,omitempty"`
+	Priority   string    `json:"priority,omitempty"`
+	Tags       []string  `json:"tags,omitempty" gorm:"serializer:json"`
+	CreatedBy  uint      `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the Macro model.
+func (Macro) TableName() string {
+	return "macros"
+}
+
+type CannedResponseStorage interface {
+	CreateResponse(*CannedResponse) error
+	UpdateResponse(*CannedResponse) error
+	DeleteResponse(uint) error
+	GetResponseByID(uint) (*CannedResponse, error)
+	GetVisibleResponses(uint, string, string) (*[]CannedResponse, error)
+	CreateMacro(*Macro) error
+	UpdateMacro(*Macro) error
+	DeleteMacro(uint) error
+	GetMacroByID(uint) (*Macro, error)
+	GetVisibleMacros(uint, string) (*[]Macro, error)
+}
+
+// CannedResponseDBModel handles database operations for CannedResponse and Macro
+type CannedResponseDBModel struct {
+	DB *gorm.DB
+}
+
+// NewCannedResponseDBModel creates a new instance of CannedResponseDBModel
+func NewCannedResponseDBModel(db *gorm.DB) *CannedResponseDBModel {
+	return &CannedResponseDBModel{
+		DB: db,
+	}
+}
+
+// visibleTo restricts a query to what an agent of a unit may see.
+func visibleTo(db *gorm.DB, agentID uint, unitName string) *gorm.DB {
+	return db.Where("scope = ? OR (scope = ? AND agent_id = ?) OR (scope = ? AND unit_name = ? AND unit_name <> '')",
+		ScopeGlobal, ScopePersonal, agentID, ScopeUnit, unitName)
+}
+
+// CreateResponse creates a canned response.
+func (as *CannedResponseDBModel) CreateResponse(response *CannedResponse) error {
+	return as.DB.Create(response).Error
+}
+
+// UpdateResponse updates a canned response.
+func (as *CannedResponseDBModel) UpdateResponse(response *CannedResponse) error {
+	return as.DB.Save(response).Error
+}
+
+// DeleteResponse deletes a canned response.
+func (as *CannedResponseDBModel) DeleteResponse(id uint) error {
+	return as.DB.Delete(&CannedResponse{}, id).Error
+}
+
+// GetResponseByID retrieves a canned response by its ID.
+func (as *CannedResponseDBModel) GetResponseByID(id uint) (*CannedResponse, error) {
+	var response CannedResponse
+	err := as.DB.Where("id = ?", id).First(&response).Error
+	return &response, err
+}
+
+// GetVisibleResponses retrieves the canned responses an agent may use,
+// optionally only those whose title or body contains search.
+func (as *CannedResponseDBModel) GetVisibleResponses(agentID uint, unitName, search string) (*[]CannedResponse, error) {
+	var responses []CannedResponse
+	query := visibleTo(as.DB, agentID, unitName)
+	if search != "" {
+		like := "%" + search + "%"
+		query = query.Where("title LIKE ? OR body LIKE ?", like, like)
+	}
+	err := query.Order("title, id").Find(&responses).Error
+	return &responses, err
+}
+
+// CreateMacro creates a macro.
+func (as *CannedResponseDBModel) CreateMacro(macro *Macro) error {
+	return as.DB.Create(macro).Error
+}
+
+// UpdateMacro updates a macro.
+func (as *CannedResponseDBModel) UpdateMacro(macro *Macro) error {
+	return as.DB.Save(macro).Error
+}
+
+// DeleteMacro deletes a macro.
+func (as *CannedResponseDBModel) DeleteMacro(id uint) error {
+	return as.DB.Delete(&Macro{}, id).Error
+}
+
+// GetMacroByID retrieves a macro by its ID.
+func (as *CannedResponseDBModel) GetMacroByID(id uint) (*Macro, error) {
+	var macro Macro
+	err := as.DB.Where("id = ?", id).First(&macro).Error
+	return &macro, err
+}
+
+// GetVisibleMacros retrieves the macros an agent may use.
+func (as *CannedResponseDBModel) GetVisibleMacros(agentID uint, unitName string) (*[]Macro, error) {
+	var macros []Macro
+	err := visibleTo(as.DB, agentID, unitName).Order("name, id").Find(&macros).Error
+	return &macros, err
+}
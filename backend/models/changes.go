@@ -0,0 +1,257 @@
+// backend/models/changes.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ChangeRequest is a planned change to infrastructure. Standard changes
+// are pre-approved; normal and emergency changes go through the approval
+// chain of their type, the change advisory board (CAB). A change is
+// carried out in its scheduled window, which may not overlap the windows
+// of other changes to the same assets.
+type ChangeRequest struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"change_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Type        string `json:"type"`
+	Status      string `json:"status" gorm:"index"`
+	RequestedBy uint   `json:"requested_by"`
+	OwnerID     uint   `json:"owner_id,omitempty"`
+	Reason      string `json:"reason"`
+	// AssetIDs are the assets the change touches.
+	AssetIDs           []uint `json:"asset_ids" gorm:"serializer:json"`
+	ImplementationPlan string `json:"implementation_plan"`
+	BackoutPlan        string `json:"backout_plan"`
+	TestPlan           string `json:"test_plan"`
+	// RiskAnswers answer the risk and impact questionnaire, from which
+	// RiskScore, ImpactScore and RiskLevel are worked out.
+	RiskAnswers  []RiskAnswer `json:"risk_answers" gorm:"serializer:json"`
+	RiskScore    int          `json:"risk_score"`
+	ImpactScore  int          `json:"impact_score"`
+	RiskLevel    string       `json:"risk_level"`
+	PlannedStart *time.Time   `json:"planned_start,omitempty" gorm:"index"`
+	PlannedEnd   *time.Time   `json:"planned_end,omitempty"`
+	ActualStart  *time.Time   `json:"actual_start,omitempty"`
+	ActualEnd    *time.Time   `json:"actual_end,omitempty"`
+	ClosureNotes string       `json:"closure_notes,omitempty"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+}
+
+// TableName sets the table name for the ChangeRequest model.
+func (ChangeRequest) TableName() string {
+	return "change_requests"
+}
+
+// RiskQuestion is a question of the change questionnaire, about the risk
+// of a change or its impact. Each option of the answer carries a score.
+type RiskQuestion struct {
+	gorm.Model
+	ID        uint         `gorm:"primaryKey" json:"question_id"`
+	Text      string       `json:"text"`
+	Kind      string       `json:"kind"`
+	Options   []RiskOption `json:"options" gorm:"serializer:json"`
+	Position  int          `json:"position"`
+	Active    bool         `json:"active"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// TableName sets the table name for the RiskQuestion model.
+func (RiskQuestion) TableName() string {
+	return "change_risk_questions"
+}
+
+// RiskOption is a possible answer to a RiskQuestion.
+type RiskOption struct {
+	Label string `json:"label"`
+	Score int    `json:"score"`
+}
+
+// RiskAnswer is the option chosen for a RiskQuestion. Score is copied from
+// the option, so later edits of the questionnaire do not rescore changes.
+type RiskAnswer struct {
+	QuestionID uint   `json:"question_id"`
+	Option     string `json:"option"`
+	Score      int    `json:"score"`
+}
+
+// Types of a ChangeRequest.
+const (
+	ChangeTypeStandard  = "standard"
+	ChangeTypeNormal    = "normal"
+	ChangeTypeEmergency = "emergency"
+)
+
+// ChangeTypes lists every change type, in the order above.
+var ChangeTypes = []string{ChangeTypeStandard, ChangeTypeNormal, ChangeTypeEmergency}
+
+// Statuses of a ChangeRequest.
+const (
+	ChangeStatusDraft           = "draft"
+	ChangeStatusPendingApproval = "pending_approval"
+	ChangeStatusApproved        = "approved"
+	ChangeStatusRejected        = "rejected"
+	ChangeStatusInProgress      = "in_progress"
+	ChangeStatusCompleted       = "completed"
+	ChangeStatusFailed          = "failed"
+	ChangeStatusCancelled       = "cancelled"
+)
+
+// ChangeStatuses lists every change status, in the order above.
+var ChangeStatuses = []string{ChangeStatusDraft, ChangeStatusPendingApproval, ChangeStatusApproved, ChangeStatusRejected, ChangeStatusInProgress, ChangeStatusCompleted, ChangeStatusFailed, ChangeStatusCancelled}
+
+// ChangeActiveStatuses are the statuses of changes whose window holds
+// their assets. Drafts do not hold them until they are submitted.
+var ChangeActiveStatuses = []string{ChangeStatusPendingApproval, ChangeStatusApproved, ChangeStatusInProgress}
+
+// Kinds of a RiskQuestion.
+const (
+	RiskKindRisk   = "risk"
+	RiskKindImpact = "impact"
+)
+
+// Levels of the risk of a ChangeRequest.
+const (
+	RiskLevelLow    = "low"
+	RiskLevelMedium = "medium"
+	RiskLevelHigh   = "high"
+)
+
+// EventChangeApproval is the notification event of the emails asking for
+// the approval of a change. It is not published on the event bus.
+const EventChangeApproval = "change.approval"
+
+type ChangeStorage interface {
+	CreateChange(*ChangeRequest) error
+	UpdateChange(*ChangeRequest) error
+	UpdateDraftChange(*ChangeRequest) (bool, error)
+	DeleteChange(uint) error
+	GetChangeByID(uint) (*ChangeRequest, error)
+	GetChanges(string) (*[]ChangeRequest, error)
+	GetScheduledChanges(time.Time, time.Time) (*[]ChangeRequest, error)
+	SetChangeStatus(uint, string) error
+	CountAssets([]uint) (int64, error)
+	CreateQuestion(*RiskQuestion) error
+	UpdateQuestion(*RiskQuestion) error
+	DeleteQuestion(uint) error
+	GetQuestionByID(uint) (*RiskQuestion, error)
+	GetQuestions(bool) (*[]RiskQuestion, error)
+}
+
+// ChangeDBModel handles database operations for ChangeRequest and
+// RiskQuestion
+type ChangeDBModel struct {
+	DB *gorm.DB
+}
+
+// NewChangeDBModel creates a new instance of ChangeDBModel
+func NewChangeDBModel(db *gorm.DB) *ChangeDBModel {
+	return &ChangeDBModel{
+		DB: db,
+	}
+}
+
+// CreateChange creates a change request.
+func (as *ChangeDBModel) CreateChange(change *ChangeRequest) error {
+	return as.DB.Create(change).Error
+}
+
+// UpdateChange updates a change request.
+func (as *ChangeDBModel) UpdateChange(change *ChangeRequest) error {
+	return as.DB.Save(change).Error
+}
+
+// UpdateDraftChange updates a change request that is still a draft in the
+// database. It reports false, updating nothing, when the change was
+// submitted or cancelled meanwhile.
+func (as *ChangeDBModel) UpdateDraftChange(change *ChangeRequest) (bool, error) {
+	res := as.DB.Model(change).Where("status = ?", ChangeStatusDraft).Select("*").Omit("created_at").Updates(change)
+	return res.RowsAffected == 1, res.Error
+}
+
+// DeleteChange deletes a change request.
+func (as *ChangeDBModel) DeleteChange(id uint) error {
+	return as.DB.Delete(&ChangeRequest{}, id).Error
+}
+
+// GetChangeByID retrieves a change request by its ID.
+func (as *ChangeDBModel) GetChangeByID(id uint) (*ChangeRequest, error) {
+	var change ChangeRequest
+	err := as.DB.Where("id = ?", id).First(&change).Error
+	return &change, err
+}
+
+// GetChanges retrieves the change requests, the latest first, optionally
+// only those with a status.
+func (as *ChangeDBModel) GetChanges(status string) (*[]ChangeRequest, error) {
+	var changes []ChangeRequest
+	query := as.DB.Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&changes).Error
+	return &changes, err
+}
+
+// GetScheduledChanges retrieves the change requests whose window overlaps
+// from to to, the earliest first.
+func (as *ChangeDBModel) GetScheduledChanges(from, to time.Time) (*[]ChangeRequest, error) {
+	var changes []ChangeRequest
+	err := as.DB.Where("planned_start < ? AND planned_end > ?", to, from).Order("planned_start, id").Find(&changes).Error
+	return &changes, err
+}
+
+// SetChangeStatus sets the status of a change request.
+func (as *ChangeDBModel) SetChangeStatus(id uint, status string) error {
+	return as.DB.Model(&ChangeRequest{}).Where("id = ?", id).Update("status", status).Error
+}
+
+// CountAssets counts the assets with the given IDs.
+func (as *ChangeDBModel) CountAssets(ids []uint) (int64, error) {
+	var count int64
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	err := as.DB.Table("assets").Where("id IN ? AND deleted_at IS NULL", ids).Count(&count).Error
+	return count, err
+}
+
+// CreateQuestion creates a risk question.
+func (as *ChangeDBModel) CreateQuestion(question *RiskQuestion) error {
+	return as.DB.Create(question).Error
+}
+
+// UpdateQuestion updates a risk question.
+func (as *ChangeDBModel) UpdateQuestion(question *RiskQuestion) error {
+	return as.DB.Save(question).Error
+}
+
+// DeleteQuestion deletes a risk question.
+func (as *ChangeDBModel) DeleteQuestion(id uint) error {
+	return as.DB.Delete(&RiskQuestion{}, id).Error
+}
+
+// GetQuestionByID retrieves a risk question by its ID.
+func (as *ChangeDBModel) GetQuestionByID(id uint) (*RiskQuestion, error) {
+	var question RiskQuestion
+	err := as.DB.Where("id = ?", id).First(&question).Error
+	return &question, err
+}
+
+// GetQuestions retrieves the risk questions in order, optionally only the
+// active ones.
+func (as *ChangeDBModel) GetQuestions(activeOnly bool) (*[]RiskQuestion, error) {
+	var questions []RiskQuestion
+	query := as.DB.Order("position, id")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Find(&questions).Error
+	return &questions, err
+}
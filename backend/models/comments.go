@@ -0,0 +1,85 @@
+// backend/models/comments.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketComment is a reply or note added to a ticket after it was created.
+type TicketComment struct {
+	gorm.Model
+	ID         uint      `gorm:"primaryKey" json:"comment_id"`
+	TicketID   uint      `json:"ticket_id" gorm:"index"`
+	UserID     uint      `json:"user_id"`
+	AgentID    uint      `json:"agent_id"`
+	Body       string    `json:"body" binding:"required"`
+	IsInternal bool      `json:"is_internal" gorm:"default:false"`
+	Source     string    `json:"source"`
+	MessageID  string    `json:"-" gorm:"index"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the TicketComment model.
+func (TicketComment) TableName() string {
+	return "ticket_comments"
+}
+
+// Sources of a TicketComment.
+const (
+	CommentSourceWeb   = "web"
+	CommentSourceEmail = "email"
+	CommentSourceRule  = "automation"
+)
+
+type CommentStorage interface {
+	CreateComment(*TicketComment) error
+	DeleteComment(uint) error
+	UpdateComment(*TicketComment) error
+	GetCommentByID(uint) (*TicketComment, error)
+	GetCommentsByTicketID(uint) (*[]TicketComment, error)
+}
+
+// CommentDBModel handles database operations for TicketComment
+type CommentDBModel struct {
+	DB *gorm.DB
+}
+
+// NewCommentDBModel creates a new instance of CommentDBModel
+func NewCommentDBModel(db *gorm.DB) *CommentDBModel {
+	return &CommentDBModel{
+		DB: db,
+	}
+}
+
+// CreateComment creates a new comment.
+func (as *CommentDBModel) CreateComment(comment *TicketComment) error {
+	return as.DB.Create(comment).Error
+}
+
+// GetCommentByID retrieves a comment by its ID.
+func (as *CommentDBModel) GetCommentByID(id uint) (*TicketComment, error) {
+	var comment TicketComment
+	err := as.DB.Where("id = ?", id).First(&comment).Error
+	return &comment, err
+}
+
+// UpdateComment updates the details of an existing comment.
+func (as *CommentDBModel) UpdateComment(comment *TicketComment) error {
+	return as.DB.Save(comment).Error
+}
+
+// DeleteComment deletes a comment from the database.
+func (as *CommentDBModel) DeleteComment(id uint) error {
+	return as.DB.Delete(&TicketComment{}, id).Error
+}
+
+// GetCommentsByTicketID retrieves the comments of a ticket, oldest first.
+func (as *CommentDBModel) GetCommentsByTicketID(ticketID uint) (*[]TicketComment, error) {
+	var comments []TicketComment
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("created_at, id").Find(&comments).Error
+	return &comments, err
+}
@@ -0,0 +1,238 @@
+// backend/models/custom_fields.go
+
+package models
+
+import (
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CustomFieldDefinition is a field an administrator adds to the tickets of
+// a category, or of one of its sub-categories. A definition without
+// category applies to every ticket. Key names the field in the API, in
+// filters and in rule conditions as custom.<key>.
+type CustomFieldDefinition struct {
+	gorm.Model
+	ID          uint     `gorm:"primaryKey" json:"field_id"`
+	Key         string   `json:"key" gorm:"uniqueIndex;size:64"`
+	Label       string   `json:"label"`
+	Type        string   `json:"type"`
+	Description string   `json:"description"`
+	Category    string   `json:"category" gorm:"index"`
+	SubCategory string   `json:"sub_category"`
+	Required    bool     `json:"required"`
+	Options     []string `json:"options,omitempty" gorm:"serializer:json"`
+	// Pattern is a regular expression text values must match.
+	Pattern string `json:"pattern,omitempty"`
+	// MaxLength bounds text values; Min and Max bound numbers.
+	MaxLength int       `json:"max_length,omitempty"`
+	Min       *float64  `json:"min,omitempty"`
+	Max       *float64  `json:"max,omitempty"`
+	Position  int       `json:"position"`
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the CustomFieldDefinition model.
+func (CustomFieldDefinition) TableName() string {
+	return "custom_field_definitions"
+}
+
+// Types of a CustomFieldDefinition. User and asset fields hold the ID of a
+// user or asset.
+const (
+	FieldTypeText        = "text"
+	FieldTypeNumber      = "number"
+	FieldTypeDate        = "date"
+	FieldTypeDropdown    = "dropdown"
+	FieldTypeMultiSelect = "multi_select"
+	FieldTypeUser        = "user"
+	FieldTypeAsset       = "asset"
+)
+
+// FieldTypes lists every field type, in the order above.
+var FieldTypes = []string{
+	FieldTypeText, FieldTypeNumber, FieldTypeDate, FieldTypeDropdown,
+	FieldTypeMultiSelect, FieldTypeUser, FieldTypeAsset,
+}
+
+// AppliesTo reports whether the field belongs on the tickets of a category
+// and sub-category.
+func (d *CustomFieldDefinition) AppliesTo(category, subCategory string) bool {
+	if d.Category == "" {
+		return true
+	}
+	if d.Category != category {
+		return false
+	}
+	return d.SubCategory == "" || d.SubCategory == subCategory
+}
+
+// TicketFieldValue is the value of a custom field on a ticket, as text.
+// Multi-select fields have a row per selected option.
+type TicketFieldValue struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"-"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	FieldID   uint      `json:"field_id" gorm:"index"`
+	FieldKey  string    `json:"key" gorm:"index:idx_ticket_field_value;size:64"`
+	FieldType string    `json:"type"`
+	Value     string    `json:"value" gorm:"index:idx_ticket_field_value;size:255"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the TicketFieldValue model.
+func (TicketFieldValue) TableName() string {
+	return "ticket_field_values"
+}
+
+// CustomFieldValues are the custom fields of a ticket by key: a string for
+// text, date and dropdown fields, a number for number fields, a list of
+// strings for multi-select fields and an ID for user and asset fields.
+type CustomFieldValues map[string]interface{}
+
+// Strings returns the values of a field as text, as stored.
+func (v CustomFieldValues) Strings(key string) []string {
+	switch value := v[key].(type) {
+	case nil:
+		return nil
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []interface{}:
+		out := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	case float64:
+		return []string{strconv.FormatFloat(value, 'f', -1, 64)}
+	case uint:
+		return []string{strconv.FormatUint(uint64(value), 10)}
+	}
+	return nil
+}
+
+type CustomFieldStorage interface {
+	CreateDefinition(*CustomFieldDefinition) error
+	UpdateDefinition(*CustomFieldDefinition) error
+	DeleteDefinition(uint) error
+	GetDefinitionByID(uint) (*CustomFieldDefinition, error)
+	GetDefinitionByKey(string) (*CustomFieldDefinition, error)
+	GetDefinitions(bool) (*[]CustomFieldDefinition, error)
+	SetTicketValues(uint, []TicketFieldValue) error
+	GetTicketValues([]uint) (map[uint]CustomFieldValues, error)
+}
+
+// CustomFieldDBModel handles database operations for CustomFieldDefinition
+// and TicketFieldValue
+type CustomFieldDBModel struct {
+	DB *gorm.DB
+}
+
+// NewCustomFieldDBModel creates a new instance of CustomFieldDBModel
+func NewCustomFieldDBModel(db *gorm.DB) *CustomFieldDBModel {
+	return &CustomFieldDBModel{
+		DB: db,
+	}
+}
+
+// CreateDefinition creates a custom field definition.
+func (as *CustomFieldDBModel) CreateDefinition(definition *CustomFieldDefinition) error {
+	return as.DB.Create(definition).Error
+}
+
+// UpdateDefinition updates a custom field definition.
+func (as *CustomFieldDBModel) UpdateDefinition(definition *CustomFieldDefinition) error {
+	return as.DB.Save(definition).Error
+}
+
+// DeleteDefinition deletes a custom field definition and its values on
+// every ticket.
+func (as *CustomFieldDBModel) DeleteDefinition(id uint) error {
+	if err := as.DB.Unscoped().Where("field_id = ?", id).Delete(&TicketFieldValue{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&CustomFieldDefinition{}, id).Error
+}
+
+// GetDefinitionByID retrieves a custom field definition by its ID.
+func (as *CustomFieldDBModel) GetDefinitionByID(id uint) (*CustomFieldDefinition, error) {
+	var definition CustomFieldDefinition
+	err := as.DB.Where("id = ?", id).First(&definition).Error
+	return &definition, err
+}
+
+// GetDefinitionByKey retrieves a custom field definition by its key.
+func (as *CustomFieldDBModel) GetDefinitionByKey(key string) (*CustomFieldDefinition, error) {
+	var definition CustomFieldDefinition
+	err := as.DB.Where("`key` = ?", key).First(&definition).Error
+	return &definition, err
+}
+
+// GetDefinitions retrieves the custom field definitions in form order,
+// optionally only the active ones.
+func (as *CustomFieldDBModel) GetDefinitions(activeOnly bool) (*[]CustomFieldDefinition, error) {
+	var definitions []CustomFieldDefinition
+	query := as.DB.Order("position, id")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Find(&definitions).Error
+	return &definitions, err
+}
+
+// SetTicketValues replaces the custom field values of a ticket.
+func (as *CustomFieldDBModel) SetTicketValues(ticketID uint, values []TicketFieldValue) error {
+	if err := as.DB.Unscoped().Where("ticket_id = ?", ticketID).Delete(&TicketFieldValue{}).Error; err != nil {
+		return err
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	for i := range values {
+		values[i].ID = 0
+		values[i].TicketID = ticketID
+	}
+	return as.DB.Create(&values).Error
+}
+
+// GetTicketValues retrieves the custom field values of tickets. Tickets
+// without any are left out of the map.
+func (as *CustomFieldDBModel) GetTicketValues(ticketIDs []uint) (map[uint]CustomFieldValues, error) {
+	values := make(map[uint]CustomFieldValues, len(ticketIDs))
+	if len(ticketIDs) == 0 {
+		return values, nil
+	}
+	var rows []TicketFieldValue
+	if err := as.DB.Where("ticket_id IN ?", ticketIDs).Order("id").Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if values[row.TicketID] == nil {
+			values[row.TicketID] = CustomFieldValues{}
+		}
+		fields := values[row.TicketID]
+		switch row.FieldType {
+		case FieldTypeMultiSelect:
+			list, _ := fields[row.FieldKey].([]string)
+			fields[row.FieldKey] = append(list, row.Value)
+		case FieldTypeNumber:
+			n, _ := strconv.ParseFloat(row.Value, 64)
+			fields[row.FieldKey] = n
+		case FieldTypeUser, FieldTypeAsset:
+			id, _ := strconv.ParseUint(row.Value, 10, 64)
+			fields[row.FieldKey] = uint(id)
+		default:
+			fields[row.FieldKey] = row.Value
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,105 @@
+// backend/models/events.go
+
+package models
+
+// Names of the things that happen to tickets, assets, changes, major
+// incidents and users. They are shared by notifications, webhooks and
+// automation so every subsystem speaks about the same events.
+const (
+	EventTicketCreated       = "ticket.created"
+	EventTicketUpdated       = "ticket.updated"
+	EventTicketAssigned      = "ticket.assigned"
+	EventTicketReplied       = "ticket.replied"
+	EventTicketResolved      = "ticket.resolved"
+	EventTicketStatusChanged = "ticket.status_changed"
+	EventTicketDeleted       = "ticket.deleted"
+	EventTicketReminder      = "ticket.reminder"
+	EventTicketMentioned     = "ticket.mentioned"
+	EventTicketApproved      = "ticket.approved"
+	EventTicketRejected      = "ticket.rejected"
+
+	EventAssetCreated  = "asset.created"
+	EventAssetUpdated  = "asset.updated"
+	EventAssetAssigned = "asset.assigned"
+	EventAssetDeleted  = "asset.deleted"
+
+	EventChangeApproved = "change.approved"
+	EventChangeRejected = "change.rejected"
+
+	EventMajorIncidentDeclared = "major_incident.declared"
+	EventMajorIncidentUpdated  = "major_incident.updated"
+	EventMajorIncidentResolved = "major_incident.resolved"
+
+	EventUserCreated = "user.created"
+	EventUserUpdated = "user.updated"
+	EventUserDeleted = "user.deleted"
+)
+
+// KnownEvents lists every event name, in the order above.
+var KnownEvents = []string{
+	EventTicketCreated, EventTicketUpdated, EventTicketAssigned, EventTicketReplied,
+	EventTicketResolved, EventTicketStatusChanged, EventTicketDeleted, EventTicketReminder,
+	EventTicketMentioned, EventTicketApproved, EventTicketRejected,
+	EventAssetCreated, EventAssetUpdated, EventAssetAssigned, EventAssetDeleted,
+	EventChangeApproved, EventChangeRejected,
+	EventMajorIncidentDeclared, EventMajorIncidentUpdated, EventMajorIncidentResolved,
+	EventUserCreated, EventUserUpdated, EventUserDeleted,
+}
+
+// IsKnownEvent reports whether name is one of the KnownEvents.
+func IsKnownEvent(name string) bool {
+	for _, e := range KnownEvents {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
+
+// TicketRef is the payload of events about a ticket that no longer exists.
+type TicketRef struct {
+	TicketID uint `json:"ticket_id"`
+}
+
+// AssetRef is the payload of events about an asset that no longer exists.
+type AssetRef struct {
+	AssetID uint `json:"asset_id"`
+}
+
+// UserRef is the payload of events about a user that no longer exists.
+type UserRef struct {
+	UserID uint `json:"user_id"`
+}
+
+// Mention is the payload of ticket.mentioned: an agent was mentioned in a
+// comment by the agent AuthorAgentID.
+type Mention struct {
+	TicketID      uint `json:"ticket_id"`
+	CommentID     uint `json:"comment_id"`
+	AgentID       uint `json:"agent_id"`
+	AuthorAgentID uint `json:"author_agent_id"`
+	IsInternal    bool `json:"is_internal"`
+}
+
+// AutomatedTicket is the payload of ticket events caused by automation
+// rules: the ticket together with the rules already applied in the chain
+// of changes, which do not run again on it.
+type AutomatedTicket struct {
+	Ticket
+	AppliedRules []uint `json:"applied_rules"`
+}
+
+// AutoClosedTicket is the payload of the events of a ticket closed by a
+// ticket policy rather than by an agent. Such closures ask the requester for
+// no satisfaction rating.
+type AutoClosedTicket struct {
+	Ticket
+	AutoClosed bool `json:"auto_closed"`
+}
+
+// AutomatedComment is the payload of ticket.replied when the comment was
+// added by automation rules.
+type AutomatedComment struct {
+	TicketComment
+	AppliedRules []uint `json:"applied_rules"`
+}
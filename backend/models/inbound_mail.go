@@ -0,0 +1,76 @@
+// backend/models/inbound_mail.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// InboundEmail records every message received by the mail ingestion so the
+// same message is never turned into a ticket twice.
+type InboundEmail struct {
+	gorm.Model
+	ID         uint      `gorm:"primaryKey" json:"inbound_email_id"`
+	MessageID  string    `json:"message_id" gorm:"uniqueIndex"`
+	Source     string    `json:"source"`
+	FromEmail  string    `json:"from_email"`
+	Subject    string    `json:"subject"`
+	TicketID   uint      `json:"ticket_id"`
+	CommentID  uint      `json:"comment_id"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	ReceivedAt time.Time `json:"received_at"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the InboundEmail model.
+func (InboundEmail) TableName() string {
+	return "inbound_emails"
+}
+
+// Outcomes of processing an InboundEmail.
+const (
+	InboundStatusTicketCreated = "ticket_created"
+	InboundStatusCommentAdded  = "comment_added"
+	InboundStatusRejected      = "rejected"
+)
+
+type InboundEmailStorage interface {
+	CreateInboundEmail(*InboundEmail) error
+	GetInboundEmailByMessageID(string) (*InboundEmail, error)
+	GetInboundEmails(int) (*[]InboundEmail, error)
+}
+
+// InboundEmailDBModel handles database operations for InboundEmail
+type InboundEmailDBModel struct {
+	DB *gorm.DB
+}
+
+// NewInboundEmailDBModel creates a new instance of InboundEmailDBModel
+func NewInboundEmailDBModel(db *gorm.DB) *InboundEmailDBModel {
+	return &InboundEmailDBModel{
+		DB: db,
+	}
+}
+
+// CreateInboundEmail records a processed message.
+func (as *InboundEmailDBModel) CreateInboundEmail(email *InboundEmail) error {
+	return as.DB.Create(email).Error
+}
+
+// GetInboundEmailByMessageID retrieves a processed message by its Message-ID header.
+func (as *InboundEmailDBModel) GetInboundEmailByMessageID(messageID string) (*InboundEmail, error) {
+	var email InboundEmail
+	err := as.DB.Where("message_id = ?", messageID).First(&email).Error
+	return &email, err
+}
+
+// GetInboundEmails retrieves the most recently processed messages.
+func (as *InboundEmailDBModel) GetInboundEmails(limit int) (*[]InboundEmail, error) {
+	var emails []InboundEmail
+	err := as.DB.Order("id desc").Limit(limit).Find(&emails).Error
+	return &emails, err
+}
@@ -0,0 +1,258 @@
+// backend/models/jobs.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Job is a unit of background work run by the job runner.
+type Job struct {
+	gorm.Model
+	ID          uint      `gorm:"primaryKey" json:"job_id"`
+	Kind        string    `json:"kind" gorm:"index"`
+	Payload     string    `json:"payload"`
+	Status      string    `json:"status" gorm:"index"`
+	Attempts    int       `json:"attempts"`
+	MaxAttempts int       `json:"max_attempts"`
+	RunAt       time.Time `json:"run_at" gorm:"index"`
+	// UniqueKey prevents a second job with the same key from being queued
+	// while the first is pending or running. It is cleared once the job
+	// finishes.
+	UniqueKey       *string    `json:"unique_key,omitempty" gorm:"uniqueIndex"`
+	LockedBy        string     `json:"locked_by,omitempty"`
+	LockedUntil     *time.Time `json:"locked_until,omitempty"`
+	CancelRequested bool       `json:"cancel_requested"`
+	LastError       string     `json:"last_error,omitempty"`
+	StartedAt       *time.Time `json:"started_at,omitempty"`
+	FinishedAt      *time.Time `json:"finished_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the Job model.
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// States of a Job.
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusSucceeded = "succeeded"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// JobSchedule is a recurring job. Replicas agree on who enqueues each run
+// by advancing NextRunAt with an update conditional on Version.
+type JobSchedule struct {
+	gorm.Model
+	ID        uint       `gorm:"primaryKey" json:"schedule_id"`
+	Name      string     `json:"name" gorm:"uniqueIndex"`
+	Spec      string     `json:"spec"`
+	Kind      string     `json:"kind"`
+	Payload   string     `json:"payload"`
+	Enabled   bool       `json:"enabled"`
+	NextRunAt time.Time  `json:"next_run_at"`
+	LastRunAt *time.Time `json:"last_run_at,omitempty"`
+	LastJobID uint       `json:"last_job_id,omitempty"`
+	Version   int        `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the JobSchedule model.
+func (JobSchedule) TableName() string {
+	return "job_schedules"
+}
+
+type JobStorage interface {
+	CreateJob(*Job) error
+	UpdateJob(*Job) error
+	GetJobByID(uint) (*Job, error)
+	GetJobByUniqueKey(string) (*Job, error)
+	GetJobs(string, string, int) (*[]Job, error)
+	GetDueJobs(time.Time, []string, int) (*[]Job, error)
+	ClaimJob(uint, string, time.Time, time.Time) (bool, error)
+	ExtendLock(uint, string, time.Time) (bool, bool, error)
+	FinishJob(*Job, string) (bool, error)
+	RequestCancel(uint) (*Job, error)
+	GetSchedules() (*[]JobSchedule, error)
+	GetScheduleByName(string) (*JobSchedule, error)
+	CreateSchedule(*JobSchedule) error
+	UpdateSchedule(*JobSchedule) error
+	AdvanceSchedule(*JobSchedule, time.Time) (bool, error)
+	SetScheduleLastJob(uint, uint) error
+}
+
+// JobDBModel handles database operations for Job
+type JobDBModel struct {
+	DB *gorm.DB
+}
+
+// NewJobDBModel creates a new instance of JobDBModel
+func NewJobDBModel(db *gorm.DB) *JobDBModel {
+	return &JobDBModel{
+		DB: db,
+	}
+}
+
+// CreateJob queues a job.
+func (as *JobDBModel) CreateJob(job *Job) error {
+	return as.DB.Create(job).Error
+}
+
+// UpdateJob updates the details of an existing job.
+func (as *JobDBModel) UpdateJob(job *Job) error {
+	return as.DB.Save(job).Error
+}
+
+// GetJobByID retrieves a job by its ID.
+func (as *JobDBModel) GetJobByID(id uint) (*Job, error) {
+	var job Job
+	err := as.DB.Where("id = ?", id).First(&job).Error
+	return &job, err
+}
+
+// GetJobByUniqueKey retrieves the pending or running job holding a unique key.
+func (as *JobDBModel) GetJobByUniqueKey(key string) (*Job, error) {
+	var job Job
+	err := as.DB.Where("unique_key = ?", key).First(&job).Error
+	return &job, err
+}
+
+// GetJobs retrieves the most recent jobs, optionally filtered by status and kind.
+func (as *JobDBModel) GetJobs(status, kind string, limit int) (*[]Job, error) {
+	var jobs []Job
+	query := as.DB.Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	err := query.Find(&jobs).Error
+	return &jobs, err
+}
+
+// GetDueJobs retrieves jobs of the given kinds that can run now: pending
+// jobs whose run time has come and running jobs whose lock expired.
+func (as *JobDBModel) GetDueJobs(now time.Time, kinds []string, limit int) (*[]Job, error) {
+	var jobs []Job
+	err := as.DB.Where("kind IN ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))",
+		kinds, JobStatusPending, now, JobStatusRunning, now).
+		Order("run_at, id").Limit(limit).Find(&jobs).Error
+	return &jobs, err
+}
+
+// ClaimJob locks a due job for a worker until lockedUntil and counts the
+// attempt. Only one worker can claim a job.
+func (as *JobDBModel) ClaimJob(id uint, worker string, now, lockedUntil time.Time) (bool, error) {
+	res := as.DB.Model(&Job{}).
+		Where("id = ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))", id, JobStatusPending, now, JobStatusRunning, now).
+		Updates(map[string]interface{}{
+			"status":       JobStatusRunning,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"locked_by":    worker,
+			"locked_until": lockedUntil,
+			"started_at":   now,
+			"updated_at":   now,
+		})
+	return res.RowsAffected == 1, res.Error
+}
+
+// ExtendLock keeps a running job locked by worker until lockedUntil. It
+// reports whether worker still held the lock, which it loses when the lock
+// expired and another worker claimed the job, and whether cancellation of
+// the job was requested.
+func (as *JobDBModel) ExtendLock(id uint, worker string, lockedUntil time.Time) (bool, bool, error) {
+	res := as.DB.Model(&Job{}).Where("id = ? AND locked_by = ? AND status = ?", id, worker, JobStatusRunning).
+		Updates(map[string]interface{}{"locked_until": lockedUntil, "updated_at": time.Now()})
+	if res.Error != nil || res.RowsAffected != 1 {
+		return false, false, res.Error
+	}
+	var job Job
+	if err := as.DB.Select("cancel_requested").Where("id = ?", id).First(&job).Error; err != nil {
+		return true, false, err
+	}
+	return true, job.CancelRequested, nil
+}
+
+// FinishJob records the outcome of a run by worker: the status, error, next
+// run time and the release of the lock. It leaves the cancellation flag
+// alone. It reports false, recording nothing, when worker no longer holds
+// the lock of the job.
+func (as *JobDBModel) FinishJob(job *Job, worker string) (bool, error) {
+	res := as.DB.Model(job).Where("locked_by = ? AND status = ?", worker, JobStatusRunning).
+		Select("status", "run_at", "unique_key", "locked_by", "locked_until", "last_error", "finished_at", "updated_at").
+		Updates(job)
+	return res.RowsAffected == 1, res.Error
+}
+
+// RequestCancel cancels a pending job right away and flags a running job
+// so its worker stops it.
+func (as *JobDBModel) RequestCancel(id uint) (*Job, error) {
+	now := time.Now()
+	err := as.DB.Model(&Job{}).Where("id = ? AND status = ?", id, JobStatusPending).
+		Updates(map[string]interface{}{"status": JobStatusCancelled, "unique_key": nil, "finished_at": now, "updated_at": now}).Error
+	if err != nil {
+		return nil, err
+	}
+	err = as.DB.Model(&Job{}).Where("id = ? AND status = ?", id, JobStatusRunning).
+		Updates(map[string]interface{}{"cancel_requested": true, "updated_at": now}).Error
+	if err != nil {
+		return nil, err
+	}
+	return as.GetJobByID(id)
+}
+
+// GetSchedules retrieves all recurring jobs.
+func (as *JobDBModel) GetSchedules() (*[]JobSchedule, error) {
+	var schedules []JobSchedule
+	err := as.DB.Order("name").Find(&schedules).Error
+	return &schedules, err
+}
+
+// GetScheduleByName retrieves a recurring job by its name.
+func (as *JobDBModel) GetScheduleByName(name string) (*JobSchedule, error) {
+	var schedule JobSchedule
+	err := as.DB.Where("name = ?", name).First(&schedule).Error
+	return &schedule, err
+}
+
+// CreateSchedule creates a recurring job.
+func (as *JobDBModel) CreateSchedule(schedule *JobSchedule) error {
+	return as.DB.Create(schedule).Error
+}
+
+// UpdateSchedule updates the details of a recurring job.
+func (as *JobDBModel) UpdateSchedule(schedule *JobSchedule) error {
+	return as.DB.Save(schedule).Error
+}
+
+// AdvanceSchedule moves a recurring job to its run at next. It reports
+// false when another replica advanced the schedule first.
+func (as *JobDBModel) AdvanceSchedule(schedule *JobSchedule, next time.Time) (bool, error) {
+	res := as.DB.Model(&JobSchedule{}).Where("id = ? AND version = ?", schedule.ID, schedule.Version).
+		Updates(map[string]interface{}{
+			"next_run_at": next,
+			"last_run_at": schedule.NextRunAt,
+			"version":     schedule.Version + 1,
+			"updated_at":  time.Now(),
+		})
+	if res.Error != nil || res.RowsAffected != 1 {
+		return false, res.Error
+	}
+	schedule.LastRunAt = &schedule.NextRunAt
+	schedule.NextRunAt = next
+	schedule.Version++
+	return true, nil
+}
+
+// SetScheduleLastJob records the job queued by the latest run of a schedule.
+func (as *JobDBModel) SetScheduleLastJob(scheduleID, jobID uint) error {
+	return as.DB.Model(&JobSchedule{}).Where("id = ?", scheduleID).Update("last_job_id", jobID).Error
+}
@@ -0,0 +1,395 @@
+// backend/models/knowledge.go
+
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// KnowledgeArticle is a knowledge base article. Its content is written in
+// Markdown and kept as numbered versions: every edit adds a version, which
+// goes through review before it is published. Title, Summary and Body are
+// those of the published version, or of the latest version until one is
+// published, so that readers keep seeing the published article while the
+// next version is being written and reviewed.
+type KnowledgeArticle struct {
+	gorm.Model
+	ID      uint   `gorm:"primaryKey" json:"article_id"`
+	Title   string `json:"title"`
+	Summary string `json:"summary"`
+	Body    string `json:"body" gorm:"type:text"`
+	// Category and SubCategory are those of the tickets the article helps
+	// with, by name like on a Ticket.
+	Category    string `json:"category" gorm:"index"`
+	SubCategory string `json:"sub_category,omitempty"`
+	Visibility  string `json:"visibility" gorm:"index"`
+	// Status is the review status of the latest version.
+	Status           string     `json:"status" gorm:"index"`
+	CurrentVersion   int        `json:"current_version"`
+	PublishedVersion int        `json:"published_version"`
+	PublishedAt      *time.Time `json:"published_at,omitempty"`
+	PublishedBy      uint       `json:"published_by,omitempty"`
+	AuthorID         uint       `json:"author_id"`
+	HelpfulCount     int        `json:"helpful_count"`
+	NotHelpfulCount  int        `json:"not_helpful_count"`
+	// Draft is the latest version when it is not the published one.
+	Draft       *ArticleVersion     `json:"draft,omitempty" gorm:"-"`
+	Attachments []ArticleAttachment `json:"attachments,omitempty" gorm:"foreignKey:ArticleID"`
+	Tickets     []ArticleTicket     `json:"tickets,omitempty" gorm:"foreignKey:ArticleID"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+// TableName sets the table name for the KnowledgeArticle model.
+func (KnowledgeArticle) TableName() string {
+	return "kb_articles"
+}
+
+// Published reports whether a version of the article is published.
+func (a *KnowledgeArticle) Published() bool {
+	return a.PublishedVersion > 0
+}
+
+// ArticleVersion is one version of the content of a knowledge article and
+// of who may read it.
+type ArticleVersion struct {
+	gorm.Model
+	ID         uint      `gorm:"primaryKey" json:"version_id"`
+	ArticleID  uint      `json:"article_id" gorm:"index"`
+	Version    int       `json:"version"`
+	Title      string    `json:"title"`
+	Summary    string    `json:"summary"`
+	Body       string    `json:"body" gorm:"type:text"`
+	Visibility string    `json:"visibility"`
+	ChangeNote string    `json:"change_note,omitempty"`
+	EditedBy   uint      `json:"edited_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the ArticleVersion model.
+func (ArticleVersion) TableName() string {
+	return "kb_article_versions"
+}
+
+// ArticleAttachment is a file attached to a knowledge article. Like a
+// ticket attachment it is stored under its checksum and scanned on upload;
+// a quarantined file is kept but not served.
+type ArticleAttachment struct {
+	gorm.Model
+	ID          uint       `gorm:"primaryKey" json:"attachment_id"`
+	ArticleID   uint       `json:"article_id" gorm:"index"`
+	Type        string     `json:"type"`
+	FileName    string     `json:"file_name"`
+	URL         string     `json:"url,omitempty" gorm:"-"`
+	ContentType string     `json:"content_type"`
+	Size        int64      `json:"size"`
+	Checksum    string     `json:"-"`
+	StorageKey  string     `json:"-" gorm:"index"`
+	UploadedBy  uint       `json:"uploaded_by"`
+	ScanStatus  string     `json:"scan_status"`
+	ScanResult  string     `json:"-"`
+	ScannedAt   *time.Time `json:"-"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName sets the table name for the ArticleAttachment model.
+func (ArticleAttachment) TableName() string {
+	return "kb_article_attachments"
+}
+
+// Available reports whether the attachment may be downloaded.
+func (a *ArticleAttachment) Available() bool {
+	return a.ScanStatus != ScanStatusQuarantined
+}
+
+// ArticleTicket links a ticket with a knowledge article that applies to
+// it. Resolved records that the article resolved the issue of the ticket.
+type ArticleTicket struct {
+	gorm.Model
+	ArticleID uint      `json:"article_id" gorm:"index"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	LinkedBy  uint      `json:"linked_by"`
+	Resolved  bool      `json:"resolved"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the ArticleTicket model.
+func (ArticleTicket) TableName() string {
+	return "kb_article_tickets"
+}
+
+// ArticleVote is whether a reader found a knowledge article helpful.
+// VoterKey identifies the reader, so that voting again changes their vote.
+type ArticleVote struct {
+	gorm.Model
+	ArticleID uint      `json:"article_id" gorm:"index"`
+	VoterKey  string    `json:"-" gorm:"index"`
+	UserID    uint      `json:"user_id,omitempty"`
+	Helpful   bool      `json:"helpful"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the ArticleVote model.
+func (ArticleVote) TableName() string {
+	return "kb_article_votes"
+}
+
+// Review statuses of a KnowledgeArticle. An article is drafted, submitted
+// for review, then published or sent back to draft; an archived article is
+// no longer published.
+const (
+	ArticleStatusDraft     = "draft"
+	ArticleStatusReview    = "review"
+	ArticleStatusPublished = "published"
+	ArticleStatusArchived  = "archived"
+)
+
+// ArticleStatuses lists every article status, in the order above.
+var ArticleStatuses = []string{ArticleStatusDraft, ArticleStatusReview, ArticleStatusPublished, ArticleStatusArchived}
+
+// Visibilities of a KnowledgeArticle. Agent articles are only shown to
+// agents; public articles are also shown on the public knowledge base.
+const (
+	ArticleVisibilityAgents = "agents"
+	ArticleVisibilityPublic = "public"
+)
+
+// ArticleVisibilities lists every article visibility.
+var ArticleVisibilities = []string{ArticleVisibilityAgents, ArticleVisibilityPublic}
+
+// ArticleFilter selects knowledge articles. Query matches the title,
+// summary or body; PublicOnly keeps the published public articles.
+type ArticleFilter struct {
+	Query       string
+	Category    string
+	SubCategory string
+	Status      string
+	PublicOnly  bool
+}
+
+type KnowledgeStorage interface {
+	CreateArticle(*KnowledgeArticle) error
+	UpdateArticle(*KnowledgeArticle) error
+	DeleteArticle(uint) error
+	GetArticleByID(uint) (*KnowledgeArticle, error)
+	GetArticles(ArticleFilter) (*[]KnowledgeArticle, error)
+	GetTicketArticles(uint) (*[]KnowledgeArticle, error)
+	CreateVersion(*ArticleVersion) error
+	GetVersion(uint, int) (*ArticleVersion, error)
+	GetVersions(uint) (*[]ArticleVersion, error)
+	CreateAttachment(*ArticleAttachment) error
+	GetAttachmentByID(uint) (*ArticleAttachment, error)
+	GetAttachmentByChecksum(uint, string) (*ArticleAttachment, error)
+	DeleteAttachment(uint) error
+	LinkTicket(*ArticleTicket) (bool, error)
+	UnlinkTicket(uint, uint) error
+	SaveVote(*ArticleVote) error
+	CountVotes(uint) (int, int, error)
+	TicketExists(uint) (bool, error)
+}
+
+// KnowledgeDBModel handles database operations for KnowledgeArticle,
+// ArticleVersion, ArticleAttachment, ArticleTicket and ArticleVote
+type KnowledgeDBModel struct {
+	DB *gorm.DB
+}
+
+// NewKnowledgeDBModel creates a new instance of KnowledgeDBModel
+func NewKnowledgeDBModel(db *gorm.DB) *KnowledgeDBModel {
+	return &KnowledgeDBModel{
+		DB: db,
+	}
+}
+
+func orderArticleAttachments(db *gorm.DB) *gorm.DB {
+	return db.Order("id")
+}
+
+func orderArticleTickets(db *gorm.DB) *gorm.DB {
+	return db.Order("ticket_id")
+}
+
+// CreateArticle creates a knowledge article, not its versions.
+func (as *KnowledgeDBModel) CreateArticle(article *KnowledgeArticle) error {
+	return as.DB.Omit("Attachments", "Tickets").Create(article).Error
+}
+
+// UpdateArticle updates a knowledge article, not its attachments and
+// tickets.
+func (as *KnowledgeDBModel) UpdateArticle(article *KnowledgeArticle) error {
+	return as.DB.Omit("Attachments", "Tickets").Save(article).Error
+}
+
+// DeleteArticle deletes a knowledge article with its versions, links to
+// tickets and votes. Its attachments are deleted by the caller, which
+// releases their stored files.
+func (as *KnowledgeDBModel) DeleteArticle(id uint) error {
+	for _, model := range []interface{}{&ArticleVersion{}, &ArticleTicket{}, &ArticleVote{}} {
+		if err := as.DB.Where("article_id = ?", id).Delete(model).Error; err != nil {
+			return err
+		}
+	}
+	return as.DB.Delete(&KnowledgeArticle{}, id).Error
+}
+
+// GetArticleByID retrieves a knowledge article with its attachments and
+// tickets.
+func (as *KnowledgeDBModel) GetArticleByID(id uint) (*KnowledgeArticle, error) {
+	var article KnowledgeArticle
+	err := as.DB.Preload("Attachments", orderArticleAttachments).Preload("Tickets", orderArticleTickets).
+		Where("id = ?", id).First(&article).Error
+	return &article, err
+}
+
+// GetArticles retrieves the knowledge articles matching a filter, the most
+// helpful first. Their attachments and tickets are not loaded.
+func (as *KnowledgeDBModel) GetArticles(filter ArticleFilter) (*[]KnowledgeArticle, error) {
+	var articles []KnowledgeArticle
+	query := as.DB.Order("helpful_count DESC, id DESC")
+	if filter.PublicOnly {
+		query = query.Where("published_version > 0 AND visibility = ?", ArticleVisibilityPublic)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Category != "" {
+		query = query.Where("LOWER(category) = LOWER(?)", filter.Category)
+	}
+	if filter.SubCategory != "" {
+		query = query.Where("LOWER(sub_category) = LOWER(?)", filter.SubCategory)
+	}
+	if filter.Query != "" {
+		like := "%" + filter.Query + "%"
+		query = query.Where("(title LIKE ? OR summary LIKE ? OR body LIKE ?)", like, like, like)
+	}
+	err := query.Find(&articles).Error
+	return &articles, err
+}
+
+// GetTicketArticles retrieves the knowledge articles linked with a ticket.
+func (as *KnowledgeDBModel) GetTicketArticles(ticketID uint) (*[]KnowledgeArticle, error) {
+	var articles []KnowledgeArticle
+	err := as.DB.Preload("Tickets", "ticket_id = ?", ticketID).
+		Where("id IN (?)", as.DB.Model(&ArticleTicket{}).Select("article_id").Where("ticket_id = ?", ticketID)).
+		Order("id").Find(&articles).Error
+	return &articles, err
+}
+
+// CreateVersion creates a version of a knowledge article.
+func (as *KnowledgeDBModel) CreateVersion(version *ArticleVersion) error {
+	return as.DB.Create(version).Error
+}
+
+// GetVersion retrieves a version of a knowledge article by its number.
+func (as *KnowledgeDBModel) GetVersion(articleID uint, version int) (*ArticleVersion, error) {
+	var v ArticleVersion
+	err := as.DB.Where("article_id = ? AND version = ?", articleID, version).First(&v).Error
+	return &v, err
+}
+
+// GetVersions retrieves the versions of a knowledge article, the latest
+// first.
+func (as *KnowledgeDBModel) GetVersions(articleID uint) (*[]ArticleVersion, error) {
+	var versions []ArticleVersion
+	err := as.DB.Where("article_id = ?", articleID).Order("version DESC").Find(&versions).Error
+	return &versions, err
+}
+
+// CreateAttachment creates an attachment record of a knowledge article.
+func (as *KnowledgeDBModel) CreateAttachment(attachment *ArticleAttachment) error {
+	return as.DB.Create(attachment).Error
+}
+
+// GetAttachmentByID retrieves an attachment of a knowledge article.
+func (as *KnowledgeDBModel) GetAttachmentByID(id uint) (*ArticleAttachment, error) {
+	var attachment ArticleAttachment
+	err := as.DB.Where("id = ?", id).First(&attachment).Error
+	return &attachment, err
+}
+
+// GetAttachmentByChecksum finds an attachment of an article with the same
+// content.
+func (as *KnowledgeDBModel) GetAttachmentByChecksum(articleID uint, checksum string) (*ArticleAttachment, error) {
+	var attachment ArticleAttachment
+	err := as.DB.Where("article_id = ? AND checksum = ?", articleID, checksum).First(&attachment).Error
+	return &attachment, err
+}
+
+// DeleteAttachment deletes an attachment record of a knowledge article.
+func (as *KnowledgeDBModel) DeleteAttachment(id uint) error {
+	return as.DB.Delete(&ArticleAttachment{}, id).Error
+}
+
+// LinkTicket links a ticket with a knowledge article, or updates whether
+// the article resolved it, and reports whether anything changed.
+func (as *KnowledgeDBModel) LinkTicket(link *ArticleTicket) (bool, error) {
+	var existing ArticleTicket
+	err := as.DB.Where("article_id = ? AND ticket_id = ?", link.ArticleID, link.TicketID).First(&existing).Error
+	if err == nil {
+		if existing.Resolved == link.Resolved {
+			*link = existing
+			return false, nil
+		}
+		existing.Resolved = link.Resolved
+		*link = existing
+		return true, as.DB.Save(link).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	err = as.DB.Create(link).Error
+	return err == nil, err
+}
+
+// UnlinkTicket removes the link between a ticket and a knowledge article.
+func (as *KnowledgeDBModel) UnlinkTicket(articleID, ticketID uint) error {
+	result := as.DB.Where("article_id = ? AND ticket_id = ?", articleID, ticketID).Delete(&ArticleTicket{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveVote records the vote of a reader on a knowledge article, replacing
+// their previous vote.
+func (as *KnowledgeDBModel) SaveVote(vote *ArticleVote) error {
+	var existing ArticleVote
+	err := as.DB.Where("article_id = ? AND voter_key = ?", vote.ArticleID, vote.VoterKey).First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return as.DB.Create(vote).Error
+	}
+	if err != nil {
+		return err
+	}
+	existing.Helpful = vote.Helpful
+	if vote.UserID != 0 {
+		existing.UserID = vote.UserID
+	}
+	*vote = existing
+	return as.DB.Save(vote).Error
+}
+
+// CountVotes counts the helpful and not helpful votes on a knowledge
+// article.
+func (as *KnowledgeDBModel) CountVotes(articleID uint) (int, int, error) {
+	var helpful, notHelpful int64
+	if err := as.DB.Model(&ArticleVote{}).Where("article_id = ? AND helpful = ?", articleID, true).Count(&helpful).Error; err != nil {
+		return 0, 0, err
+	}
+	err := as.DB.Model(&ArticleVote{}).Where("article_id = ? AND helpful = ?", articleID, false).Count(&notHelpful).Error
+	return int(helpful), int(notHelpful), err
+}
+
+// TicketExists reports whether a ticket exists.
+func (as *KnowledgeDBModel) TicketExists(ticketID uint) (bool, error) {
+	var count int64
+	err := as.DB.Table("tickets").Where("id = ? AND deleted_at IS NULL", ticketID).Count(&count).Error
+	return count > 0, err
+}
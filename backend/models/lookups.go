@@ -0,0 +1,303 @@
+// backend/models/lookups.go
+
+package models
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// CategoryNode is a category with its sub-categories, as shown on the
+// request form.
+type CategoryNode struct {
+	CategoryID    int           `json:"category_id"`
+	CategoryName  string        `json:"category_name"`
+	SubCategories []SubCategory `json:"sub_categories"`
+}
+
+// LookupDBModel handles database operations for the values tickets refer
+// to: Category, SubCategory, Priority, Status and Sla. Tickets keep a copy
+// of them rather than a foreign key, by name for categories and statuses.
+type LookupDBModel struct {
+	DB *gorm.DB
+}
+
+// NewLookupDBModel creates a new instance of LookupDBModel
+func NewLookupDBModel(db *gorm.DB) *LookupDBModel {
+	return &LookupDBModel{
+		DB: db,
+	}
+}
+
+// nextID returns the ID after the highest of column in table. Priority,
+// SubCategory, Status and Sla number their IDs themselves, the
+// auto-increment key of their tables being that of gorm.Model.
+func (as *LookupDBModel) nextID(table, column string) (int, error) {
+	var max int
+	err := as.DB.Table(table).Select(fmt.Sprintf("COALESCE(MAX(%s), 0)", column)).Scan(&max).Error
+	return max + 1, err
+}
+
+// CountTickets counts the tickets whose column holds value.
+func (as *LookupDBModel) CountTickets(column string, value interface{}) (int64, error) {
+	var count int64
+	err := as.DB.Table("tickets").Where("deleted_at IS NULL").Where(column+" = ?", value).Count(&count).Error
+	return count, err
+}
+
+// CountSubCategoryTickets counts the tickets of a sub-category of a
+// category.
+func (as *LookupDBModel) CountSubCategoryTickets(category, subCategory string) (int64, error) {
+	var count int64
+	err := as.DB.Table("tickets").Where("deleted_at IS NULL").
+		Where("category_name = ? AND sub_category_name = ?", category, subCategory).Count(&count).Error
+	return count, err
+}
+
+// CreateCategory creates a category.
+func (as *LookupDBModel) CreateCategory(category *Category) error {
+	return as.DB.Create(category).Error
+}
+
+// UpdateCategory updates a category.
+func (as *LookupDBModel) UpdateCategory(category *Category) error {
+	return as.DB.Save(category).Error
+}
+
+// DeleteCategory deletes a category and its sub-categories.
+func (as *LookupDBModel) DeleteCategory(id int) error {
+	if err := as.DB.Where("category_id = ?", id).Delete(&SubCategory{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Where("id = ?", id).Delete(&Category{}).Error
+}
+
+// GetCategoryByID retrieves a category by its ID.
+func (as *LookupDBModel) GetCategoryByID(id int) (*Category, error) {
+	var category Category
+	err := as.DB.Where("id = ?", id).First(&category).Error
+	return &category, err
+}
+
+// GetCategoryByName retrieves a category by its name.
+func (as *LookupDBModel) GetCategoryByName(name string) (*Category, error) {
+	var category Category
+	err := as.DB.Where("category_name = ?", name).First(&category).Error
+	return &category, err
+}
+
+// GetAllCategories retrieves every category, retired ones included.
+func (as *LookupDBModel) GetAllCategories() (*[]Category, error) {
+	var categories []Category
+	err := as.DB.Order("category_name, id").Find(&categories).Error
+	return &categories, err
+}
+
+// RenameCategory renames a category on the tickets, custom fields and
+// ticket policies that refer to it.
+func (as *LookupDBModel) RenameCategory(from, to string) error {
+	if err := as.DB.Table("tickets").Where("category_name = ?", from).Update("category_name", to).Error; err != nil {
+		return err
+	}
+	if err := as.DB.Model(&CustomFieldDefinition{}).Where("category = ?", from).Update("category", to).Error; err != nil {
+		return err
+	}
+	return as.DB.Model(&TicketPolicy{}).Where("category_name = ?", from).Update("category_name", to).Error
+}
+
+// CreateSubCategory creates a sub-category.
+func (as *LookupDBModel) CreateSubCategory(subCategory *SubCategory) error {
+	id, err := as.nextID("subCategory", "sub_category_id")
+	if err != nil {
+		return err
+	}
+	subCategory.SubCategoryID = id
+	return as.DB.Create(subCategory).Error
+}
+
+// UpdateSubCategory updates a sub-category.
+func (as *LookupDBModel) UpdateSubCategory(subCategory *SubCategory) error {
+	return as.DB.Save(subCategory).Error
+}
+
+// DeleteSubCategory deletes a sub-category.
+func (as *LookupDBModel) DeleteSubCategory(id int) error {
+	return as.DB.Where("sub_category_id = ?", id).Delete(&SubCategory{}).Error
+}
+
+// GetSubCategoryByID retrieves a sub-category by its ID.
+func (as *LookupDBModel) GetSubCategoryByID(id int) (*SubCategory, error) {
+	var subCategory SubCategory
+	err := as.DB.Where("sub_category_id = ?", id).First(&subCategory).Error
+	return &subCategory, err
+}
+
+// GetSubCategoryByName retrieves a sub-category of a category by its name.
+func (as *LookupDBModel) GetSubCategoryByName(categoryID int, name string) (*SubCategory, error) {
+	var subCategory SubCategory
+	err := as.DB.Where("category_id = ? AND sub_category_name = ?", categoryID, name).First(&subCategory).Error
+	return &subCategory, err
+}
+
+// GetAllSubCategories retrieves every sub-category, retired ones included.
+func (as *LookupDBModel) GetAllSubCategories() (*[]SubCategory, error) {
+	var subCategories []SubCategory
+	err := as.DB.Order("category_id, sub_category_name, sub_category_id").Find(&subCategories).Error
+	return &subCategories, err
+}
+
+// RenameSubCategory renames a sub-category of a category on the tickets and
+// custom fields that refer to it.
+func (as *LookupDBModel) RenameSubCategory(category, from, to string) error {
+	if err := as.DB.Table("tickets").Where("category_name = ? AND sub_category_name = ?", category, from).Update("sub_category_name", to).Error; err != nil {
+		return err
+	}
+	return as.DB.Model(&CustomFieldDefinition{}).Where("category = ? AND sub_category = ?", category, from).Update("sub_category", to).Error
+}
+
+// CreatePriority creates a priority.
+func (as *LookupDBModel) CreatePriority(priority *Priority) error {
+	id, err := as.nextID("priority", "priority_id")
+	if err != nil {
+		return err
+	}
+	priority.PriorityID = id
+	return as.DB.Create(priority).Error
+}
+
+// UpdatePriority updates a priority.
+func (as *LookupDBModel) UpdatePriority(priority *Priority) error {
+	return as.DB.Save(priority).Error
+}
+
+// DeletePriority deletes a priority.
+func (as *LookupDBModel) DeletePriority(id int) error {
+	return as.DB.Where("priority_id = ?", id).Delete(&Priority{}).Error
+}
+
+// GetPriorityByID retrieves a priority by its ID.
+func (as *LookupDBModel) GetPriorityByID(id int) (*Priority, error) {
+	var priority Priority
+	err := as.DB.Where("priority_id = ?", id).First(&priority).Error
+	return &priority, err
+}
+
+// GetPriorityByName retrieves a priority by its name.
+func (as *LookupDBModel) GetPriorityByName(name string) (*Priority, error) {
+	var priority Priority
+	err := as.DB.Where("name = ?", name).First(&priority).Error
+	return &priority, err
+}
+
+// GetPriorities retrieves every priority.
+func (as *LookupDBModel) GetPriorities() (*[]Priority, error) {
+	var priorities []Priority
+	err := as.DB.Order("priority_id").Find(&priorities).Error
+	return &priorities, err
+}
+
+// RenamePriority renames a priority on the tickets that have it.
+func (as *LookupDBModel) RenamePriority(id int, to string) error {
+	return as.DB.Table("tickets").Where("priority_id = ?", id).Update("name", to).Error
+}
+
+// CountPriorityUses counts the SLAs and cells of the priority matrix that
+// refer to a priority.
+func (as *LookupDBModel) CountPriorityUses(id int) (int64, error) {
+	var slas, cells int64
+	if err := as.DB.Model(&Sla{}).Where("priority_id = ?", id).Count(&slas).Error; err != nil {
+		return 0, err
+	}
+	if err := as.DB.Model(&PriorityMatrixCell{}).Where("priority_id = ?", id).Count(&cells).Error; err != nil {
+		return 0, err
+	}
+	return slas + cells, nil
+}
+
+// CreateStatus creates a status.
+func (as *LookupDBModel) CreateStatus(status *Status) error {
+	id, err := as.nextID("status", "status_id")
+	if err != nil {
+		return err
+	}
+	status.StatusID = id
+	return as.DB.Create(status).Error
+}
+
+// UpdateStatus updates a status.
+func (as *LookupDBModel) UpdateStatus(status *Status) error {
+	return as.DB.Save(status).Error
+}
+
+// DeleteStatus deletes a status.
+func (as *LookupDBModel) DeleteStatus(id int) error {
+	return as.DB.Where("status_id = ?", id).Delete(&Status{}).Error
+}
+
+// GetStatusByID retrieves a status by its ID.
+func (as *LookupDBModel) GetStatusByID(id int) (*Status, error) {
+	var status Status
+	err := as.DB.Where("status_id = ?", id).First(&status).Error
+	return &status, err
+}
+
+// GetStatusByName retrieves a status by its name.
+func (as *LookupDBModel) GetStatusByName(name string) (*Status, error) {
+	var status Status
+	err := as.DB.Where("status_name = ?", name).First(&status).Error
+	return &status, err
+}
+
+// GetStatuses retrieves every status.
+func (as *LookupDBModel) GetStatuses() (*[]Status, error) {
+	var statuses []Status
+	err := as.DB.Order("status_id").Find(&statuses).Error
+	return &statuses, err
+}
+
+// RenameStatus renames a status on the tickets that have it.
+func (as *LookupDBModel) RenameStatus(from, to string) error {
+	return as.DB.Table("tickets").Where("status_name = ?", from).Update("status_name", to).Error
+}
+
+// CreateSla creates an SLA.
+func (as *LookupDBModel) CreateSla(sla *Sla) error {
+	id, err := as.nextID("sla", "sla_id")
+	if err != nil {
+		return err
+	}
+	sla.SlaID = id
+	return as.DB.Create(sla).Error
+}
+
+// UpdateSla updates an SLA.
+func (as *LookupDBModel) UpdateSla(sla *Sla) error {
+	return as.DB.Save(sla).Error
+}
+
+// DeleteSla deletes an SLA.
+func (as *LookupDBModel) DeleteSla(id int) error {
+	return as.DB.Where("sla_id = ?", id).Delete(&Sla{}).Error
+}
+
+// GetSlaByID retrieves an SLA by its ID.
+func (as *LookupDBModel) GetSlaByID(id int) (*Sla, error) {
+	var sla Sla
+	err := as.DB.Where("sla_id = ?", id).First(&sla).Error
+	return &sla, err
+}
+
+// GetSlaByPriorityID retrieves the SLA of a priority.
+func (as *LookupDBModel) GetSlaByPriorityID(priorityID int) (*Sla, error) {
+	var sla Sla
+	err := as.DB.Where("priority_id = ?", priorityID).First(&sla).Error
+	return &sla, err
+}
+
+// GetAllSla retrieves every SLA.
+func (as *LookupDBModel) GetAllSla() (*[]Sla, error) {
+	var slas []Sla
+	err := as.DB.Order("sla_id").Find(&slas).Error
+	return &slas, err
+}
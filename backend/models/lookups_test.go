@@ -0,0 +1,28 @@
+package models
+
+import (
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+)
+
+func TestRetiredColumnsOfEmbeddedLookups(t *testing.T) {
+	cache := &sync.Map{}
+	columns := map[string]bool{}
+	for _, model := range []interface{}{&Category{}, &SubCategory{}} {
+		s, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		field := s.LookUpField("Retired")
+		if field == nil || field.DBName == "" {
+			t.Fatalf("%s has no retired column", s.Name)
+		}
+		// Both are embedded in Ticket without a prefix.
+		if columns[field.DBName] {
+			t.Fatalf("%s.Retired shares the %s column", s.Name, field.DBName)
+		}
+		columns[field.DBName] = true
+	}
+}
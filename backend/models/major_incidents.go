@@ -0,0 +1,206 @@
+// backend/models/major_incidents.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// MajorIncident is an outage declared on one ticket, its master ticket,
+// that many users report at once. While it is active, new tickets that
+// match its category, site and keywords are linked to it, and its status
+// updates are broadcast to the requesters of every linked ticket.
+type MajorIncident struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"major_incident_id"`
+	TicketID    uint   `json:"ticket_id" gorm:"index"`
+	Title       string `json:"title"`
+	Summary     string `json:"summary"`
+	Status      string `json:"status" gorm:"index"`
+	Site        string `json:"site" gorm:"index"`
+	Category    string `json:"category,omitempty"`
+	SubCategory string `json:"sub_category,omitempty"`
+	// Keywords match tickets mentioning any of them in their subject or
+	// description. Without a category, site or keyword nothing is linked
+	// automatically.
+	Keywords   []string              `json:"keywords" gorm:"serializer:json"`
+	DeclaredBy uint                  `json:"declared_by"`
+	ResolvedAt *time.Time            `json:"resolved_at,omitempty"`
+	Tickets    []MajorIncidentTicket `json:"tickets" gorm:"foreignKey:MajorIncidentID"`
+	Updates    []MajorIncidentUpdate `json:"updates" gorm:"foreignKey:MajorIncidentID"`
+	CreatedAt  time.Time             `json:"created_at"`
+	UpdatedAt  time.Time             `json:"updated_at"`
+}
+
+// TableName sets the table name for the MajorIncident model.
+func (MajorIncident) TableName() string {
+	return "major_incidents"
+}
+
+// MajorIncidentTicket links a ticket with a major incident. UserID is the
+// requester of the ticket, who receives the status updates.
+type MajorIncidentTicket struct {
+	gorm.Model
+	MajorIncidentID uint      `json:"major_incident_id" gorm:"index"`
+	TicketID        uint      `json:"ticket_id" gorm:"index"`
+	UserID          uint      `json:"user_id"`
+	LinkedBy        uint      `json:"linked_by,omitempty"`
+	Automatic       bool      `json:"automatic"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the MajorIncidentTicket model.
+func (MajorIncidentTicket) TableName() string {
+	return "major_incident_tickets"
+}
+
+// MajorIncidentUpdate is a status update broadcast about a major incident.
+type MajorIncidentUpdate struct {
+	gorm.Model
+	ID              uint      `gorm:"primaryKey" json:"update_id"`
+	MajorIncidentID uint      `json:"major_incident_id" gorm:"index"`
+	Status          string    `json:"status"`
+	Message         string    `json:"message"`
+	PostedBy        uint      `json:"posted_by"`
+	Recipients      int       `json:"recipients"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the MajorIncidentUpdate model.
+func (MajorIncidentUpdate) TableName() string {
+	return "major_incident_updates"
+}
+
+// Statuses of a MajorIncident. Every status but resolved is active.
+const (
+	MajorIncidentInvestigating = "investigating"
+	MajorIncidentIdentified    = "identified"
+	MajorIncidentMonitoring    = "monitoring"
+	MajorIncidentResolved      = "resolved"
+)
+
+// MajorIncidentStatuses lists every major incident status, in the order
+// above.
+var MajorIncidentStatuses = []string{MajorIncidentInvestigating, MajorIncidentIdentified, MajorIncidentMonitoring, MajorIncidentResolved}
+
+// EventMajorIncidentBroadcast is the notification event of the emails
+// broadcasting a status update. It is not published on the event bus.
+const EventMajorIncidentBroadcast = "major_incident.broadcast"
+
+type MajorIncidentStorage interface {
+	CreateIncident(*MajorIncident) error
+	UpdateIncident(*MajorIncident) error
+	GetIncidentByID(uint) (*MajorIncident, error)
+	GetIncidents(bool) (*[]MajorIncident, error)
+	GetActiveIncidentsBySite(string) (*[]MajorIncident, error)
+	GetTicketIncident(uint) (*MajorIncident, error)
+	LinkTicket(*MajorIncidentTicket) (bool, error)
+	UnlinkTicket(uint, uint) error
+	CreateUpdate(*MajorIncidentUpdate) error
+}
+
+// MajorIncidentDBModel handles database operations for MajorIncident,
+// MajorIncidentTicket and MajorIncidentUpdate
+type MajorIncidentDBModel struct {
+	DB *gorm.DB
+}
+
+// NewMajorIncidentDBModel creates a new instance of MajorIncidentDBModel
+func NewMajorIncidentDBModel(db *gorm.DB) *MajorIncidentDBModel {
+	return &MajorIncidentDBModel{
+		DB: db,
+	}
+}
+
+func orderLinkedTickets(db *gorm.DB) *gorm.DB {
+	return db.Order("ticket_id")
+}
+
+func orderUpdates(db *gorm.DB) *gorm.DB {
+	return db.Order("id DESC")
+}
+
+// CreateIncident creates a major incident.
+func (as *MajorIncidentDBModel) CreateIncident(incident *MajorIncident) error {
+	return as.DB.Omit("Tickets", "Updates").Create(incident).Error
+}
+
+// UpdateIncident updates a major incident, not its tickets and updates.
+func (as *MajorIncidentDBModel) UpdateIncident(incident *MajorIncident) error {
+	return as.DB.Omit("Tickets", "Updates").Save(incident).Error
+}
+
+// GetIncidentByID retrieves a major incident with its tickets and its
+// updates, the latest first.
+func (as *MajorIncidentDBModel) GetIncidentByID(id uint) (*MajorIncident, error) {
+	var incident MajorIncident
+	err := as.DB.Preload("Tickets", orderLinkedTickets).Preload("Updates", orderUpdates).Where("id = ?", id).First(&incident).Error
+	return &incident, err
+}
+
+// GetIncidents retrieves the major incidents, the latest first, optionally
+// only the active ones. Their tickets are not loaded.
+func (as *MajorIncidentDBModel) GetIncidents(activeOnly bool) (*[]MajorIncident, error) {
+	var incidents []MajorIncident
+	query := as.DB.Preload("Updates", orderUpdates).Order("id DESC")
+	if activeOnly {
+		query = query.Where("status <> ?", MajorIncidentResolved)
+	}
+	err := query.Find(&incidents).Error
+	return &incidents, err
+}
+
+// GetActiveIncidentsBySite retrieves the active major incidents with their
+// updates by site, optionally only those of one site.
+func (as *MajorIncidentDBModel) GetActiveIncidentsBySite(site string) (*[]MajorIncident, error) {
+	var incidents []MajorIncident
+	query := as.DB.Preload("Updates", orderUpdates).Where("status <> ?", MajorIncidentResolved).Order("LOWER(site), id")
+	if site != "" {
+		query = query.Where("LOWER(site) = LOWER(?)", site)
+	}
+	err := query.Find(&incidents).Error
+	return &incidents, err
+}
+
+// GetTicketIncident retrieves the latest major incident a ticket is the
+// master ticket of or is linked with.
+func (as *MajorIncidentDBModel) GetTicketIncident(ticketID uint) (*MajorIncident, error) {
+	var incident MajorIncident
+	err := as.DB.Preload("Updates", orderUpdates).
+		Where("ticket_id = ? OR id IN (?)", ticketID, as.DB.Model(&MajorIncidentTicket{}).Select("major_incident_id").Where("ticket_id = ?", ticketID)).
+		Order("id DESC").First(&incident).Error
+	return &incident, err
+}
+
+// LinkTicket links a ticket with a major incident unless they are already
+// linked, and reports whether it did.
+func (as *MajorIncidentDBModel) LinkTicket(link *MajorIncidentTicket) (bool, error) {
+	var count int64
+	if err := as.DB.Model(&MajorIncidentTicket{}).Where("major_incident_id = ? AND ticket_id = ?", link.MajorIncidentID, link.TicketID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	err := as.DB.Create(link).Error
+	return err == nil, err
+}
+
+// UnlinkTicket removes the link between a ticket and a major incident.
+func (as *MajorIncidentDBModel) UnlinkTicket(incidentID, ticketID uint) error {
+	result := as.DB.Where("major_incident_id = ? AND ticket_id = ?", incidentID, ticketID).Delete(&MajorIncidentTicket{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// CreateUpdate creates a status update of a major incident.
+func (as *MajorIncidentDBModel) CreateUpdate(update *MajorIncidentUpdate) error {
+	return as.DB.Create(update).Error
+}
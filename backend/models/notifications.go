@@ -0,0 +1,236 @@
+// backend/models/notifications.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Notification is an outgoing message kept in the outbox until it has been
+// delivered, so nothing is lost when a mail server is briefly unavailable.
+type Notification struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey" json:"notification_id"`
+	Event         string     `json:"event" gorm:"index"`
+	Channel       string     `json:"channel"`
+	RecipientType string     `json:"recipient_type"`
+	RecipientID   uint       `json:"recipient_id"`
+	Recipient     string     `json:"recipient"`
+	Cc            string     `json:"cc,omitempty"`
+	TicketID      uint       `json:"ticket_id" gorm:"index"`
+	Subject       string     `json:"subject"`
+	TextBody      string     `json:"-"`
+	HTMLBody      string     `json:"-"`
+	ReplyTo       string     `json:"reply_to"`
+	Headers       string     `json:"-"`
+	Status        string     `json:"status" gorm:"index"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error,omitempty"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+	// ProviderMessageID is the ID given by an SMS or WhatsApp gateway, which
+	// its delivery receipts refer to.
+	ProviderMessageID string     `json:"provider_message_id,omitempty" gorm:"index"`
+	DeliveryStatus    string     `json:"delivery_status,omitempty"`
+	DeliveredAt       *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the Notification model.
+func (Notification) TableName() string {
+	return "notification_outbox"
+}
+
+// Delivery states of a Notification.
+const (
+	NotificationStatusPending = "pending"
+	NotificationStatusSending = "sending"
+	NotificationStatusSent    = "sent"
+	NotificationStatusFailed  = "failed"
+)
+
+// Notification channels.
+const (
+	ChannelEmail    = "email"
+	ChannelSMS      = "sms"
+	ChannelWhatsApp = "whatsapp"
+)
+
+// NotificationReceipt is a delivery report sent by a messaging gateway
+// about a notification.
+type NotificationReceipt struct {
+	gorm.Model
+	ID             uint      `gorm:"primaryKey" json:"receipt_id"`
+	NotificationID uint      `json:"notification_id" gorm:"index"`
+	Status         string    `json:"status"`
+	Detail         string    `json:"detail,omitempty"`
+	ReceivedAt     time.Time `json:"received_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the NotificationReceipt model.
+func (NotificationReceipt) TableName() string {
+	return "notification_receipts"
+}
+
+// Delivery states reported by messaging gateways.
+const (
+	DeliveryStatusDelivered   = "delivered"
+	DeliveryStatusRead        = "read"
+	DeliveryStatusUndelivered = "undelivered"
+	DeliveryStatusFailed      = "failed"
+)
+
+// Recipient types of notifications and preferences.
+const (
+	RecipientUser  = "user"
+	RecipientAgent = "agent"
+)
+
+// NotificationPreference records that a user or agent does or does not want
+// to be told about an event on a channel. Without a record, notifications
+// are sent.
+type NotificationPreference struct {
+	gorm.Model
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	RecipientType string    `json:"-" gorm:"uniqueIndex:idx_notification_preference"`
+	RecipientID   uint      `json:"-" gorm:"uniqueIndex:idx_notification_preference"`
+	Event         string    `json:"event" binding:"required" gorm:"uniqueIndex:idx_notification_preference"`
+	Channel       string    `json:"channel" binding:"required" gorm:"uniqueIndex:idx_notification_preference"`
+	Enabled       bool      `json:"enabled"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the NotificationPreference model.
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
+
+type NotificationStorage interface {
+	CreateNotification(*Notification) error
+	UpdateNotification(*Notification) error
+	GetNotificationByID(uint) (*Notification, error)
+	GetDueNotifications(time.Time, time.Time, int) (*[]Notification, error)
+	ClaimNotification(uint, time.Time) (bool, error)
+	GetNotificationsByTicketID(uint) (*[]Notification, error)
+	GetNotificationByProviderMessageID(string, string) (*Notification, error)
+	CreateReceipt(*NotificationReceipt) error
+	GetReceiptsByNotificationID(uint) (*[]NotificationReceipt, error)
+	GetPreferences(string, uint) (*[]NotificationPreference, error)
+	SavePreference(*NotificationPreference) error
+	IsOptedOut(string, uint, string, string) (bool, error)
+}
+
+// NotificationDBModel handles database operations for Notification
+type NotificationDBModel struct {
+	DB *gorm.DB
+}
+
+// NewNotificationDBModel creates a new instance of NotificationDBModel
+func NewNotificationDBModel(db *gorm.DB) *NotificationDBModel {
+	return &NotificationDBModel{
+		DB: db,
+	}
+}
+
+// CreateNotification adds a notification to the outbox.
+func (as *NotificationDBModel) CreateNotification(notification *Notification) error {
+	return as.DB.Create(notification).Error
+}
+
+// UpdateNotification updates the details of an existing notification.
+func (as *NotificationDBModel) UpdateNotification(notification *Notification) error {
+	return as.DB.Save(notification).Error
+}
+
+// GetNotificationByID retrieves a notification by its ID.
+func (as *NotificationDBModel) GetNotificationByID(id uint) (*Notification, error) {
+	var notification Notification
+	err := as.DB.Where("id = ?", id).First(&notification).Error
+	return &notification, err
+}
+
+// GetDueNotifications retrieves pending notifications whose next attempt is
+// due, and notifications stuck in sending since before staleBefore.
+func (as *NotificationDBModel) GetDueNotifications(now, staleBefore time.Time, limit int) (*[]Notification, error) {
+	var notifications []Notification
+	err := as.DB.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+		NotificationStatusPending, now, NotificationStatusSending, staleBefore).
+		Order("next_attempt_at, id").Limit(limit).Find(&notifications).Error
+	return &notifications, err
+}
+
+// ClaimNotification marks a pending notification as being sent. Only one
+// caller can claim a notification, so several workers may share the outbox.
+// Notifications stuck in sending since before staleBefore, for example
+// after a crash, can be claimed again.
+func (as *NotificationDBModel) ClaimNotification(id uint, staleBefore time.Time) (bool, error) {
+	res := as.DB.Model(&Notification{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, NotificationStatusPending, NotificationStatusSending, staleBefore).
+		Updates(map[string]interface{}{"status": NotificationStatusSending, "updated_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+// GetNotificationsByTicketID retrieves the notifications sent about a ticket.
+func (as *NotificationDBModel) GetNotificationsByTicketID(ticketID uint) (*[]Notification, error) {
+	var notifications []Notification
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("id").Find(&notifications).Error
+	return &notifications, err
+}
+
+// GetNotificationByProviderMessageID retrieves a notification by the ID
+// its gateway gave it.
+func (as *NotificationDBModel) GetNotificationByProviderMessageID(channel, providerMessageID string) (*Notification, error) {
+	var notification Notification
+	err := as.DB.Where("channel = ? AND provider_message_id = ?", channel, providerMessageID).First(&notification).Error
+	return &notification, err
+}
+
+// CreateReceipt stores a delivery receipt.
+func (as *NotificationDBModel) CreateReceipt(receipt *NotificationReceipt) error {
+	return as.DB.Create(receipt).Error
+}
+
+// GetReceiptsByNotificationID retrieves the delivery receipts of a notification, oldest first.
+func (as *NotificationDBModel) GetReceiptsByNotificationID(notificationID uint) (*[]NotificationReceipt, error) {
+	var receipts []NotificationReceipt
+	err := as.DB.Where("notification_id = ?", notificationID).Order("received_at, id").Find(&receipts).Error
+	return &receipts, err
+}
+
+// GetPreferences retrieves the notification preferences of a user or agent.
+func (as *NotificationDBModel) GetPreferences(recipientType string, recipientID uint) (*[]NotificationPreference, error) {
+	var preferences []NotificationPreference
+	err := as.DB.Where("recipient_type = ? AND recipient_id = ?", recipientType, recipientID).
+		Order("event, channel").Find(&preferences).Error
+	return &preferences, err
+}
+
+// SavePreference creates or updates a preference for its recipient, event and channel.
+func (as *NotificationDBModel) SavePreference(preference *NotificationPreference) error {
+	var existing NotificationPreference
+	err := as.DB.Where("recipient_type = ? AND recipient_id = ? AND event = ? AND channel = ?",
+		preference.RecipientType, preference.RecipientID, preference.Event, preference.Channel).First(&existing).Error
+	if err == nil {
+		preference.ID = existing.ID
+		preference.Model = existing.Model
+		preference.CreatedAt = existing.CreatedAt
+	} else if err != gorm.ErrRecordNotFound {
+		return err
+	}
+	return as.DB.Save(preference).Error
+}
+
+// IsOptedOut reports whether a recipient disabled an event on a channel.
+func (as *NotificationDBModel) IsOptedOut(recipientType string, recipientID uint, event, channel string) (bool, error) {
+	var count int64
+	err := as.DB.Model(&NotificationPreference{}).
+		Where("recipient_type = ? AND recipient_id = ? AND event = ? AND channel = ? AND enabled = ?",
+			recipientType, recipientID, event, channel, false).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,143 @@
+// backend/models/outbox.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OutboxEvent is a domain event written in the same transaction as the
+// change it describes, and dispatched to subscribers afterwards.
+type OutboxEvent struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey" json:"outbox_id"`
+	EventID       string     `json:"event_id" gorm:"uniqueIndex"`
+	Name          string     `json:"event" gorm:"index"`
+	Payload       string     `json:"payload"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+	Status        string     `json:"status" gorm:"index"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at" gorm:"index"`
+	LastError     string     `json:"last_error,omitempty"`
+	ProcessedAt   *time.Time `json:"processed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the OutboxEvent model.
+func (OutboxEvent) TableName() string {
+	return "event_outbox"
+}
+
+// Dispatch states of an OutboxEvent.
+const (
+	OutboxStatusPending    = "pending"
+	OutboxStatusProcessing = "processing"
+	OutboxStatusDone       = "done"
+	OutboxStatusFailed     = "failed"
+)
+
+// OutboxDelivery records that a subscriber handled an event, so that a
+// retry only goes to the subscribers that failed.
+type OutboxDelivery struct {
+	gorm.Model
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	OutboxEventID uint      `json:"outbox_id" gorm:"uniqueIndex:idx_outbox_delivery"`
+	Subscriber    string    `json:"subscriber" gorm:"uniqueIndex:idx_outbox_delivery"`
+	DeliveredAt   time.Time `json:"delivered_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the OutboxDelivery model.
+func (OutboxDelivery) TableName() string {
+	return "event_outbox_deliveries"
+}
+
+type OutboxStorage interface {
+	CreateEvent(*OutboxEvent) error
+	UpdateEvent(*OutboxEvent) error
+	GetEventByID(uint) (*OutboxEvent, error)
+	GetEvents(string, int) (*[]OutboxEvent, error)
+	GetDueEvents(time.Time, time.Time, int) (*[]OutboxEvent, error)
+	ClaimEvent(uint, time.Time) (bool, error)
+	GetDeliveredSubscribers(uint) ([]string, error)
+	MarkDelivered(uint, string) error
+}
+
+// OutboxDBModel handles database operations for the event outbox
+type OutboxDBModel struct {
+	DB *gorm.DB
+}
+
+// NewOutboxDBModel creates a new instance of OutboxDBModel
+func NewOutboxDBModel(db *gorm.DB) *OutboxDBModel {
+	return &OutboxDBModel{
+		DB: db,
+	}
+}
+
+// CreateEvent adds an event to the outbox.
+func (as *OutboxDBModel) CreateEvent(event *OutboxEvent) error {
+	return as.DB.Create(event).Error
+}
+
+// UpdateEvent updates the details of an existing event.
+func (as *OutboxDBModel) UpdateEvent(event *OutboxEvent) error {
+	return as.DB.Save(event).Error
+}
+
+// GetEventByID retrieves an event by its ID.
+func (as *OutboxDBModel) GetEventByID(id uint) (*OutboxEvent, error) {
+	var event OutboxEvent
+	err := as.DB.Where("id = ?", id).First(&event).Error
+	return &event, err
+}
+
+// GetEvents retrieves the most recent events, optionally only those in a status.
+func (as *OutboxDBModel) GetEvents(status string, limit int) (*[]OutboxEvent, error) {
+	var events []OutboxEvent
+	query := as.DB.Order("id desc").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&events).Error
+	return &events, err
+}
+
+// GetDueEvents retrieves pending events whose next attempt is due, and
+// events stuck in processing since before staleBefore, oldest first.
+func (as *OutboxDBModel) GetDueEvents(now, staleBefore time.Time, limit int) (*[]OutboxEvent, error) {
+	var events []OutboxEvent
+	err := as.DB.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+		OutboxStatusPending, now, OutboxStatusProcessing, staleBefore).
+		Order("id").Limit(limit).Find(&events).Error
+	return &events, err
+}
+
+// ClaimEvent marks a due event as being processed, so that only one
+// dispatcher handles it.
+func (as *OutboxDBModel) ClaimEvent(id uint, staleBefore time.Time) (bool, error) {
+	res := as.DB.Model(&OutboxEvent{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, OutboxStatusPending, OutboxStatusProcessing, staleBefore).
+		Updates(map[string]interface{}{"status": OutboxStatusProcessing, "updated_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+// GetDeliveredSubscribers retrieves the subscribers that handled an event.
+func (as *OutboxDBModel) GetDeliveredSubscribers(eventID uint) ([]string, error) {
+	var subscribers []string
+	err := as.DB.Model(&OutboxDelivery{}).Where("outbox_event_id = ?", eventID).Pluck("subscriber", &subscribers).Error
+	return subscribers, err
+}
+
+// MarkDelivered records that a subscriber handled an event.
+func (as *OutboxDBModel) MarkDelivered(eventID uint, subscriber string) error {
+	return as.DB.Create(&OutboxDelivery{
+		OutboxEventID: eventID,
+		Subscriber:    subscriber,
+		DeliveredAt:   time.Now(),
+	}).Error
+}
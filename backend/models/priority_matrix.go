@@ -0,0 +1,235 @@
+// backend/models/priority_matrix.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Impact is how much of the business a ticket affects. Rank orders the
+// impacts, 1 being the widest. The default impact is used for tickets
+// created without one.
+type Impact struct {
+	gorm.Model
+	ID          uint      `gorm:"primaryKey" json:"impact_id"`
+	Name        string    `json:"impact_name" gorm:"uniqueIndex;size:100"`
+	Description string    `json:"description"`
+	Rank        int       `json:"rank" gorm:"column:impact_rank"`
+	IsDefault   bool      `json:"default"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the Impact model.
+func (Impact) TableName() string {
+	return "impact"
+}
+
+// Urgency is how quickly a ticket needs to be resolved. Rank orders the
+// urgencies, 1 being the most urgent. The default urgency is used for
+// tickets created without one.
+type Urgency struct {
+	gorm.Model
+	ID          uint      `gorm:"primaryKey" json:"urgency_id"`
+	Name        string    `json:"urgency_name" gorm:"uniqueIndex;size:100"`
+	Description string    `json:"description"`
+	Rank        int       `json:"rank" gorm:"column:urgency_rank"`
+	IsDefault   bool      `json:"default"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the Urgency model.
+func (Urgency) TableName() string {
+	return "urgency"
+}
+
+// PriorityMatrixCell is the priority of the tickets with an impact and an
+// urgency.
+type PriorityMatrixCell struct {
+	gorm.Model
+	ID         uint      `gorm:"primaryKey" json:"cell_id"`
+	ImpactID   uint      `json:"impact_id" gorm:"uniqueIndex:idx_priority_matrix_cell"`
+	UrgencyID  uint      `json:"urgency_id" gorm:"uniqueIndex:idx_priority_matrix_cell"`
+	PriorityID int       `json:"priority_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the PriorityMatrixCell model.
+func (PriorityMatrixCell) TableName() string {
+	return "priority_matrix"
+}
+
+type PriorityMatrixStorage interface {
+	CreateImpact(*Impact) error
+	UpdateImpact(*Impact) error
+	DeleteImpact(uint) error
+	GetImpactByID(uint) (*Impact, error)
+	GetImpacts() (*[]Impact, error)
+	GetDefaultImpact() (*Impact, error)
+	ClearDefaultImpact(uint) error
+	CreateUrgency(*Urgency) error
+	UpdateUrgency(*Urgency) error
+	DeleteUrgency(uint) error
+	GetUrgencyByID(uint) (*Urgency, error)
+	GetUrgencies() (*[]Urgency, error)
+	GetDefaultUrgency() (*Urgency, error)
+	ClearDefaultUrgency(uint) error
+	SetCell(*PriorityMatrixCell) error
+	GetCell(uint, uint) (*PriorityMatrixCell, error)
+	GetCells() (*[]PriorityMatrixCell, error)
+	GetPriorityByID(int) (*Priority, error)
+	GetSlaByPriorityID(int) (*Sla, error)
+}
+
+// PriorityMatrixDBModel handles database operations for the impacts,
+// urgencies and the priority matrix
+type PriorityMatrixDBModel struct {
+	DB *gorm.DB
+}
+
+// NewPriorityMatrixDBModel creates a new instance of PriorityMatrixDBModel
+func NewPriorityMatrixDBModel(db *gorm.DB) *PriorityMatrixDBModel {
+	return &PriorityMatrixDBModel{
+		DB: db,
+	}
+}
+
+// CreateImpact creates an impact.
+func (as *PriorityMatrixDBModel) CreateImpact(impact *Impact) error {
+	return as.DB.Create(impact).Error
+}
+
+// UpdateImpact updates an impact.
+func (as *PriorityMatrixDBModel) UpdateImpact(impact *Impact) error {
+	return as.DB.Save(impact).Error
+}
+
+// DeleteImpact deletes an impact together with its cells of the matrix.
+func (as *PriorityMatrixDBModel) DeleteImpact(id uint) error {
+	if err := as.DB.Where("impact_id = ?", id).Delete(&PriorityMatrixCell{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&Impact{}, id).Error
+}
+
+// GetImpactByID retrieves an impact by its ID.
+func (as *PriorityMatrixDBModel) GetImpactByID(id uint) (*Impact, error) {
+	var impact Impact
+	err := as.DB.Where("id = ?", id).First(&impact).Error
+	return &impact, err
+}
+
+// GetImpacts retrieves the impacts, widest first.
+func (as *PriorityMatrixDBModel) GetImpacts() (*[]Impact, error) {
+	var impacts []Impact
+	err := as.DB.Order("impact_rank, id").Find(&impacts).Error
+	return &impacts, err
+}
+
+// GetDefaultImpact retrieves the default impact.
+func (as *PriorityMatrixDBModel) GetDefaultImpact() (*Impact, error) {
+	var impact Impact
+	err := as.DB.Where("is_default = ?", true).First(&impact).Error
+	return &impact, err
+}
+
+// ClearDefaultImpact makes no impact the default, but the one with ID keep.
+func (as *PriorityMatrixDBModel) ClearDefaultImpact(keep uint) error {
+	return as.DB.Model(&Impact{}).Where("is_default = ? AND id <> ?", true, keep).Update("is_default", false).Error
+}
+
+// CreateUrgency creates an urgency.
+func (as *PriorityMatrixDBModel) CreateUrgency(urgency *Urgency) error {
+	return as.DB.Create(urgency).Error
+}
+
+// UpdateUrgency updates an urgency.
+func (as *PriorityMatrixDBModel) UpdateUrgency(urgency *Urgency) error {
+	return as.DB.Save(urgency).Error
+}
+
+// DeleteUrgency deletes an urgency together with its cells of the matrix.
+func (as *PriorityMatrixDBModel) DeleteUrgency(id uint) error {
+	if err := as.DB.Where("urgency_id = ?", id).Delete(&PriorityMatrixCell{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&Urgency{}, id).Error
+}
+
+// GetUrgencyByID retrieves an urgency by its ID.
+func (as *PriorityMatrixDBModel) GetUrgencyByID(id uint) (*Urgency, error) {
+	var urgency Urgency
+	err := as.DB.Where("id = ?", id).First(&urgency).Error
+	return &urgency, err
+}
+
+// GetUrgencies retrieves the urgencies, most urgent first.
+func (as *PriorityMatrixDBModel) GetUrgencies() (*[]Urgency, error) {
+	var urgencies []Urgency
+	err := as.DB.Order("urgency_rank, id").Find(&urgencies).Error
+	return &urgencies, err
+}
+
+// GetDefaultUrgency retrieves the default urgency.
+func (as *PriorityMatrixDBModel) GetDefaultUrgency() (*Urgency, error) {
+	var urgency Urgency
+	err := as.DB.Where("is_default = ?", true).First(&urgency).Error
+	return &urgency, err
+}
+
+// ClearDefaultUrgency makes no urgency the default, but the one with ID keep.
+func (as *PriorityMatrixDBModel) ClearDefaultUrgency(keep uint) error {
+	return as.DB.Model(&Urgency{}).Where("is_default = ? AND id <> ?", true, keep).Update("is_default", false).Error
+}
+
+// SetCell sets the priority of an impact and urgency, replacing the one
+// they had.
+func (as *PriorityMatrixDBModel) SetCell(cell *PriorityMatrixCell) error {
+	var existing PriorityMatrixCell
+	err := as.DB.Where("impact_id = ? AND urgency_id = ?", cell.ImpactID, cell.UrgencyID).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		cell.ID = 0
+		return as.DB.Create(cell).Error
+	}
+	if err != nil {
+		return err
+	}
+	existing.PriorityID = cell.PriorityID
+	if err := as.DB.Save(&existing).Error; err != nil {
+		return err
+	}
+	*cell = existing
+	return nil
+}
+
+// GetCell retrieves the cell of the matrix for an impact and urgency.
+func (as *PriorityMatrixDBModel) GetCell(impactID, urgencyID uint) (*PriorityMatrixCell, error) {
+	var cell PriorityMatrixCell
+	err := as.DB.Where("impact_id = ? AND urgency_id = ?", impactID, urgencyID).First(&cell).Error
+	return &cell, err
+}
+
+// GetCells retrieves every cell of the matrix.
+func (as *PriorityMatrixDBModel) GetCells() (*[]PriorityMatrixCell, error) {
+	var cells []PriorityMatrixCell
+	err := as.DB.Order("impact_id, urgency_id").Find(&cells).Error
+	return &cells, err
+}
+
+// GetPriorityByID retrieves a priority by its priority ID.
+func (as *PriorityMatrixDBModel) GetPriorityByID(id int) (*Priority, error) {
+	var priority Priority
+	err := as.DB.Where("priority_id = ?", id).First(&priority).Error
+	return &priority, err
+}
+
+// GetSlaByPriorityID retrieves the SLA of a priority.
+func (as *PriorityMatrixDBModel) GetSlaByPriorityID(priorityID int) (*Sla, error) {
+	var sla Sla
+	err := as.DB.Where("priority_id = ?", priorityID).First(&sla).Error
+	return &sla, err
+}
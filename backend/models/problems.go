@@ -0,0 +1,184 @@
+// backend/models/problems.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Problem is the underlying cause of one or more incidents. Its root-cause
+// analysis is recorded as it is investigated; once the cause is understood
+// but not yet fixed it becomes a known error with a workaround, and fixing
+// it can resolve its incidents with it.
+type Problem struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"problem_id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Status      string `json:"status" gorm:"index"`
+	OwnerID     uint   `json:"owner_id,omitempty"`
+	// Root-cause analysis.
+	Symptoms            string `json:"symptoms"`
+	RootCause           string `json:"root_cause"`
+	ContributingFactors string `json:"contributing_factors"`
+	AnalysisMethod      string `json:"analysis_method"`
+	// Workaround lets affected users carry on until the problem is fixed;
+	// a known error has one.
+	Workaround   string     `json:"workaround"`
+	KnownErrorAt *time.Time `json:"known_error_at,omitempty"`
+	Resolution   string     `json:"resolution"`
+	ResolvedAt   *time.Time `json:"resolved_at,omitempty"`
+	ResolvedBy   uint       `json:"resolved_by,omitempty"`
+	CreatedBy    uint       `json:"created_by"`
+	// Incidents are the links to the incident tickets of the problem.
+	Incidents []ProblemIncident `json:"incidents" gorm:"foreignKey:ProblemID"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// TableName sets the table name for the Problem model.
+func (Problem) TableName() string {
+	return "problems"
+}
+
+// ProblemIncident links an incident ticket with a problem. Like a
+// RelatedTicket it is only a link: a ticket can be an incident of several
+// problems and a problem has many incidents.
+type ProblemIncident struct {
+	gorm.Model
+	ProblemID uint      `json:"problem_id" gorm:"index"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	LinkedBy  uint      `json:"linked_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName sets the table name for the ProblemIncident model.
+func (ProblemIncident) TableName() string {
+	return "problem_incidents"
+}
+
+// Statuses of a Problem.
+const (
+	ProblemStatusOpen          = "open"
+	ProblemStatusInvestigating = "investigating"
+	ProblemStatusKnownError    = "known_error"
+	ProblemStatusResolved      = "resolved"
+	ProblemStatusClosed        = "closed"
+)
+
+// ProblemStatuses lists every problem status, in the order above.
+var ProblemStatuses = []string{ProblemStatusOpen, ProblemStatusInvestigating, ProblemStatusKnownError, ProblemStatusResolved, ProblemStatusClosed}
+
+type ProblemStorage interface {
+	CreateProblem(*Problem) error
+	UpdateProblem(*Problem) error
+	DeleteProblem(uint) error
+	GetProblemByID(uint) (*Problem, error)
+	GetProblems(string) (*[]Problem, error)
+	GetTicketProblems(uint) (*[]Problem, error)
+	LinkIncident(uint, uint, uint) (bool, error)
+	UnlinkIncident(uint, uint) error
+	GetExistingTicketIDs([]uint) ([]uint, error)
+}
+
+// ProblemDBModel handles database operations for Problem and
+// ProblemIncident
+type ProblemDBModel struct {
+	DB *gorm.DB
+}
+
+// NewProblemDBModel creates a new instance of ProblemDBModel
+func NewProblemDBModel(db *gorm.DB) *ProblemDBModel {
+	return &ProblemDBModel{
+		DB: db,
+	}
+}
+
+func orderIncidents(db *gorm.DB) *gorm.DB {
+	return db.Order("ticket_id")
+}
+
+// CreateProblem creates a problem.
+func (as *ProblemDBModel) CreateProblem(problem *Problem) error {
+	return as.DB.Omit("Incidents").Create(problem).Error
+}
+
+// UpdateProblem updates a problem, not its incidents.
+func (as *ProblemDBModel) UpdateProblem(problem *Problem) error {
+	return as.DB.Omit("Incidents").Save(problem).Error
+}
+
+// DeleteProblem deletes a problem and its links to incidents.
+func (as *ProblemDBModel) DeleteProblem(id uint) error {
+	if err := as.DB.Where("problem_id = ?", id).Delete(&ProblemIncident{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&Problem{}, id).Error
+}
+
+// GetProblemByID retrieves a problem with its incidents.
+func (as *ProblemDBModel) GetProblemByID(id uint) (*Problem, error) {
+	var problem Problem
+	err := as.DB.Preload("Incidents", orderIncidents).Where("id = ?", id).First(&problem).Error
+	return &problem, err
+}
+
+// GetProblems retrieves the problems with their incidents, the latest
+// first, optionally only those with a status.
+func (as *ProblemDBModel) GetProblems(status string) (*[]Problem, error) {
+	var problems []Problem
+	query := as.DB.Preload("Incidents", orderIncidents).Order("id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&problems).Error
+	return &problems, err
+}
+
+// GetTicketProblems retrieves the problems a ticket is an incident of.
+func (as *ProblemDBModel) GetTicketProblems(ticketID uint) (*[]Problem, error) {
+	var problems []Problem
+	err := as.DB.Preload("Incidents", orderIncidents).
+		Where("id IN (?)", as.DB.Model(&ProblemIncident{}).Select("problem_id").Where("ticket_id = ?", ticketID)).
+		Order("id DESC").Find(&problems).Error
+	return &problems, err
+}
+
+// LinkIncident links a ticket with a problem unless they are already
+// linked, and reports whether it did.
+func (as *ProblemDBModel) LinkIncident(problemID, ticketID, linkedBy uint) (bool, error) {
+	var count int64
+	if err := as.DB.Model(&ProblemIncident{}).Where("problem_id = ? AND ticket_id = ?", problemID, ticketID).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	err := as.DB.Create(&ProblemIncident{ProblemID: problemID, TicketID: ticketID, LinkedBy: linkedBy}).Error
+	return err == nil, err
+}
+
+// UnlinkIncident removes the link between a ticket and a problem.
+func (as *ProblemDBModel) UnlinkIncident(problemID, ticketID uint) error {
+	result := as.DB.Where("problem_id = ? AND ticket_id = ?", problemID, ticketID).Delete(&ProblemIncident{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// GetExistingTicketIDs returns those of ids that are tickets.
+func (as *ProblemDBModel) GetExistingTicketIDs(ids []uint) ([]uint, error) {
+	existing := []uint{}
+	if len(ids) == 0 {
+		return existing, nil
+	}
+	err := as.DB.Table("tickets").Where("id IN ? AND deleted_at IS NULL", ids).Order("id").Pluck("id", &existing).Error
+	return existing, err
+}
@@ -0,0 +1,241 @@
+// backend/models/rules.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Rule is an automation rule: when one of its events happens to a ticket
+// that meets its conditions, its actions are applied in order.
+type Rule struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"rule_id"`
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	// Events are the ticket events that trigger the rule.
+	Events []string `json:"events" gorm:"serializer:json"`
+	// Match is "all" when every condition must hold and "any" when one is
+	// enough.
+	Match      string          `json:"match"`
+	Conditions []RuleCondition `json:"conditions" gorm:"serializer:json"`
+	Actions    []RuleAction    `json:"actions" gorm:"serializer:json"`
+	// Position orders the rules; lower positions run first.
+	Position int  `json:"position" gorm:"index"`
+	Active   bool `json:"active"`
+	// StopProcessing skips the rules after this one when it fires.
+	StopProcessing bool      `json:"stop_processing"`
+	CreatedBy      uint      `json:"created_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the Rule model.
+func (Rule) TableName() string {
+	return "automation_rules"
+}
+
+// Ways of combining the conditions of a Rule.
+const (
+	RuleMatchAll = "all"
+	RuleMatchAny = "any"
+)
+
+// Triggers reports whether the rule runs on an event.
+func (r *Rule) Triggers(event string) bool {
+	for _, e := range r.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// RuleCondition compares a ticket field with a value, e.g. priority equals
+// P1. Values is used by the in and not_in operators.
+type RuleCondition struct {
+	Field    string   `json:"field"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// Operators of a RuleCondition. Comparisons ignore case.
+const (
+	OperatorEquals      = "equals"
+	OperatorNotEquals   = "not_equals"
+	OperatorContains    = "contains"
+	OperatorNotContains = "not_contains"
+	OperatorStartsWith  = "starts_with"
+	OperatorIn          = "in"
+	OperatorNotIn       = "not_in"
+	OperatorIsEmpty     = "is_empty"
+	OperatorIsNotEmpty  = "is_not_empty"
+)
+
+// RuleAction is one step of a rule. Which fields apply depends on Type:
+//
+//   - set_field sets Field to Value
+//   - assign assigns the ticket to AgentID, or queues it for Unit
+//   - add_tag adds the tag Value
+//   - add_comment adds Body as a comment, internal when Internal is set
+//   - notify emails Subject and Body to To: "requester", "assignee",
+//     "supervisor" (of the assignee), "agent:<id>" or an email address
+//   - call_webhook sends the ticket to the webhook WebhookID
+//
+// Subject and Body are Go templates rendered with the ticket as .Ticket,
+// which has the fields of rules.TicketView: {{.Ticket.Subject}},
+// {{.Ticket.Requester.FirstName}}, {{.Ticket.CustomFields.asset_tag}}, ...
+type RuleAction struct {
+	Type      string `json:"type"`
+	Field     string `json:"field,omitempty"`
+	Value     string `json:"value,omitempty"`
+	AgentID   uint   `json:"agent_id,omitempty"`
+	Unit      string `json:"unit,omitempty"`
+	To        string `json:"to,omitempty"`
+	Subject   string `json:"subject,omitempty"`
+	Body      string `json:"body,omitempty"`
+	Internal  bool   `json:"internal,omitempty"`
+	WebhookID uint   `json:"webhook_id,omitempty"`
+}
+
+// Types of a RuleAction.
+const (
+	ActionSetField    = "set_field"
+	ActionAssign      = "assign"
+	ActionAddTag      = "add_tag"
+	ActionAddComment  = "add_comment"
+	ActionNotify      = "notify"
+	ActionCallWebhook = "call_webhook"
+)
+
+// Events recorded on the notifications and webhook deliveries made by the
+// notify and call_webhook actions.
+const (
+	EventRuleNotification = "rule.notification"
+	EventRuleTriggered    = "rule.triggered"
+)
+
+// RuleExecution records a rule firing on a ticket, or being held back by
+// loop protection. Actions holds the outcome of each action as JSON.
+type RuleExecution struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"execution_id"`
+	RuleID    uint      `json:"rule_id" gorm:"index"`
+	RuleName  string    `json:"rule_name"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	Event     string    `json:"event"`
+	EventID   string    `json:"event_id" gorm:"index"`
+	Status    string    `json:"status"`
+	Actions   string    `json:"actions"`
+	Error     string    `json:"error,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the RuleExecution model.
+func (RuleExecution) TableName() string {
+	return "rule_executions"
+}
+
+// Outcomes of a RuleExecution.
+const (
+	RuleExecutionApplied = "applied"
+	RuleExecutionFailed  = "failed"
+	RuleExecutionSkipped = "skipped"
+)
+
+type RuleStorage interface {
+	CreateRule(*Rule) error
+	UpdateRule(*Rule) error
+	DeleteRule(uint) error
+	GetRuleByID(uint) (*Rule, error)
+	GetAllRules() (*[]Rule, error)
+	GetActiveRules() (*[]Rule, error)
+	CreateExecution(*RuleExecution) error
+	UpdateExecution(*RuleExecution) error
+	HasExecution(uint, string) (bool, error)
+	GetExecutions(uint, uint, int) (*[]RuleExecution, error)
+}
+
+// RuleDBModel handles database operations for Rule
+type RuleDBModel struct {
+	DB *gorm.DB
+}
+
+// NewRuleDBModel creates a new instance of RuleDBModel
+func NewRuleDBModel(db *gorm.DB) *RuleDBModel {
+	return &RuleDBModel{
+		DB: db,
+	}
+}
+
+// CreateRule creates a new automation rule.
+func (as *RuleDBModel) CreateRule(rule *Rule) error {
+	return as.DB.Create(rule).Error
+}
+
+// UpdateRule updates the details of an existing rule.
+func (as *RuleDBModel) UpdateRule(rule *Rule) error {
+	return as.DB.Save(rule).Error
+}
+
+// DeleteRule deletes a rule.
+func (as *RuleDBModel) DeleteRule(id uint) error {
+	return as.DB.Delete(&Rule{}, id).Error
+}
+
+// GetRuleByID retrieves a rule by its ID.
+func (as *RuleDBModel) GetRuleByID(id uint) (*Rule, error) {
+	var rule Rule
+	err := as.DB.Where("id = ?", id).First(&rule).Error
+	return &rule, err
+}
+
+// GetAllRules retrieves all rules in the order they run.
+func (as *RuleDBModel) GetAllRules() (*[]Rule, error) {
+	var rules []Rule
+	err := as.DB.Order("position, id").Find(&rules).Error
+	return &rules, err
+}
+
+// GetActiveRules retrieves the active rules in the order they run.
+func (as *RuleDBModel) GetActiveRules() (*[]Rule, error) {
+	var rules []Rule
+	err := as.DB.Where("active = ?", true).Order("position, id").Find(&rules).Error
+	return &rules, err
+}
+
+// CreateExecution records a rule execution.
+func (as *RuleDBModel) CreateExecution(execution *RuleExecution) error {
+	return as.DB.Create(execution).Error
+}
+
+// UpdateExecution updates a recorded rule execution.
+func (as *RuleDBModel) UpdateExecution(execution *RuleExecution) error {
+	return as.DB.Save(execution).Error
+}
+
+// HasExecution reports whether a rule already ran for an event.
+func (as *RuleDBModel) HasExecution(ruleID uint, eventID string) (bool, error) {
+	var count int64
+	err := as.DB.Model(&RuleExecution{}).Where("rule_id = ? AND event_id = ?", ruleID, eventID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetExecutions retrieves the most recent executions, optionally only those
+// of a rule or of a ticket.
+func (as *RuleDBModel) GetExecutions(ruleID, ticketID uint, limit int) (*[]RuleExecution, error) {
+	var executions []RuleExecution
+	query := as.DB.Order("id desc").Limit(limit)
+	if ruleID != 0 {
+		query = query.Where("rule_id = ?", ruleID)
+	}
+	if ticketID != 0 {
+		query = query.Where("ticket_id = ?", ticketID)
+	}
+	err := query.Find(&executions).Error
+	return &executions, err
+}
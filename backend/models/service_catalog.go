@@ -0,0 +1,234 @@
+// backend/models/service_catalog.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// CatalogItem is an offering of the service catalog, such as a new laptop
+// or VPN access. A request for it is a ticket of its category and
+// sub-category, whose custom fields make up the request form, and Tasks
+// are the fulfilment tasks created with that ticket.
+type CatalogItem struct {
+	gorm.Model
+	ID          uint   `gorm:"primaryKey" json:"catalog_item_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	SubCategory string `json:"sub_category"`
+	// PolicyIDs link the Policies that apply to the item; Policies is
+	// filled in when the item is read.
+	PolicyIDs []int      `json:"policy_ids" gorm:"serializer:json"`
+	Policies  []Policies `json:"policies,omitempty" gorm:"-"`
+	// DeliveryHours is the expected time to deliver the item, from which
+	// requests get their due date. Zero leaves it open.
+	DeliveryHours int                   `json:"delivery_hours"`
+	Active        bool                  `json:"active"`
+	Tasks         []CatalogTaskTemplate `json:"tasks" gorm:"foreignKey:CatalogItemID"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+}
+
+// TableName sets the table name for the CatalogItem model.
+func (CatalogItem) TableName() string {
+	return "catalog_items"
+}
+
+// CatalogTaskTemplate is a fulfilment task created for every request of a
+// catalog item, for a unit. DueHours sets the due date of the task from
+// the request; zero gives it the due date of the request.
+type CatalogTaskTemplate struct {
+	gorm.Model
+	ID            uint      `gorm:"primaryKey" json:"template_id"`
+	CatalogItemID uint      `json:"catalog_item_id" gorm:"index"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	UnitID        int       `json:"unit_id"`
+	UnitName      string    `json:"unit_name"`
+	Position      int       `json:"position"`
+	DueHours      int       `json:"due_hours"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the CatalogTaskTemplate model.
+func (CatalogTaskTemplate) TableName() string {
+	return "catalog_task_templates"
+}
+
+// FulfilmentTask is a task of a unit to deliver a service request. The
+// request's ticket is resolved once all its tasks are finished.
+type FulfilmentTask struct {
+	gorm.Model
+	ID            uint       `gorm:"primaryKey" json:"task_id"`
+	TicketID      uint       `json:"ticket_id" gorm:"index"`
+	CatalogItemID uint       `json:"catalog_item_id"`
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	UnitID        int        `json:"unit_id"`
+	UnitName      string     `json:"unit_name" gorm:"index"`
+	AgentID       uint       `json:"agent_id,omitempty"`
+	Status        string     `json:"status" gorm:"index"`
+	Position      int        `json:"position"`
+	Note          string     `json:"note,omitempty"`
+	DueAt         *time.Time `json:"due_at,omitempty"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CompletedBy   uint       `json:"completed_by,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the FulfilmentTask model.
+func (FulfilmentTask) TableName() string {
+	return "fulfilment_tasks"
+}
+
+// Statuses of a FulfilmentTask.
+const (
+	TaskStatusOpen       = "open"
+	TaskStatusInProgress = "in_progress"
+	TaskStatusDone       = "done"
+	TaskStatusCancelled  = "cancelled"
+)
+
+// TaskStatuses lists every task status, in the order above.
+var TaskStatuses = []string{TaskStatusOpen, TaskStatusInProgress, TaskStatusDone, TaskStatusCancelled}
+
+// IsFinished reports whether the task is done or cancelled.
+func (t *FulfilmentTask) IsFinished() bool {
+	return t.Status == TaskStatusDone || t.Status == TaskStatusCancelled
+}
+
+type ServiceCatalogStorage interface {
+	CreateItem(*CatalogItem) error
+	UpdateItem(*CatalogItem) error
+	DeleteItem(uint) error
+	GetItemByID(uint) (*CatalogItem, error)
+	GetItems(bool) (*[]CatalogItem, error)
+	GetPolicies([]int) ([]Policies, error)
+	CreateTasks([]FulfilmentTask) error
+	UpdateTask(*FulfilmentTask) error
+	GetTaskByID(uint) (*FulfilmentTask, error)
+	GetTicketTasks(uint) (*[]FulfilmentTask, error)
+	GetUnitTasks(string, bool) (*[]FulfilmentTask, error)
+}
+
+// ServiceCatalogDBModel handles database operations for CatalogItem,
+// CatalogTaskTemplate and FulfilmentTask
+type ServiceCatalogDBModel struct {
+	DB *gorm.DB
+}
+
+// NewServiceCatalogDBModel creates a new instance of ServiceCatalogDBModel
+func NewServiceCatalogDBModel(db *gorm.DB) *ServiceCatalogDBModel {
+	return &ServiceCatalogDBModel{
+		DB: db,
+	}
+}
+
+// CreateItem creates a catalog item with its task templates.
+func (as *ServiceCatalogDBModel) CreateItem(item *CatalogItem) error {
+	return as.DB.Create(item).Error
+}
+
+// UpdateItem updates a catalog item and replaces its task templates.
+func (as *ServiceCatalogDBModel) UpdateItem(item *CatalogItem) error {
+	if err := as.DB.Omit("Tasks").Save(item).Error; err != nil {
+		return err
+	}
+	if err := as.DB.Where("catalog_item_id = ?", item.ID).Delete(&CatalogTaskTemplate{}).Error; err != nil {
+		return err
+	}
+	if len(item.Tasks) == 0 {
+		return nil
+	}
+	for i := range item.Tasks {
+		item.Tasks[i].ID = 0
+		item.Tasks[i].CatalogItemID = item.ID
+	}
+	return as.DB.Create(&item.Tasks).Error
+}
+
+// DeleteItem deletes a catalog item and its task templates. The tasks of
+// requests already made are kept.
+func (as *ServiceCatalogDBModel) DeleteItem(id uint) error {
+	if err := as.DB.Where("catalog_item_id = ?", id).Delete(&CatalogTaskTemplate{}).Error; err != nil {
+		return err
+	}
+	return as.DB.Delete(&CatalogItem{}, id).Error
+}
+
+// GetItemByID retrieves a catalog item with its task templates.
+func (as *ServiceCatalogDBModel) GetItemByID(id uint) (*CatalogItem, error) {
+	var item CatalogItem
+	err := as.DB.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Where("id = ?", id).First(&item).Error
+	return &item, err
+}
+
+// GetItems retrieves the catalog items by name with their task templates,
+// optionally only the active ones.
+func (as *ServiceCatalogDBModel) GetItems(activeOnly bool) (*[]CatalogItem, error) {
+	var items []CatalogItem
+	query := as.DB.Preload("Tasks", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Order("name, id")
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	err := query.Find(&items).Error
+	return &items, err
+}
+
+// GetPolicies retrieves the policies with the given policy IDs.
+func (as *ServiceCatalogDBModel) GetPolicies(ids []int) ([]Policies, error) {
+	policies := []Policies{}
+	if len(ids) == 0 {
+		return policies, nil
+	}
+	err := as.DB.Where("policy_id IN ?", ids).Order("policy_id").Find(&policies).Error
+	return policies, err
+}
+
+// CreateTasks creates fulfilment tasks.
+func (as *ServiceCatalogDBModel) CreateTasks(tasks []FulfilmentTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+	return as.DB.Create(&tasks).Error
+}
+
+// UpdateTask updates a fulfilment task.
+func (as *ServiceCatalogDBModel) UpdateTask(task *FulfilmentTask) error {
+	return as.DB.Save(task).Error
+}
+
+// GetTaskByID retrieves a fulfilment task by its ID.
+func (as *ServiceCatalogDBModel) GetTaskByID(id uint) (*FulfilmentTask, error) {
+	var task FulfilmentTask
+	err := as.DB.Where("id = ?", id).First(&task).Error
+	return &task, err
+}
+
+// GetTicketTasks retrieves the fulfilment tasks of a ticket in order.
+func (as *ServiceCatalogDBModel) GetTicketTasks(ticketID uint) (*[]FulfilmentTask, error) {
+	var tasks []FulfilmentTask
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("position, id").Find(&tasks).Error
+	return &tasks, err
+}
+
+// GetUnitTasks retrieves the fulfilment tasks of a unit, the earliest due
+// first, optionally only those not finished.
+func (as *ServiceCatalogDBModel) GetUnitTasks(unitName string, openOnly bool) (*[]FulfilmentTask, error) {
+	var tasks []FulfilmentTask
+	query := as.DB.Where("unit_name = ?", unitName)
+	if openOnly {
+		query = query.Where("status IN ?", []string{TaskStatusOpen, TaskStatusInProgress})
+	}
+	err := query.Order("due_at IS NULL, due_at, id").Find(&tasks).Error
+	return &tasks, err
+}
@@ -0,0 +1,160 @@
+// backend/models/surveys.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SatisfactionSurvey is the satisfaction survey sent to the requester when
+// a ticket is resolved. The agent, unit and category are copied from the
+// ticket at that moment so the aggregates do not shift when the ticket
+// changes later. Only a hash of the rating link token is stored.
+type SatisfactionSurvey struct {
+	gorm.Model
+	ID             uint       `gorm:"primaryKey" json:"survey_id"`
+	TicketID       uint       `json:"ticket_id" gorm:"index"`
+	EventID        string     `json:"-" gorm:"index"`
+	UserID         uint       `json:"user_id"`
+	AgentID        uint       `json:"agent_id" gorm:"index"`
+	AgentName      string     `json:"agent_name"`
+	UnitName       string     `json:"unit_name"`
+	CategoryName   string     `json:"category_name"`
+	TokenHash      string     `json:"-" gorm:"uniqueIndex"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	SatisfactionID int        `json:"satisfaction_id,omitempty"`
+	Rating         string     `json:"rating,omitempty"`
+	Emoji          string     `json:"emoji,omitempty"`
+	Rank           int        `json:"rank,omitempty" gorm:"column:rating_rank"`
+	Feedback       string     `json:"feedback,omitempty"`
+	RespondedAt    *time.Time `json:"responded_at,omitempty"`
+	Reopened       bool       `json:"reopened"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the SatisfactionSurvey model.
+func (SatisfactionSurvey) TableName() string {
+	return "satisfaction_surveys"
+}
+
+// EventTicketSurvey is the notification event of survey emails. It is not
+// published on the event bus.
+const EventTicketSurvey = "ticket.survey"
+
+// Groupings of the survey statistics.
+const (
+	SurveyGroupAgent    = "agent"
+	SurveyGroupUnit     = "unit"
+	SurveyGroupCategory = "category"
+)
+
+// SurveyStats aggregates the answered surveys of an agent, unit or
+// category. ID is only set for agents.
+type SurveyStats struct {
+	ID          uint    `json:"id,omitempty"`
+	Group       string  `json:"group"`
+	Responses   int64   `json:"responses"`
+	AverageRank float64 `json:"average_rank"`
+	Satisfied   int64   `json:"satisfied"`
+	CSAT        float64 `json:"csat" gorm:"-"`
+}
+
+type SurveyStorage interface {
+	GetSatisfactions() (*[]Satisfaction, error)
+	GetSatisfactionByID(int) (*Satisfaction, error)
+	CreateSatisfaction(*Satisfaction) error
+	CreateSurvey(*SatisfactionSurvey) error
+	UpdateSurvey(*SatisfactionSurvey) error
+	GetSurveyByTokenHash(string) (*SatisfactionSurvey, error)
+	GetSurveysByTicketID(uint) (*[]SatisfactionSurvey, error)
+	HasSurveyForEvent(string) (bool, error)
+	GetSurveyStats(string, int, time.Time, time.Time) (*[]SurveyStats, error)
+}
+
+// SurveyDBModel handles database operations for SatisfactionSurvey and the
+// Satisfaction levels it is rated with.
+type SurveyDBModel struct {
+	DB *gorm.DB
+}
+
+// NewSurveyDBModel creates a new instance of SurveyDBModel
+func NewSurveyDBModel(db *gorm.DB) *SurveyDBModel {
+	return &SurveyDBModel{
+		DB: db,
+	}
+}
+
+// GetSatisfactions retrieves the satisfaction levels, worst first.
+func (as *SurveyDBModel) GetSatisfactions() (*[]Satisfaction, error) {
+	var levels []Satisfaction
+	err := as.DB.Order("`rank`, satisfaction_id").Find(&levels).Error
+	return &levels, err
+}
+
+// GetSatisfactionByID retrieves a satisfaction level by its SatisfactionID.
+func (as *SurveyDBModel) GetSatisfactionByID(id int) (*Satisfaction, error) {
+	var level Satisfaction
+	err := as.DB.Where("satisfaction_id = ?", id).First(&level).Error
+	return &level, err
+}
+
+// CreateSatisfaction creates a satisfaction level.
+func (as *SurveyDBModel) CreateSatisfaction(level *Satisfaction) error {
+	return as.DB.Create(level).Error
+}
+
+// CreateSurvey creates a survey.
+func (as *SurveyDBModel) CreateSurvey(survey *SatisfactionSurvey) error {
+	return as.DB.Create(survey).Error
+}
+
+// UpdateSurvey updates a survey.
+func (as *SurveyDBModel) UpdateSurvey(survey *SatisfactionSurvey) error {
+	return as.DB.Save(survey).Error
+}
+
+// GetSurveyByTokenHash retrieves the survey of a rating link.
+func (as *SurveyDBModel) GetSurveyByTokenHash(hash string) (*SatisfactionSurvey, error) {
+	var survey SatisfactionSurvey
+	err := as.DB.Where("token_hash = ?", hash).First(&survey).Error
+	return &survey, err
+}
+
+// GetSurveysByTicketID retrieves the surveys of a ticket, oldest first.
+func (as *SurveyDBModel) GetSurveysByTicketID(ticketID uint) (*[]SatisfactionSurvey, error) {
+	var surveys []SatisfactionSurvey
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("id").Find(&surveys).Error
+	return &surveys, err
+}
+
+// HasSurveyForEvent reports whether a survey was already created for an event.
+func (as *SurveyDBModel) HasSurveyForEvent(eventID string) (bool, error) {
+	var count int64
+	err := as.DB.Model(&SatisfactionSurvey{}).Where("event_id = ?", eventID).Count(&count).Error
+	return count > 0, err
+}
+
+// GetSurveyStats aggregates the surveys answered between from and to by
+// agent, unit or category. Ratings of satisfiedRank and above count as
+// satisfied.
+func (as *SurveyDBModel) GetSurveyStats(groupBy string, satisfiedRank int, from, to time.Time) (*[]SurveyStats, error) {
+	var columns, group string
+	switch groupBy {
+	case SurveyGroupAgent:
+		columns, group = "agent_id AS id, agent_name AS `group`", "agent_id, agent_name"
+	case SurveyGroupUnit:
+		columns, group = "unit_name AS `group`", "unit_name"
+	default:
+		columns, group = "category_name AS `group`", "category_name"
+	}
+	var stats []SurveyStats
+	err := as.DB.Model(&SatisfactionSurvey{}).
+		Select(columns+", COUNT(*) AS responses, AVG(rating_rank) AS average_rank, "+
+			"SUM(CASE WHEN rating_rank >= ? THEN 1 ELSE 0 END) AS satisfied", satisfiedRank).
+		Where("responded_at IS NOT NULL AND responded_at >= ? AND responded_at < ?", from, to).
+		Group(group).Order("responses DESC").Scan(&stats).Error
+	return &stats, err
+}
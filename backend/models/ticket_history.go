@@ -0,0 +1,100 @@
+// backend/models/ticket_history.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketHistory is an entry in the history of a ticket: something done to
+// it and by whom. Actor is "system" for background policies and the email
+// of the agent otherwise.
+type TicketHistory struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"history_id"`
+	TicketID  uint      `json:"ticket_id" gorm:"index"`
+	Action    string    `json:"action" gorm:"index"`
+	Detail    string    `json:"detail"`
+	Actor     string    `json:"actor"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the TicketHistory model.
+func (TicketHistory) TableName() string {
+	return "ticket_history"
+}
+
+// Actions recorded in the TicketHistory.
+const (
+	HistoryReminderSent = "reminder_sent"
+	HistoryAutoClosed   = "auto_closed"
+	HistoryReopened     = "reopened"
+	HistoryMacroApplied = "macro_applied"
+	HistoryBulkUpdated  = "bulk_updated"
+	HistoryMerged       = "merged"
+	// HistoryPriorityOverridden records the justification of an agent who
+	// chose the priority of a ticket instead of the priority matrix.
+	HistoryPriorityOverridden = "priority_overridden"
+	// HistoryTaskUpdated records a change to a fulfilment task of a
+	// service request.
+	HistoryTaskUpdated = "task_updated"
+	// HistoryApproval records a decision on an approval of the ticket and
+	// the outcome of its approval request.
+	HistoryApproval = "approval"
+	// HistoryProblemLinked and HistoryProblemUnlinked record the ticket
+	// being linked with a problem as one of its incidents, or unlinked;
+	// HistoryProblemResolved records it being resolved with its problem.
+	HistoryProblemLinked   = "problem_linked"
+	HistoryProblemUnlinked = "problem_unlinked"
+	HistoryProblemResolved = "problem_resolved"
+	// HistoryMajorIncident records the ticket being declared a major
+	// incident, linked with one or unlinked.
+	HistoryMajorIncident = "major_incident"
+	// HistoryArticleLinked and HistoryArticleUnlinked record a knowledge
+	// article being linked with the ticket, or resolving it, and unlinked.
+	HistoryArticleLinked   = "article_linked"
+	HistoryArticleUnlinked = "article_unlinked"
+)
+
+// ActorSystem is the actor of changes made by background policies.
+const ActorSystem = "system"
+
+type TicketHistoryStorage interface {
+	CreateEntry(*TicketHistory) error
+	GetHistoryByTicketID(uint) (*[]TicketHistory, error)
+	HasEntrySince(uint, string, time.Time) (bool, error)
+}
+
+// TicketHistoryDBModel handles database operations for TicketHistory
+type TicketHistoryDBModel struct {
+	DB *gorm.DB
+}
+
+// NewTicketHistoryDBModel creates a new instance of TicketHistoryDBModel
+func NewTicketHistoryDBModel(db *gorm.DB) *TicketHistoryDBModel {
+	return &TicketHistoryDBModel{
+		DB: db,
+	}
+}
+
+// CreateEntry adds an entry to the history of a ticket.
+func (as *TicketHistoryDBModel) CreateEntry(entry *TicketHistory) error {
+	return as.DB.Create(entry).Error
+}
+
+// GetHistoryByTicketID retrieves the history of a ticket, oldest first.
+func (as *TicketHistoryDBModel) GetHistoryByTicketID(ticketID uint) (*[]TicketHistory, error) {
+	var history []TicketHistory
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("created_at, id").Find(&history).Error
+	return &history, err
+}
+
+// HasEntrySince reports whether an action was recorded on a ticket after since.
+func (as *TicketHistoryDBModel) HasEntrySince(ticketID uint, action string, since time.Time) (bool, error) {
+	var count int64
+	err := as.DB.Model(&TicketHistory{}).Where("ticket_id = ? AND action = ? AND created_at > ?", ticketID, action, since).Count(&count).Error
+	return count > 0, err
+}
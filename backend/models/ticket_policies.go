@@ -0,0 +1,97 @@
+// backend/models/ticket_policies.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketPolicy holds the time-based automations of the tickets of a
+// category; the policy with an empty CategoryName applies to the other
+// categories. Delays count business days since the ticket was last
+// updated, and zero turns a step off.
+type TicketPolicy struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey" json:"policy_id"`
+	Name         string `json:"name"`
+	CategoryName string `json:"category_name" gorm:"uniqueIndex"`
+	// PendingStatus is the status of tickets waiting for the requester.
+	PendingStatus string `json:"pending_status"`
+	// RemindAfterDays reminds the requester of a pending ticket once.
+	RemindAfterDays int `json:"remind_after_days"`
+	// CloseAfterDays closes a pending ticket the requester did not answer.
+	CloseAfterDays int `json:"close_after_days"`
+	// CloseResolvedAfterDays closes a resolved ticket.
+	CloseResolvedAfterDays int       `json:"close_resolved_after_days"`
+	Active                 bool      `json:"active"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the TicketPolicy model.
+func (TicketPolicy) TableName() string {
+	return "ticket_policies"
+}
+
+// StatusPending is the default PendingStatus.
+const StatusPending = "Pending"
+
+type TicketPolicyStorage interface {
+	CreatePolicy(*TicketPolicy) error
+	UpdatePolicy(*TicketPolicy) error
+	DeletePolicy(uint) error
+	GetPolicyByID(uint) (*TicketPolicy, error)
+	GetAllPolicies() (*[]TicketPolicy, error)
+	GetActivePolicies() (*[]TicketPolicy, error)
+}
+
+// TicketPolicyDBModel handles database operations for TicketPolicy
+type TicketPolicyDBModel struct {
+	DB *gorm.DB
+}
+
+// NewTicketPolicyDBModel creates a new instance of TicketPolicyDBModel
+func NewTicketPolicyDBModel(db *gorm.DB) *TicketPolicyDBModel {
+	return &TicketPolicyDBModel{
+		DB: db,
+	}
+}
+
+// CreatePolicy creates a new ticket policy.
+func (as *TicketPolicyDBModel) CreatePolicy(policy *TicketPolicy) error {
+	return as.DB.Create(policy).Error
+}
+
+// UpdatePolicy updates the details of an existing policy.
+func (as *TicketPolicyDBModel) UpdatePolicy(policy *TicketPolicy) error {
+	return as.DB.Save(policy).Error
+}
+
+// DeletePolicy deletes a policy for good, so its category can be given a
+// new one.
+func (as *TicketPolicyDBModel) DeletePolicy(id uint) error {
+	return as.DB.Unscoped().Delete(&TicketPolicy{}, id).Error
+}
+
+// GetPolicyByID retrieves a policy by its ID.
+func (as *TicketPolicyDBModel) GetPolicyByID(id uint) (*TicketPolicy, error) {
+	var policy TicketPolicy
+	err := as.DB.Where("id = ?", id).First(&policy).Error
+	return &policy, err
+}
+
+// GetAllPolicies retrieves all policies.
+func (as *TicketPolicyDBModel) GetAllPolicies() (*[]TicketPolicy, error) {
+	var policies []TicketPolicy
+	err := as.DB.Order("category_name").Find(&policies).Error
+	return &policies, err
+}
+
+// GetActivePolicies retrieves the active policies.
+func (as *TicketPolicyDBModel) GetActivePolicies() (*[]TicketPolicy, error) {
+	var policies []TicketPolicy
+	err := as.DB.Where("active = ?", true).Find(&policies).Error
+	return &policies, err
+}
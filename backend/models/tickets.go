@@ -3,6 +3,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
@@ -28,6 +29,21 @@ type Ticket struct {
 	Tags             []Tags                  `json:"hashtags" gorm:"foreignKey:TicketID"`
 	Site             string                  `json:"site"`
 	Status           Status                  `json:"status" gorm:"embedded"`
+	ImpactID         uint                    `json:"impact_id"`
+	UrgencyID        uint                    `json:"urgency_id"`
+	// PriorityOverriddenBy is the agent who chose the priority instead of
+	// the priority matrix, for the reason in PriorityJustification.
+	PriorityOverriddenBy  uint   `json:"priority_overridden_by,omitempty"`
+	PriorityJustification string `json:"priority_justification,omitempty"`
+	// TimeSpent sums the work logged on the ticket. It is filled in when
+	// the ticket is read, not stored.
+	TimeSpent *TimeTotals `json:"time_spent,omitempty" gorm:"-"`
+	// CustomFields are the values of the custom fields of the ticket's
+	// category. They are stored as TicketFieldValue rows.
+	CustomFields CustomFieldValues `json:"custom_fields,omitempty" gorm:"-"`
+	// CatalogItemID is the item of the service catalog the ticket requests,
+	// if any.
+	CatalogItemID uint `json:"catalog_item_id,omitempty"`
 }
 
 // TableName sets the table name for the Ticket model.
@@ -111,10 +127,14 @@ func (Satisfaction) TableName() string {
 
 type Category struct {
 	gorm.Model
-	ID           int       `gorm:"primaryKey" json:"category_id"`
-	CategoryName string    `json:"category_name"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID           int    `gorm:"primaryKey" json:"category_id"`
+	CategoryName string `json:"category_name"`
+	// Retired categories are kept for the tickets that have them but
+	// cannot be chosen for other tickets. The column is named apart from
+	// that of SubCategory, both being embedded in Ticket.
+	Retired   bool      `json:"retired" gorm:"column:category_retired"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // TableName sets the table name for the Category model.
@@ -124,11 +144,13 @@ func (Category) TableName() string {
 
 type SubCategory struct {
 	gorm.Model
-	SubCategoryID   int       `gorm:"primaryKey" json:"sub_category_id"`
-	SubCategoryName string    `json:"sub_category_name"`
-	CategoryID      int       `json:"category_id"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	SubCategoryID   int    `gorm:"primaryKey" json:"sub_category_id"`
+	SubCategoryName string `json:"sub_category_name"`
+	CategoryID      int    `json:"category_id"`
+	// Retired sub-categories are kept like retired categories.
+	Retired   bool      `json:"retired" gorm:"column:sub_category_retired"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
 // TableName sets the table name for the SubCategory model.
@@ -149,6 +171,17 @@ func (Status) TableName() string {
 	return "status"
 }
 
+// Names of the Status values with special meaning to the service desk.
+const (
+	StatusResolved = "Resolved"
+	StatusClosed   = "Closed"
+)
+
+// IsResolved reports whether a status marks the ticket as resolved or closed.
+func (s Status) IsResolved() bool {
+	return strings.EqualFold(s.StatusName, StatusResolved) || strings.EqualFold(s.StatusName, StatusClosed)
+}
+
 type Policies struct {
 	gorm.Model
 	PolicyID     int       `gorm:"primaryKey" json:"policy_id"`
@@ -167,15 +200,29 @@ func (Policies) TableName() string {
 // MediaAttachment struct for storing media attachments related to the Tickets
 type TicketMediaAttachment struct {
 	gorm.Model
-	ID        uint   `gorm:"primaryKey" json:"-"`
-	URL       string `json:"url"`
-	Type      string `json:"type"`
-	Caption   string `json:"caption"`
-	AltText   string `json:"altText"`
-	IsPrimary bool   `json:"isPrimary" gorm:"default:false"`
-	Order     int    `json:"order" gorm:"default:0"`
-	TicketID  uint   `json:"-"`
-	// Add more fields as needed
+	ID          uint   `gorm:"primaryKey" json:"attachment_id"`
+	URL         string `json:"url"`
+	Type        string `json:"type"`
+	Caption     string `json:"caption"`
+	AltText     string `json:"altText"`
+	IsPrimary   bool   `json:"isPrimary" gorm:"default:false"`
+	Order       int    `json:"order" gorm:"default:0"`
+	TicketID    uint   `json:"-" gorm:"index"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType"`
+	Size        int64  `json:"size"`
+	Checksum    string `json:"checksum" gorm:"index"`
+	StorageKey  string `json:"-"`
+	UploadedBy  uint   `json:"uploadedBy"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+
+	ScanStatus string     `json:"scanStatus" gorm:"index"`
+	ScanResult string     `json:"scanResult,omitempty"`
+	ScannedAt  *time.Time `json:"scannedAt,omitempty"`
+	ReleasedBy uint       `json:"releasedBy,omitempty"`
+
+	Variants []TicketAttachmentVariant `json:"variants,omitempty" gorm:"foreignKey:AttachmentID"`
 }
 
 // TableName sets the table name for the Ticket model.
@@ -183,6 +230,19 @@ func (TicketMediaAttachment) TableName() string {
 	return "ticket_media_attachment"
 }
 
+// Scan states of a TicketMediaAttachment.
+const (
+	ScanStatusNotScanned  = "not_scanned"
+	ScanStatusClean       = "clean"
+	ScanStatusQuarantined = "quarantined"
+	ScanStatusReleased    = "released"
+)
+
+// Available reports whether the attachment may be served to agents and users.
+func (a *TicketMediaAttachment) Available() bool {
+	return a.ScanStatus != ScanStatusQuarantined
+}
+
 type TicketStorage interface {
 	CreateTicket(*Ticket) error
 	DeleteTicket(int) error
@@ -266,7 +326,9 @@ func (as *TicketDBModel) CreateTicket(ticket *Ticket) error {
 // GetTicketByID retrieves a Ticket by its ID.
 func (as *TicketDBModel) GetTicketByID(id uint) (*Ticket, error) {
 	var ticket Ticket
-	err := as.DB.Where("id = ?", id).First(&ticket).Error
+	err := as.DB.Preload("MediaAttachments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("`order`, id")
+	}).Preload("MediaAttachments.Variants").Where("id = ?", id).First(&ticket).Error
 	return &ticket, err
 }
 
@@ -286,6 +348,114 @@ func (as *TicketDBModel) DeleteTicket(id uint) error {
 	return nil
 }
 
+// GetStaleTickets retrieves, in ID order, up to limit tickets after afterID
+// in one of the statuses, in any case, that were last updated before
+// updatedBefore.
+func (as *TicketDBModel) GetStaleTickets(statuses []string, updatedBefore time.Time, afterID uint, limit int) (*[]Ticket, error) {
+	lower := make([]string, len(statuses))
+	for i, status := range statuses {
+		lower[i] = strings.ToLower(status)
+	}
+	var tickets []Ticket
+	err := as.DB.Where("LOWER(status_name) IN ? AND updated_at < ? AND id > ?", lower, updatedBefore, afterID).
+		Order("id").Limit(limit).Find(&tickets).Error
+	return &tickets, err
+}
+
+// GetTicketTags retrieves the tags of a ticket.
+func (as *TicketDBModel) GetTicketTags(ticketID uint) ([]Tags, error) {
+	var tags []Tags
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("id").Find(&tags).Error
+	return tags, err
+}
+
+// AddTag tags a ticket unless it already carries the tag.
+func (as *TicketDBModel) AddTag(ticketID uint, name string) (bool, error) {
+	var count int64
+	if err := as.DB.Model(&Tags{}).Where("ticket_id = ? AND tag_name = ?", ticketID, name).Count(&count).Error; err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, nil
+	}
+	return true, as.DB.Create(&Tags{TicketID: ticketID, TagName: name}).Error
+}
+
+// TicketFilter selects tickets by their fields. Empty fields match every
+// ticket; text fields are compared exactly.
+type TicketFilter struct {
+	Status        string     `json:"status,omitempty"`
+	Category      string     `json:"category,omitempty"`
+	Priority      string     `json:"priority,omitempty"`
+	Unit          string     `json:"unit,omitempty"`
+	AgentEmail    string     `json:"agent_email,omitempty"`
+	Site          string     `json:"site,omitempty"`
+	Tag           string     `json:"tag,omitempty"`
+	CreatedAfter  *time.Time `json:"created_after,omitempty"`
+	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	// CustomFields selects tickets by the value of custom fields, by key.
+	// A multi-select field matches when the value is one of its options.
+	CustomFields map[string]string `json:"custom_fields,omitempty"`
+}
+
+// IsEmpty reports whether the filter would match every ticket.
+func (f TicketFilter) IsEmpty() bool {
+	return f.Status == "" && f.Category == "" && f.Priority == "" && f.Unit == "" &&
+		f.AgentEmail == "" && f.Site == "" && f.Tag == "" &&
+		f.CreatedAfter == nil && f.CreatedBefore == nil && len(f.CustomFields) == 0
+}
+
+// FindTicketIDs retrieves, in ID order, the IDs of up to limit tickets that
+// match filter.
+func (as *TicketDBModel) FindTicketIDs(filter TicketFilter, limit int) ([]uint, error) {
+	query := as.DB.Model(&Ticket{})
+	for column, value := range map[string]string{
+		"status_name":   filter.Status,
+		"category_name": filter.Category,
+		"name":          filter.Priority,
+		"unit_name":     filter.Unit,
+		"agent_email":   filter.AgentEmail,
+		"site":          filter.Site,
+	} {
+		if value != "" {
+			query = query.Where(column+" = ?", value)
+		}
+	}
+	if filter.Tag != "" {
+		query = query.Where("id IN (?)", as.DB.Model(&Tags{}).Select("ticket_id").Where("tag_name = ?", filter.Tag))
+	}
+	for key, value := range filter.CustomFields {
+		query = query.Where("id IN (?)", as.DB.Model(&TicketFieldValue{}).Select("ticket_id").Where("field_key = ? AND value = ?", key, value))
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at < ?", *filter.CreatedBefore)
+	}
+	var ids []uint
+	err := query.Order("id").Limit(limit).Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AddRelatedTicket links two tickets, in both directions, unless they are
+// already linked.
+func (as *TicketDBModel) AddRelatedTicket(ticketID, relatedTicketID uint) error {
+	for _, pair := range [][2]uint{{ticketID, relatedTicketID}, {relatedTicketID, ticketID}} {
+		var count int64
+		if err := as.DB.Model(&RelatedTicket{}).Where("ticket_id = ? AND related_ticket_id = ?", pair[0], pair[1]).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		if err := as.DB.Create(&RelatedTicket{TicketID: pair[0], RelatedTicketID: pair[1]}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetAllTickets retrieves all tickets from the database.
 func (as *TicketDBModel) GetAllTickets() (*[]Ticket, error) {
 	var tickets []Ticket
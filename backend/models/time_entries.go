@@ -0,0 +1,191 @@
+// backend/models/time_entries.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TimeEntry is work an agent logged against a ticket, either directly or
+// by starting and stopping a timer. A running timer is an entry with
+// Running set; it counts towards no total until it is stopped. The unit of
+// the agent, the department of the requester and the category of the ticket
+// are copied when the entry is logged so the reports of past periods do not
+// shift when they change.
+type TimeEntry struct {
+	gorm.Model
+	ID             uint       `gorm:"primaryKey" json:"time_entry_id"`
+	TicketID       uint       `json:"ticket_id" gorm:"index"`
+	AgentID        uint       `json:"agent_id" gorm:"index"`
+	AgentName      string     `json:"agent_name"`
+	UnitName       string     `json:"unit_name"`
+	DepartmentName string     `json:"department_name"`
+	CategoryName   string     `json:"category_name"`
+	ActivityType   string     `json:"activity_type"`
+	Minutes        int        `json:"minutes"`
+	Billable       bool       `json:"billable"`
+	Note           string     `json:"note"`
+	WorkedAt       time.Time  `json:"worked_at" gorm:"index"`
+	Running        bool       `json:"running" gorm:"index"`
+	StoppedAt      *time.Time `json:"stopped_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the TimeEntry model.
+func (TimeEntry) TableName() string {
+	return "time_entries"
+}
+
+// Activity types of a TimeEntry.
+const (
+	ActivityInvestigation = "investigation"
+	ActivityRemoteSupport = "remote_support"
+	ActivityOnsite        = "onsite"
+	ActivityConfiguration = "configuration"
+	ActivityCommunication = "communication"
+	ActivityTravel        = "travel"
+	ActivityOther         = "other"
+)
+
+// ActivityTypes lists every activity type, in the order above.
+var ActivityTypes = []string{
+	ActivityInvestigation, ActivityRemoteSupport, ActivityOnsite, ActivityConfiguration,
+	ActivityCommunication, ActivityTravel, ActivityOther,
+}
+
+// Groupings of the time reports.
+const (
+	TimeGroupAgent      = "agent"
+	TimeGroupUnit       = "unit"
+	TimeGroupDepartment = "department"
+	TimeGroupCategory   = "category"
+)
+
+// TimeTotals sums the stopped time entries of a ticket.
+type TimeTotals struct {
+	Entries         int64 `json:"entries"`
+	Minutes         int64 `json:"minutes"`
+	BillableMinutes int64 `json:"billable_minutes"`
+}
+
+// TimeReport sums the time logged by an agent, or for a unit, department
+// or category. ID is only set for agents.
+type TimeReport struct {
+	ID              uint   `json:"id,omitempty"`
+	Group           string `json:"group"`
+	Tickets         int64  `json:"tickets"`
+	Entries         int64  `json:"entries"`
+	Minutes         int64  `json:"minutes"`
+	BillableMinutes int64  `json:"billable_minutes"`
+}
+
+type TimeEntryStorage interface {
+	CreateTimeEntry(*TimeEntry) error
+	UpdateTimeEntry(*TimeEntry) error
+	DeleteTimeEntry(uint) error
+	GetTimeEntryByID(uint) (*TimeEntry, error)
+	GetTimeEntriesByTicket(uint) (*[]TimeEntry, error)
+	GetRunningTimer(uint, uint) (*TimeEntry, error)
+	GetTicketTotals([]uint) (map[uint]TimeTotals, error)
+	GetTimeReport(string, time.Time, time.Time) (*[]TimeReport, error)
+}
+
+// TimeEntryDBModel handles database operations for TimeEntry
+type TimeEntryDBModel struct {
+	DB *gorm.DB
+}
+
+// NewTimeEntryDBModel creates a new instance of TimeEntryDBModel
+func NewTimeEntryDBModel(db *gorm.DB) *TimeEntryDBModel {
+	return &TimeEntryDBModel{
+		DB: db,
+	}
+}
+
+// CreateTimeEntry creates a time entry.
+func (as *TimeEntryDBModel) CreateTimeEntry(entry *TimeEntry) error {
+	return as.DB.Create(entry).Error
+}
+
+// UpdateTimeEntry updates a time entry.
+func (as *TimeEntryDBModel) UpdateTimeEntry(entry *TimeEntry) error {
+	return as.DB.Save(entry).Error
+}
+
+// DeleteTimeEntry deletes a time entry.
+func (as *TimeEntryDBModel) DeleteTimeEntry(id uint) error {
+	return as.DB.Delete(&TimeEntry{}, id).Error
+}
+
+// GetTimeEntryByID retrieves a time entry by its ID.
+func (as *TimeEntryDBModel) GetTimeEntryByID(id uint) (*TimeEntry, error) {
+	var entry TimeEntry
+	err := as.DB.Where("id = ?", id).First(&entry).Error
+	return &entry, err
+}
+
+// GetTimeEntriesByTicket retrieves the time entries of a ticket in the
+// order the work was done.
+func (as *TimeEntryDBModel) GetTimeEntriesByTicket(ticketID uint) (*[]TimeEntry, error) {
+	var entries []TimeEntry
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("worked_at, id").Find(&entries).Error
+	return &entries, err
+}
+
+// GetRunningTimer retrieves the running timer of an agent on a ticket.
+func (as *TimeEntryDBModel) GetRunningTimer(agentID, ticketID uint) (*TimeEntry, error) {
+	var entry TimeEntry
+	err := as.DB.Where("agent_id = ? AND ticket_id = ? AND running = ?", agentID, ticketID, true).First(&entry).Error
+	return &entry, err
+}
+
+// GetTicketTotals sums the stopped time entries of tickets. Tickets without
+// any are left out of the map.
+func (as *TimeEntryDBModel) GetTicketTotals(ticketIDs []uint) (map[uint]TimeTotals, error) {
+	totals := make(map[uint]TimeTotals, len(ticketIDs))
+	if len(ticketIDs) == 0 {
+		return totals, nil
+	}
+	var rows []struct {
+		TicketID uint
+		TimeTotals
+	}
+	err := as.DB.Model(&TimeEntry{}).
+		Select("ticket_id, COUNT(*) AS entries, SUM(minutes) AS minutes, "+
+			"SUM(CASE WHEN billable THEN minutes ELSE 0 END) AS billable_minutes").
+		Where("ticket_id IN ? AND running = ?", ticketIDs, false).
+		Group("ticket_id").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		totals[row.TicketID] = row.TimeTotals
+	}
+	return totals, nil
+}
+
+// GetTimeReport sums the stopped time entries worked between from and to by
+// agent, unit, department or category, most time first.
+func (as *TimeEntryDBModel) GetTimeReport(groupBy string, from, to time.Time) (*[]TimeReport, error) {
+	var columns, group string
+	switch groupBy {
+	case TimeGroupAgent:
+		columns, group = "agent_id AS id, agent_name AS `group`", "agent_id, agent_name"
+	case TimeGroupUnit:
+		columns, group = "unit_name AS `group`", "unit_name"
+	case TimeGroupDepartment:
+		columns, group = "department_name AS `group`", "department_name"
+	default:
+		columns, group = "category_name AS `group`", "category_name"
+	}
+	var report []TimeReport
+	err := as.DB.Model(&TimeEntry{}).
+		Select(columns+", COUNT(DISTINCT ticket_id) AS tickets, COUNT(*) AS entries, SUM(minutes) AS minutes, "+
+			"SUM(CASE WHEN billable THEN minutes ELSE 0 END) AS billable_minutes").
+		Where("running = ? AND worked_at >= ? AND worked_at < ?", false, from, to).
+		Group(group).Order("minutes DESC").Scan(&report).Error
+	return &report, err
+}
@@ -107,6 +107,25 @@ func (as *UserDBModel) GetUserByID(id uint) (*Users, error) {
 	return &user, err
 }
 
+// GetUserByEmail retrieves a user by email address, ignoring case.
+func (as *UserDBModel) GetUserByEmail(email string) (*Users, error) {
+	var user Users
+	err := as.DB.Where("LOWER(email) = LOWER(?)", email).First(&user).Error
+	return &user, err
+}
+
+// GetUsersByPosition retrieves the users holding a position, in a
+// department unless departmentID is zero.
+func (as *UserDBModel) GetUsersByPosition(positionID, departmentID int) (*[]Users, error) {
+	var users []Users
+	query := as.DB.Where("position_id = ?", positionID)
+	if departmentID != 0 {
+		query = query.Where("department_id = ?", departmentID)
+	}
+	err := query.Order("id").Find(&users).Error
+	return &users, err
+}
+
 // UpdateUser updates the details of an existing user.
 func (as *UserDBModel) UpdateUser(user *Users) error {
 	if err := as.DB.Save(user).Error; err != nil {
@@ -0,0 +1,102 @@
+// backend/models/watchers.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TicketWatcher is someone besides the requester and the assigned agent who
+// follows a ticket: a user or agent, notified like them, or an external
+// email address copied on the replies to the requester.
+type TicketWatcher struct {
+	gorm.Model
+	ID       uint `gorm:"primaryKey" json:"watcher_id"`
+	TicketID uint `json:"ticket_id" gorm:"uniqueIndex:idx_ticket_watcher"`
+	// WatcherType is "user", "agent" or "cc".
+	WatcherType string `json:"watcher_type" gorm:"uniqueIndex:idx_ticket_watcher"`
+	// RecipientID is the user or agent, and zero for CC addresses.
+	RecipientID uint   `json:"recipient_id,omitempty" gorm:"uniqueIndex:idx_ticket_watcher"`
+	Email       string `json:"email,omitempty" gorm:"uniqueIndex:idx_ticket_watcher"`
+	// Source tells how the watcher was added.
+	Source string `json:"source"`
+	// AddedBy is the user or agent, as AddedByType tells, who added the
+	// watcher.
+	AddedBy     uint      `json:"added_by,omitempty"`
+	AddedByType string    `json:"added_by_type,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the TicketWatcher model.
+func (TicketWatcher) TableName() string {
+	return "ticket_watchers"
+}
+
+// WatcherCC is the WatcherType of external email addresses; users and
+// agents use RecipientUser and RecipientAgent.
+const WatcherCC = "cc"
+
+// Sources of a TicketWatcher.
+const (
+	WatcherSourceManual  = "manual"
+	WatcherSourceMention = "mention"
+)
+
+type WatcherStorage interface {
+	AddWatcher(*TicketWatcher) (bool, error)
+	RemoveWatcher(uint) error
+	GetWatcherByID(uint) (*TicketWatcher, error)
+	GetWatchers(uint) (*[]TicketWatcher, error)
+}
+
+// WatcherDBModel handles database operations for TicketWatcher
+type WatcherDBModel struct {
+	DB *gorm.DB
+}
+
+// NewWatcherDBModel creates a new instance of WatcherDBModel
+func NewWatcherDBModel(db *gorm.DB) *WatcherDBModel {
+	return &WatcherDBModel{
+		DB: db,
+	}
+}
+
+// AddWatcher adds a watcher to a ticket unless it already watches it, in
+// which case the existing watcher is loaded into watcher and false is
+// reported.
+func (as *WatcherDBModel) AddWatcher(watcher *TicketWatcher) (bool, error) {
+	var existing TicketWatcher
+	err := as.DB.Where("ticket_id = ? AND watcher_type = ? AND recipient_id = ? AND email = ?",
+		watcher.TicketID, watcher.WatcherType, watcher.RecipientID, watcher.Email).First(&existing).Error
+	if err == nil {
+		*watcher = existing
+		return false, nil
+	}
+	if err != gorm.ErrRecordNotFound {
+		return false, err
+	}
+	return true, as.DB.Create(watcher).Error
+}
+
+// RemoveWatcher removes a watcher. The row is deleted for good so the same
+// watcher can be added again.
+func (as *WatcherDBModel) RemoveWatcher(id uint) error {
+	return as.DB.Unscoped().Delete(&TicketWatcher{}, id).Error
+}
+
+// GetWatcherByID retrieves a watcher by its ID.
+func (as *WatcherDBModel) GetWatcherByID(id uint) (*TicketWatcher, error) {
+	var watcher TicketWatcher
+	err := as.DB.Where("id = ?", id).First(&watcher).Error
+	return &watcher, err
+}
+
+// GetWatchers retrieves the watchers of a ticket in the order they were added.
+func (as *WatcherDBModel) GetWatchers(ticketID uint) (*[]TicketWatcher, error) {
+	var watchers []TicketWatcher
+	err := as.DB.Where("ticket_id = ?", ticketID).Order("id").Find(&watchers).Error
+	return &watchers, err
+}
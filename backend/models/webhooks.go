@@ -0,0 +1,191 @@
+// backend/models/webhooks.go
+
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// WebhookSubscription sends the events it lists to a URL. The secret keys
+// the HMAC signature of every request.
+type WebhookSubscription struct {
+	gorm.Model
+	ID        uint      `gorm:"primaryKey" json:"webhook_id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events" gorm:"serializer:json"`
+	Secret    string    `json:"secret,omitempty"`
+	Active    bool      `json:"active"`
+	CreatedBy uint      `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName sets the table name for the WebhookSubscription model.
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// WildcardEvent subscribes to every event.
+const WildcardEvent = "*"
+
+// Subscribes reports whether the subscription wants an event.
+func (ws *WebhookSubscription) Subscribes(event string) bool {
+	for _, e := range ws.Events {
+		if e == event || e == WildcardEvent {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is one event sent, or to be sent, to a subscription. It
+// doubles as the delivery log.
+type WebhookDelivery struct {
+	gorm.Model
+	ID             uint       `gorm:"primaryKey" json:"delivery_id"`
+	SubscriptionID uint       `json:"webhook_id" gorm:"index"`
+	EventID        string     `json:"event_id" gorm:"index"`
+	Event          string     `json:"event"`
+	Payload        string     `json:"payload"`
+	Status         string     `json:"status" gorm:"index"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"index"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	ReplayOf       uint       `json:"replay_of,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// TableName sets the table name for the WebhookDelivery model.
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// Delivery states of a WebhookDelivery.
+const (
+	WebhookStatusPending   = "pending"
+	WebhookStatusSending   = "sending"
+	WebhookStatusDelivered = "delivered"
+	WebhookStatusFailed    = "failed"
+)
+
+type WebhookStorage interface {
+	CreateSubscription(*WebhookSubscription) error
+	UpdateSubscription(*WebhookSubscription) error
+	DeleteSubscription(uint) error
+	GetSubscriptionByID(uint) (*WebhookSubscription, error)
+	GetAllSubscriptions() (*[]WebhookSubscription, error)
+	GetActiveSubscriptions() (*[]WebhookSubscription, error)
+	CreateDelivery(*WebhookDelivery) error
+	UpdateDelivery(*WebhookDelivery) error
+	GetDeliveryByID(uint) (*WebhookDelivery, error)
+	GetDueDeliveries(time.Time, time.Time, int) (*[]WebhookDelivery, error)
+	ClaimDelivery(uint, time.Time) (bool, error)
+	GetDeliveriesBySubscriptionID(uint, int) (*[]WebhookDelivery, error)
+	HasDelivery(uint, string) (bool, error)
+}
+
+// WebhookDBModel handles database operations for webhooks
+type WebhookDBModel struct {
+	DB *gorm.DB
+}
+
+// NewWebhookDBModel creates a new instance of WebhookDBModel
+func NewWebhookDBModel(db *gorm.DB) *WebhookDBModel {
+	return &WebhookDBModel{
+		DB: db,
+	}
+}
+
+// CreateSubscription creates a new webhook subscription.
+func (as *WebhookDBModel) CreateSubscription(subscription *WebhookSubscription) error {
+	return as.DB.Create(subscription).Error
+}
+
+// UpdateSubscription updates the details of an existing subscription.
+func (as *WebhookDBModel) UpdateSubscription(subscription *WebhookSubscription) error {
+	return as.DB.Save(subscription).Error
+}
+
+// DeleteSubscription deletes a subscription by its ID.
+func (as *WebhookDBModel) DeleteSubscription(id uint) error {
+	return as.DB.Delete(&WebhookSubscription{}, id).Error
+}
+
+// GetSubscriptionByID retrieves a subscription by its ID.
+func (as *WebhookDBModel) GetSubscriptionByID(id uint) (*WebhookSubscription, error) {
+	var subscription WebhookSubscription
+	err := as.DB.Where("id = ?", id).First(&subscription).Error
+	return &subscription, err
+}
+
+// GetAllSubscriptions retrieves all subscriptions.
+func (as *WebhookDBModel) GetAllSubscriptions() (*[]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	err := as.DB.Order("id").Find(&subscriptions).Error
+	return &subscriptions, err
+}
+
+// GetActiveSubscriptions retrieves the subscriptions that receive events.
+func (as *WebhookDBModel) GetActiveSubscriptions() (*[]WebhookSubscription, error) {
+	var subscriptions []WebhookSubscription
+	err := as.DB.Where("active = ?", true).Order("id").Find(&subscriptions).Error
+	return &subscriptions, err
+}
+
+// CreateDelivery queues a delivery.
+func (as *WebhookDBModel) CreateDelivery(delivery *WebhookDelivery) error {
+	return as.DB.Create(delivery).Error
+}
+
+// UpdateDelivery updates the details of an existing delivery.
+func (as *WebhookDBModel) UpdateDelivery(delivery *WebhookDelivery) error {
+	return as.DB.Save(delivery).Error
+}
+
+// GetDeliveryByID retrieves a delivery by its ID.
+func (as *WebhookDBModel) GetDeliveryByID(id uint) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	err := as.DB.Where("id = ?", id).First(&delivery).Error
+	return &delivery, err
+}
+
+// GetDueDeliveries retrieves pending deliveries whose next attempt is due,
+// and deliveries stuck in sending since before staleBefore.
+func (as *WebhookDBModel) GetDueDeliveries(now, staleBefore time.Time, limit int) (*[]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := as.DB.Where("(status = ? AND next_attempt_at <= ?) OR (status = ? AND updated_at < ?)",
+		WebhookStatusPending, now, WebhookStatusSending, staleBefore).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	return &deliveries, err
+}
+
+// ClaimDelivery marks a due delivery as being sent, so that only one worker
+// sends it.
+func (as *WebhookDBModel) ClaimDelivery(id uint, staleBefore time.Time) (bool, error) {
+	res := as.DB.Model(&WebhookDelivery{}).
+		Where("id = ? AND (status = ? OR (status = ? AND updated_at < ?))", id, WebhookStatusPending, WebhookStatusSending, staleBefore).
+		Updates(map[string]interface{}{"status": WebhookStatusSending, "updated_at": time.Now()})
+	return res.RowsAffected == 1, res.Error
+}
+
+// GetDeliveriesBySubscriptionID retrieves the most recent deliveries of a subscription.
+func (as *WebhookDBModel) GetDeliveriesBySubscriptionID(subscriptionID uint, limit int) (*[]WebhookDelivery, error) {
+	var deliveries []WebhookDelivery
+	err := as.DB.Where("subscription_id = ?", subscriptionID).Order("id desc").Limit(limit).Find(&deliveries).Error
+	return &deliveries, err
+}
+
+// HasDelivery reports whether an event was already queued for a subscription.
+func (as *WebhookDBModel) HasDelivery(subscriptionID uint, eventID string) (bool, error) {
+	var count int64
+	err := as.DB.Model(&WebhookDelivery{}).
+		Where("subscription_id = ? AND event_id = ? AND replay_of = 0", subscriptionID, eventID).Count(&count).Error
+	return count > 0, err
+}
@@ -0,0 +1,44 @@
+// backend/notify/address.go
+
+package notify
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+// ReplyAddress returns the plus-addressed variant of a mailbox that routes
+// replies to a ticket, e.g. support+ticket-42@example.com. The mailbox may
+// include a display name, which is kept.
+func ReplyAddress(mailbox string, ticketID uint) string {
+	addr, err := mail.ParseAddress(mailbox)
+	if err != nil {
+		return mailbox
+	}
+	at := strings.LastIndex(addr.Address, "@")
+	if at < 0 {
+		return mailbox
+	}
+	local := addr.Address[:at]
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	addr.Address = fmt.Sprintf("%s+ticket-%d%s", local, ticketID, addr.Address[at:])
+	return addr.String()
+}
+
+// ThreadID returns the Message-ID every notification about a ticket refers
+// to, so mail clients show them as one conversation.
+func ThreadID(from string, ticketID uint) string {
+	return fmt.Sprintf("<ticket-%d@%s>", ticketID, domainOf(from))
+}
+
+func domainOf(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		if i := strings.LastIndex(addr.Address, "@"); i >= 0 {
+			return addr.Address[i+1:]
+		}
+	}
+	return "localhost"
+}
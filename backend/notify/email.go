@@ -0,0 +1,241 @@
+// backend/notify/email.go
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Email is a rendered message ready to be sent.
+type Email struct {
+	From    string
+	To      []string
+	Cc      []string
+	ReplyTo string
+	Subject string
+	Text    string
+	HTML    string
+	// Headers are added verbatim, e.g. Message-ID, In-Reply-To and References.
+	Headers map[string]string
+}
+
+// EmailSender delivers email.
+type EmailSender interface {
+	SendEmail(ctx context.Context, email *Email) error
+}
+
+// SMTP security modes.
+const (
+	SMTPSecurityNone     = "none"
+	SMTPSecuritySTARTTLS = "starttls"
+	SMTPSecurityTLS      = "tls"
+)
+
+// SMTPConfig holds the settings of an SMTP relay. A local sink such as
+// MailHog or Mailpit works with Security "none" and no credentials.
+type SMTPConfig struct {
+	Host     string        `json:"host"`
+	Port     int           `json:"port"`
+	Username string        `json:"username"`
+	Password string        `json:"password"`
+	From     string        `json:"from"`
+	Security string        `json:"security"`
+	Timeout  time.Duration `json:"timeout"`
+}
+
+// SMTPSender sends email through an SMTP relay.
+type SMTPSender struct {
+	Config SMTPConfig
+}
+
+// NewSMTPSender creates a new SMTPSender.
+func NewSMTPSender(config SMTPConfig) *SMTPSender {
+	if config.Timeout == 0 {
+		config.Timeout = 30 * time.Second
+	}
+	if config.Security == "" {
+		config.Security = SMTPSecuritySTARTTLS
+	}
+	return &SMTPSender{
+		Config: config,
+	}
+}
+
+// SendEmail delivers one message to all of its recipients.
+func (ss *SMTPSender) SendEmail(ctx context.Context, email *Email) error {
+	if email.From == "" {
+		email.From = ss.Config.From
+	}
+	from, err := mail.ParseAddress(email.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender %q: %w", email.From, err)
+	}
+	body, err := BuildMIME(email)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(ss.Config.Host, strconv.Itoa(ss.Config.Port))
+	dialer := &net.Dialer{Timeout: ss.Config.Timeout}
+	var conn net.Conn
+	if ss.Config.Security == SMTPSecurityTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: ss.Config.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	conn.SetDeadline(time.Now().Add(ss.Config.Timeout))
+
+	c, err := smtp.NewClient(conn, ss.Config.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+
+	if ss.Config.Security == SMTPSecuritySTARTTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("smtp: server does not support STARTTLS")
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: ss.Config.Host}); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+	if ss.Config.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", ss.Config.Username, ss.Config.Password, ss.Config.Host)); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	for _, to := range append(append([]string{}, email.To...), email.Cc...) {
+		rcpt, err := mail.ParseAddress(to)
+		if err != nil {
+			return fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("smtp: %w", err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return c.Quit()
+}
+
+// BuildMIME renders an Email as an RFC 5322 message with a
+// multipart/alternative body when both text and HTML are present.
+func BuildMIME(email *Email) ([]byte, error) {
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", email.From)
+	header.Set("To", strings.Join(email.To, ", "))
+	if len(email.Cc) > 0 {
+		header.Set("Cc", strings.Join(email.Cc, ", "))
+	}
+	if email.ReplyTo != "" {
+		header.Set("Reply-To", email.ReplyTo)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", email.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+	header.Set("Auto-Submitted", "auto-generated")
+	for k, v := range email.Headers {
+		header.Set(k, v)
+	}
+	if header.Get("Message-Id") == "" {
+		header.Set("Message-Id", NewMessageID(email.From, "notification"))
+	}
+
+	if email.HTML == "" {
+		header.Set("Content-Type", "text/plain; charset=utf-8")
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+		writeHeader(&buf, header)
+		if err := writeQP(&buf, email.Text); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQP(pw, part.content); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	header.Set("Content-Type", "multipart/alternative; boundary="+mw.Boundary())
+	writeHeader(&buf, header)
+	buf.Write(body.Bytes())
+	return buf.Bytes(), nil
+}
+
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+	buf.WriteString("\r\n")
+}
+
+// writeQP writes s quoted-printable encoded; line endings become CRLF.
+func writeQP(w io.Writer, s string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(s)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+// NewMessageID returns a unique Message-ID in the domain of the from address.
+func NewMessageID(from, prefix string) string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("<%s.%s@%s>", prefix, hex.EncodeToString(b), domainOf(from))
+}
@@ -0,0 +1,97 @@
+package notify
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/inbound"
+)
+
+func TestBuildMIME(t *testing.T) {
+	tests := []struct {
+		name  string
+		email Email
+		text  string
+	}{
+		{
+			name: "text only",
+			email: Email{
+				From:    "Support <support@example.com>",
+				To:      []string{"ann@example.com"},
+				Subject: "[Ticket #42] Café machine",
+				Text:    "The café machine is fixed.\n" + strings.Repeat("long line ", 20),
+			},
+			text: "The café machine is fixed.\r\n" + strings.Repeat("long line ", 20),
+		},
+		{
+			name: "text and html",
+			email: Email{
+				From:    "Support <support@example.com>",
+				To:      []string{"ann@example.com", "bob@example.com"},
+				Cc:      []string{"carol@example.com"},
+				ReplyTo: "support+ticket-42@example.com",
+				Subject: "[Ticket #42] Café machine",
+				Text:    "Fixed.",
+				HTML:    "<p>Fixed.</p>",
+				Headers: map[string]string{"Message-Id": "<reply-1@example.com>", "In-Reply-To": "<ticket-42@example.com>"},
+			},
+			text: "Fixed.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := BuildMIME(&tt.email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(raw, []byte("Auto-Submitted: auto-generated\r\n")) {
+				t.Error("notification not marked as auto-generated")
+			}
+			m, err := inbound.Parse(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.Subject != tt.email.Subject {
+				t.Errorf("subject = %q, want %q", m.Subject, tt.email.Subject)
+			}
+			if m.TextBody != tt.text {
+				t.Errorf("text = %q, want %q", m.TextBody, tt.text)
+			}
+			if m.HTMLBody != tt.email.HTML {
+				t.Errorf("html = %q, want %q", m.HTMLBody, tt.email.HTML)
+			}
+			if len(m.To) != len(tt.email.To) || len(m.Cc) != len(tt.email.Cc) {
+				t.Errorf("recipients = %v cc %v", m.To, m.Cc)
+			}
+			if m.MessageID == "" || !strings.HasSuffix(m.MessageID, "@example.com") {
+				t.Errorf("message id = %q", m.MessageID)
+			}
+			if id, ok := tt.email.Headers["In-Reply-To"]; ok && "<"+m.InReplyTo+">" != id {
+				t.Errorf("in-reply-to = %q, want %q", m.InReplyTo, id)
+			}
+		})
+	}
+}
+
+func TestReplyAddress(t *testing.T) {
+	tests := []struct {
+		mailbox string
+		want    string
+	}{
+		{"support@example.com", "<support+ticket-42@example.com>"},
+		{"Help Desk <support@example.com>", `"Help Desk" <support+ticket-42@example.com>`},
+		{"support+old@example.com", "<support+ticket-42@example.com>"},
+		{"not an address", "not an address"},
+	}
+	for _, tt := range tests {
+		if got := ReplyAddress(tt.mailbox, 42); got != tt.want {
+			t.Errorf("ReplyAddress(%q) = %q, want %q", tt.mailbox, got, tt.want)
+		}
+	}
+	// Replies to the notification thread lead back to the ticket.
+	msg := &inbound.Message{InReplyTo: strings.Trim(ThreadID("Help Desk <support@example.com>", 42), "<>")}
+	if id, ok := inbound.TicketReferenceFromThread(msg); !ok || id != 42 {
+		t.Errorf("thread %q refers to ticket %d", msg.InReplyTo, id)
+	}
+}
@@ -0,0 +1,121 @@
+// backend/notify/gateway.go
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// GatewayConfig holds the settings of an HTTP messaging gateway.
+type GatewayConfig struct {
+	// Channel is the name of the channel, "sms" or "whatsapp".
+	Channel string `json:"channel"`
+	// URL receives the messages.
+	URL   string `json:"url"`
+	Token string `json:"token"`
+	// From is the sender number or ID registered with the provider.
+	From string `json:"from"`
+	// CallbackURL is passed to the gateway as the address for delivery
+	// receipts.
+	CallbackURL string        `json:"callback_url"`
+	Timeout     time.Duration `json:"timeout"`
+}
+
+// HTTPGateway sends text messages through an HTTP gateway. Every message is
+// POSTed as JSON:
+//
+//	{"channel": "sms", "from": "+15550100", "to": "+15550123",
+//	 "body": "...", "status_callback": "https://desk/..."}
+//
+// with the token as a bearer credential. The gateway answers with a 2xx
+// status and {"id": "<message id>"}. Most SMS and WhatsApp providers, or a
+// small adapter in front of them, can serve this interface, and a fake
+// gateway for local testing is a few lines of code.
+type HTTPGateway struct {
+	Config GatewayConfig
+	Client *http.Client
+}
+
+// NewHTTPGateway creates a new HTTPGateway.
+func NewHTTPGateway(config GatewayConfig) *HTTPGateway {
+	if config.Channel == "" {
+		config.Channel = "sms"
+	}
+	if config.Timeout == 0 {
+		config.Timeout = 15 * time.Second
+	}
+	return &HTTPGateway{
+		Config: config,
+		Client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Name identifies the channel in notifications and preferences.
+func (hg *HTTPGateway) Name() string {
+	return hg.Config.Channel
+}
+
+type gatewayRequest struct {
+	Channel        string `json:"channel"`
+	From           string `json:"from,omitempty"`
+	To             string `json:"to"`
+	Body           string `json:"body"`
+	StatusCallback string `json:"status_callback,omitempty"`
+}
+
+type gatewayResponse struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// SendText delivers one message. Rejections by the gateway other than rate
+// limiting are permanent errors.
+func (hg *HTTPGateway) SendText(ctx context.Context, msg *TextMessage) (string, error) {
+	if !ValidE164(msg.To) {
+		return "", &PermanentError{Err: fmt.Errorf("%s: invalid phone number %q", hg.Config.Channel, msg.To)}
+	}
+	payload, err := json.Marshal(gatewayRequest{
+		Channel:        hg.Config.Channel,
+		From:           hg.Config.From,
+		To:             msg.To,
+		Body:           msg.Body,
+		StatusCallback: hg.Config.CallbackURL,
+	})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hg.Config.URL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if hg.Config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+hg.Config.Token)
+	}
+	resp, err := hg.Client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", hg.Config.Channel, err)
+	}
+	defer resp.Body.Close()
+
+	var out gatewayResponse
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	json.Unmarshal(body, &out)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		if out.Error == "" {
+			out.Error = http.StatusText(resp.StatusCode)
+		}
+		err := fmt.Errorf("%s: gateway returned %d: %s", hg.Config.Channel, resp.StatusCode, out.Error)
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			return "", &PermanentError{Err: err}
+		}
+		return "", err
+	}
+	return out.ID, nil
+}
@@ -0,0 +1,94 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestValidE164(t *testing.T) {
+	tests := []struct {
+		phone string
+		valid bool
+	}{
+		{"+442071234567", true},
+		{"+15550100", true},
+		{"442071234567", false},
+		{"+0442071234567", false},
+		{"+44 20 7123 4567", false},
+		{"+1234567890123456", false},
+		{"+1", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidE164(tt.phone); got != tt.valid {
+			t.Errorf("ValidE164(%q) = %v, want %v", tt.phone, got, tt.valid)
+		}
+	}
+}
+
+func TestHTTPGatewaySendText(t *testing.T) {
+	var got gatewayRequest
+	var auth string
+	status, reply := http.StatusOK, `{"id":"msg-1"}`
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(status)
+		w.Write([]byte(reply))
+	}))
+	defer gw.Close()
+	hg := NewHTTPGateway(GatewayConfig{Channel: "whatsapp", URL: gw.URL, Token: "token", From: "+15550100", CallbackURL: "https://desk/receipts"})
+
+	tests := []struct {
+		name      string
+		to        string
+		status    int
+		reply     string
+		id        string
+		wantErr   bool
+		permanent bool
+	}{
+		{"delivered", "+15550123", http.StatusOK, `{"id":"msg-1"}`, "msg-1", false, false},
+		{"invalid number", "555-0123", http.StatusOK, `{"id":"msg-2"}`, "", true, true},
+		{"rejected", "+15550123", http.StatusBadRequest, `{"error":"unknown recipient"}`, "", true, true},
+		{"rate limited", "+15550123", http.StatusTooManyRequests, "", "", true, false},
+		{"gateway down", "+15550123", http.StatusBadGateway, "", "", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, reply = tt.status, tt.reply
+			id, err := hg.SendText(context.Background(), &TextMessage{To: tt.to, Body: "Ticket #42 updated"})
+			if (err != nil) != tt.wantErr || IsPermanent(err) != tt.permanent {
+				t.Fatalf("err = %v, want error %v permanent %v", err, tt.wantErr, tt.permanent)
+			}
+			if id != tt.id {
+				t.Fatalf("id = %q, want %q", id, tt.id)
+			}
+		})
+	}
+	want := gatewayRequest{Channel: "whatsapp", From: "+15550100", To: "+15550123", Body: "Ticket #42 updated", StatusCallback: "https://desk/receipts"}
+	if got != want || auth != "Bearer token" {
+		t.Fatalf("gateway received %+v with %q", got, auth)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	rl := NewRateLimiter(3, 300*time.Millisecond)
+	for i := 0; i < 3; i++ {
+		if wait := rl.Reserve(); wait != 0 {
+			t.Fatalf("send %d of the burst waits %v", i+1, wait)
+		}
+	}
+	wait := rl.Reserve()
+	if wait <= 0 || wait > 100*time.Millisecond {
+		t.Fatalf("send after the burst waits %v, want up to 100ms", wait)
+	}
+	time.Sleep(wait)
+	if wait := rl.Reserve(); wait != 0 {
+		t.Fatalf("send after waiting still waits %v", wait)
+	}
+}
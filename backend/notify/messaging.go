@@ -0,0 +1,51 @@
+// backend/notify/messaging.go
+
+package notify
+
+import (
+	"context"
+	"errors"
+	"regexp"
+)
+
+// TextMessage is a short message for a phone number.
+type TextMessage struct {
+	To   string
+	Body string
+}
+
+// MessagingChannel delivers text messages, for example by SMS or WhatsApp.
+type MessagingChannel interface {
+	// Name identifies the channel in notifications and preferences.
+	Name() string
+	// SendText delivers a message and returns the provider's message ID,
+	// which delivery receipts refer to.
+	SendText(ctx context.Context, msg *TextMessage) (string, error)
+}
+
+// PermanentError marks a delivery failure that retrying cannot fix, such as
+// an invalid recipient.
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent reports whether err is a PermanentError.
+func IsPermanent(err error) bool {
+	var pe *PermanentError
+	return errors.As(err, &pe)
+}
+
+var e164Pattern = regexp.MustCompile(`^\+[1-9]\d{1,14}$`)
+
+// ValidE164 reports whether phone is an E.164 number such as +442071234567.
+func ValidE164(phone string) bool {
+	return e164Pattern.MatchString(phone)
+}
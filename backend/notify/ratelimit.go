@@ -0,0 +1,49 @@
+// backend/notify/ratelimit.go
+
+package notify
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket allowing limit sends per period, with
+// bursts of up to limit.
+type RateLimiter struct {
+	mu       sync.Mutex
+	limit    float64
+	interval time.Duration
+	tokens   float64
+	last     time.Time
+}
+
+// NewRateLimiter creates a RateLimiter allowing limit sends per period.
+func NewRateLimiter(limit int, period time.Duration) *RateLimiter {
+	if limit < 1 {
+		limit = 1
+	}
+	return &RateLimiter{
+		limit:    float64(limit),
+		interval: period / time.Duration(limit),
+		tokens:   float64(limit),
+		last:     time.Now(),
+	}
+}
+
+// Reserve takes a token if one is available and returns zero. Otherwise it
+// takes nothing and returns how long to wait for the next token.
+func (rl *RateLimiter) Reserve() time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	rl.tokens += float64(now.Sub(rl.last)) / float64(rl.interval)
+	if rl.tokens > rl.limit {
+		rl.tokens = rl.limit
+	}
+	rl.last = now
+	if rl.tokens >= 1 {
+		rl.tokens--
+		return 0
+	}
+	return time.Duration((1 - rl.tokens) * float64(rl.interval))
+}
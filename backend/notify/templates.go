@@ -0,0 +1,138 @@
+// backend/notify/templates.go
+
+package notify
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates/*.tmpl
+var defaultTemplates embed.FS
+
+// Rendered is the output of a template for one recipient.
+type Rendered struct {
+	Subject string
+	Text    string
+	HTML    string
+	// Short is the body of SMS and WhatsApp messages.
+	Short string
+}
+
+// Templates renders notification messages. Every event has one file named
+// after it with ".", "-" replaced by "_" (ticket.created -> ticket_created.tmpl).
+// Email needs a "subject" and a "text" template and may add an "html" one;
+// text messages use a "short" template. Events without a "short" template
+// are not sent by SMS or WhatsApp, and events with only a "short" template
+// are not sent by email. Subject, text and short are rendered with
+// text/template, html with html/template so placeholder values are escaped.
+type Templates struct {
+	text map[string]*texttemplate.Template
+	html map[string]*htmltemplate.Template
+}
+
+// DefaultTemplates returns the templates shipped with the service desk.
+func DefaultTemplates() *Templates {
+	t, err := LoadTemplates(defaultTemplates)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// LoadTemplates parses every templates/*.tmpl file of fsys. Use it with
+// os.DirFS to override the defaults without rebuilding.
+func LoadTemplates(fsys fs.FS) (*Templates, error) {
+	files, err := fs.Glob(fsys, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	t := &Templates{
+		text: map[string]*texttemplate.Template{},
+		html: map[string]*htmltemplate.Template{},
+	}
+	for _, f := range files {
+		src, err := fs.ReadFile(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(f, "templates/"), ".tmpl")
+		tt, err := texttemplate.New(name).Parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", f, err)
+		}
+		ht, err := htmltemplate.New(name).Parse(string(src))
+		if err != nil {
+			return nil, fmt.Errorf("template %s: %w", f, err)
+		}
+		t.text[name] = tt
+		t.html[name] = ht
+	}
+	return t, nil
+}
+
+// Has reports whether a template exists for an event.
+func (t *Templates) Has(event string) bool {
+	_, ok := t.text[templateName(event)]
+	return ok
+}
+
+// HasEmail reports whether an event can be sent by email.
+func (t *Templates) HasEmail(event string) bool {
+	tt, ok := t.text[templateName(event)]
+	return ok && tt.Lookup("subject") != nil && tt.Lookup("text") != nil
+}
+
+// HasShort reports whether an event can be sent as a text message.
+func (t *Templates) HasShort(event string) bool {
+	tt, ok := t.text[templateName(event)]
+	return ok && tt.Lookup("short") != nil
+}
+
+// Render executes the templates of an event with data.
+func (t *Templates) Render(event string, data interface{}) (*Rendered, error) {
+	name := templateName(event)
+	tt, ok := t.text[name]
+	if !ok {
+		return nil, fmt.Errorf("no template for event %q", event)
+	}
+	var out Rendered
+	var buf bytes.Buffer
+	if tt.Lookup("subject") != nil {
+		if err := tt.ExecuteTemplate(&buf, "subject", data); err != nil {
+			return nil, err
+		}
+		out.Subject = strings.Join(strings.Fields(buf.String()), " ")
+	}
+	if tt.Lookup("text") != nil {
+		buf.Reset()
+		if err := tt.ExecuteTemplate(&buf, "text", data); err != nil {
+			return nil, err
+		}
+		out.Text = strings.TrimSpace(buf.String()) + "\n"
+	}
+	if tt.Lookup("short") != nil {
+		buf.Reset()
+		if err := tt.ExecuteTemplate(&buf, "short", data); err != nil {
+			return nil, err
+		}
+		out.Short = strings.Join(strings.Fields(buf.String()), " ")
+	}
+	if ht := t.html[name]; ht.Lookup("html") != nil {
+		buf.Reset()
+		if err := ht.ExecuteTemplate(&buf, "html", data); err != nil {
+			return nil, err
+		}
+		out.HTML = buf.String()
+	}
+	return &out, nil
+}
+
+func templateName(event string) string {
+	return strings.NewReplacer(".", "_", "-", "_").Replace(event)
+}
@@ -0,0 +1,63 @@
+package notify
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplatesRender(t *testing.T) {
+	templates, err := LoadTemplates(fstest.MapFS{
+		"templates/ticket_created.tmpl": {Data: []byte(`{{define "subject"}}  [#{{.ID}}]
+ {{.Subject}} {{end}}{{define "text"}}
+Subject: {{.Subject}}
+{{end}}{{define "html"}}<p>{{.Subject}}</p>{{end}}`)},
+		"templates/ticket_assigned.tmpl": {Data: []byte(`{{define "short"}}Ticket #{{.ID}}
+ assigned to you{{end}}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := struct {
+		ID      uint
+		Subject string
+	}{42, "<b>Printer</b> & scanner"}
+
+	tests := []struct {
+		event       string
+		email, text bool
+		want        Rendered
+	}{
+		{"ticket.created", true, false, Rendered{
+			Subject: "[#42] <b>Printer</b> & scanner",
+			Text:    "Subject: <b>Printer</b> & scanner\n",
+			HTML:    "<p>&lt;b&gt;Printer&lt;/b&gt; &amp; scanner</p>",
+		}},
+		{"ticket.assigned", false, true, Rendered{Short: "Ticket #42 assigned to you"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.event, func(t *testing.T) {
+			if templates.HasEmail(tt.event) != tt.email || templates.HasShort(tt.event) != tt.text {
+				t.Fatalf("email %v text %v", templates.HasEmail(tt.event), templates.HasShort(tt.event))
+			}
+			got, err := templates.Render(tt.event, data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *got != tt.want {
+				t.Fatalf("got %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+	if _, err := templates.Render("ticket.resolved", data); err == nil {
+		t.Fatal("rendered an event without a template")
+	}
+}
+
+func TestDefaultTemplates(t *testing.T) {
+	templates := DefaultTemplates()
+	for _, event := range []string{"ticket.created", "ticket.assigned", "ticket.replied", "ticket.resolved"} {
+		if !templates.HasEmail(event) {
+			t.Errorf("no email template for %s", event)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetApprovalRoutes(r *gin.Engine, approvals *controllers.ApprovalController) {
+
+	// The links of approval emails are signed and work without a session.
+	a := r.Group("/approvals")
+	a.GET("/", middleware.AuthorizeRequest(), approvals.GetPendingApprovals)
+	a.GET("/:id/:decision", approvals.GetPrompt)
+	a.POST("/:id/:decision", unlessSigned(middleware.AuthorizeRequest()), approvals.Decide)
+
+	r.GET("/tickets/:id/approval", middleware.AuthorizeRequest(), approvals.GetTicketApproval)
+
+	c := r.Group("/admin/approval-chains", middleware.AuthorizeAdminRequest())
+	c.GET("/", approvals.GetChains)
+	c.POST("/", approvals.CreateChain)
+	c.GET("/:id", approvals.GetChain)
+	c.PUT("/:id", approvals.UpdateChain)
+	c.DELETE("/:id", approvals.DeleteChain)
+
+}
+
+// unlessSigned authorizes the requests that do not carry the signature of
+// an emailed link with authorize; signed requests are checked by their
+// handler.
+func unlessSigned(authorize gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Query("signature") != "" {
+			c.Next()
+			return
+		}
+		authorize(c)
+	}
+}
@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"github.com/shuttlersit/service-desk/backend/storage"
+)
+
+func TestApprovalsRequireSessionUnlessSigned(t *testing.T) {
+	db := openTestDB(t, &models.ApprovalRequest{}, &models.Approval{})
+	service := services.NewDefaultApprovalService(models.NewApprovalDBModel(db), models.NewUserDBModel(db), models.NewServiceCatalogDBModel(db), nil, nil, storage.NewURLSigner("secret"), services.ApprovalConfig{})
+	r := newTestRouter(t)
+	SetApprovalRoutes(r, controllers.NewApprovalController(service))
+	expires := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie string
+		status int
+	}{
+		{"anonymous pending approvals", http.MethodGet, "/approvals/", "", http.StatusUnauthorized},
+		{"anonymous decision", http.MethodPost, "/approvals/1/approve", "", http.StatusUnauthorized},
+		{"anonymous ticket approval", http.MethodGet, "/tickets/1/approval", "", http.StatusUnauthorized},
+		{"user pending approvals", http.MethodGet, "/approvals/", loginUser(t, r, 1), http.StatusOK},
+		{"user decision", http.MethodPost, "/approvals/1/approve", loginUser(t, r, 1), http.StatusNotFound},
+		{"agent ticket approval", http.MethodGet, "/tickets/1/approval", login(t, r, 1), http.StatusNotFound},
+		{"signed link prompt", http.MethodGet, "/approvals/1/approve?expires=" + expires + "&signature=forged", "", http.StatusForbidden},
+		{"signed link decision", http.MethodPost, "/approvals/1/approve?expires=" + expires + "&signature=forged", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.cookie, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
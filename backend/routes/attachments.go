@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetAttachmentRoutes(r *gin.Engine, attachments *controllers.AttachmentController) {
+
+	t := r.Group("/tickets/:id/attachments", middleware.AuthorizeRequest())
+	t.GET("/", attachments.GetAttachments)
+	t.POST("/", attachments.UploadAttachments)
+	t.DELETE("/:attachment_id", attachments.DeleteAttachment)
+
+	a := r.Group("/attachments")
+	a.GET("/:id/download", attachments.DownloadAttachment)
+	a.GET("/:id/variants/:name", attachments.DownloadVariant)
+
+	admin := r.Group("/admin/attachments", middleware.AuthorizeAdminRequest())
+	admin.GET("/quarantine", attachments.GetQuarantinedAttachments)
+	admin.POST("/:id/release", attachments.ReleaseAttachment)
+	admin.DELETE("/:id", attachments.PurgeAttachment)
+
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"github.com/shuttlersit/service-desk/backend/storage"
+)
+
+func TestTicketAttachmentsRequireSession(t *testing.T) {
+	db := openTestDB(t, &models.TicketMediaAttachment{}, &models.TicketAttachmentVariant{})
+	service := services.NewDefaultAttachmentService(models.NewAttachmentDBModel(db), models.NewTicketDBModel(db), nil, storage.NewURLSigner("secret"), nil, services.AttachmentConfig{})
+	r := newTestRouter(t)
+	SetAttachmentRoutes(r, controllers.NewAttachmentController(service))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie string
+		status int
+	}{
+		{"anonymous list", http.MethodGet, "/tickets/1/attachments/", "", http.StatusUnauthorized},
+		{"anonymous upload", http.MethodPost, "/tickets/1/attachments/", "", http.StatusUnauthorized},
+		{"anonymous delete", http.MethodDelete, "/tickets/1/attachments/1", "", http.StatusUnauthorized},
+		{"user list", http.MethodGet, "/tickets/1/attachments/", loginUser(t, r, 1), http.StatusOK},
+		{"agent list", http.MethodGet, "/tickets/1/attachments/", login(t, r, 1), http.StatusOK},
+		{"unsigned download", http.MethodGet, "/attachments/1/download", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.cookie, "")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
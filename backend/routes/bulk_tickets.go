@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetBulkTicketRoutes(r *gin.Engine, bulk *controllers.BulkTicketController) {
+
+	b := r.Group("/tickets/bulk", middleware.AuthorizeAdminRequest())
+	b.POST("", bulk.SubmitBulkJob)
+	b.GET("", bulk.GetBulkJobs)
+	b.GET("/:id", bulk.GetBulkJob)
+	b.GET("/:id/results", bulk.GetBulkResults)
+
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetCannedResponseRoutes(r *gin.Engine, canned *controllers.CannedResponseController) {
+
+	c := r.Group("/canned-responses", middleware.AuthorizeAdminRequest())
+	c.GET("/", canned.GetResponses)
+	c.POST("/", canned.CreateResponse)
+	c.GET("/placeholders", canned.GetPlaceholders)
+	c.GET("/:id", canned.GetResponseByID)
+	c.PUT("/:id", canned.UpdateResponse)
+	c.DELETE("/:id", canned.DeleteResponse)
+	c.GET("/:id/render", canned.RenderResponse)
+
+	m := r.Group("/macros", middleware.AuthorizeAdminRequest())
+	m.GET("/", canned.GetMacros)
+	m.POST("/", canned.CreateMacro)
+	m.GET("/:id", canned.GetMacroByID)
+	m.PUT("/:id", canned.UpdateMacro)
+	m.DELETE("/:id", canned.DeleteMacro)
+	m.POST("/:id/apply", canned.ApplyMacro)
+
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestPersonalCannedResponsesBelongToSignedInAgent(t *testing.T) {
+	db := openTestDB(t, &models.Agents{}, &models.CannedResponse{})
+	for _, email := range []string{"ada@example.com", "bob@example.com"} {
+		if err := db.Create(&models.Agents{AgentEmail: email}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	service := services.NewDefaultCannedResponseService(models.NewCannedResponseDBModel(db), services.NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewAgentDBModel(db), models.NewUserDBModel(db))
+	r := newTestRouter(t)
+	SetCannedResponseRoutes(r, controllers.NewCannedResponseController(service))
+	owner, other := login(t, r, 1), login(t, r, 2)
+
+	w := serve(r, http.MethodPost, "/canned-responses/", owner, `{"title":"Hello","body":"Hi there","scope":"personal"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", w.Code, w.Body)
+	}
+	var created models.CannedResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.AgentID != 1 {
+		t.Fatalf("agent_id = %d, want 1", created.AgentID)
+	}
+
+	tests := []struct {
+		name   string
+		cookie string
+		status int
+		listed int
+	}{
+		{"owner", owner, http.StatusOK, 1},
+		{"another agent", other, http.StatusNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodGet, "/canned-responses/"+strconv.Itoa(int(created.ID)), tt.cookie, "")
+			if w.Code != tt.status {
+				t.Fatalf("get: status = %d, want %d", w.Code, tt.status)
+			}
+			var listed []models.CannedResponse
+			w = serve(r, http.MethodGet, "/canned-responses/", tt.cookie, "")
+			if err := json.Unmarshal(w.Body.Bytes(), &listed); err != nil {
+				t.Fatalf("list: %v: %s", err, w.Body)
+			}
+			if len(listed) != tt.listed {
+				t.Fatalf("listed %d responses, want %d", len(listed), tt.listed)
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetChangeRoutes(r *gin.Engine, changes *controllers.ChangeController) {
+
+	r.GET("/changes/calendar.ics", changes.GetCalendar)
+
+	c := r.Group("/changes", middleware.AuthorizeAdminRequest())
+	c.GET("/", changes.GetChanges)
+	c.POST("/", changes.CreateChange)
+	c.GET("/calendar/link", changes.GetCalendarLink)
+	c.GET("/:id", changes.GetChange)
+	c.PUT("/:id", changes.UpdateChange)
+	c.DELETE("/:id", changes.DeleteChange)
+	c.POST("/:id/submit", changes.SubmitChange)
+	c.POST("/:id/start", changes.StartChange)
+	c.POST("/:id/complete", changes.CompleteChange)
+	c.POST("/:id/fail", changes.FailChange)
+	c.POST("/:id/cancel", changes.CancelChange)
+	c.GET("/:id/conflicts", changes.GetConflicts)
+	c.GET("/:id/approval", changes.GetChangeApproval)
+
+	q := r.Group("/admin/change-risk-questions", middleware.AuthorizeAdminRequest())
+	q.GET("/", changes.GetQuestions)
+	q.POST("/", changes.CreateQuestion)
+	q.PUT("/:id", changes.UpdateQuestion)
+	q.DELETE("/:id", changes.DeleteQuestion)
+
+}
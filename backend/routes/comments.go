@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetCommentRoutes(r *gin.Engine, comments *controllers.CommentController) {
+
+	c := r.Group("/tickets/:id/comments", middleware.AuthorizeRequest())
+	c.GET("/", comments.GetComments)
+	c.POST("/", comments.CreateComment)
+	c.DELETE("/:comment_id", comments.DeleteComment)
+
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestCommentsAndWatchersRequireSession(t *testing.T) {
+	db := openTestDB(t, &models.TicketComment{}, &models.TicketWatcher{})
+	r := newTestRouter(t)
+	SetCommentRoutes(r, controllers.NewCommentController(services.NewDefaultCommentService(models.NewCommentDBModel(db), models.NewTicketDBModel(db))))
+	SetWatcherRoutes(r, controllers.NewWatcherController(services.NewDefaultWatcherService(models.NewWatcherDBModel(db), models.NewTicketDBModel(db), models.NewAgentDBModel(db), models.NewUserDBModel(db))))
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie string
+		body   string
+		status int
+	}{
+		{"anonymous comments", http.MethodGet, "/tickets/1/comments/", "", "", http.StatusUnauthorized},
+		{"anonymous comment", http.MethodPost, "/tickets/1/comments/", "", `{"body":"Hi","agent_id":1}`, http.StatusUnauthorized},
+		{"anonymous comment delete", http.MethodDelete, "/tickets/1/comments/1", "", "", http.StatusUnauthorized},
+		{"anonymous watchers", http.MethodGet, "/tickets/1/watchers", "", "", http.StatusUnauthorized},
+		{"anonymous watcher", http.MethodPost, "/tickets/1/watchers", "", `{"watcher_type":"cc","email":"a@example.com"}`, http.StatusUnauthorized},
+		{"anonymous watcher delete", http.MethodDelete, "/tickets/1/watchers/1", "", "", http.StatusUnauthorized},
+		{"user comments", http.MethodGet, "/tickets/1/comments/", loginUser(t, r, 1), "", http.StatusOK},
+		{"agent comments", http.MethodGet, "/tickets/1/comments/", login(t, r, 1), "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.cookie, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
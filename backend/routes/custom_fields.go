@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetCustomFieldRoutes(r *gin.Engine, customFields *controllers.CustomFieldController) {
+
+	r.GET("/custom-fields/form", customFields.GetForm)
+
+	f := r.Group("/admin/custom-fields", middleware.AuthorizeAdminRequest())
+	f.GET("/", customFields.GetFields)
+	f.POST("/", customFields.CreateField)
+	f.GET("/:id", customFields.GetField)
+	f.PUT("/:id", customFields.UpdateField)
+	f.DELETE("/:id", customFields.DeleteField)
+
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetEventRoutes(r *gin.Engine, events *controllers.EventController) {
+
+	e := r.Group("/admin/events", middleware.AuthorizeAdminRequest())
+	e.GET("/", events.GetEvents)
+	e.POST("/:id/retry", events.RetryEvent)
+
+}
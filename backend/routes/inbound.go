@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetInboundMailRoutes(r *gin.Engine, inbound *controllers.InboundMailController) {
+
+	i := r.Group("/inbound")
+	i.POST("/email", inbound.ReceiveEmail)
+
+	admin := r.Group("/admin/inbound", middleware.AuthorizeAdminRequest())
+	admin.GET("/emails", inbound.GetInboundEmails)
+
+}
@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetJobRoutes(r *gin.Engine, jobs *controllers.JobController) {
+
+	j := r.Group("/admin/jobs", middleware.AuthorizeAdminRequest())
+	j.GET("/", jobs.GetJobs)
+	j.GET("/schedules", jobs.GetSchedules)
+	j.GET("/:id", jobs.GetJobByID)
+	j.POST("/:id/retry", jobs.RetryJob)
+	j.POST("/:id/cancel", jobs.CancelJob)
+
+}
@@ -0,0 +1,38 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetKnowledgeRoutes(r *gin.Engine, knowledge *controllers.KnowledgeController) {
+
+	p := r.Group("/kb")
+	p.GET("/articles", knowledge.GetPublicArticles)
+	p.GET("/articles/:id", knowledge.GetPublicArticle)
+	p.POST("/articles/:id/vote", knowledge.PublicVote)
+	p.GET("/attachments/:id/download", knowledge.DownloadAttachment)
+
+	k := r.Group("/knowledge/articles", middleware.AuthorizeAdminRequest())
+	k.GET("/", knowledge.GetArticles)
+	k.POST("/", knowledge.CreateArticle)
+	k.GET("/:id", knowledge.GetArticle)
+	k.PUT("/:id", knowledge.UpdateArticle)
+	k.DELETE("/:id", knowledge.DeleteArticle)
+	k.GET("/:id/versions", knowledge.GetVersions)
+	k.GET("/:id/versions/:version", knowledge.GetVersion)
+	k.POST("/:id/submit", knowledge.SubmitArticle)
+	k.POST("/:id/publish", knowledge.PublishArticle)
+	k.POST("/:id/reject", knowledge.RejectArticle)
+	k.POST("/:id/archive", knowledge.ArchiveArticle)
+	k.POST("/:id/attachments", knowledge.UploadAttachments)
+	k.DELETE("/:id/attachments/:attachment_id", knowledge.DeleteAttachment)
+	k.POST("/:id/tickets", knowledge.LinkTicket)
+	k.DELETE("/:id/tickets/:ticketID", knowledge.UnlinkTicket)
+	k.POST("/:id/vote", knowledge.Vote)
+
+	r.GET("/tickets/:id/articles", middleware.AuthorizeAdminRequest(), knowledge.GetTicketArticles)
+	r.GET("/tickets/:id/articles/suggested", middleware.AuthorizeAdminRequest(), knowledge.SuggestArticles)
+
+}
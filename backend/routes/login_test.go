@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestLoginSignsInOnSession(t *testing.T) {
+	db := openTestDB(t, &models.Users{}, &models.UsersLoginCredentials{}, &models.Agents{}, &models.AgentLoginCredentials{})
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Users{ID: 4, Email: "ada@example.com", Credentials: models.UsersLoginCredentials{Password: string(hash)}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.Agents{ID: 4, AgentEmail: "bob@example.com", Credentials: models.AgentLoginCredentials{Password: string(hash)}}).Error; err != nil {
+		t.Fatal(err)
+	}
+	r := newTestRouter(t)
+	SetOpenRoutes(r, controllers.NewAuthController(services.NewDefaultAuthService(models.NewAuthDBModel(db))))
+	whoami := func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"user": c.GetUint("userID"), "agent": c.GetUint("agentID")})
+	}
+	r.GET("/whoami", middleware.AuthorizeRequest(), whoami)
+	r.GET("/admin/whoami", middleware.AuthorizeAdminRequest(), whoami)
+
+	tests := []struct {
+		name   string
+		path   string
+		body   string
+		status int
+		probe  string
+		seen   string
+	}{
+		{"user", "/login", `{"email":"ada@example.com","password":"s3cret"}`, http.StatusOK, "/whoami", `{"agent":0,"user":4}`},
+		{"agent", "/admin/login", `{"email":"bob@example.com","password":"s3cret"}`, http.StatusOK, "/whoami", `{"agent":4,"user":0}`},
+		{"user is no agent", "/login", `{"email":"ada@example.com","password":"s3cret"}`, http.StatusOK, "/admin/whoami", ""},
+		{"wrong password", "/login", `{"email":"ada@example.com","password":"guess"}`, http.StatusUnauthorized, "/whoami", ""},
+		{"unknown agent", "/admin/login", `{"email":"ada@example.com","password":"s3cret"}`, http.StatusUnauthorized, "/admin/whoami", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPost, tt.path, "", tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("login status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			w = serve(r, http.MethodGet, tt.probe, w.Header().Get("Set-Cookie"), "")
+			if tt.seen == "" {
+				if w.Code != http.StatusUnauthorized {
+					t.Fatalf("%s status = %d, want 401", tt.probe, w.Code)
+				}
+				return
+			}
+			if w.Code != http.StatusOK || w.Body.String() != tt.seen {
+				t.Fatalf("%s = %d %s, want %s", tt.probe, w.Code, w.Body, tt.seen)
+			}
+		})
+	}
+}
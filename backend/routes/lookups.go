@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetLookupRoutes(r *gin.Engine, lookups *controllers.LookupController) {
+
+	r.GET("/categories/tree", lookups.GetCategoryTree)
+
+	a := r.Group("/admin", middleware.AuthorizeAdminRequest())
+	a.GET("/categories", lookups.GetCategories)
+	a.POST("/categories", lookups.CreateCategory)
+	a.PUT("/categories/:id", lookups.UpdateCategory)
+	a.DELETE("/categories/:id", lookups.DeleteCategory)
+	a.GET("/sub-categories", lookups.GetSubCategories)
+	a.POST("/sub-categories", lookups.CreateSubCategory)
+	a.PUT("/sub-categories/:id", lookups.UpdateSubCategory)
+	a.DELETE("/sub-categories/:id", lookups.DeleteSubCategory)
+	a.GET("/priorities", lookups.GetPriorities)
+	a.POST("/priorities", lookups.CreatePriority)
+	a.PUT("/priorities/:id", lookups.UpdatePriority)
+	a.DELETE("/priorities/:id", lookups.DeletePriority)
+	a.GET("/statuses", lookups.GetStatuses)
+	a.POST("/statuses", lookups.CreateStatus)
+	a.PUT("/statuses/:id", lookups.UpdateStatus)
+	a.DELETE("/statuses/:id", lookups.DeleteStatus)
+	a.GET("/slas", lookups.GetSlas)
+	a.POST("/slas", lookups.CreateSla)
+	a.PUT("/slas/:id", lookups.UpdateSla)
+	a.DELETE("/slas/:id", lookups.DeleteSla)
+
+}
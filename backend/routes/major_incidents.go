@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetMajorIncidentRoutes(r *gin.Engine, incidents *controllers.MajorIncidentController) {
+
+	r.GET("/status", incidents.GetStatus)
+
+	m := r.Group("/major-incidents", middleware.AuthorizeAdminRequest())
+	m.GET("/", incidents.GetIncidents)
+	m.POST("/", incidents.DeclareIncident)
+	m.GET("/:id", incidents.GetIncident)
+	m.PUT("/:id", incidents.UpdateIncident)
+	m.POST("/:id/tickets", incidents.LinkTickets)
+	m.DELETE("/:id/tickets/:ticketID", incidents.UnlinkTicket)
+	m.POST("/:id/updates", incidents.Broadcast)
+
+	r.GET("/tickets/:id/major-incident", middleware.AuthorizeAdminRequest(), incidents.GetTicketIncident)
+
+}
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetNotificationRoutes(r *gin.Engine, notifications *controllers.NotificationController) {
+
+	p := r.Group("/", middleware.AuthorizeRequest())
+	p.GET("/users/:id/notification-preferences", notifications.GetUserPreferences)
+	p.PUT("/users/:id/notification-preferences", notifications.UpdateUserPreferences)
+
+	p.GET("/agents/:id/notification-preferences", notifications.GetAgentPreferences)
+	p.PUT("/agents/:id/notification-preferences", notifications.UpdateAgentPreferences)
+
+	r.GET("/tickets/:id/notifications", middleware.AuthorizeAdminRequest(), notifications.GetTicketNotifications)
+
+	n := r.Group("/notifications")
+	n.POST("/receipts/:channel", notifications.ReceiveReceipt)
+	n.GET("/:id/receipts", middleware.AuthorizeAdminRequest(), notifications.GetReceipts)
+
+}
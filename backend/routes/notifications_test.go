@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestNotificationPreferencesAreOwnOnly(t *testing.T) {
+	db := openTestDB(t, &models.NotificationPreference{}, &models.Notification{}, &models.NotificationReceipt{})
+	service := services.NewDefaultNotificationService(models.NewNotificationDBModel(db), models.NewUserDBModel(db), models.NewAgentDBModel(db), models.NewTicketDBModel(db), nil, nil, services.NotificationConfig{})
+	r := newTestRouter(t)
+	SetNotificationRoutes(r, controllers.NewNotificationController(service))
+	user, agent := loginUser(t, r, 3), login(t, r, 3)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		cookie string
+		status int
+	}{
+		{"anonymous", http.MethodGet, "/users/3/notification-preferences", "", http.StatusUnauthorized},
+		{"anonymous change", http.MethodPut, "/users/3/notification-preferences", "", http.StatusUnauthorized},
+		{"own user preferences", http.MethodGet, "/users/3/notification-preferences", user, http.StatusOK},
+		{"another user", http.MethodPut, "/users/4/notification-preferences", user, http.StatusForbidden},
+		{"user as the agent of the same ID", http.MethodGet, "/agents/3/notification-preferences", user, http.StatusForbidden},
+		{"own agent preferences", http.MethodGet, "/agents/3/notification-preferences", agent, http.StatusOK},
+		{"agent as the user of the same ID", http.MethodPut, "/users/3/notification-preferences", agent, http.StatusForbidden},
+		{"receipts need an agent", http.MethodGet, "/notifications/1/receipts", user, http.StatusUnauthorized},
+		{"agent reads receipts", http.MethodGet, "/notifications/1/receipts", agent, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, tt.method, tt.path, tt.cookie, "[]")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
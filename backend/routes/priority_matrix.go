@@ -0,0 +1,25 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetPriorityMatrixRoutes(r *gin.Engine, matrix *controllers.PriorityMatrixController) {
+
+	r.PUT("/tickets/:id/priority", middleware.AuthorizeAdminRequest(), matrix.OverridePriority)
+
+	m := r.Group("/admin/priority-matrix", middleware.AuthorizeAdminRequest())
+	m.GET("/", matrix.GetMatrix)
+	m.PUT("/", matrix.SetCells)
+	m.GET("/impacts", matrix.GetImpacts)
+	m.POST("/impacts", matrix.CreateImpact)
+	m.PUT("/impacts/:id", matrix.UpdateImpact)
+	m.DELETE("/impacts/:id", matrix.DeleteImpact)
+	m.GET("/urgencies", matrix.GetUrgencies)
+	m.POST("/urgencies", matrix.CreateUrgency)
+	m.PUT("/urgencies/:id", matrix.UpdateUrgency)
+	m.DELETE("/urgencies/:id", matrix.DeleteUrgency)
+
+}
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestOverridePriorityRequiresSignedInAgent(t *testing.T) {
+	db := openTestDB(t, &models.Agents{})
+	if err := db.Create(&models.Agents{FirstName: "Ada", AgentEmail: "ada@example.com"}).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewDefaultPriorityMatrixService(models.NewPriorityMatrixDBModel(db), services.NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewAgentDBModel(db))
+	r := newTestRouter(t)
+	SetPriorityMatrixRoutes(r, controllers.NewPriorityMatrixController(service))
+
+	tests := []struct {
+		name     string
+		agentID  uint
+		rejected bool
+	}{
+		{"unknown agent", 99, true},
+		{"signed-in agent", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPut, "/tickets/1/priority", login(t, r, tt.agentID), `{"priority_id":1,"justification":"VIP"}`)
+			rejected := w.Code == http.StatusBadRequest && strings.Contains(w.Body.String(), "only agents")
+			if rejected != tt.rejected {
+				t.Fatalf("rejected = %v, want %v: %d %s", rejected, tt.rejected, w.Code, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetProblemRoutes(r *gin.Engine, problems *controllers.ProblemController) {
+
+	p := r.Group("/problems", middleware.AuthorizeAdminRequest())
+	p.GET("/", problems.GetProblems)
+	p.POST("/", problems.CreateProblem)
+	p.GET("/:id", problems.GetProblem)
+	p.PUT("/:id", problems.UpdateProblem)
+	p.DELETE("/:id", problems.DeleteProblem)
+	p.POST("/:id/incidents", problems.LinkIncidents)
+	p.DELETE("/:id/incidents/:ticketID", problems.UnlinkIncident)
+	p.POST("/:id/resolve", problems.ResolveProblem)
+
+	r.GET("/tickets/:id/problems", middleware.AuthorizeAdminRequest(), problems.GetTicketProblems)
+
+}
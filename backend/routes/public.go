@@ -9,8 +9,9 @@ func SetOpenRoutes(r *gin.Engine, public *controllers.AuthController) {
 
 	p := r.Group("/")
 	//p.GET("/", public.index)
-	p.GET("/login", public.Registration)
-	p.GET("/login", public.Login)
+	p.POST("/register", public.Registration)
+	p.POST("/login", public.Login)
+	p.POST("/admin/login", public.AgentLogin)
 	//p.POST("logout", public.Logout)
 	//publics.PUT("/support", public.UpdateAdvertisement)
 	//publics.DELETE("/shuttlers-admin", public.DeleteAdvertisement)
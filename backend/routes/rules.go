@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetRuleRoutes(r *gin.Engine, rules *controllers.RuleController) {
+
+	ru := r.Group("/admin/rules", middleware.AuthorizeAdminRequest())
+	ru.GET("/", rules.GetRules)
+	ru.POST("/", rules.CreateRule)
+	ru.POST("/dry-run", rules.DryRunRule)
+	ru.GET("/executions", rules.GetExecutions)
+	ru.GET("/:id", rules.GetRuleByID)
+	ru.PUT("/:id", rules.UpdateRule)
+	ru.DELETE("/:id", rules.DeleteRule)
+	ru.POST("/:id/dry-run", rules.DryRunRule)
+	ru.GET("/:id/executions", rules.GetExecutions)
+
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetServiceCatalogRoutes(r *gin.Engine, catalog *controllers.ServiceCatalogController) {
+
+	c := r.Group("/catalog")
+	c.GET("/", catalog.GetCatalog)
+	c.GET("/:id", catalog.GetRequestForm)
+	c.POST("/:id/requests", middleware.AuthorizeUserRequest(), catalog.SubmitRequest)
+
+	r.GET("/tickets/:id/tasks", middleware.AuthorizeAdminRequest(), catalog.GetTicketTasks)
+
+	t := r.Group("/fulfilment-tasks", middleware.AuthorizeAdminRequest())
+	t.GET("/", catalog.GetUnitTasks)
+	t.PUT("/:id", catalog.UpdateTask)
+
+	a := r.Group("/admin/catalog", middleware.AuthorizeAdminRequest())
+	a.GET("/", catalog.GetItems)
+	a.POST("/", catalog.CreateItem)
+	a.GET("/:id", catalog.GetItem)
+	a.PUT("/:id", catalog.UpdateItem)
+	a.DELETE("/:id", catalog.DeleteItem)
+
+}
@@ -0,0 +1,36 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestSubmitRequestRequiresSignedInUser(t *testing.T) {
+	db := openTestDB(t, &models.CatalogItem{})
+	tickets := services.NewDefaultTicketingService(models.NewTicketDBModel(db))
+	service := services.NewDefaultServiceCatalogService(models.NewServiceCatalogDBModel(db), models.NewUserDBModel(db), models.NewAgentDBModel(db), tickets)
+	r := newTestRouter(t)
+	SetServiceCatalogRoutes(r, controllers.NewServiceCatalogController(service))
+
+	tests := []struct {
+		name   string
+		cookie string
+		status int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"agent session", login(t, r, 1), http.StatusUnauthorized},
+		{"user session", loginUser(t, r, 1), http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPost, "/catalog/1/requests", tt.cookie, `{"subject":"Laptop"}`)
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
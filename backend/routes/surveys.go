@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetSurveyRoutes(r *gin.Engine, surveys *controllers.SurveyController) {
+
+	s := r.Group("/surveys")
+	s.GET("/:token", surveys.GetSurvey)
+	s.POST("/:token", surveys.RespondToSurvey)
+
+	r.GET("/tickets/:id/surveys", middleware.AuthorizeAdminRequest(), surveys.GetTicketSurveys)
+
+	admin := r.Group("/admin/csat", middleware.AuthorizeAdminRequest())
+	admin.GET("/", surveys.GetStats)
+
+}
@@ -0,0 +1,75 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+)
+
+func TestSurveyLinkRecordsRatingOnlyOnPost(t *testing.T) {
+	db := openTestDB(t, &models.Satisfaction{}, &models.SatisfactionSurvey{})
+	if err := db.Create(&models.Satisfaction{SatisfactionID: 5, Name: "Very satisfied", Rank: 5}).Error; err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte("token"))
+	survey := &models.SatisfactionSurvey{TicketID: 1, TokenHash: hex.EncodeToString(sum[:]), ExpiresAt: time.Now().Add(time.Hour)}
+	if err := db.Create(survey).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewDefaultSurveyService(models.NewSurveyDBModel(db), services.NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewTicketHistoryDBModel(db), nil, services.SurveyConfig{})
+	r := newTestRouter(t)
+	SetSurveyRoutes(r, controllers.NewSurveyController(service))
+
+	tests := []struct {
+		method string
+		rating int
+	}{
+		{http.MethodGet, 0},
+		{http.MethodGet, 0},
+		{http.MethodPost, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			w := serve(r, tt.method, "/surveys/token?rating=5", "", "")
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+			var stored models.SatisfactionSurvey
+			if err := db.First(&stored, survey.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+			if stored.SatisfactionID != tt.rating {
+				t.Fatalf("rating = %d, want %d", stored.SatisfactionID, tt.rating)
+			}
+		})
+	}
+}
+
+func TestTicketSurveysRequireAgent(t *testing.T) {
+	db := openTestDB(t, &models.SatisfactionSurvey{})
+	service := services.NewDefaultSurveyService(models.NewSurveyDBModel(db), services.NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewTicketHistoryDBModel(db), nil, services.SurveyConfig{})
+	r := newTestRouter(t)
+	SetSurveyRoutes(r, controllers.NewSurveyController(service))
+
+	tests := []struct {
+		name   string
+		cookie string
+		status int
+	}{
+		{"anonymous", "", http.StatusUnauthorized},
+		{"agent", login(t, r, 1), http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(r, http.MethodGet, "/tickets/1/surveys", tt.cookie, ""); w.Code != tt.status {
+				t.Fatalf("status = %d, want %d", w.Code, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetTicketPolicyRoutes(r *gin.Engine, policies *controllers.TicketPolicyController) {
+
+	r.GET("/tickets/:id/history", policies.GetTicketHistory)
+
+	p := r.Group("/admin/ticket-policies", middleware.AuthorizeAdminRequest())
+	p.GET("/", policies.GetPolicies)
+	p.POST("/", policies.CreatePolicy)
+	p.GET("/:id", policies.GetPolicyByID)
+	p.PUT("/:id", policies.UpdatePolicy)
+	p.DELETE("/:id", policies.DeletePolicy)
+
+}
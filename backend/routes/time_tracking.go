@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetTimeTrackingRoutes(r *gin.Engine, timeTracking *controllers.TimeTrackingController) {
+
+	t := r.Group("/tickets/:id/time", middleware.AuthorizeAdminRequest())
+	t.GET("", timeTracking.GetTimeEntries)
+	t.POST("", timeTracking.LogTime)
+	t.PUT("/:entry_id", timeTracking.UpdateTimeEntry)
+	t.DELETE("/:entry_id", timeTracking.DeleteTimeEntry)
+	t.POST("/timer/start", timeTracking.StartTimer)
+	t.POST("/timer/stop", timeTracking.StopTimer)
+
+	r.GET("/time/activity-types", middleware.AuthorizeAdminRequest(), timeTracking.GetActivityTypes)
+
+	admin := r.Group("/admin/time-reports", middleware.AuthorizeAdminRequest())
+	admin.GET("/", timeTracking.GetTimeReport)
+
+}
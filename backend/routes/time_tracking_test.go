@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+	"github.com/gin-gonic/contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/services"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// newTestRouter returns a router with sessions, the login pages and a
+// /test/login/:kind/:id route signing an agent or a user in.
+func newTestRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	pages := template.Must(template.New("login.html").Parse("Please login."))
+	template.Must(pages.New("admin/login.html").Parse("Please login."))
+	r.SetHTMLTemplate(pages)
+	r.Use(sessions.Sessions("session", sessions.NewCookieStore([]byte("secret"))))
+	r.POST("/test/login/:kind/:id", func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+			"userID": id,
+			"exp":    time.Now().Add(time.Hour).Unix(),
+		}).SignedString([]byte("your-secret-key"))
+		if err == nil && c.Param("kind") == "user" {
+			err = middleware.SetUserSession(c, token)
+		} else if err == nil {
+			err = middleware.SetAgentSession(c, token)
+		}
+		if err != nil {
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+// login signs an agent in and returns the session cookie.
+func login(t *testing.T, r *gin.Engine, agentID uint) string {
+	t.Helper()
+	return signIn(t, r, "agent", agentID)
+}
+
+// loginUser signs a user in and returns the session cookie.
+func loginUser(t *testing.T, r *gin.Engine, userID uint) string {
+	t.Helper()
+	return signIn(t, r, "user", userID)
+}
+
+func signIn(t *testing.T, r *gin.Engine, kind string, id uint) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/test/login/"+kind+"/"+strconv.Itoa(int(id)), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("login: status %d", w.Code)
+	}
+	return w.Header().Get("Set-Cookie")
+}
+
+func serve(r *gin.Engine, method, path, cookie, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Cookie", cookie)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func TestStopTimerAttributesSignedInAgent(t *testing.T) {
+	db := openTestDB(t, &models.TimeEntry{})
+	entries := models.NewTimeEntryDBModel(db)
+	running := &models.TimeEntry{
+		TicketID:     1,
+		AgentID:      7,
+		ActivityType: models.ActivityOther,
+		WorkedAt:     time.Now().Add(-30 * time.Minute),
+		Running:      true,
+	}
+	if err := db.Create(running).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := services.NewDefaultTimeTrackingService(entries, models.NewTicketDBModel(db), models.NewAgentDBModel(db))
+	r := newTestRouter(t)
+	SetTimeTrackingRoutes(r, controllers.NewTimeTrackingController(service))
+
+	tests := []struct {
+		name    string
+		agentID uint
+		status  int
+	}{
+		{"another agent has no timer", 8, http.StatusConflict},
+		{"signed-in agent stops their timer", 7, http.StatusOK},
+		{"timer is already stopped", 7, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(r, http.MethodPost, "/tickets/1/time/timer/stop", login(t, r, tt.agentID), "{}")
+			if w.Code != tt.status {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+
+	var stopped models.TimeEntry
+	if err := db.First(&stopped, running.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stopped.Running || stopped.Minutes < 30 {
+		t.Fatalf("entry not stopped: running=%v minutes=%d", stopped.Running, stopped.Minutes)
+	}
+}
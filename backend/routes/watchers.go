@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetWatcherRoutes(r *gin.Engine, watchers *controllers.WatcherController) {
+
+	w := r.Group("/tickets/:id/watchers", middleware.AuthorizeRequest())
+	w.GET("", watchers.GetWatchers)
+	w.POST("", watchers.AddWatcher)
+	w.DELETE("/:watcher_id", watchers.RemoveWatcher)
+
+}
@@ -0,0 +1,21 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/shuttlersit/service-desk/backend/controllers"
+	"github.com/shuttlersit/service-desk/backend/middleware"
+)
+
+func SetWebhookRoutes(r *gin.Engine, webhooks *controllers.WebhookController) {
+
+	w := r.Group("/admin/webhooks", middleware.AuthorizeAdminRequest())
+	w.GET("/", webhooks.GetWebhooks)
+	w.POST("/", webhooks.CreateWebhook)
+	w.GET("/:id", webhooks.GetWebhookByID)
+	w.PUT("/:id", webhooks.UpdateWebhook)
+	w.DELETE("/:id", webhooks.DeleteWebhook)
+
+	w.GET("/:id/deliveries", webhooks.GetDeliveries)
+	w.POST("/:id/deliveries/:delivery_id/replay", webhooks.ReplayDelivery)
+
+}
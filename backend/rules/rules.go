@@ -0,0 +1,374 @@
+// backend/rules/rules.go
+
+// Package rules evaluates automation rules against tickets. It only decides
+// whether a rule matches and how its field actions change a ticket; the
+// rule service carries out the actions and records them.
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+// Fields that conditions can test.
+const (
+	FieldEvent          = "event"
+	FieldSubject        = "subject"
+	FieldDescription    = "description"
+	FieldCategory       = "category"
+	FieldSubCategory    = "sub_category"
+	FieldPriority       = "priority"
+	FieldStatus         = "status"
+	FieldSite           = "site"
+	FieldAgentID        = "agent_id"
+	FieldAgentEmail     = "agent_email"
+	FieldUnit           = "unit"
+	FieldRequesterID    = "requester_id"
+	FieldRequesterEmail = "requester_email"
+	FieldTags           = "tags"
+
+	// FieldCustomPrefix starts the fields of custom field values, as in
+	// custom.asset_tag.
+	FieldCustomPrefix = "custom."
+)
+
+var conditionFields = map[string]bool{
+	FieldEvent: true, FieldSubject: true, FieldDescription: true, FieldCategory: true,
+	FieldSubCategory: true, FieldPriority: true, FieldStatus: true, FieldSite: true,
+	FieldAgentID: true, FieldAgentEmail: true, FieldUnit: true, FieldRequesterID: true,
+	FieldRequesterEmail: true, FieldTags: true,
+}
+
+// settableFields are the fields set_field can change.
+var settableFields = map[string]bool{
+	FieldCategory: true, FieldSubCategory: true, FieldPriority: true, FieldStatus: true, FieldSite: true,
+}
+
+var operators = map[string]bool{
+	models.OperatorEquals: true, models.OperatorNotEquals: true, models.OperatorContains: true,
+	models.OperatorNotContains: true, models.OperatorStartsWith: true, models.OperatorIn: true,
+	models.OperatorNotIn: true, models.OperatorIsEmpty: true, models.OperatorIsNotEmpty: true,
+}
+
+// Facts are the values of the condition fields for one ticket and event.
+// Every field holds a list so that tags are tested like any other field: a
+// condition holds if it holds for one of the values.
+type Facts map[string][]string
+
+// TicketFacts collects the facts of a ticket for an event.
+func TicketFacts(ticket *models.Ticket, event string) Facts {
+	facts := Facts{
+		FieldEvent:          {event},
+		FieldSubject:        {ticket.Subject},
+		FieldDescription:    {ticket.Description},
+		FieldCategory:       {ticket.Category.CategoryName},
+		FieldSubCategory:    {ticket.SubCategory.SubCategoryName},
+		FieldPriority:       {ticket.Priority.Name},
+		FieldStatus:         {ticket.Status.StatusName},
+		FieldSite:           {ticket.Site},
+		FieldAgentEmail:     {ticket.AgentID.AgentEmail},
+		FieldUnit:           {ticket.AgentID.Unit.UnitName},
+		FieldRequesterEmail: {ticket.UserID.Email},
+	}
+	if ticket.AgentID.ID != 0 {
+		facts[FieldAgentID] = []string{strconv.FormatUint(uint64(ticket.AgentID.ID), 10)}
+	}
+	if ticket.UserID.ID != 0 {
+		facts[FieldRequesterID] = []string{strconv.FormatUint(uint64(ticket.UserID.ID), 10)}
+	}
+	for _, tag := range ticket.Tags {
+		facts[FieldTags] = append(facts[FieldTags], tag.TagName)
+	}
+	for key := range ticket.CustomFields {
+		facts[FieldCustomPrefix+key] = ticket.CustomFields.Strings(key)
+	}
+	return facts
+}
+
+// ConditionResult is the outcome of one condition, for dry runs.
+type ConditionResult struct {
+	models.RuleCondition
+	Actual  []string `json:"actual"`
+	Matched bool     `json:"matched"`
+}
+
+// Evaluate reports whether the conditions of a rule hold for facts. A rule
+// without conditions always matches.
+func Evaluate(rule *models.Rule, facts Facts) (bool, []ConditionResult) {
+	results := make([]ConditionResult, 0, len(rule.Conditions))
+	matchedAny := false
+	all := true
+	for _, c := range rule.Conditions {
+		values := nonEmpty(facts[c.Field])
+		ok := holds(c, values)
+		results = append(results, ConditionResult{RuleCondition: c, Actual: values, Matched: ok})
+		matchedAny = matchedAny || ok
+		all = all && ok
+	}
+	if len(rule.Conditions) == 0 {
+		return true, results
+	}
+	if rule.Match == models.RuleMatchAny {
+		return matchedAny, results
+	}
+	return all, results
+}
+
+func nonEmpty(values []string) []string {
+	out := []string{}
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func holds(c models.RuleCondition, values []string) bool {
+	switch c.Operator {
+	case models.OperatorIsEmpty:
+		return len(values) == 0
+	case models.OperatorIsNotEmpty:
+		return len(values) > 0
+	case models.OperatorNotEquals:
+		return !someValue(values, func(v string) bool { return strings.EqualFold(v, c.Value) })
+	case models.OperatorNotContains:
+		return !someValue(values, func(v string) bool { return containsFold(v, c.Value) })
+	case models.OperatorNotIn:
+		return !someValue(values, func(v string) bool { return inFold(v, c.Values) })
+	case models.OperatorEquals:
+		return someValue(values, func(v string) bool { return strings.EqualFold(v, c.Value) })
+	case models.OperatorContains:
+		return someValue(values, func(v string) bool { return containsFold(v, c.Value) })
+	case models.OperatorStartsWith:
+		return someValue(values, func(v string) bool { return strings.HasPrefix(strings.ToLower(v), strings.ToLower(c.Value)) })
+	case models.OperatorIn:
+		return someValue(values, func(v string) bool { return inFold(v, c.Values) })
+	}
+	return false
+}
+
+func someValue(values []string, fn func(string) bool) bool {
+	for _, v := range values {
+		if fn(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func inFold(v string, list []string) bool {
+	for _, item := range list {
+		if strings.EqualFold(v, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInvalidRule wraps the problems Validate finds.
+var ErrInvalidRule = errors.New("invalid rule")
+
+func invalid(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRule, fmt.Sprintf(format, args...))
+}
+
+// Validate checks that a rule only uses known events, fields, operators and
+// actions, and that its templates parse.
+func Validate(rule *models.Rule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return invalid("name is required")
+	}
+	if len(rule.Events) == 0 {
+		return invalid("at least one event is required")
+	}
+	for _, e := range rule.Events {
+		if !strings.HasPrefix(e, "ticket.") || !models.IsKnownEvent(e) || e == models.EventTicketDeleted {
+			return invalid("unsupported event %q", e)
+		}
+	}
+	if rule.Match != "" && rule.Match != models.RuleMatchAll && rule.Match != models.RuleMatchAny {
+		return invalid("match must be %q or %q", models.RuleMatchAll, models.RuleMatchAny)
+	}
+	for _, c := range rule.Conditions {
+		if !conditionFields[c.Field] && !isCustomField(c.Field) {
+			return invalid("unknown field %q", c.Field)
+		}
+		if !operators[c.Operator] {
+			return invalid("unknown operator %q", c.Operator)
+		}
+	}
+	if len(rule.Actions) == 0 {
+		return invalid("at least one action is required")
+	}
+	for i, a := range rule.Actions {
+		if err := validateAction(a); err != nil {
+			return invalid("action %d: %v", i+1, err)
+		}
+	}
+	return nil
+}
+
+// isCustomField reports whether field names a custom field. Whether the
+// custom field exists is not checked: a rule on a field that no ticket has
+// sees it as empty.
+func isCustomField(field string) bool {
+	return strings.HasPrefix(field, FieldCustomPrefix) && len(field) > len(FieldCustomPrefix)
+}
+
+func validateAction(a models.RuleAction) error {
+	switch a.Type {
+	case models.ActionSetField:
+		if !settableFields[a.Field] {
+			return fmt.Errorf("field %q cannot be set", a.Field)
+		}
+	case models.ActionAssign:
+		if a.AgentID == 0 && a.Unit == "" {
+			return errors.New("agent_id or unit is required")
+		}
+	case models.ActionAddTag:
+		if strings.TrimSpace(a.Value) == "" {
+			return errors.New("value is required")
+		}
+	case models.ActionAddComment:
+		if strings.TrimSpace(a.Body) == "" {
+			return errors.New("body is required")
+		}
+	case models.ActionNotify:
+		if a.To == "" || a.Subject == "" {
+			return errors.New("to and subject are required")
+		}
+	case models.ActionCallWebhook:
+		if a.WebhookID == 0 {
+			return errors.New("webhook_id is required")
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Type)
+	}
+	// Rendering with an empty ticket also catches fields a ticket does not
+	// have.
+	for _, text := range []string{a.Subject, a.Body} {
+		if _, err := render(text, TicketView{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetField applies a set_field action to a ticket and returns the previous
+// value. It reports false when the field already had the value.
+func SetField(ticket *models.Ticket, field, value string) (string, bool, error) {
+	var target *string
+	switch field {
+	case FieldCategory:
+		target = &ticket.Category.CategoryName
+	case FieldSubCategory:
+		target = &ticket.SubCategory.SubCategoryName
+	case FieldPriority:
+		target = &ticket.Priority.Name
+	case FieldStatus:
+		target = &ticket.Status.StatusName
+	case FieldSite:
+		target = &ticket.Site
+	default:
+		return "", false, fmt.Errorf("field %q cannot be set", field)
+	}
+	previous := *target
+	if previous == value {
+		return previous, false, nil
+	}
+	*target = value
+	return previous, true, nil
+}
+
+// TicketView is what the templates of actions see of a ticket, as .Ticket.
+// It only holds fields that may be shown to anyone a rule writes to, so no
+// template can reach the credentials of the requester or agent.
+type TicketView struct {
+	ID          uint
+	Subject     string
+	Description string
+	Category    string
+	SubCategory string
+	Priority    string
+	Status      string
+	Site        string
+	Tags        []string
+	Requester   PersonView
+	Agent       PersonView
+	// Unit is the unit of the agent, or the unit the ticket is queued for.
+	Unit string
+	// CustomFields are the values of the custom fields by key, lists
+	// joined with commas.
+	CustomFields map[string]string
+}
+
+// PersonView is the requester or agent of a ticket in a TicketView.
+type PersonView struct {
+	ID        uint
+	FirstName string
+	LastName  string
+	Email     string
+}
+
+// NewTicketView collects the template fields of a ticket.
+func NewTicketView(ticket *models.Ticket) TicketView {
+	view := TicketView{
+		ID:          ticket.ID,
+		Subject:     ticket.Subject,
+		Description: ticket.Description,
+		Category:    ticket.Category.CategoryName,
+		SubCategory: ticket.SubCategory.SubCategoryName,
+		Priority:    ticket.Priority.Name,
+		Status:      ticket.Status.StatusName,
+		Site:        ticket.Site,
+		Requester: PersonView{
+			ID:        ticket.UserID.ID,
+			FirstName: ticket.UserID.FirstName,
+			LastName:  ticket.UserID.LastName,
+			Email:     ticket.UserID.Email,
+		},
+		Agent: PersonView{
+			ID:        ticket.AgentID.ID,
+			FirstName: ticket.AgentID.FirstName,
+			LastName:  ticket.AgentID.LastName,
+			Email:     ticket.AgentID.AgentEmail,
+		},
+		Unit:         ticket.AgentID.Unit.UnitName,
+		CustomFields: map[string]string{},
+	}
+	for _, tag := range ticket.Tags {
+		view.Tags = append(view.Tags, tag.TagName)
+	}
+	for key := range ticket.CustomFields {
+		view.CustomFields[key] = strings.Join(ticket.CustomFields.Strings(key), ", ")
+	}
+	return view
+}
+
+// Render renders the subject or body of an action with the ticket.
+func Render(text string, ticket *models.Ticket) (string, error) {
+	return render(text, NewTicketView(ticket))
+}
+
+func render(text string, view TicketView) (string, error) {
+	tmpl, err := template.New("action").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ Ticket TicketView }{view}); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
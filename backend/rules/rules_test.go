@@ -0,0 +1,167 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func testTicket() *models.Ticket {
+	ticket := &models.Ticket{Subject: "VPN down", Site: "HQ"}
+	ticket.ID = 42
+	ticket.Priority.Name = "P2"
+	ticket.Status.StatusName = "Open"
+	ticket.Category.CategoryName = "Network"
+	ticket.UserID.ID = 7
+	ticket.UserID.FirstName = "Ada"
+	ticket.UserID.Email = "ada@example.com"
+	ticket.UserID.Credentials.Username = "ada-login"
+	ticket.UserID.Credentials.Password = "ada-hash"
+	ticket.AgentID.AgentEmail = "bob@example.com"
+	ticket.AgentID.Credentials.Password = "bob-hash"
+	ticket.AgentID.Unit.UnitName = "Networks"
+	ticket.Tags = []models.Tags{{TagName: "vip"}, {TagName: "remote"}}
+	ticket.CustomFields = models.CustomFieldValues{"asset_tag": "LT-1", "sites": []interface{}{"HQ", "Lab"}}
+	return ticket
+}
+
+func TestEvaluate(t *testing.T) {
+	facts := TicketFacts(testTicket(), models.EventTicketCreated)
+	cond := func(field, operator, value string, values ...string) models.RuleCondition {
+		return models.RuleCondition{Field: field, Operator: operator, Value: value, Values: values}
+	}
+	tests := []struct {
+		name       string
+		match      string
+		conditions []models.RuleCondition
+		want       bool
+	}{
+		{"no conditions", models.RuleMatchAll, nil, true},
+		{"equals ignores case", models.RuleMatchAll, []models.RuleCondition{cond(FieldPriority, models.OperatorEquals, "p2")}, true},
+		{"not equals", models.RuleMatchAll, []models.RuleCondition{cond(FieldPriority, models.OperatorNotEquals, "P2")}, false},
+		{"contains", models.RuleMatchAll, []models.RuleCondition{cond(FieldSubject, models.OperatorContains, "vpn")}, true},
+		{"not contains", models.RuleMatchAll, []models.RuleCondition{cond(FieldSubject, models.OperatorNotContains, "printer")}, true},
+		{"starts with", models.RuleMatchAll, []models.RuleCondition{cond(FieldRequesterEmail, models.OperatorStartsWith, "ADA@")}, true},
+		{"in", models.RuleMatchAll, []models.RuleCondition{cond(FieldStatus, models.OperatorIn, "", "new", "open")}, true},
+		{"not in", models.RuleMatchAll, []models.RuleCondition{cond(FieldStatus, models.OperatorNotIn, "", "new", "open")}, false},
+		{"one of many tags", models.RuleMatchAll, []models.RuleCondition{cond(FieldTags, models.OperatorEquals, "remote")}, true},
+		{"no tag matches", models.RuleMatchAll, []models.RuleCondition{cond(FieldTags, models.OperatorNotEquals, "vip")}, false},
+		{"unassigned agent is empty", models.RuleMatchAll, []models.RuleCondition{cond(FieldAgentID, models.OperatorIsEmpty, "")}, true},
+		{"requester is set", models.RuleMatchAll, []models.RuleCondition{cond(FieldRequesterID, models.OperatorEquals, "7")}, true},
+		{"empty field", models.RuleMatchAll, []models.RuleCondition{cond(FieldSubCategory, models.OperatorIsNotEmpty, "")}, false},
+		{"empty field does not contain", models.RuleMatchAll, []models.RuleCondition{cond(FieldSubCategory, models.OperatorNotContains, "x")}, true},
+		{"custom field", models.RuleMatchAll, []models.RuleCondition{cond("custom.asset_tag", models.OperatorEquals, "lt-1")}, true},
+		{"custom list field", models.RuleMatchAll, []models.RuleCondition{cond("custom.sites", models.OperatorEquals, "Lab")}, true},
+		{"unknown custom field is empty", models.RuleMatchAll, []models.RuleCondition{cond("custom.missing", models.OperatorIsEmpty, "")}, true},
+		{"event", models.RuleMatchAll, []models.RuleCondition{cond(FieldEvent, models.OperatorEquals, models.EventTicketCreated)}, true},
+		{"all needs every condition", models.RuleMatchAll, []models.RuleCondition{
+			cond(FieldPriority, models.OperatorEquals, "P2"),
+			cond(FieldSite, models.OperatorEquals, "Branch"),
+		}, false},
+		{"any needs one condition", models.RuleMatchAny, []models.RuleCondition{
+			cond(FieldPriority, models.OperatorEquals, "P1"),
+			cond(FieldSite, models.OperatorEquals, "hq"),
+		}, true},
+		{"any with none holding", models.RuleMatchAny, []models.RuleCondition{
+			cond(FieldPriority, models.OperatorEquals, "P1"),
+			cond(FieldSite, models.OperatorEquals, "Branch"),
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := &models.Rule{Match: tt.match, Conditions: tt.conditions}
+			got, results := Evaluate(rule, facts)
+			if got != tt.want {
+				t.Fatalf("Evaluate = %v, want %v: %+v", got, tt.want, results)
+			}
+			if len(results) != len(tt.conditions) {
+				t.Fatalf("%d condition results, want %d", len(results), len(tt.conditions))
+			}
+		})
+	}
+}
+
+func TestSetField(t *testing.T) {
+	ticket := testTicket()
+	previous, changed, err := SetField(ticket, FieldPriority, "P1")
+	if err != nil || !changed || previous != "P2" || ticket.Priority.Name != "P1" {
+		t.Fatalf("SetField = %q, %v, %v; priority %q", previous, changed, err, ticket.Priority.Name)
+	}
+	if _, changed, err := SetField(ticket, FieldPriority, "P1"); err != nil || changed {
+		t.Fatalf("setting the same value = %v, %v; want unchanged", changed, err)
+	}
+	if _, changed, err := SetField(ticket, FieldSite, "Lab"); err != nil || !changed || ticket.Site != "Lab" {
+		t.Fatalf("SetField site = %v, %v; site %q", changed, err, ticket.Site)
+	}
+	if _, _, err := SetField(ticket, FieldSubject, "x"); err == nil {
+		t.Fatal("SetField on subject succeeded, want an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *models.Rule {
+		return &models.Rule{
+			Name:       "Escalate VIPs",
+			Events:     []string{models.EventTicketCreated},
+			Conditions: []models.RuleCondition{{Field: FieldTags, Operator: models.OperatorEquals, Value: "vip"}},
+			Actions:    []models.RuleAction{{Type: models.ActionSetField, Field: FieldPriority, Value: "P1"}},
+		}
+	}
+	if err := Validate(valid()); err != nil {
+		t.Fatalf("Validate = %v", err)
+	}
+	tests := []struct {
+		name   string
+		change func(r *models.Rule)
+	}{
+		{"no name", func(r *models.Rule) { r.Name = " " }},
+		{"no events", func(r *models.Rule) { r.Events = nil }},
+		{"deleted tickets", func(r *models.Rule) { r.Events = []string{models.EventTicketDeleted} }},
+		{"unknown match", func(r *models.Rule) { r.Match = "most" }},
+		{"unknown field", func(r *models.Rule) { r.Conditions[0].Field = "password" }},
+		{"unknown operator", func(r *models.Rule) { r.Conditions[0].Operator = "like" }},
+		{"no actions", func(r *models.Rule) { r.Actions = nil }},
+		{"field not settable", func(r *models.Rule) { r.Actions[0].Field = FieldSubject }},
+		{"broken template", func(r *models.Rule) {
+			r.Actions = []models.RuleAction{{Type: models.ActionAddComment, Body: "{{.Ticket.Subject"}}
+		}},
+		{"template reaching credentials", func(r *models.Rule) {
+			r.Actions = []models.RuleAction{{Type: models.ActionAddComment, Body: "{{.Ticket.UserID.Credentials.Password}}"}}
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := valid()
+			tt.change(rule)
+			if err := Validate(rule); !errors.Is(err, ErrInvalidRule) {
+				t.Fatalf("Validate = %v, want ErrInvalidRule", err)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	got, err := Render("#{{.Ticket.ID}} {{.Ticket.Subject}} for {{.Ticket.Requester.FirstName}} ({{.Ticket.Unit}}, {{.Ticket.CustomFields.sites}}){{.Ticket.CustomFields.missing}}", testTicket())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "#42 VPN down for Ada (Networks, HQ, Lab)"; got != want {
+		t.Fatalf("Render = %q, want %q", got, want)
+	}
+	for _, text := range []string{"{{.Ticket}}", "{{printf \"%+v\" .}}"} {
+		got, err := Render(text, testTicket())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, secret := range []string{"ada-login", "ada-hash", "bob-hash"} {
+			if strings.Contains(got, secret) {
+				t.Fatalf("Render(%q) exposes %q: %s", text, secret, got)
+			}
+		}
+	}
+	if _, err := Render("{{.Ticket.AgentID.Credentials.Password}}", testTicket()); err == nil {
+		t.Fatal("Render reached the credentials of the agent")
+	}
+}
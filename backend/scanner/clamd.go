@@ -0,0 +1,125 @@
+// backend/scanner/clamd.go
+
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// ClamdScanner talks to a ClamAV daemon using the clamd INSTREAM protocol.
+type ClamdScanner struct {
+	// Network is "tcp" or "unix".
+	Network string
+	// Address is host:port for tcp or the socket path for unix.
+	Address string
+	Timeout time.Duration
+	// ChunkSize is the size of each INSTREAM chunk. It must stay below the
+	// daemon's StreamMaxLength.
+	ChunkSize int
+}
+
+// NewClamdScanner creates a new ClamdScanner.
+func NewClamdScanner(network, address string) *ClamdScanner {
+	return &ClamdScanner{
+		Network:   network,
+		Address:   address,
+		Timeout:   2 * time.Minute,
+		ChunkSize: 64 << 10,
+	}
+}
+
+// Ping checks that the daemon is reachable.
+func (cs *ClamdScanner) Ping(ctx context.Context) error {
+	reply, err := cs.command(ctx, "zPING\x00", nil)
+	if err != nil {
+		return err
+	}
+	if reply != "PONG" {
+		return fmt.Errorf("clamd: unexpected reply %q", reply)
+	}
+	return nil
+}
+
+// Scan streams r to clamd and interprets the verdict.
+func (cs *ClamdScanner) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	reply, err := cs.command(ctx, "zINSTREAM\x00", r)
+	if err != nil {
+		return Result{}, err
+	}
+	// Replies look like "stream: OK", "stream: Eicar-Signature FOUND" or
+	// "INSTREAM size limit exceeded. ERROR".
+	reply = strings.TrimPrefix(reply, "stream: ")
+	switch {
+	case reply == "OK":
+		return Result{Clean: true}, nil
+	case strings.HasSuffix(reply, " FOUND"):
+		return Result{Signature: strings.TrimSuffix(reply, " FOUND")}, nil
+	default:
+		return Result{}, fmt.Errorf("clamd: %s", reply)
+	}
+}
+
+// command sends a null terminated command, optionally followed by a stream,
+// and returns the daemon's reply without the terminator.
+func (cs *ClamdScanner) command(ctx context.Context, cmd string, stream io.Reader) (string, error) {
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := dialer.DialContext(ctx, cs.Network, cs.Address)
+	if err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(cs.Timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	if _, err := io.WriteString(conn, cmd); err != nil {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	if stream != nil {
+		if err := cs.writeChunks(conn, stream); err != nil {
+			return "", err
+		}
+	}
+
+	reply, err := bufio.NewReader(conn).ReadBytes(0)
+	if err != nil && !(err == io.EOF && len(reply) > 0) {
+		return "", fmt.Errorf("clamd: %w", err)
+	}
+	return string(bytes.TrimRight(reply, "\x00\n")), nil
+}
+
+// writeChunks frames the stream as length prefixed chunks ended by a zero length chunk.
+func (cs *ClamdScanner) writeChunks(w io.Writer, r io.Reader) error {
+	size := cs.ChunkSize
+	if size <= 0 {
+		size = 64 << 10
+	}
+	buf := make([]byte, 4+size)
+	for {
+		n, err := io.ReadFull(r, buf[4:])
+		if n > 0 {
+			binary.BigEndian.PutUint32(buf, uint32(n))
+			if _, werr := w.Write(buf[:4+n]); werr != nil {
+				return fmt.Errorf("clamd: %w", werr)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := w.Write([]byte{0, 0, 0, 0})
+	return err
+}
@@ -0,0 +1,131 @@
+package scanner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeClamd accepts one INSTREAM connection at a time, records the chunk
+// sizes it receives and answers with reply for the reassembled stream.
+func fakeClamd(t *testing.T, reply func(data []byte) string) (addr string, chunks chan []int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	chunks = make(chan []int, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			r := bufio.NewReader(conn)
+			cmd, err := r.ReadString(0)
+			if err != nil || cmd != "zINSTREAM\x00" {
+				conn.Close()
+				continue
+			}
+			var sizes []int
+			var data bytes.Buffer
+			for {
+				var size uint32
+				if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+					break
+				}
+				sizes = append(sizes, int(size))
+				if size == 0 {
+					break
+				}
+				io.CopyN(&data, r, int64(size))
+			}
+			chunks <- sizes
+			io.WriteString(conn, reply(data.Bytes())+"\x00")
+			conn.Close()
+		}
+	}()
+	return ln.Addr().String(), chunks
+}
+
+func TestClamdScan(t *testing.T) {
+	addr, chunks := fakeClamd(t, func(data []byte) string {
+		switch {
+		case bytes.Contains(data, []byte("EICAR")):
+			return "stream: Eicar-Signature FOUND"
+		case len(data) > 16:
+			return "INSTREAM size limit exceeded. ERROR"
+		}
+		return "stream: OK"
+	})
+	cs := NewClamdScanner("tcp", addr)
+	cs.ChunkSize = 4
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Result
+		chunks  []int
+		wantErr bool
+	}{
+		{"clean file", "hello world", Result{Clean: true}, []int{4, 4, 3, 0}, false},
+		{"empty file", "", Result{Clean: true}, []int{0}, false},
+		{"infected file", "X5O!EICAR", Result{Signature: "Eicar-Signature"}, []int{4, 4, 1, 0}, false},
+		{"daemon error", strings.Repeat("a", 17), Result{}, []int{4, 4, 4, 4, 1, 0}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cs.Scan(context.Background(), strings.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("result = %+v, want %+v", got, tt.want)
+			}
+			if sizes := <-chunks; !reflect.DeepEqual(sizes, tt.chunks) {
+				t.Fatalf("chunks = %v, want %v", sizes, tt.chunks)
+			}
+		})
+	}
+}
+
+func TestClamdUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	if _, err := NewClamdScanner("tcp", addr).Scan(context.Background(), strings.NewReader("data")); err == nil {
+		t.Fatal("scan succeeded without a daemon")
+	}
+}
+
+func TestExtensionBlocked(t *testing.T) {
+	tests := []struct {
+		name    string
+		blocked bool
+	}{
+		{"report.pdf", false},
+		{"setup.exe", true},
+		{"SETUP.EXE", true},
+		{"setup.exe.", true},
+		{"setup.exe ", true},
+		{"archive.tar.gz", false},
+		{"invoice.pdf.js", true},
+		{"../../evil.bat", true},
+		{"README", false},
+	}
+	for _, tt := range tests {
+		if got := ExtensionBlocked(tt.name, DefaultBlockedExtensions); got != tt.blocked {
+			t.Errorf("ExtensionBlocked(%q) = %v, want %v", tt.name, got, tt.blocked)
+		}
+	}
+}
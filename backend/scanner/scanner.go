@@ -0,0 +1,56 @@
+// backend/scanner/scanner.go
+
+package scanner
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Result is the verdict of a scan.
+type Result struct {
+	Clean bool
+	// Signature names the detected threat when Clean is false.
+	Signature string
+}
+
+// Scanner inspects file contents before they are made available to agents.
+type Scanner interface {
+	Scan(ctx context.Context, r io.Reader) (Result, error)
+}
+
+// ScannerFunc adapts an ordinary function to the Scanner interface, which is
+// handy for fakes and for chaining content policies.
+type ScannerFunc func(ctx context.Context, r io.Reader) (Result, error)
+
+// Scan calls f(ctx, r).
+func (f ScannerFunc) Scan(ctx context.Context, r io.Reader) (Result, error) {
+	return f(ctx, r)
+}
+
+// DefaultBlockedExtensions are file types that are executable on common
+// desktops and have no business being attached to a ticket.
+var DefaultBlockedExtensions = []string{
+	".exe", ".com", ".scr", ".pif", ".bat", ".cmd", ".msi", ".msp", ".dll",
+	".cpl", ".js", ".jse", ".vbs", ".vbe", ".wsf", ".wsh", ".ps1", ".psm1",
+	".hta", ".jar", ".lnk", ".reg", ".iso", ".img", ".vhd", ".apk", ".app",
+}
+
+// ExtensionBlocked reports whether a file name uses one of the blocked
+// extensions. Trailing dots and spaces, which Windows ignores, are stripped
+// first so "setup.exe." is caught as well.
+func ExtensionBlocked(fileName string, blocked []string) bool {
+	name := strings.TrimRight(filepath.Base(fileName), ". ")
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" {
+		return false
+	}
+	for _, b := range blocked {
+		if ext == strings.ToLower(b) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,849 @@
+// backend/services/approval_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/storage"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidApprovalChain = errors.New("invalid approval chain")
+	ErrNoApprover           = errors.New("no approver found")
+	ErrApprovalPending      = errors.New("ticket is awaiting approval")
+	ErrInvalidDecision      = errors.New("invalid approval decision")
+	ErrApprovalDecided      = errors.New("approval is already decided")
+	ErrNotApprover          = errors.New("not an approver of this approval")
+)
+
+// Decisions on an approval, as they appear in its links.
+const (
+	ApprovalDecisionApprove = "approve"
+	ApprovalDecisionReject  = "reject"
+)
+
+// ApprovalConfig configures the approval workflows.
+type ApprovalConfig struct {
+	// LinkURL is prefixed to the signed approve and reject links of the
+	// approval emails, e.g. "https://desk.example.com". The page it serves
+	// confirms the decision and posts it back, so link scanners opening the
+	// email cannot decide. It defaults to PortalURL.
+	LinkURL string
+	// LinkTTL is how long the links of an approval email are valid.
+	LinkTTL time.Duration
+	// RejectedStatus is the status of a ticket whose approval is rejected.
+	RejectedStatus string
+}
+
+// DefaultApprovalConfig returns the default approval settings.
+func DefaultApprovalConfig() ApprovalConfig {
+	return ApprovalConfig{
+		LinkTTL:        7 * 24 * time.Hour,
+		RejectedStatus: models.StatusClosed,
+	}
+}
+
+// ApprovalPrompt is what an approval link shows before the decision is
+// confirmed.
+type ApprovalPrompt struct {
+	Approval    *models.Approval `json:"approval"`
+	Decision    string           `json:"decision"`
+	Subject     string           `json:"subject"`
+	Description string           `json:"description"`
+	Requester   string           `json:"requester"`
+}
+
+// ApprovalServiceInterface provides methods for approval chains and the
+// approval of tickets and change requests.
+type ApprovalServiceInterface interface {
+	CreateChain(chain *models.ApprovalChain) error
+	UpdateChain(chain *models.ApprovalChain) (*models.ApprovalChain, error)
+	DeleteChain(id uint) (bool, error)
+	GetChain(id uint) (*models.ApprovalChain, error)
+	GetChains() (*[]models.ApprovalChain, error)
+	GetTicketApproval(ticketID uint) (*models.ApprovalRequest, error)
+	GetPendingApprovals(userID uint) (*[]models.Approval, error)
+	Decide(userID, approvalID uint, decision, comment string) (*models.ApprovalRequest, error)
+	GetPrompt(approvalID uint, decision, expires, signature string) (*ApprovalPrompt, error)
+	DecideByLink(approvalID uint, decision, expires, signature, comment string) (*models.ApprovalRequest, error)
+}
+
+// DefaultApprovalService is the default implementation of ApprovalService
+type DefaultApprovalService struct {
+	DB                    *gorm.DB
+	ApprovalDBModel       *models.ApprovalDBModel
+	UserDBModel           *models.UserDBModel
+	ServiceCatalogDBModel *models.ServiceCatalogDBModel
+	TicketService         *DefaultTicketingService
+	Notifications         *DefaultNotificationService
+	Signer                *storage.URLSigner
+	Events                EventPublisher
+	Config                ApprovalConfig
+}
+
+// NewDefaultApprovalService creates a new DefaultApprovalService.
+func NewDefaultApprovalService(approvalDBModel *models.ApprovalDBModel, userDBModel *models.UserDBModel, serviceCatalogDBModel *models.ServiceCatalogDBModel, ticketService *DefaultTicketingService, notifications *DefaultNotificationService, signer *storage.URLSigner, config ApprovalConfig) *DefaultApprovalService {
+	defaults := DefaultApprovalConfig()
+	if config.LinkTTL <= 0 {
+		config.LinkTTL = defaults.LinkTTL
+	}
+	if config.RejectedStatus == "" {
+		config.RejectedStatus = defaults.RejectedStatus
+	}
+	if config.LinkURL == "" && notifications != nil {
+		config.LinkURL = notifications.Config.PortalURL
+	}
+	config.LinkURL = strings.TrimRight(config.LinkURL, "/")
+	return &DefaultApprovalService{
+		DB:                    approvalDBModel.DB,
+		ApprovalDBModel:       approvalDBModel,
+		UserDBModel:           userDBModel,
+		ServiceCatalogDBModel: serviceCatalogDBModel,
+		TicketService:         ticketService,
+		Notifications:         notifications,
+		Signer:                signer,
+		Config:                config,
+	}
+}
+
+func approvalPath(id uint, decision string) string {
+	return fmt.Sprintf("/approvals/%d/%s", id, decision)
+}
+
+// CreateChain creates an approval chain with its steps.
+func (as *DefaultApprovalService) CreateChain(chain *models.ApprovalChain) error {
+	chain.ID = 0
+	if err := as.validateChain(chain); err != nil {
+		return err
+	}
+	return as.ApprovalDBModel.CreateChain(chain)
+}
+
+// UpdateChain updates an approval chain and replaces its steps. Requests
+// already started keep the steps they were started with.
+func (as *DefaultApprovalService) UpdateChain(chain *models.ApprovalChain) (*models.ApprovalChain, error) {
+	existing, err := as.ApprovalDBModel.GetChainByID(chain.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := as.validateChain(chain); err != nil {
+		return nil, err
+	}
+	chain.Model = existing.Model
+	chain.CreatedAt = existing.CreatedAt
+	err = as.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewApprovalDBModel(tx).UpdateChain(chain)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return as.ApprovalDBModel.GetChainByID(chain.ID)
+}
+
+// DeleteChain deletes an approval chain and its steps.
+func (as *DefaultApprovalService) DeleteChain(id uint) (bool, error) {
+	if _, err := as.ApprovalDBModel.GetChainByID(id); err != nil {
+		return false, err
+	}
+	err := as.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewApprovalDBModel(tx).DeleteChain(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetChain retrieves an approval chain with its steps.
+func (as *DefaultApprovalService) GetChain(id uint) (*models.ApprovalChain, error) {
+	return as.ApprovalDBModel.GetChainByID(id)
+}
+
+// GetChains retrieves every approval chain with its steps.
+func (as *DefaultApprovalService) GetChains() (*[]models.ApprovalChain, error) {
+	return as.ApprovalDBModel.GetChains()
+}
+
+// GetTicketApproval retrieves the approval request of a ticket.
+func (as *DefaultApprovalService) GetTicketApproval(ticketID uint) (*models.ApprovalRequest, error) {
+	return as.ApprovalDBModel.GetTicketRequest(ticketID)
+}
+
+// GetPendingApprovals retrieves the approvals awaiting a user's decision.
+func (as *DefaultApprovalService) GetPendingApprovals(userID uint) (*[]models.Approval, error) {
+	return as.ApprovalDBModel.GetPendingApprovals(userID)
+}
+
+// IsPending reports whether a ticket awaits approval.
+func (as *DefaultApprovalService) IsPending(ticketID uint) (bool, error) {
+	return as.ApprovalDBModel.HasPendingRequest(ticketID)
+}
+
+// Start starts the approval chain of a new ticket within tx, if one applies
+// to it: the chain of its catalog item, otherwise the chain of its category
+// and sub-category, otherwise that of its category alone. The approvers of
+// every step are resolved right away; the requester never approves their
+// own request through a position. It returns nil when no chain applies.
+func (as *DefaultApprovalService) Start(tx *gorm.DB, ticket *models.Ticket) (*models.ApprovalRequest, error) {
+	chain, err := as.chainFor(ticket)
+	if err != nil || chain == nil {
+		return nil, err
+	}
+	requester := ticket.UserID
+	if requester.ID != 0 {
+		if user, err := as.UserDBModel.GetUserByID(requester.ID); err == nil {
+			requester = *user
+		}
+	}
+
+	request, err := as.newRequest(chain, requester)
+	if err != nil {
+		return nil, err
+	}
+	request.TicketID = ticket.ID
+	for i := range request.Approvals {
+		request.Approvals[i].TicketID = ticket.ID
+	}
+	if err := models.NewApprovalDBModel(tx).CreateRequest(request); err != nil {
+		return nil, err
+	}
+	err = models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+		TicketID: ticket.ID,
+		Action:   models.HistoryApproval,
+		Detail:   fmt.Sprintf("approval requested: %s", chain.Name),
+		Actor:    models.ActorSystem,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// StartChange starts the CAB approval of a change request within tx: the
+// chain of its type, with emergency changes falling back to the chain of
+// normal changes. Changes are raised by agents, so position approvers
+// need a department of their own. It returns nil when no chain applies.
+func (as *DefaultApprovalService) StartChange(tx *gorm.DB, change *models.ChangeRequest) (*models.ApprovalRequest, error) {
+	chain, err := as.chainForChange(change.Type)
+	if err != nil || chain == nil {
+		return nil, err
+	}
+	request, err := as.newRequest(chain, models.Users{})
+	if err != nil {
+		return nil, err
+	}
+	request.ChangeID = change.ID
+	for i := range request.Approvals {
+		request.Approvals[i].ChangeID = change.ID
+	}
+	if err := models.NewApprovalDBModel(tx).CreateRequest(request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// CancelChange cancels the pending approval of a change request within tx,
+// skipping the approvals not decided yet.
+func (as *DefaultApprovalService) CancelChange(tx *gorm.DB, changeID uint) error {
+	approvals := models.NewApprovalDBModel(tx)
+	request, err := approvals.GetChangeRequest(changeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil || request.Status != models.ApprovalStatusPending {
+		return err
+	}
+	now := time.Now()
+	request.Status = models.ApprovalStatusCancelled
+	request.CompletedAt = &now
+	if err := approvals.UpdateRequest(request); err != nil {
+		return err
+	}
+	for i := range request.Approvals {
+		a := &request.Approvals[i]
+		if a.Status != models.ApprovalStatusPending && a.Status != models.ApprovalStatusWaiting {
+			continue
+		}
+		a.Status = models.ApprovalStatusSkipped
+		if err := approvals.UpdateApproval(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetChangeApproval retrieves the approval request of a change request.
+func (as *DefaultApprovalService) GetChangeApproval(changeID uint) (*models.ApprovalRequest, error) {
+	return as.ApprovalDBModel.GetChangeRequest(changeID)
+}
+
+// newRequest copies a chain into a pending approval request, resolving the
+// approvers of each of its steps.
+func (as *DefaultApprovalService) newRequest(chain *models.ApprovalChain, requester models.Users) (*models.ApprovalRequest, error) {
+	request := &models.ApprovalRequest{
+		ChainID:     chain.ID,
+		ChainName:   chain.Name,
+		Mode:        chain.Mode,
+		RequesterID: requester.ID,
+		Status:      models.ApprovalStatusPending,
+	}
+	for i, step := range chain.Steps {
+		approvers, err := as.resolveApprovers(step, requester)
+		if err != nil {
+			return nil, err
+		}
+		if len(approvers) == 0 {
+			return nil, fmt.Errorf("%w for %q", ErrNoApprover, step.Name)
+		}
+		status := models.ApprovalStatusPending
+		if chain.Mode == models.ApprovalModeSequential && i > 0 {
+			status = models.ApprovalStatusWaiting
+		}
+		for _, approver := range approvers {
+			request.Approvals = append(request.Approvals, models.Approval{
+				StepPosition:  step.Position,
+				StepName:      step.Name,
+				Rule:          step.Rule,
+				ApproverID:    approver.ID,
+				ApproverName:  strings.TrimSpace(approver.FirstName + " " + approver.LastName),
+				ApproverEmail: approver.Email,
+				Status:        status,
+			})
+		}
+	}
+	return request, nil
+}
+
+// Notify emails the pending approvers of a request their approve and
+// reject links.
+func (as *DefaultApprovalService) Notify(request *models.ApprovalRequest, ticket *models.Ticket) error {
+	if as.Notifications == nil {
+		return nil
+	}
+	return as.notifyPending(request, func(approval *models.Approval, approveURL, rejectURL string) error {
+		return as.Notifications.NotifyApproval(approval, ticket, approveURL, rejectURL)
+	})
+}
+
+// NotifyChange emails the pending CAB approvers of a change request their
+// approve and reject links.
+func (as *DefaultApprovalService) NotifyChange(request *models.ApprovalRequest, change *models.ChangeRequest) error {
+	if as.Notifications == nil {
+		return nil
+	}
+	return as.notifyPending(request, func(approval *models.Approval, approveURL, rejectURL string) error {
+		return as.Notifications.NotifyChangeApproval(approval, change, approveURL, rejectURL)
+	})
+}
+
+// notifyPending signs the links of the pending approvals of a request and
+// hands them to send.
+func (as *DefaultApprovalService) notifyPending(request *models.ApprovalRequest, send func(approval *models.Approval, approveURL, rejectURL string) error) error {
+	expires := time.Now().Add(as.Config.LinkTTL)
+	for i := range request.Approvals {
+		approval := &request.Approvals[i]
+		if approval.Status != models.ApprovalStatusPending {
+			continue
+		}
+		approveURL := as.Config.LinkURL + as.Signer.Sign(approvalPath(approval.ID, ApprovalDecisionApprove), expires)
+		rejectURL := as.Config.LinkURL + as.Signer.Sign(approvalPath(approval.ID, ApprovalDecisionReject), expires)
+		if err := send(approval, approveURL, rejectURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Decide records the decision of a signed-in user on one of their
+// approvals.
+func (as *DefaultApprovalService) Decide(userID, approvalID uint, decision, comment string) (*models.ApprovalRequest, error) {
+	approval, err := as.ApprovalDBModel.GetApprovalByID(approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if approval.ApproverID != userID {
+		return nil, ErrNotApprover
+	}
+	return as.decide(approval, decision, comment)
+}
+
+// GetPrompt verifies an approval link and retrieves what it asks the
+// approver to decide on, without deciding.
+func (as *DefaultApprovalService) GetPrompt(approvalID uint, decision, expires, signature string) (*ApprovalPrompt, error) {
+	if err := as.Signer.Verify(approvalPath(approvalID, decision), expires, signature); err != nil {
+		return nil, err
+	}
+	approval, err := as.ApprovalDBModel.GetApprovalByID(approvalID)
+	if err != nil {
+		return nil, err
+	}
+	if approval.ChangeID != 0 {
+		change, err := models.NewChangeDBModel(as.DB).GetChangeByID(approval.ChangeID)
+		if err != nil {
+			return nil, err
+		}
+		prompt := &ApprovalPrompt{
+			Approval:    approval,
+			Decision:    decision,
+			Subject:     change.Title,
+			Description: change.Description,
+		}
+		if agent, err := models.NewAgentDBModel(as.DB).GetAgentByID(change.RequestedBy); err == nil {
+			prompt.Requester = strings.TrimSpace(agent.FirstName + " " + agent.LastName)
+		}
+		return prompt, nil
+	}
+	ticket, err := as.TicketService.TicketDBModel.GetTicketByID(approval.TicketID)
+	if err != nil {
+		return nil, err
+	}
+	return &ApprovalPrompt{
+		Approval:    approval,
+		Decision:    decision,
+		Subject:     ticket.Subject,
+		Description: ticket.Description,
+		Requester:   strings.TrimSpace(ticket.UserID.FirstName + " " + ticket.UserID.LastName),
+	}, nil
+}
+
+// DecideByLink records the decision of an approval link from an email.
+func (as *DefaultApprovalService) DecideByLink(approvalID uint, decision, expires, signature, comment string) (*models.ApprovalRequest, error) {
+	if err := as.Signer.Verify(approvalPath(approvalID, decision), expires, signature); err != nil {
+		return nil, err
+	}
+	approval, err := as.ApprovalDBModel.GetApprovalByID(approvalID)
+	if err != nil {
+		return nil, err
+	}
+	return as.decide(approval, decision, comment)
+}
+
+// decide records a decision and settles its step once the rule of the step
+// allows: a rejected step rejects the request, and an approved one opens
+// the next step in sequential mode or approves the request when it was the
+// last one. The approvals a settled step no longer needs are skipped. A
+// rejected ticket is given the rejected status, and a change request takes
+// the outcome of its approval as its status. Concurrent decisions on one
+// request are taken one after the other, each settling the step with the
+// decisions already taken.
+func (as *DefaultApprovalService) decide(approval *models.Approval, decision, comment string) (*models.ApprovalRequest, error) {
+	var status string
+	switch decision {
+	case ApprovalDecisionApprove:
+		status = models.ApprovalStatusApproved
+	case ApprovalDecisionReject:
+		status = models.ApprovalStatusRejected
+	default:
+		return nil, fmt.Errorf("%w: decision must be %s or %s", ErrInvalidDecision, ApprovalDecisionApprove, ApprovalDecisionReject)
+	}
+	switch approval.Status {
+	case models.ApprovalStatusPending:
+	case models.ApprovalStatusWaiting:
+		return nil, fmt.Errorf("%w: %s has not been reached yet", ErrInvalidDecision, approval.StepName)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrApprovalDecided, approval.Status)
+	}
+
+	now := time.Now()
+	approval.Status = status
+	approval.Comment = strings.TrimSpace(comment)
+	approval.DecidedAt = &now
+	var request *models.ApprovalRequest
+	var opened []uint
+	err := as.DB.Transaction(func(tx *gorm.DB) error {
+		approvals := models.NewApprovalDBModel(tx)
+		pending, err := approvals.LockPendingRequest(approval.RequestID)
+		if err != nil {
+			return err
+		}
+		if !pending {
+			return fmt.Errorf("%w: the request is no longer pending", ErrApprovalDecided)
+		}
+		decided, err := approvals.DecideApproval(approval)
+		if err != nil {
+			return err
+		}
+		if !decided {
+			return fmt.Errorf("%w: the approval is no longer pending", ErrApprovalDecided)
+		}
+		// The step is settled from its approvals as they are now.
+		if request, err = approvals.GetRequestByID(approval.RequestID); err != nil {
+			return err
+		}
+		var changed map[uint]bool
+		opened, changed = settleStep(request, approval.StepPosition)
+		for i := range request.Approvals {
+			if changed[request.Approvals[i].ID] {
+				if err := approvals.UpdateApproval(&request.Approvals[i]); err != nil {
+					return err
+				}
+			}
+		}
+		if request.ChangeID != 0 {
+			if request.Status == models.ApprovalStatusPending {
+				return nil
+			}
+			request.CompletedAt = &now
+			if err := approvals.UpdateRequest(request); err != nil {
+				return err
+			}
+			status, event := models.ChangeStatusApproved, models.EventChangeApproved
+			if request.Status == models.ApprovalStatusRejected {
+				status, event = models.ChangeStatusRejected, models.EventChangeRejected
+			}
+			if err := models.NewChangeDBModel(tx).SetChangeStatus(request.ChangeID, status); err != nil {
+				return err
+			}
+			return publish(tx, as.Events, event, request)
+		}
+		detail := fmt.Sprintf("%s: %s by %s", approval.StepName, approval.Status, approval.ApproverName)
+		if approval.Comment != "" {
+			detail += ": " + approval.Comment
+		}
+		history := models.NewTicketHistoryDBModel(tx)
+		err = history.CreateEntry(&models.TicketHistory{
+			TicketID: request.TicketID,
+			Action:   models.HistoryApproval,
+			Detail:   detail,
+			Actor:    approval.ApproverEmail,
+		})
+		if err != nil || request.Status == models.ApprovalStatusPending {
+			return err
+		}
+		request.CompletedAt = &now
+		if err := approvals.UpdateRequest(request); err != nil {
+			return err
+		}
+		err = history.CreateEntry(&models.TicketHistory{
+			TicketID: request.TicketID,
+			Action:   models.HistoryApproval,
+			Detail:   fmt.Sprintf("approval %s: %s", request.Status, request.ChainName),
+			Actor:    models.ActorSystem,
+		})
+		if err != nil {
+			return err
+		}
+		if request.Status == models.ApprovalStatusRejected {
+			if err := publish(tx, as.Events, models.EventTicketRejected, request); err != nil {
+				return err
+			}
+			return as.closeRejected(tx, request.TicketID)
+		}
+		return publish(tx, as.Events, models.EventTicketApproved, request)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if request.ChangeID != 0 {
+		if len(opened) > 0 {
+			if change, err := models.NewChangeDBModel(as.DB).GetChangeByID(request.ChangeID); err != nil {
+				log.Printf("approval request %d: failed to load change %d: %v", request.ID, request.ChangeID, err)
+			} else if err := as.NotifyChange(request, change); err != nil {
+				log.Printf("approval request %d: failed to notify approvers: %v", request.ID, err)
+			}
+		}
+		return request, nil
+	}
+	if len(opened) > 0 {
+		if ticket, err := as.TicketService.TicketDBModel.GetTicketByID(request.TicketID); err != nil {
+			log.Printf("approval request %d: failed to load ticket %d: %v", request.ID, request.TicketID, err)
+		} else if err := as.Notify(request, ticket); err != nil {
+			log.Printf("approval request %d: failed to notify approvers: %v", request.ID, err)
+		}
+	}
+	return request, nil
+}
+
+// settleStep settles the step at position of a request once its rule
+// allows, opening the next step or completing the request. It returns the
+// approvals it opened and every approval it changed.
+func settleStep(request *models.ApprovalRequest, position int) ([]uint, map[uint]bool) {
+	changed := map[uint]bool{}
+	outcome := stepOutcome(request.Approvals, position)
+	if outcome == models.ApprovalStatusPending {
+		return nil, changed
+	}
+	var opened []uint
+	next := 0
+	if outcome == models.ApprovalStatusApproved && request.Mode == models.ApprovalModeSequential {
+		next = nextStep(request.Approvals, position)
+	}
+	for i := range request.Approvals {
+		a := &request.Approvals[i]
+		switch {
+		case a.StepPosition == next && a.Status == models.ApprovalStatusWaiting:
+			a.Status = models.ApprovalStatusPending
+			opened = append(opened, a.ID)
+		case a.StepPosition == position || outcome == models.ApprovalStatusRejected:
+			if a.Status != models.ApprovalStatusPending && a.Status != models.ApprovalStatusWaiting {
+				continue
+			}
+			a.Status = models.ApprovalStatusSkipped
+		default:
+			continue
+		}
+		changed[a.ID] = true
+	}
+	if outcome == models.ApprovalStatusRejected {
+		request.Status = models.ApprovalStatusRejected
+	} else if next == 0 && allStepsApproved(request.Approvals) {
+		request.Status = models.ApprovalStatusApproved
+	}
+	return opened, changed
+}
+
+// closeRejected gives a ticket whose approval was rejected the rejected
+// status within tx, the transaction of the decision rejecting it.
+func (as *DefaultApprovalService) closeRejected(tx *gorm.DB, ticketID uint) error {
+	ticket, err := models.NewTicketDBModel(tx).GetTicketByID(ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket.Status.IsResolved() {
+		return nil
+	}
+	previous := *ticket
+	ticket.Status.StatusName = as.Config.RejectedStatus
+	return as.TicketService.UpdateTicketTx(tx, &previous, ticket, nil)
+}
+
+// stepOutcome tells whether the approvals of a step approve or reject it
+// under its rule, or are still pending.
+func stepOutcome(approvals []models.Approval, position int) string {
+	rule, total, approved, rejected := "", 0, 0, 0
+	for _, a := range approvals {
+		if a.StepPosition != position {
+			continue
+		}
+		rule = a.Rule
+		total++
+		switch a.Status {
+		case models.ApprovalStatusApproved:
+			approved++
+		case models.ApprovalStatusRejected:
+			rejected++
+		}
+	}
+	if rule == models.ApprovalRuleAny {
+		switch {
+		case approved > 0:
+			return models.ApprovalStatusApproved
+		case rejected == total:
+			return models.ApprovalStatusRejected
+		}
+		return models.ApprovalStatusPending
+	}
+	switch {
+	case rejected > 0:
+		return models.ApprovalStatusRejected
+	case approved == total:
+		return models.ApprovalStatusApproved
+	}
+	return models.ApprovalStatusPending
+}
+
+// nextStep returns the position of the first waiting step after position,
+// or zero when there is none.
+func nextStep(approvals []models.Approval, position int) int {
+	next := 0
+	for _, a := range approvals {
+		if a.Status == models.ApprovalStatusWaiting && a.StepPosition > position && (next == 0 || a.StepPosition < next) {
+			next = a.StepPosition
+		}
+	}
+	return next
+}
+
+// allStepsApproved reports whether every step of a request is approved.
+func allStepsApproved(approvals []models.Approval) bool {
+	for _, a := range approvals {
+		if stepOutcome(approvals, a.StepPosition) != models.ApprovalStatusApproved {
+			return false
+		}
+	}
+	return true
+}
+
+// chainFor finds the active approval chain that applies to a ticket, the
+// most specific first.
+func (as *DefaultApprovalService) chainFor(ticket *models.Ticket) (*models.ApprovalChain, error) {
+	chains, err := as.ApprovalDBModel.GetActiveChains()
+	if err != nil {
+		return nil, err
+	}
+	var best *models.ApprovalChain
+	bestScore := 0
+	for i := range *chains {
+		chain := &(*chains)[i]
+		score := 0
+		switch {
+		case chain.ChangeType != "":
+		case chain.CatalogItemID != 0:
+			if chain.CatalogItemID == ticket.CatalogItemID {
+				score = 3
+			}
+		case !strings.EqualFold(chain.Category, ticket.Category.CategoryName):
+		case chain.SubCategory == "":
+			score = 1
+		case strings.EqualFold(chain.SubCategory, ticket.SubCategory.SubCategoryName):
+			score = 2
+		}
+		if score > bestScore {
+			best, bestScore = chain, score
+		}
+	}
+	return best, nil
+}
+
+// chainForChange finds the active approval chain of a change type. An
+// emergency change without a chain of its own uses that of normal changes.
+func (as *DefaultApprovalService) chainForChange(changeType string) (*models.ApprovalChain, error) {
+	chains, err := as.ApprovalDBModel.GetActiveChains()
+	if err != nil {
+		return nil, err
+	}
+	var fallback *models.ApprovalChain
+	for i := range *chains {
+		chain := &(*chains)[i]
+		switch {
+		case chain.ChangeType == changeType:
+			return chain, nil
+		case changeType == models.ChangeTypeEmergency && chain.ChangeType == models.ChangeTypeNormal && fallback == nil:
+			fallback = chain
+		}
+	}
+	return fallback, nil
+}
+
+// resolveApprovers returns the users who approve a step of a request,
+// without duplicates.
+func (as *DefaultApprovalService) resolveApprovers(step models.ApprovalStep, requester models.Users) ([]models.Users, error) {
+	var approvers []models.Users
+	seen := map[uint]bool{}
+	for _, rule := range step.Approvers {
+		var users []models.Users
+		switch rule.Type {
+		case models.ApproverTypeUser:
+			user, err := as.UserDBModel.GetUserByID(rule.UserID)
+			if err != nil {
+				return nil, notFoundAs(err, fmt.Errorf("%w: unknown user %d for %q", ErrNoApprover, rule.UserID, step.Name))
+			}
+			users = []models.Users{*user}
+		case models.ApproverTypePosition:
+			department := rule.DepartmentID
+			if department == 0 {
+				department = requester.Department.DepartmentID
+			}
+			if department == 0 {
+				continue
+			}
+			holders, err := as.UserDBModel.GetUsersByPosition(rule.PositionID, department)
+			if err != nil {
+				return nil, err
+			}
+			for _, user := range *holders {
+				if user.ID != requester.ID {
+					users = append(users, user)
+				}
+			}
+		}
+		for _, user := range users {
+			if !seen[user.ID] {
+				seen[user.ID] = true
+				approvers = append(approvers, user)
+			}
+		}
+	}
+	return approvers, nil
+}
+
+// validateChain checks an approval chain and numbers its steps in order.
+func (as *DefaultApprovalService) validateChain(chain *models.ApprovalChain) error {
+	chain.Name = strings.TrimSpace(chain.Name)
+	if chain.Name == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidApprovalChain)
+	}
+	if chain.Mode == "" {
+		chain.Mode = models.ApprovalModeSequential
+	}
+	if chain.Mode != models.ApprovalModeSequential && chain.Mode != models.ApprovalModeParallel {
+		return fmt.Errorf("%w: mode must be %s or %s", ErrInvalidApprovalChain, models.ApprovalModeSequential, models.ApprovalModeParallel)
+	}
+	if chain.ChangeType != "" {
+		if chain.CatalogItemID != 0 || chain.Category != "" || chain.SubCategory != "" {
+			return fmt.Errorf("%w: a chain for changes is not for a catalog item or a category", ErrInvalidApprovalChain)
+		}
+		if chain.ChangeType != models.ChangeTypeNormal && chain.ChangeType != models.ChangeTypeEmergency {
+			return fmt.Errorf("%w: change type must be %s or %s", ErrInvalidApprovalChain, models.ChangeTypeNormal, models.ChangeTypeEmergency)
+		}
+	} else if chain.CatalogItemID != 0 {
+		if chain.Category != "" || chain.SubCategory != "" {
+			return fmt.Errorf("%w: a chain is for a catalog item or a category, not both", ErrInvalidApprovalChain)
+		}
+		if _, err := as.ServiceCatalogDBModel.GetItemByID(chain.CatalogItemID); err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown catalog item %d", ErrInvalidApprovalChain, chain.CatalogItemID))
+		}
+	} else if chain.Category == "" {
+		return fmt.Errorf("%w: a catalog item, a category or a change type is required", ErrInvalidApprovalChain)
+	}
+	if as.TicketService.Lookups != nil && chain.Category != "" {
+		probe := &models.Ticket{}
+		probe.Category.CategoryName = chain.Category
+		probe.SubCategory.SubCategoryName = chain.SubCategory
+		if err := as.TicketService.Lookups.ValidateTicket(nil, probe); err != nil {
+			if errors.Is(err, ErrInvalidTicketReference) {
+				return fmt.Errorf("%w: %v", ErrInvalidApprovalChain, err)
+			}
+			return err
+		}
+	}
+
+	if len(chain.Steps) == 0 {
+		return fmt.Errorf("%w: at least one step is required", ErrInvalidApprovalChain)
+	}
+	sort.SliceStable(chain.Steps, func(i, j int) bool {
+		return chain.Steps[i].Position < chain.Steps[j].Position
+	})
+	for i := range chain.Steps {
+		step := &chain.Steps[i]
+		step.ID = 0
+		step.Position = i + 1
+		step.Name = strings.TrimSpace(step.Name)
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("Step %d", step.Position)
+		}
+		if step.Rule == "" {
+			step.Rule = models.ApprovalRuleAll
+		}
+		if step.Rule != models.ApprovalRuleAll && step.Rule != models.ApprovalRuleAny {
+			return fmt.Errorf("%w: the rule of %q must be %s or %s", ErrInvalidApprovalChain, step.Name, models.ApprovalRuleAll, models.ApprovalRuleAny)
+		}
+		if len(step.Approvers) == 0 {
+			return fmt.Errorf("%w: %q needs an approver", ErrInvalidApprovalChain, step.Name)
+		}
+		for _, rule := range step.Approvers {
+			switch rule.Type {
+			case models.ApproverTypeUser:
+				if _, err := as.UserDBModel.GetUserByID(rule.UserID); err != nil {
+					return notFoundAs(err, fmt.Errorf("%w: unknown user %d in %q", ErrInvalidApprovalChain, rule.UserID, step.Name))
+				}
+			case models.ApproverTypePosition:
+				if rule.PositionID <= 0 || rule.DepartmentID < 0 {
+					return fmt.Errorf("%w: %q needs a position", ErrInvalidApprovalChain, step.Name)
+				}
+			default:
+				return fmt.Errorf("%w: approvers of %q must be of type %s or %s", ErrInvalidApprovalChain, step.Name, models.ApproverTypeUser, models.ApproverTypePosition)
+			}
+		}
+	}
+	return nil
+}
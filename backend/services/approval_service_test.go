@@ -0,0 +1,81 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T, tables ...interface{}) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+t.Name()+"?mode=memory&cache=shared"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(tables...); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestDecideSettlesStepWithConcurrentDecisions(t *testing.T) {
+	db := openTestDB(t, &models.ApprovalRequest{}, &models.Approval{}, &models.ChangeRequest{})
+	change := &models.ChangeRequest{Title: "Upgrade", Status: models.ChangeStatusPendingApproval}
+	if err := db.Create(change).Error; err != nil {
+		t.Fatal(err)
+	}
+	request := &models.ApprovalRequest{
+		ChangeID: change.ID,
+		Mode:     models.ApprovalModeParallel,
+		Status:   models.ApprovalStatusPending,
+		Approvals: []models.Approval{
+			{ChangeID: change.ID, StepPosition: 1, Rule: models.ApprovalRuleAll, ApproverID: 1, Status: models.ApprovalStatusPending},
+			{ChangeID: change.ID, StepPosition: 1, Rule: models.ApprovalRuleAll, ApproverID: 2, Status: models.ApprovalStatusPending},
+		},
+	}
+	if err := db.Create(request).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := NewDefaultApprovalService(models.NewApprovalDBModel(db), models.NewUserDBModel(db), models.NewServiceCatalogDBModel(db), nil, nil, nil, ApprovalConfig{})
+
+	// Every decision works on the approval as loaded before any of them,
+	// as concurrent requests would.
+	stale := func(i int) *models.Approval {
+		approval := request.Approvals[i]
+		return &approval
+	}
+	tests := []struct {
+		name     string
+		approval *models.Approval
+		err      error
+		status   string
+	}{
+		{"first approver", stale(0), nil, models.ApprovalStatusPending},
+		{"first approver again", stale(0), ErrApprovalDecided, ""},
+		{"second approver settles the step", stale(1), nil, models.ApprovalStatusApproved},
+		{"decision after the request completed", stale(1), ErrApprovalDecided, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decided, err := service.decide(tt.approval, ApprovalDecisionApprove, "")
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err == nil && decided.Status != tt.status {
+				t.Fatalf("request status = %s, want %s", decided.Status, tt.status)
+			}
+		})
+	}
+
+	var stored models.ChangeRequest
+	if err := db.First(&stored, change.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != models.ChangeStatusApproved {
+		t.Fatalf("change status = %s, want %s", stored.Status, models.ChangeStatusApproved)
+	}
+}
@@ -20,6 +20,7 @@ type AssetServiceInterface interface {
 type DefaultAssetService struct {
 	DB           *gorm.DB
 	AssetDBModel *models.AssetDBModel
+	Events       EventPublisher
 	// Add any dependencies or data needed for the service
 }
 
@@ -41,11 +42,12 @@ func (ps *DefaultAssetService) GetAllAssets() (*[]models.Assets, error) {
 
 // CreateAsset creates a new Asset.
 func (ps *DefaultAssetService) CreateAsset(asset *models.Assets) error {
-	err := ps.AssetDBModel.CreateAsset(asset)
-	if err != nil {
-		return err
-	}
-	return nil
+	return ps.AssetDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewAssetDBModel(tx).CreateAsset(asset); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventAssetCreated, asset)
+	})
 }
 
 // CreateAsset creates a new asset.
@@ -59,7 +61,22 @@ func (ps *DefaultAssetService) GetAssetByID(id uint) (*models.Assets, error) {
 
 // UpdateAsset updates an existing asset.
 func (ps *DefaultAssetService) UpdateAsset(asset *models.Assets) (*models.Assets, error) {
-	err := ps.AssetDBModel.UpdateAsset(asset)
+	previous, _ := ps.AssetDBModel.GetAssetByID(asset.ID)
+	events := []string{models.EventAssetUpdated}
+	if previous != nil && asset.Assignment.UserID != 0 && asset.Assignment.UserID != previous.Assignment.UserID {
+		events = append(events, models.EventAssetAssigned)
+	}
+	err := ps.AssetDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewAssetDBModel(tx).UpdateAsset(asset); err != nil {
+			return err
+		}
+		for _, event := range events {
+			if err := publish(tx, ps.Events, event, asset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -69,7 +86,12 @@ func (ps *DefaultAssetService) UpdateAsset(asset *models.Assets) (*models.Assets
 // DeleteAsset deletes an asset by ID.
 func (ps *DefaultAssetService) DeleteAsset(id uint) (bool, error) {
 	status := false
-	err := ps.AssetDBModel.DeleteAsset(id)
+	err := ps.AssetDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewAssetDBModel(tx).DeleteAsset(id); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventAssetDeleted, models.AssetRef{AssetID: id})
+	})
 	if err != nil {
 		return status, err
 	}
@@ -3,6 +3,7 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -17,24 +18,33 @@ type LoginInfo struct {
 	Password string `json:"password"`
 }
 
+// ErrInvalidLogin is returned when an email address and password do not
+// match.
+var ErrInvalidLogin = errors.New("invalid email or password")
+
 // AuthServiceInterface provides methods for managing auth.
 type AuthServiceInterface interface {
 	Registration(user *models.Users) (*models.Users, string, error)
 	Login(login *LoginInfo) (string, error)
+	AgentLogin(login *LoginInfo) (string, error)
 }
 
 // DefaultAuthService is the default implementation of AuthService
 type DefaultAuthService struct {
-	DB          *gorm.DB
-	AuthDBModel *models.AuthDBModel
-	UserDBModel *models.UserDBModel
+	DB           *gorm.DB
+	AuthDBModel  *models.AuthDBModel
+	UserDBModel  *models.UserDBModel
+	AgentDBModel *models.AgentDBModel
 	// Add any dependencies or data needed for the service
 }
 
 // NewDefaultAuthService creates a new DefaultAuthService.
 func NewDefaultAuthService(authDBModel *models.AuthDBModel) *DefaultAuthService {
 	return &DefaultAuthService{
-		AuthDBModel: authDBModel,
+		DB:           authDBModel.DB,
+		AuthDBModel:  authDBModel,
+		UserDBModel:  models.NewUserDBModel(authDBModel.DB),
+		AgentDBModel: models.NewAgentDBModel(authDBModel.DB),
 	}
 }
 
@@ -65,20 +75,44 @@ func (a *DefaultAuthService) Registration(user *models.Users) (*models.Users, st
 
 // User login
 func (a *DefaultAuthService) Login(login *LoginInfo) (string, error) {
-	loginInfo := login
-	var user models.Users
-	if err := a.DB.Where("email = ?", loginInfo.Email).First(&user).Error; err != nil {
-		return "", err
+	user, err := a.UserDBModel.GetUserByEmail(login.Email)
+	if err != nil {
+		return "", loginError(err)
+	}
+	credentials, err := a.AuthDBModel.GetUserCredentialsByUserID(user.ID)
+	if err != nil {
+		return "", loginError(err)
 	}
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Credentials.Password), []byte(loginInfo.Password)); err != nil {
-		return "", fmt.Errorf("invalid email or password")
+	if err := bcrypt.CompareHashAndPassword([]byte(credentials.Password), []byte(login.Password)); err != nil {
+		return "", ErrInvalidLogin
 	}
 	// Generate a JWT token for successful login
-	token, err := generateJWTToken(user.ID)
+	return generateJWTToken(user.ID)
+}
+
+// AgentLogin signs an agent in, returning a token carrying the agent's ID.
+func (a *DefaultAuthService) AgentLogin(login *LoginInfo) (string, error) {
+	agent, err := a.AgentDBModel.GetAgentByEmail(login.Email)
 	if err != nil {
-		return "", err
+		return "", loginError(err)
+	}
+	credentials, err := a.AuthDBModel.GetAgentCredentialsByAgentID(agent.ID)
+	if err != nil {
+		return "", loginError(err)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(credentials.Password), []byte(login.Password)); err != nil {
+		return "", ErrInvalidLogin
+	}
+	return generateJWTToken(agent.ID)
+}
+
+// loginError tells unknown accounts apart from failed lookups, without
+// telling which of the email address and password is wrong.
+func loginError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInvalidLogin
 	}
-	return token, nil
+	return err
 }
 
 // Define a function to generate JWT token
@@ -0,0 +1,450 @@
+// backend/services/bulk_ticket_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/jobs"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/rules"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBulkRequest = errors.New("invalid bulk request")
+	ErrJobsDisabled       = errors.New("background jobs are not configured")
+)
+
+// MaxBulkTickets bounds the tickets of one bulk request.
+const MaxBulkTickets = 1000
+
+// JobBulkTickets is the job kind that runs a bulk ticket job.
+const JobBulkTickets = "tickets.bulk"
+
+// BulkTicketRequest selects tickets, either by ID or with a filter, and the
+// operations to apply to them in order.
+type BulkTicketRequest struct {
+	TicketIDs  []uint                 `json:"ticket_ids"`
+	Filter     *models.TicketFilter   `json:"filter"`
+	Operations []models.BulkOperation `json:"operations"`
+}
+
+// bulkJobPayload is the payload of a JobBulkTickets job.
+type bulkJobPayload struct {
+	BulkJobID uint `json:"bulk_job_id"`
+}
+
+// BulkTicketServiceInterface provides methods for changing many tickets at once.
+type BulkTicketServiceInterface interface {
+	Submit(requestedBy uint, request BulkTicketRequest) (*models.BulkTicketJob, error)
+	GetBulkJob(id uint) (*models.BulkTicketJob, error)
+	GetBulkJobs(limit int) (*[]models.BulkTicketJob, error)
+	GetResults(id uint, status string) (*[]models.BulkTicketResult, error)
+	RunBulkJob(ctx context.Context, id uint) error
+}
+
+// DefaultBulkTicketService is the default implementation of BulkTicketService
+type DefaultBulkTicketService struct {
+	DB                *gorm.DB
+	BulkTicketDBModel *models.BulkTicketDBModel
+	TicketDBModel     *models.TicketDBModel
+	TicketService     *DefaultTicketingService
+	AgentDBModel      *models.AgentDBModel
+	Runner            *jobs.Runner
+	Events            EventPublisher
+}
+
+// NewDefaultBulkTicketService creates a new DefaultBulkTicketService.
+func NewDefaultBulkTicketService(bulkTicketDBModel *models.BulkTicketDBModel, ticketService *DefaultTicketingService, agentDBModel *models.AgentDBModel) *DefaultBulkTicketService {
+	return &DefaultBulkTicketService{
+		BulkTicketDBModel: bulkTicketDBModel,
+		TicketDBModel:     ticketService.TicketDBModel,
+		TicketService:     ticketService,
+		AgentDBModel:      agentDBModel,
+	}
+}
+
+// RegisterJobs registers the bulk jobs with the job runner, which also
+// queues them.
+func (bs *DefaultBulkTicketService) RegisterJobs(runner *jobs.Runner) {
+	bs.Runner = runner
+	runner.Register(JobBulkTickets, func(ctx context.Context, job *models.Job) error {
+		var payload bulkJobPayload
+		if err := jobs.Decode(job, &payload); err != nil {
+			return jobs.Permanent(err)
+		}
+		return bs.RunBulkJob(ctx, payload.BulkJobID)
+	})
+}
+
+func invalidBulk(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidBulkRequest, fmt.Sprintf(format, args...))
+}
+
+func (bs *DefaultBulkTicketService) validateOperations(operations []models.BulkOperation) error {
+	if len(operations) == 0 {
+		return invalidBulk("at least one operation is required")
+	}
+	merges := 0
+	for i, op := range operations {
+		switch op.Type {
+		case models.BulkAssign:
+			if op.AgentID == 0 && op.Unit == "" {
+				return invalidBulk("operation %d: agent_id or unit is required", i+1)
+			}
+			if op.AgentID != 0 {
+				if _, err := bs.AgentDBModel.GetAgentByID(op.AgentID); err != nil {
+					return invalidBulk("operation %d: agent %d not found", i+1, op.AgentID)
+				}
+			}
+		case models.BulkSetStatus, models.BulkSetPriority, models.BulkAddTag:
+			if strings.TrimSpace(op.Value) == "" {
+				return invalidBulk("operation %d: value is required", i+1)
+			}
+		case models.BulkMergeInto:
+			merges++
+			if op.TargetTicketID == 0 {
+				return invalidBulk("operation %d: target_ticket_id is required", i+1)
+			}
+			if _, err := bs.TicketDBModel.GetTicketByID(op.TargetTicketID); err != nil {
+				return invalidBulk("operation %d: ticket %d not found", i+1, op.TargetTicketID)
+			}
+		case models.BulkDelete:
+			if len(operations) > 1 {
+				return invalidBulk("delete cannot be combined with other operations")
+			}
+		default:
+			return invalidBulk("operation %d: unknown type %q", i+1, op.Type)
+		}
+	}
+	if merges > 1 {
+		return invalidBulk("tickets can only be merged into one ticket")
+	}
+	return nil
+}
+
+// Submit validates a bulk request, fixes the tickets it applies to and
+// queues it as a background job.
+func (bs *DefaultBulkTicketService) Submit(requestedBy uint, request BulkTicketRequest) (*models.BulkTicketJob, error) {
+	if bs.Runner == nil {
+		return nil, ErrJobsDisabled
+	}
+	hasFilter := request.Filter != nil && !request.Filter.IsEmpty()
+	if (len(request.TicketIDs) > 0) == hasFilter {
+		return nil, invalidBulk("either ticket_ids or a filter is required")
+	}
+	if err := bs.validateOperations(request.Operations); err != nil {
+		return nil, err
+	}
+
+	var ticketIDs []uint
+	if hasFilter {
+		ids, err := bs.TicketDBModel.FindTicketIDs(*request.Filter, MaxBulkTickets+1)
+		if err != nil {
+			return nil, err
+		}
+		ticketIDs = ids
+	} else {
+		seen := map[uint]bool{}
+		for _, id := range request.TicketIDs {
+			if id != 0 && !seen[id] {
+				seen[id] = true
+				ticketIDs = append(ticketIDs, id)
+			}
+		}
+		sort.Slice(ticketIDs, func(i, j int) bool { return ticketIDs[i] < ticketIDs[j] })
+	}
+	if len(ticketIDs) == 0 {
+		return nil, invalidBulk("no tickets selected")
+	}
+	if len(ticketIDs) > MaxBulkTickets {
+		return nil, invalidBulk("at most %d tickets can be changed at once", MaxBulkTickets)
+	}
+
+	bulkJob := &models.BulkTicketJob{
+		TicketIDs:   ticketIDs,
+		Filter:      request.Filter,
+		Operations:  request.Operations,
+		Status:      models.BulkJobQueued,
+		Total:       len(ticketIDs),
+		RequestedBy: requestedBy,
+	}
+	if !hasFilter {
+		bulkJob.Filter = nil
+	}
+	if err := bs.BulkTicketDBModel.CreateBulkJob(bulkJob); err != nil {
+		return nil, err
+	}
+	job, _, err := bs.Runner.Enqueue(JobBulkTickets, bulkJobPayload{BulkJobID: bulkJob.ID}, jobs.EnqueueOptions{
+		UniqueKey: fmt.Sprintf("%s:%d", JobBulkTickets, bulkJob.ID),
+	})
+	if err != nil {
+		bulkJob.Status = models.BulkJobFailed
+		if updateErr := bs.BulkTicketDBModel.UpdateBulkJob(bulkJob); updateErr != nil {
+			return nil, updateErr
+		}
+		return nil, err
+	}
+	bulkJob.JobID = job.ID
+	if err := bs.BulkTicketDBModel.UpdateBulkJob(bulkJob); err != nil {
+		return nil, err
+	}
+	return bulkJob, nil
+}
+
+// GetBulkJob retrieves a bulk job by its ID.
+func (bs *DefaultBulkTicketService) GetBulkJob(id uint) (*models.BulkTicketJob, error) {
+	return bs.BulkTicketDBModel.GetBulkJobByID(id)
+}
+
+// GetBulkJobs retrieves the most recent bulk jobs.
+func (bs *DefaultBulkTicketService) GetBulkJobs(limit int) (*[]models.BulkTicketJob, error) {
+	return bs.BulkTicketDBModel.GetBulkJobs(limit)
+}
+
+// GetResults retrieves the per-ticket results of a bulk job, optionally
+// only those with a status.
+func (bs *DefaultBulkTicketService) GetResults(id uint, status string) (*[]models.BulkTicketResult, error) {
+	if _, err := bs.BulkTicketDBModel.GetBulkJobByID(id); err != nil {
+		return nil, err
+	}
+	return bs.BulkTicketDBModel.GetResults(id, status)
+}
+
+// RunBulkJob applies a bulk job to its tickets. Every ticket is changed in
+// its own transaction and gets a result, so one failing ticket does not
+// hold back the others. A job that is interrupted picks up where it left
+// off when it runs again.
+func (bs *DefaultBulkTicketService) RunBulkJob(ctx context.Context, id uint) error {
+	bulkJob, err := bs.BulkTicketDBModel.GetBulkJobByID(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	switch bulkJob.Status {
+	case models.BulkJobCompleted, models.BulkJobCompletedWithErrors, models.BulkJobFailed:
+		return nil
+	}
+	previous, err := bs.BulkTicketDBModel.GetResults(id, "")
+	if err != nil {
+		return err
+	}
+	processed := map[uint]bool{}
+	bulkJob.Succeeded, bulkJob.Failed, bulkJob.Skipped = 0, 0, 0
+	for _, result := range *previous {
+		processed[result.TicketID] = true
+		bs.count(bulkJob, result.Status)
+	}
+	now := time.Now()
+	bulkJob.Status = models.BulkJobRunning
+	if bulkJob.StartedAt == nil {
+		bulkJob.StartedAt = &now
+	}
+	if err := bs.BulkTicketDBModel.UpdateBulkJob(bulkJob); err != nil {
+		return err
+	}
+
+	actor := fmt.Sprintf("agent:%d", bulkJob.RequestedBy)
+	if agent, err := bs.AgentDBModel.GetAgentByID(bulkJob.RequestedBy); err == nil && agent.AgentEmail != "" {
+		actor = agent.AgentEmail
+	}
+	for _, ticketID := range bulkJob.TicketIDs {
+		if processed[ticketID] {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		result := bs.apply(bulkJob.Operations, ticketID, actor)
+		result.BulkJobID = bulkJob.ID
+		if err := bs.BulkTicketDBModel.CreateResult(&result); err != nil {
+			return err
+		}
+		bs.count(bulkJob, result.Status)
+		if err := bs.BulkTicketDBModel.UpdateBulkJob(bulkJob); err != nil {
+			return err
+		}
+	}
+
+	finished := time.Now()
+	bulkJob.FinishedAt = &finished
+	bulkJob.Status = models.BulkJobCompleted
+	if bulkJob.Failed > 0 {
+		bulkJob.Status = models.BulkJobCompletedWithErrors
+	}
+	return bs.BulkTicketDBModel.UpdateBulkJob(bulkJob)
+}
+
+func (bs *DefaultBulkTicketService) count(bulkJob *models.BulkTicketJob, status string) {
+	switch status {
+	case models.BulkResultSucceeded:
+		bulkJob.Succeeded++
+	case models.BulkResultFailed:
+		bulkJob.Failed++
+	case models.BulkResultSkipped:
+		bulkJob.Skipped++
+	}
+}
+
+// apply applies the operations to one ticket and describes the outcome.
+func (bs *DefaultBulkTicketService) apply(operations []models.BulkOperation, ticketID uint, actor string) models.BulkTicketResult {
+	result := models.BulkTicketResult{TicketID: ticketID}
+	changes, err := bs.applyOperations(operations, ticketID, actor)
+	switch {
+	case errors.Is(err, errMergeTarget):
+		result.Status, result.Error = models.BulkResultSkipped, err.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		result.Status, result.Error = models.BulkResultFailed, "ticket not found"
+	case err != nil:
+		result.Status, result.Error = models.BulkResultFailed, err.Error()
+	case len(changes) == 0:
+		result.Status, result.Error = models.BulkResultSkipped, "nothing to change"
+	default:
+		result.Status, result.Changes = models.BulkResultSucceeded, changes
+	}
+	return result
+}
+
+var errMergeTarget = errors.New("ticket is the merge target")
+
+func (bs *DefaultBulkTicketService) applyOperations(operations []models.BulkOperation, ticketID uint, actor string) ([]string, error) {
+	var merge *models.BulkOperation
+	for i := range operations {
+		if operations[i].Type == models.BulkMergeInto {
+			merge = &operations[i]
+			if merge.TargetTicketID == ticketID {
+				return nil, errMergeTarget
+			}
+		}
+	}
+	ticket, err := bs.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if operations[0].Type == models.BulkDelete {
+		return []string{"deleted"}, bs.delete(ticket, actor)
+	}
+	if ticket.Tags, err = bs.TicketDBModel.GetTicketTags(ticketID); err != nil {
+		return nil, err
+	}
+	previous := *ticket
+
+	var changes, tags []string
+	ticketChanged := false
+	for _, op := range operations {
+		var detail string
+		var changed bool
+		switch op.Type {
+		case models.BulkAssign:
+			detail, changed, err = assignTicket(bs.AgentDBModel, ticket, op.AgentID, op.Unit)
+		case models.BulkSetStatus, models.BulkSetPriority:
+			field := rules.FieldStatus
+			if op.Type == models.BulkSetPriority {
+				field = rules.FieldPriority
+			}
+			var before string
+			before, changed, err = rules.SetField(ticket, field, op.Value)
+			detail = fmt.Sprintf("%s: %q -> %q", field, before, op.Value)
+		case models.BulkAddTag:
+			detail, changed = "tag "+op.Value, !hasTag(ticket, op.Value)
+			if changed {
+				ticket.Tags = append(ticket.Tags, models.Tags{TicketID: ticket.ID, TagName: op.Value})
+				tags = append(tags, op.Value)
+			}
+		case models.BulkMergeInto:
+			ticket.Status.StatusName = models.StatusClosed
+			detail, changed = fmt.Sprintf("merged into #%d", op.TargetTicketID), true
+		}
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			changes = append(changes, detail)
+			ticketChanged = ticketChanged || op.Type != models.BulkAddTag
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	err = bs.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		ticketDB := models.NewTicketDBModel(tx)
+		historyDB := models.NewTicketHistoryDBModel(tx)
+		if ticketChanged {
+			saved := *ticket
+			saved.Tags = nil
+			if err := bs.TicketService.UpdateTicketTx(tx, &previous, &saved, nil); err != nil {
+				return err
+			}
+		}
+		for _, tag := range tags {
+			if _, err := ticketDB.AddTag(ticket.ID, tag); err != nil {
+				return err
+			}
+		}
+		if merge != nil {
+			if err := bs.merge(tx, ticket.ID, merge.TargetTicketID, actor); err != nil {
+				return err
+			}
+		}
+		return historyDB.CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryBulkUpdated,
+			Detail:   "Bulk update: " + strings.Join(changes, "; "),
+			Actor:    actor,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// merge links a ticket with the ticket it is merged into and leaves an
+// internal note on both.
+func (bs *DefaultBulkTicketService) merge(tx *gorm.DB, ticketID, targetID uint, actor string) error {
+	if err := models.NewTicketDBModel(tx).AddRelatedTicket(ticketID, targetID); err != nil {
+		return err
+	}
+	notes := []models.TicketComment{
+		{TicketID: ticketID, Body: fmt.Sprintf("Merged into ticket #%d.", targetID), IsInternal: true, Source: models.CommentSourceWeb},
+		{TicketID: targetID, Body: fmt.Sprintf("Ticket #%d was merged into this ticket.", ticketID), IsInternal: true, Source: models.CommentSourceWeb},
+	}
+	for i := range notes {
+		if err := models.NewCommentDBModel(tx).CreateComment(&notes[i]); err != nil {
+			return err
+		}
+	}
+	return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+		TicketID: targetID,
+		Action:   models.HistoryMerged,
+		Detail:   fmt.Sprintf("Ticket #%d merged into this ticket", ticketID),
+		Actor:    actor,
+	})
+}
+
+func (bs *DefaultBulkTicketService) delete(ticket *models.Ticket, actor string) error {
+	return bs.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewTicketDBModel(tx).DeleteTicket(ticket.ID); err != nil {
+			return err
+		}
+		err := models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryBulkUpdated,
+			Detail:   "Bulk update: deleted",
+			Actor:    actor,
+		})
+		if err != nil {
+			return err
+		}
+		return publish(tx, bs.Events, models.EventTicketDeleted, models.TicketRef{TicketID: ticket.ID})
+	})
+}
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+func newTestBulkService(t *testing.T, tables ...interface{}) (*gorm.DB, *DefaultBulkTicketService) {
+	t.Helper()
+	db := openTestDB(t, append([]interface{}{&models.BulkTicketJob{}, &models.BulkTicketResult{}, &models.Agents{}}, tables...)...)
+	service := NewDefaultBulkTicketService(models.NewBulkTicketDBModel(db), NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewAgentDBModel(db))
+	return db, service
+}
+
+func TestValidateBulkOperations(t *testing.T) {
+	_, service := newTestBulkService(t)
+	tests := []struct {
+		name       string
+		operations []models.BulkOperation
+	}{
+		{"no operations", nil},
+		{"status without value", []models.BulkOperation{{Type: models.BulkSetStatus}}},
+		{"assign to nobody", []models.BulkOperation{{Type: models.BulkAssign}}},
+		{"assign to unknown agent", []models.BulkOperation{{Type: models.BulkAssign, AgentID: 9}}},
+		{"merge without target", []models.BulkOperation{{Type: models.BulkMergeInto}}},
+		{"delete with other operations", []models.BulkOperation{{Type: models.BulkDelete}, {Type: models.BulkAddTag, Value: "vip"}}},
+		{"unknown type", []models.BulkOperation{{Type: "archive"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := service.validateOperations(tt.operations); !errors.Is(err, ErrInvalidBulkRequest) {
+				t.Fatalf("err = %v, want ErrInvalidBulkRequest", err)
+			}
+		})
+	}
+	if err := service.validateOperations([]models.BulkOperation{{Type: models.BulkDelete}}); err != nil {
+		t.Fatalf("delete alone: %v", err)
+	}
+}
+
+func TestRunBulkJobRecordsEveryTicket(t *testing.T) {
+	db, service := newTestBulkService(t)
+	// Ticket 3 was done before the job was interrupted; ticket 2 cannot be
+	// found and ticket 1 is the merge target.
+	job := &models.BulkTicketJob{
+		TicketIDs:   []uint{2, 1, 3},
+		Operations:  []models.BulkOperation{{Type: models.BulkMergeInto, TargetTicketID: 1}},
+		Status:      models.BulkJobRunning,
+		Total:       3,
+		RequestedBy: 7,
+	}
+	if err := db.Create(job).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(&models.BulkTicketResult{BulkJobID: job.ID, TicketID: 3, Status: models.BulkResultSucceeded}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.RunBulkJob(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	got, err := service.GetBulkJob(job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Status != models.BulkJobCompletedWithErrors || got.Succeeded != 1 || got.Failed != 1 || got.Skipped != 1 || got.FinishedAt == nil {
+		t.Fatalf("job = %s, %d succeeded, %d failed, %d skipped", got.Status, got.Succeeded, got.Failed, got.Skipped)
+	}
+	results, err := service.GetResults(job.ID, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statuses := map[uint]string{}
+	for _, result := range *results {
+		if _, ok := statuses[result.TicketID]; ok {
+			t.Fatalf("ticket %d has two results", result.TicketID)
+		}
+		statuses[result.TicketID] = result.Status
+		if result.Status != models.BulkResultSucceeded && result.Error == "" {
+			t.Fatalf("ticket %d %s without an error", result.TicketID, result.Status)
+		}
+	}
+	want := map[uint]string{1: models.BulkResultSkipped, 2: models.BulkResultFailed, 3: models.BulkResultSucceeded}
+	for id, status := range want {
+		if statuses[id] != status {
+			t.Fatalf("ticket %d = %q, want %q", id, statuses[id], status)
+		}
+	}
+
+	// A finished job does not run again.
+	if err := service.RunBulkJob(context.Background(), job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if results, _ := service.GetResults(job.ID, ""); len(*results) != 3 {
+		t.Fatalf("%d results after running again, want 3", len(*results))
+	}
+}
+
+func TestBulkMergeLinksAndNotesBothTickets(t *testing.T) {
+	db, service := newTestBulkService(t, &models.RelatedTicket{}, &models.TicketComment{}, &models.TicketHistory{})
+	merge := func() error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			return service.merge(tx, 2, 1, "ada@example.com")
+		})
+	}
+	if err := merge(); err != nil {
+		t.Fatal(err)
+	}
+
+	var links []models.RelatedTicket
+	if err := db.Order("ticket_id").Find(&links).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 2 || links[0].TicketID != 1 || links[0].RelatedTicketID != 2 || links[1].TicketID != 2 || links[1].RelatedTicketID != 1 {
+		t.Fatalf("links = %+v", links)
+	}
+	var notes []models.TicketComment
+	if err := db.Find(&notes).Error; err != nil {
+		t.Fatal(err)
+	}
+	noted := map[uint]bool{}
+	for _, note := range notes {
+		if !note.IsInternal {
+			t.Fatalf("note on ticket %d is public", note.TicketID)
+		}
+		noted[note.TicketID] = true
+	}
+	if len(notes) != 2 || !noted[1] || !noted[2] {
+		t.Fatalf("notes = %+v", notes)
+	}
+	var history []models.TicketHistory
+	if err := db.Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].TicketID != 1 || history[0].Action != models.HistoryMerged || history[0].Actor != "ada@example.com" {
+		t.Fatalf("history = %+v", history)
+	}
+
+	// Merging again links nothing twice.
+	if err := merge(); err != nil {
+		t.Fatal(err)
+	}
+	var count int64
+	if err := db.Model(&models.RelatedTicket{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatalf("%d links after merging again, want 2", count)
+	}
+}
+
+type failingPublisher struct{}
+
+func (failingPublisher) Publish(tx *gorm.DB, event string, payload interface{}) error {
+	return errors.New("outbox unavailable")
+}
+
+func TestBulkDeleteIsAllOrNothing(t *testing.T) {
+	db, service := newTestBulkService(t, &models.TicketHistory{})
+	service.Events = failingPublisher{}
+
+	if err := service.delete(&models.Ticket{ID: 2}, "ada@example.com"); err == nil {
+		t.Fatal("delete succeeded without its event")
+	}
+	var count int64
+	if err := db.Model(&models.TicketHistory{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("%d history entries for a delete that failed", count)
+	}
+}
@@ -0,0 +1,471 @@
+// backend/services/canned_response_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/canned"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/rules"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCannedResponse = errors.New("invalid canned response")
+	ErrInvalidMacro          = errors.New("invalid macro")
+)
+
+// MaxMacroTickets bounds the tickets a macro is applied to at once.
+const MaxMacroTickets = 100
+
+// MacroResult is the outcome of applying a macro to one ticket.
+type MacroResult struct {
+	TicketID uint     `json:"ticket_id"`
+	Applied  bool     `json:"applied"`
+	Changes  []string `json:"changes,omitempty"`
+	Error    string   `json:"error,omitempty"`
+}
+
+// CannedResponseServiceInterface provides methods for canned responses and macros.
+type CannedResponseServiceInterface interface {
+	CreateResponse(agentID uint, response *models.CannedResponse) error
+	UpdateResponse(agentID uint, response *models.CannedResponse) (*models.CannedResponse, error)
+	GetResponse(agentID, id uint) (*models.CannedResponse, error)
+	GetResponses(agentID uint, search string) (*[]models.CannedResponse, error)
+	DeleteResponse(agentID, id uint) (bool, error)
+	RenderResponse(agentID, id, ticketID uint) (string, error)
+	CreateMacro(agentID uint, macro *models.Macro) error
+	UpdateMacro(agentID uint, macro *models.Macro) (*models.Macro, error)
+	GetMacro(agentID, id uint) (*models.Macro, error)
+	GetMacros(agentID uint) (*[]models.Macro, error)
+	DeleteMacro(agentID, id uint) (bool, error)
+	ApplyMacro(agentID, id uint, ticketIDs []uint) ([]MacroResult, error)
+}
+
+// DefaultCannedResponseService is the default implementation of CannedResponseService
+type DefaultCannedResponseService struct {
+	DB                    *gorm.DB
+	CannedResponseDBModel *models.CannedResponseDBModel
+	TicketDBModel         *models.TicketDBModel
+	TicketService         *DefaultTicketingService
+	AgentDBModel          *models.AgentDBModel
+	UserDBModel           *models.UserDBModel
+	Events                EventPublisher
+}
+
+// NewDefaultCannedResponseService creates a new DefaultCannedResponseService.
+func NewDefaultCannedResponseService(cannedResponseDBModel *models.CannedResponseDBModel, ticketService *DefaultTicketingService, agentDBModel *models.AgentDBModel, userDBModel *models.UserDBModel) *DefaultCannedResponseService {
+	return &DefaultCannedResponseService{
+		CannedResponseDBModel: cannedResponseDBModel,
+		TicketDBModel:         ticketService.TicketDBModel,
+		TicketService:         ticketService,
+		AgentDBModel:          agentDBModel,
+		UserDBModel:           userDBModel,
+	}
+}
+
+// agent returns the acting agent. An unknown agent only sees global
+// responses and macros.
+func (cs *DefaultCannedResponseService) agent(agentID uint) (models.Agents, error) {
+	if agentID == 0 {
+		return models.Agents{}, nil
+	}
+	agent, err := cs.AgentDBModel.GetAgentByID(agentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return models.Agents{}, nil
+	}
+	if err != nil {
+		return models.Agents{}, err
+	}
+	return *agent, nil
+}
+
+// visibleInScope reports whether an agent may see something of a scope.
+// What an agent may not see is reported as not found.
+func visibleInScope(agent models.Agents, scope string, ownerID uint, unitName string) bool {
+	switch scope {
+	case models.ScopeGlobal:
+		return true
+	case models.ScopePersonal:
+		return agent.ID != 0 && ownerID == agent.ID
+	case models.ScopeUnit:
+		return unitName != "" && strings.EqualFold(unitName, agent.Unit.UnitName)
+	}
+	return false
+}
+
+// setScope normalizes the scope fields of a new or updated response or
+// macro. Personal ones belong to the acting agent, and unit ones default to
+// the unit of the acting agent.
+func setScope(agent models.Agents, scope *string, ownerID *uint, unitName *string) error {
+	switch *scope {
+	case "", models.ScopePersonal:
+		if agent.ID == 0 {
+			return errors.New("personal scope requires a known agent")
+		}
+		*scope, *ownerID, *unitName = models.ScopePersonal, agent.ID, ""
+	case models.ScopeUnit:
+		if *unitName == "" {
+			*unitName = agent.Unit.UnitName
+		}
+		if *unitName == "" {
+			return errors.New("unit_name is required")
+		}
+		*ownerID = 0
+	case models.ScopeGlobal:
+		*ownerID, *unitName = 0, ""
+	default:
+		return fmt.Errorf("scope must be %q, %q or %q", models.ScopePersonal, models.ScopeUnit, models.ScopeGlobal)
+	}
+	return nil
+}
+
+func (cs *DefaultCannedResponseService) prepareResponse(agent models.Agents, response *models.CannedResponse) error {
+	if strings.TrimSpace(response.Title) == "" || strings.TrimSpace(response.Body) == "" {
+		return fmt.Errorf("%w: title and body are required", ErrInvalidCannedResponse)
+	}
+	if err := canned.Validate(response.Body); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCannedResponse, err)
+	}
+	if err := setScope(agent, &response.Scope, &response.AgentID, &response.UnitName); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCannedResponse, err)
+	}
+	return nil
+}
+
+// CreateResponse validates and creates a canned response for an agent.
+func (cs *DefaultCannedResponseService) CreateResponse(agentID uint, response *models.CannedResponse) error {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return err
+	}
+	if err := cs.prepareResponse(agent, response); err != nil {
+		return err
+	}
+	response.CreatedBy = agentID
+	return cs.CannedResponseDBModel.CreateResponse(response)
+}
+
+// UpdateResponse validates and updates a canned response the agent can see.
+func (cs *DefaultCannedResponseService) UpdateResponse(agentID uint, response *models.CannedResponse) (*models.CannedResponse, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := cs.getResponse(agent, response.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.prepareResponse(agent, response); err != nil {
+		return nil, err
+	}
+	response.CreatedBy = existing.CreatedBy
+	response.CreatedAt = existing.CreatedAt
+	if err := cs.CannedResponseDBModel.UpdateResponse(response); err != nil {
+		return nil, err
+	}
+	return response, nil
+}
+
+func (cs *DefaultCannedResponseService) getResponse(agent models.Agents, id uint) (*models.CannedResponse, error) {
+	response, err := cs.CannedResponseDBModel.GetResponseByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !visibleInScope(agent, response.Scope, response.AgentID, response.UnitName) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return response, nil
+}
+
+// GetResponse retrieves a canned response the agent can see.
+func (cs *DefaultCannedResponseService) GetResponse(agentID, id uint) (*models.CannedResponse, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return cs.getResponse(agent, id)
+}
+
+// GetResponses retrieves the canned responses the agent can see, optionally
+// only those matching search.
+func (cs *DefaultCannedResponseService) GetResponses(agentID uint, search string) (*[]models.CannedResponse, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return cs.CannedResponseDBModel.GetVisibleResponses(agent.ID, agent.Unit.UnitName, strings.TrimSpace(search))
+}
+
+// DeleteResponse deletes a canned response the agent can see.
+func (cs *DefaultCannedResponseService) DeleteResponse(agentID, id uint) (bool, error) {
+	if _, err := cs.GetResponse(agentID, id); err != nil {
+		return false, err
+	}
+	if err := cs.CannedResponseDBModel.DeleteResponse(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RenderResponse fills in a canned response for a ticket, so the agent can
+// review it before sending.
+func (cs *DefaultCannedResponseService) RenderResponse(agentID, id, ticketID uint) (string, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return "", err
+	}
+	response, err := cs.getResponse(agent, id)
+	if err != nil {
+		return "", err
+	}
+	ticket, err := cs.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return "", err
+	}
+	return canned.Render(response.Body, cs.placeholderData(ticket, agent)), nil
+}
+
+func (cs *DefaultCannedResponseService) placeholderData(ticket *models.Ticket, agent models.Agents) canned.Data {
+	user := ticket.UserID
+	if user.ID != 0 && cs.UserDBModel != nil {
+		if u, err := cs.UserDBModel.GetUserByID(user.ID); err == nil {
+			user = *u
+		}
+	}
+	return canned.Data{Ticket: ticket, User: user, Agent: agent}
+}
+
+func (cs *DefaultCannedResponseService) prepareMacro(agent models.Agents, macro *models.Macro) error {
+	if strings.TrimSpace(macro.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidMacro)
+	}
+	var tags []string
+	for _, tag := range macro.Tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	macro.Tags = tags
+	if macro.ResponseID == 0 && macro.Status == "" && macro.Priority == "" && len(macro.Tags) == 0 {
+		return fmt.Errorf("%w: a response, status, priority or tag is required", ErrInvalidMacro)
+	}
+	if macro.ResponseID != 0 {
+		if _, err := cs.getResponse(agent, macro.ResponseID); err != nil {
+			return fmt.Errorf("%w: response %d not found", ErrInvalidMacro, macro.ResponseID)
+		}
+	}
+	if err := setScope(agent, &macro.Scope, &macro.AgentID, &macro.UnitName); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidMacro, err)
+	}
+	return nil
+}
+
+// CreateMacro validates and creates a macro for an agent.
+func (cs *DefaultCannedResponseService) CreateMacro(agentID uint, macro *models.Macro) error {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return err
+	}
+	if err := cs.prepareMacro(agent, macro); err != nil {
+		return err
+	}
+	macro.CreatedBy = agentID
+	return cs.CannedResponseDBModel.CreateMacro(macro)
+}
+
+// UpdateMacro validates and updates a macro the agent can see.
+func (cs *DefaultCannedResponseService) UpdateMacro(agentID uint, macro *models.Macro) (*models.Macro, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := cs.getMacro(agent, macro.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.prepareMacro(agent, macro); err != nil {
+		return nil, err
+	}
+	macro.CreatedBy = existing.CreatedBy
+	macro.CreatedAt = existing.CreatedAt
+	if err := cs.CannedResponseDBModel.UpdateMacro(macro); err != nil {
+		return nil, err
+	}
+	return macro, nil
+}
+
+func (cs *DefaultCannedResponseService) getMacro(agent models.Agents, id uint) (*models.Macro, error) {
+	macro, err := cs.CannedResponseDBModel.GetMacroByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !visibleInScope(agent, macro.Scope, macro.AgentID, macro.UnitName) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return macro, nil
+}
+
+// GetMacro retrieves a macro the agent can see.
+func (cs *DefaultCannedResponseService) GetMacro(agentID, id uint) (*models.Macro, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return cs.getMacro(agent, id)
+}
+
+// GetMacros retrieves the macros the agent can see.
+func (cs *DefaultCannedResponseService) GetMacros(agentID uint) (*[]models.Macro, error) {
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	return cs.CannedResponseDBModel.GetVisibleMacros(agent.ID, agent.Unit.UnitName)
+}
+
+// DeleteMacro deletes a macro the agent can see.
+func (cs *DefaultCannedResponseService) DeleteMacro(agentID, id uint) (bool, error) {
+	if _, err := cs.GetMacro(agentID, id); err != nil {
+		return false, err
+	}
+	if err := cs.CannedResponseDBModel.DeleteMacro(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ApplyMacro applies a macro to tickets on behalf of an agent. Each ticket
+// is changed in its own transaction, so one failing ticket does not hold
+// back the others; the outcome for every ticket is returned in order.
+func (cs *DefaultCannedResponseService) ApplyMacro(agentID, id uint, ticketIDs []uint) ([]MacroResult, error) {
+	if len(ticketIDs) == 0 || len(ticketIDs) > MaxMacroTickets {
+		return nil, fmt.Errorf("%w: between 1 and %d tickets are required", ErrInvalidMacro, MaxMacroTickets)
+	}
+	agent, err := cs.agent(agentID)
+	if err != nil {
+		return nil, err
+	}
+	macro, err := cs.getMacro(agent, id)
+	if err != nil {
+		return nil, err
+	}
+	var response *models.CannedResponse
+	if macro.ResponseID != 0 {
+		if response, err = cs.CannedResponseDBModel.GetResponseByID(macro.ResponseID); err != nil {
+			return nil, fmt.Errorf("%w: response %d: %v", ErrInvalidMacro, macro.ResponseID, err)
+		}
+	}
+
+	results := make([]MacroResult, 0, len(ticketIDs))
+	seen := map[uint]bool{}
+	for _, ticketID := range ticketIDs {
+		if seen[ticketID] {
+			continue
+		}
+		seen[ticketID] = true
+		result := MacroResult{TicketID: ticketID}
+		result.Changes, err = cs.applyMacro(ticketID, macro, response, agent)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			result.Error = "ticket not found"
+		} else if err != nil {
+			result.Error = err.Error()
+		} else {
+			result.Applied = len(result.Changes) > 0
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// applyMacro applies a macro to one ticket and returns what it changed.
+func (cs *DefaultCannedResponseService) applyMacro(ticketID uint, macro *models.Macro, response *models.CannedResponse, agent models.Agents) ([]string, error) {
+	ticket, err := cs.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Tags, err = cs.TicketDBModel.GetTicketTags(ticketID); err != nil {
+		return nil, err
+	}
+	previous := *ticket
+
+	var changes []string
+	ticketChanged := false
+	for _, field := range []struct{ name, value string }{{rules.FieldStatus, macro.Status}, {rules.FieldPriority, macro.Priority}} {
+		if field.value == "" {
+			continue
+		}
+		before, changed, err := rules.SetField(ticket, field.name, field.value)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", field.name, before, field.value))
+			ticketChanged = true
+		}
+	}
+	var tags []string
+	for _, tag := range macro.Tags {
+		if !hasTag(ticket, tag) {
+			tags = append(tags, tag)
+			ticket.Tags = append(ticket.Tags, models.Tags{TicketID: ticket.ID, TagName: tag})
+			changes = append(changes, "tag "+tag)
+		}
+	}
+	var comment *models.TicketComment
+	if response != nil {
+		comment = &models.TicketComment{
+			TicketID:   ticket.ID,
+			AgentID:    agent.ID,
+			Body:       canned.Render(response.Body, cs.placeholderData(ticket, agent)),
+			IsInternal: macro.Internal,
+			Source:     models.CommentSourceWeb,
+		}
+		changes = append(changes, "response "+response.Title)
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	actor := agent.AgentEmail
+	if actor == "" {
+		actor = fmt.Sprintf("agent:%d", agent.ID)
+	}
+	err = cs.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if ticketChanged {
+			saved := *ticket
+			saved.Tags = nil
+			if err := cs.TicketService.UpdateTicketTx(tx, &previous, &saved, nil); err != nil {
+				return err
+			}
+		}
+		for _, tag := range tags {
+			if _, err := models.NewTicketDBModel(tx).AddTag(ticket.ID, tag); err != nil {
+				return err
+			}
+		}
+		if comment != nil {
+			if err := models.NewCommentDBModel(tx).CreateComment(comment); err != nil {
+				return err
+			}
+			if !comment.IsInternal {
+				if err := publish(tx, cs.Events, models.EventTicketReplied, comment); err != nil {
+					return err
+				}
+			}
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryMacroApplied,
+			Detail:   fmt.Sprintf("Macro %q: %s", macro.Name, strings.Join(changes, "; ")),
+			Actor:    actor,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
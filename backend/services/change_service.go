@@ -0,0 +1,589 @@
+// backend/services/change_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/calendar"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/storage"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidChange       = errors.New("invalid change request")
+	ErrInvalidRiskQuestion = errors.New("invalid risk question")
+	ErrChangeStatus        = errors.New("change request cannot do this in its status")
+	ErrChangeConflict      = errors.New("change window conflicts with another change")
+	ErrNoChangeChain       = errors.New("no approval chain for this change type")
+)
+
+// changeCalendarPath is the path of the iCalendar feed of the changes.
+const changeCalendarPath = "/changes/calendar.ics"
+
+// ChangeConfig configures change management.
+type ChangeConfig struct {
+	// MediumRisk and HighRisk are the combined risk and impact scores from
+	// which a change is of medium and of high risk.
+	MediumRisk int
+	HighRisk   int
+	// FeedTTL is how long a signed link to the change calendar is valid.
+	FeedTTL time.Duration
+	// LinkURL is prefixed to the calendar link and to the links of its
+	// entries. It defaults to that of the approvals.
+	LinkURL string
+}
+
+// DefaultChangeConfig returns the default change management settings.
+func DefaultChangeConfig() ChangeConfig {
+	return ChangeConfig{
+		MediumRisk: 5,
+		HighRisk:   10,
+		FeedTTL:    365 * 24 * time.Hour,
+	}
+}
+
+// ChangeTransition moves a change request on, with notes on how it went.
+type ChangeTransition struct {
+	Notes string `json:"notes"`
+}
+
+// ChangeServiceInterface provides methods for change management.
+type ChangeServiceInterface interface {
+	CreateChange(agentID uint, change *models.ChangeRequest) error
+	UpdateChange(change *models.ChangeRequest) (*models.ChangeRequest, error)
+	DeleteChange(id uint) (bool, error)
+	GetChange(id uint) (*models.ChangeRequest, error)
+	GetChanges(status string) (*[]models.ChangeRequest, error)
+	SubmitChange(id uint) (*models.ChangeRequest, error)
+	StartChange(id uint) (*models.ChangeRequest, error)
+	CompleteChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error)
+	FailChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error)
+	CancelChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error)
+	GetConflicts(id uint) (*[]models.ChangeRequest, error)
+	GetCalendarLink() string
+	WriteCalendar(w io.Writer, expires, signature string, from, to time.Time) error
+	CreateQuestion(question *models.RiskQuestion) error
+	UpdateQuestion(question *models.RiskQuestion) (*models.RiskQuestion, error)
+	DeleteQuestion(id uint) (bool, error)
+	GetQuestions() (*[]models.RiskQuestion, error)
+}
+
+// DefaultChangeService is the default implementation of ChangeService
+type DefaultChangeService struct {
+	DB            *gorm.DB
+	ChangeDBModel *models.ChangeDBModel
+	Approvals     *DefaultApprovalService
+	Signer        *storage.URLSigner
+	Config        ChangeConfig
+}
+
+// NewDefaultChangeService creates a new DefaultChangeService.
+func NewDefaultChangeService(changeDBModel *models.ChangeDBModel, approvals *DefaultApprovalService, signer *storage.URLSigner, config ChangeConfig) *DefaultChangeService {
+	defaults := DefaultChangeConfig()
+	if config.MediumRisk <= 0 {
+		config.MediumRisk = defaults.MediumRisk
+	}
+	if config.HighRisk <= 0 {
+		config.HighRisk = defaults.HighRisk
+	}
+	if config.FeedTTL <= 0 {
+		config.FeedTTL = defaults.FeedTTL
+	}
+	if config.LinkURL == "" && approvals != nil {
+		config.LinkURL = approvals.Config.LinkURL
+	}
+	config.LinkURL = strings.TrimRight(config.LinkURL, "/")
+	return &DefaultChangeService{
+		DB:            changeDBModel.DB,
+		ChangeDBModel: changeDBModel,
+		Approvals:     approvals,
+		Signer:        signer,
+		Config:        config,
+	}
+}
+
+// CreateChange records a draft change request raised by an agent.
+func (cs *DefaultChangeService) CreateChange(agentID uint, change *models.ChangeRequest) error {
+	change.ID = 0
+	change.RequestedBy = agentID
+	change.Status = models.ChangeStatusDraft
+	change.ActualStart, change.ActualEnd, change.ClosureNotes = nil, nil, ""
+	if err := cs.validateChange(change); err != nil {
+		return err
+	}
+	return cs.ChangeDBModel.CreateChange(change)
+}
+
+// UpdateChange updates a draft change request. Editing a rejected change
+// takes it back to draft so it can be submitted again.
+func (cs *DefaultChangeService) UpdateChange(change *models.ChangeRequest) (*models.ChangeRequest, error) {
+	existing, err := cs.ChangeDBModel.GetChangeByID(change.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Status != models.ChangeStatusDraft && existing.Status != models.ChangeStatusRejected {
+		return nil, fmt.Errorf("%w: only draft and rejected changes can be edited, this one is %s", ErrChangeStatus, existing.Status)
+	}
+	change.Model = existing.Model
+	change.CreatedAt = existing.CreatedAt
+	change.RequestedBy = existing.RequestedBy
+	change.Status = models.ChangeStatusDraft
+	change.ActualStart, change.ActualEnd, change.ClosureNotes = nil, nil, ""
+	if err := cs.validateChange(change); err != nil {
+		return nil, err
+	}
+	if err := cs.ChangeDBModel.UpdateChange(change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// DeleteChange deletes a change request that is not under way.
+func (cs *DefaultChangeService) DeleteChange(id uint) (bool, error) {
+	change, err := cs.ChangeDBModel.GetChangeByID(id)
+	if err != nil {
+		return false, err
+	}
+	if change.Status == models.ChangeStatusInProgress {
+		return false, fmt.Errorf("%w: the change is in progress", ErrChangeStatus)
+	}
+	err = cs.DB.Transaction(func(tx *gorm.DB) error {
+		if cs.Approvals != nil {
+			if err := cs.Approvals.CancelChange(tx, id); err != nil {
+				return err
+			}
+		}
+		return models.NewChangeDBModel(tx).DeleteChange(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetChange retrieves a change request.
+func (cs *DefaultChangeService) GetChange(id uint) (*models.ChangeRequest, error) {
+	return cs.ChangeDBModel.GetChangeByID(id)
+}
+
+// GetChanges retrieves the change requests, optionally only those with a
+// status.
+func (cs *DefaultChangeService) GetChanges(status string) (*[]models.ChangeRequest, error) {
+	if status != "" && !containsString(models.ChangeStatuses, status) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalidChange, status)
+	}
+	return cs.ChangeDBModel.GetChanges(status)
+}
+
+// SubmitChange submits a draft change request once its plans, window and
+// questionnaire are complete. Its window may not overlap that of another
+// change to the same assets, except for an emergency change, which can
+// be checked for conflicts afterwards. A standard change is approved right
+// away; the others await the approval of the chain of their type.
+func (cs *DefaultChangeService) SubmitChange(id uint) (*models.ChangeRequest, error) {
+	change, err := cs.ChangeDBModel.GetChangeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != models.ChangeStatusDraft {
+		return nil, fmt.Errorf("%w: only draft changes can be submitted, this one is %s", ErrChangeStatus, change.Status)
+	}
+	var missing []string
+	if strings.TrimSpace(change.ImplementationPlan) == "" {
+		missing = append(missing, "an implementation plan")
+	}
+	if strings.TrimSpace(change.BackoutPlan) == "" {
+		missing = append(missing, "a back-out plan")
+	}
+	if change.PlannedStart == nil || change.PlannedEnd == nil {
+		missing = append(missing, "a scheduled window")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%w: %s required", ErrInvalidChange, strings.Join(missing, ", "))
+	}
+	if err := cs.scoreRisk(change, true); err != nil {
+		return nil, err
+	}
+
+	var request *models.ApprovalRequest
+	err = cs.DB.Transaction(func(tx *gorm.DB) error {
+		// The overlapping changes stay locked until the change is submitted,
+		// so two changes to the same assets cannot both pass the check.
+		if change.Type != models.ChangeTypeEmergency {
+			locked := models.NewChangeDBModel(tx.Clauses(clause.Locking{Strength: "UPDATE"}))
+			conflicts, err := cs.conflicts(locked, change)
+			if err != nil {
+				return err
+			}
+			if len(conflicts) > 0 {
+				ids := make([]string, len(conflicts))
+				for i, c := range conflicts {
+					ids[i] = fmt.Sprintf("#%d", c.ID)
+				}
+				return fmt.Errorf("%w: %s", ErrChangeConflict, strings.Join(ids, ", "))
+			}
+		}
+		change.Status = models.ChangeStatusApproved
+		if change.Type != models.ChangeTypeStandard {
+			if cs.Approvals != nil {
+				request, err = cs.Approvals.StartChange(tx, change)
+				if err != nil {
+					return err
+				}
+			}
+			if request == nil {
+				return fmt.Errorf("%w: %s", ErrNoChangeChain, change.Type)
+			}
+			change.Status = models.ChangeStatusPendingApproval
+		}
+		submitted, err := models.NewChangeDBModel(tx).UpdateDraftChange(change)
+		if err != nil {
+			return err
+		}
+		if !submitted {
+			return fmt.Errorf("%w: the change is no longer a draft", ErrChangeStatus)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if request != nil {
+		if err := cs.Approvals.NotifyChange(request, change); err != nil {
+			log.Printf("change %d: failed to notify approvers: %v", change.ID, err)
+		}
+	}
+	return change, nil
+}
+
+// StartChange records that the implementation of an approved change has
+// begun.
+func (cs *DefaultChangeService) StartChange(id uint) (*models.ChangeRequest, error) {
+	return cs.transition(id, []string{models.ChangeStatusApproved}, models.ChangeStatusInProgress, func(change *models.ChangeRequest, now time.Time) {
+		change.ActualStart = &now
+	})
+}
+
+// CompleteChange records that a change was implemented.
+func (cs *DefaultChangeService) CompleteChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error) {
+	return cs.transition(id, []string{models.ChangeStatusInProgress}, models.ChangeStatusCompleted, func(change *models.ChangeRequest, now time.Time) {
+		change.ActualEnd = &now
+		change.ClosureNotes = strings.TrimSpace(transition.Notes)
+	})
+}
+
+// FailChange records that a change failed and was backed out.
+func (cs *DefaultChangeService) FailChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error) {
+	return cs.transition(id, []string{models.ChangeStatusInProgress}, models.ChangeStatusFailed, func(change *models.ChangeRequest, now time.Time) {
+		change.ActualEnd = &now
+		change.ClosureNotes = strings.TrimSpace(transition.Notes)
+	})
+}
+
+// CancelChange cancels a change that has not begun, and its approval if it
+// is pending.
+func (cs *DefaultChangeService) CancelChange(id uint, transition *ChangeTransition) (*models.ChangeRequest, error) {
+	from := []string{models.ChangeStatusDraft, models.ChangeStatusPendingApproval, models.ChangeStatusApproved, models.ChangeStatusRejected}
+	return cs.transition(id, from, models.ChangeStatusCancelled, func(change *models.ChangeRequest, now time.Time) {
+		change.ClosureNotes = strings.TrimSpace(transition.Notes)
+	})
+}
+
+// transition moves a change in one of the from statuses to status.
+func (cs *DefaultChangeService) transition(id uint, from []string, status string, apply func(change *models.ChangeRequest, now time.Time)) (*models.ChangeRequest, error) {
+	change, err := cs.ChangeDBModel.GetChangeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(from, change.Status) {
+		return nil, fmt.Errorf("%w: a %s change cannot become %s", ErrChangeStatus, change.Status, status)
+	}
+	pending := change.Status == models.ChangeStatusPendingApproval
+	change.Status = status
+	apply(change, time.Now())
+	err = cs.DB.Transaction(func(tx *gorm.DB) error {
+		if pending && cs.Approvals != nil {
+			if err := cs.Approvals.CancelChange(tx, change.ID); err != nil {
+				return err
+			}
+		}
+		return models.NewChangeDBModel(tx).UpdateChange(change)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// GetConflicts retrieves the active changes whose window overlaps that of
+// a change and which touch one of its assets.
+func (cs *DefaultChangeService) GetConflicts(id uint) (*[]models.ChangeRequest, error) {
+	change, err := cs.ChangeDBModel.GetChangeByID(id)
+	if err != nil {
+		return nil, err
+	}
+	conflicts, err := cs.conflicts(cs.ChangeDBModel, change)
+	if err != nil {
+		return nil, err
+	}
+	return &conflicts, nil
+}
+
+// conflicts retrieves, through changes, the active changes whose window
+// overlaps that of change and which touch one of its assets.
+func (cs *DefaultChangeService) conflicts(changes *models.ChangeDBModel, change *models.ChangeRequest) ([]models.ChangeRequest, error) {
+	conflicts := []models.ChangeRequest{}
+	if change.PlannedStart == nil || change.PlannedEnd == nil || len(change.AssetIDs) == 0 {
+		return conflicts, nil
+	}
+	scheduled, err := changes.GetScheduledChanges(*change.PlannedStart, *change.PlannedEnd)
+	if err != nil {
+		return nil, err
+	}
+	assets := map[uint]bool{}
+	for _, id := range change.AssetIDs {
+		assets[id] = true
+	}
+	for _, other := range *scheduled {
+		if other.ID == change.ID || !containsString(models.ChangeActiveStatuses, other.Status) {
+			continue
+		}
+		for _, id := range other.AssetIDs {
+			if assets[id] {
+				conflicts = append(conflicts, other)
+				break
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// GetCalendarLink returns a signed link to the iCalendar feed of the
+// changes, for calendar clients to subscribe to.
+func (cs *DefaultChangeService) GetCalendarLink() string {
+	return cs.Config.LinkURL + cs.Signer.Sign(changeCalendarPath, time.Now().Add(cs.Config.FeedTTL))
+}
+
+// WriteCalendar verifies a calendar link and writes the changes scheduled
+// from from to to as an iCalendar feed. Pending changes are tentative,
+// rejected and cancelled ones cancelled; drafts are left out.
+func (cs *DefaultChangeService) WriteCalendar(w io.Writer, expires, signature string, from, to time.Time) error {
+	if err := cs.Signer.Verify(changeCalendarPath, expires, signature); err != nil {
+		return err
+	}
+	changes, err := cs.ChangeDBModel.GetScheduledChanges(from, to)
+	if err != nil {
+		return err
+	}
+	events := []calendar.Event{}
+	for _, change := range *changes {
+		var status string
+		switch change.Status {
+		case models.ChangeStatusDraft:
+			continue
+		case models.ChangeStatusPendingApproval:
+			status = calendar.EventTentative
+		case models.ChangeStatusRejected, models.ChangeStatusCancelled:
+			status = calendar.EventCancelled
+		default:
+			status = calendar.EventConfirmed
+		}
+		description := fmt.Sprintf("%s change, %s risk, %s", change.Type, change.RiskLevel, strings.ReplaceAll(change.Status, "_", " "))
+		if change.Description != "" {
+			description += "\n\n" + change.Description
+		}
+		event := calendar.Event{
+			UID:         fmt.Sprintf("change-%d@service-desk", change.ID),
+			Start:       *change.PlannedStart,
+			End:         *change.PlannedEnd,
+			Summary:     fmt.Sprintf("[Change #%d] %s", change.ID, change.Title),
+			Description: description,
+			Status:      status,
+			Updated:     change.UpdatedAt,
+		}
+		if cs.Config.LinkURL != "" {
+			event.URL = fmt.Sprintf("%s/changes/%d", cs.Config.LinkURL, change.ID)
+		}
+		events = append(events, event)
+	}
+	return calendar.WriteICal(w, "Change calendar", events)
+}
+
+// CreateQuestion creates a risk question.
+func (cs *DefaultChangeService) CreateQuestion(question *models.RiskQuestion) error {
+	question.ID = 0
+	if err := validateQuestion(question); err != nil {
+		return err
+	}
+	return cs.ChangeDBModel.CreateQuestion(question)
+}
+
+// UpdateQuestion updates a risk question. Changes already answered keep
+// the scores of their answers.
+func (cs *DefaultChangeService) UpdateQuestion(question *models.RiskQuestion) (*models.RiskQuestion, error) {
+	existing, err := cs.ChangeDBModel.GetQuestionByID(question.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateQuestion(question); err != nil {
+		return nil, err
+	}
+	question.Model = existing.Model
+	question.CreatedAt = existing.CreatedAt
+	if err := cs.ChangeDBModel.UpdateQuestion(question); err != nil {
+		return nil, err
+	}
+	return question, nil
+}
+
+// DeleteQuestion deletes a risk question.
+func (cs *DefaultChangeService) DeleteQuestion(id uint) (bool, error) {
+	if _, err := cs.ChangeDBModel.GetQuestionByID(id); err != nil {
+		return false, err
+	}
+	if err := cs.ChangeDBModel.DeleteQuestion(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetQuestions retrieves the risk questionnaire, inactive questions
+// included.
+func (cs *DefaultChangeService) GetQuestions() (*[]models.RiskQuestion, error) {
+	return cs.ChangeDBModel.GetQuestions(false)
+}
+
+// validateChange checks a change request and scores the answers it has.
+func (cs *DefaultChangeService) validateChange(change *models.ChangeRequest) error {
+	change.Title = strings.TrimSpace(change.Title)
+	if change.Title == "" {
+		return fmt.Errorf("%w: a title is required", ErrInvalidChange)
+	}
+	if change.Type == "" {
+		change.Type = models.ChangeTypeNormal
+	}
+	if !containsString(models.ChangeTypes, change.Type) {
+		return fmt.Errorf("%w: type must be one of %s", ErrInvalidChange, strings.Join(models.ChangeTypes, ", "))
+	}
+	if (change.PlannedStart == nil) != (change.PlannedEnd == nil) {
+		return fmt.Errorf("%w: a window needs a start and an end", ErrInvalidChange)
+	}
+	if change.PlannedStart != nil && !change.PlannedEnd.After(*change.PlannedStart) {
+		return fmt.Errorf("%w: the window must end after it starts", ErrInvalidChange)
+	}
+
+	seen := map[uint]bool{}
+	assets := []uint{}
+	for _, id := range change.AssetIDs {
+		if id != 0 && !seen[id] {
+			seen[id] = true
+			assets = append(assets, id)
+		}
+	}
+	sort.Slice(assets, func(i, j int) bool { return assets[i] < assets[j] })
+	change.AssetIDs = assets
+	count, err := cs.ChangeDBModel.CountAssets(assets)
+	if err != nil {
+		return err
+	}
+	if int(count) != len(assets) {
+		return fmt.Errorf("%w: unknown asset", ErrInvalidChange)
+	}
+	return cs.scoreRisk(change, false)
+}
+
+// scoreRisk checks the answers of a change against the active questions,
+// copying the score of each chosen option, and works out its risk and
+// impact scores and risk level. With complete every active question must
+// be answered.
+func (cs *DefaultChangeService) scoreRisk(change *models.ChangeRequest, complete bool) error {
+	questions, err := cs.ChangeDBModel.GetQuestions(true)
+	if err != nil {
+		return err
+	}
+	answers := map[uint]models.RiskAnswer{}
+	for _, answer := range change.RiskAnswers {
+		answers[answer.QuestionID] = answer
+	}
+	change.RiskAnswers = []models.RiskAnswer{}
+	change.RiskScore, change.ImpactScore = 0, 0
+	var unanswered []string
+	for _, question := range *questions {
+		answer, ok := answers[question.ID]
+		if !ok {
+			unanswered = append(unanswered, fmt.Sprintf("%q", question.Text))
+			continue
+		}
+		delete(answers, question.ID)
+		found := false
+		for _, option := range question.Options {
+			if strings.EqualFold(option.Label, strings.TrimSpace(answer.Option)) {
+				answer.Option, answer.Score, found = option.Label, option.Score, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %q is not an option of %q", ErrInvalidChange, answer.Option, question.Text)
+		}
+		change.RiskAnswers = append(change.RiskAnswers, answer)
+		if question.Kind == models.RiskKindImpact {
+			change.ImpactScore += answer.Score
+		} else {
+			change.RiskScore += answer.Score
+		}
+	}
+	for id := range answers {
+		return fmt.Errorf("%w: unknown risk question %d", ErrInvalidChange, id)
+	}
+	if complete && len(unanswered) > 0 {
+		return fmt.Errorf("%w: unanswered risk questions %s", ErrInvalidChange, strings.Join(unanswered, ", "))
+	}
+	switch total := change.RiskScore + change.ImpactScore; {
+	case total >= cs.Config.HighRisk:
+		change.RiskLevel = models.RiskLevelHigh
+	case total >= cs.Config.MediumRisk:
+		change.RiskLevel = models.RiskLevelMedium
+	default:
+		change.RiskLevel = models.RiskLevelLow
+	}
+	return nil
+}
+
+// validateQuestion checks a risk question.
+func validateQuestion(question *models.RiskQuestion) error {
+	question.Text = strings.TrimSpace(question.Text)
+	if question.Text == "" {
+		return fmt.Errorf("%w: text is required", ErrInvalidRiskQuestion)
+	}
+	if question.Kind == "" {
+		question.Kind = models.RiskKindRisk
+	}
+	if question.Kind != models.RiskKindRisk && question.Kind != models.RiskKindImpact {
+		return fmt.Errorf("%w: kind must be %s or %s", ErrInvalidRiskQuestion, models.RiskKindRisk, models.RiskKindImpact)
+	}
+	if len(question.Options) < 2 {
+		return fmt.Errorf("%w: at least two options are required", ErrInvalidRiskQuestion)
+	}
+	seen := map[string]bool{}
+	for i := range question.Options {
+		option := &question.Options[i]
+		option.Label = strings.TrimSpace(option.Label)
+		if option.Label == "" || seen[strings.ToLower(option.Label)] {
+			return fmt.Errorf("%w: options need distinct labels", ErrInvalidRiskQuestion)
+		}
+		if option.Score < 0 {
+			return fmt.Errorf("%w: scores cannot be negative", ErrInvalidRiskQuestion)
+		}
+		seen[strings.ToLower(option.Label)] = true
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestSubmitChangeChecksConflictsAndStatus(t *testing.T) {
+	db := openTestDB(t, &models.ChangeRequest{}, &models.RiskQuestion{})
+	cs := NewDefaultChangeService(models.NewChangeDBModel(db), nil, nil, ChangeConfig{})
+	start := time.Now().Add(24 * time.Hour)
+	end := start.Add(2 * time.Hour)
+	draft := func(title string, assets ...uint) *models.ChangeRequest {
+		change := &models.ChangeRequest{
+			Title:              title,
+			Type:               models.ChangeTypeStandard,
+			Status:             models.ChangeStatusDraft,
+			AssetIDs:           assets,
+			ImplementationPlan: "Patch",
+			BackoutPlan:        "Roll back",
+			PlannedStart:       &start,
+			PlannedEnd:         &end,
+		}
+		if err := db.Create(change).Error; err != nil {
+			t.Fatal(err)
+		}
+		return change
+	}
+	first, overlapping, other := draft("Patch switch", 1, 2), draft("Replace switch", 2), draft("Patch printer", 3)
+
+	tests := []struct {
+		name   string
+		change *models.ChangeRequest
+		want   error
+	}{
+		{"free window", first, nil},
+		{"same assets in the window", overlapping, ErrChangeConflict},
+		{"other assets in the window", other, nil},
+		{"already submitted", first, ErrChangeStatus},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := cs.SubmitChange(tt.change.ID)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("SubmitChange = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	// A copy read while the change was still a draft cannot submit it again.
+	stale := *first
+	stale.Status = models.ChangeStatusPendingApproval
+	updated, err := models.NewChangeDBModel(db).UpdateDraftChange(&stale)
+	if err != nil || updated {
+		t.Fatalf("UpdateDraftChange = %v, %v; want no update", updated, err)
+	}
+	saved, err := cs.ChangeDBModel.GetChangeByID(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.Status != models.ChangeStatusApproved {
+		t.Fatalf("status = %s, want %s", saved.Status, models.ChangeStatusApproved)
+	}
+}
@@ -0,0 +1,85 @@
+// backend/services/comment_service.go
+
+package services
+
+import (
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+// CommentServiceInterface provides methods for managing ticket comments.
+type CommentServiceInterface interface {
+	CreateComment(comment *models.TicketComment) error
+	GetCommentsByTicket(ticketID uint) (*[]models.TicketComment, error)
+	DeleteComment(id uint) (bool, error)
+}
+
+// DefaultCommentService is the default implementation of CommentService
+type DefaultCommentService struct {
+	DB             *gorm.DB
+	CommentDBModel *models.CommentDBModel
+	TicketDBModel  *models.TicketDBModel
+	Events         EventPublisher
+	// Watchers, when set, turns the @mentions of new agent comments into
+	// watchers.
+	Watchers *DefaultWatcherService
+}
+
+// NewDefaultCommentService creates a new DefaultCommentService.
+func NewDefaultCommentService(commentDBModel *models.CommentDBModel, ticketDBModel *models.TicketDBModel) *DefaultCommentService {
+	return &DefaultCommentService{
+		CommentDBModel: commentDBModel,
+		TicketDBModel:  ticketDBModel,
+	}
+}
+
+// CreateComment adds a comment to an existing ticket. The agents an agent
+// mentions in it, internal notes included, are added to the watchers of the
+// ticket.
+func (cs *DefaultCommentService) CreateComment(comment *models.TicketComment) error {
+	return cs.CreateCommentWith(comment, nil)
+}
+
+// CreateCommentWith creates a comment like CreateComment, calling then
+// within the transaction creating it so that what records the comment is
+// written along with it.
+func (cs *DefaultCommentService) CreateCommentWith(comment *models.TicketComment, then func(tx *gorm.DB) error) error {
+	if _, err := cs.TicketDBModel.GetTicketByID(comment.TicketID); err != nil {
+		return err
+	}
+	if comment.Source == "" {
+		comment.Source = models.CommentSourceWeb
+	}
+	return cs.CommentDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewCommentDBModel(tx).CreateComment(comment); err != nil {
+			return err
+		}
+		if cs.Watchers != nil {
+			if _, err := cs.Watchers.ProcessMentions(tx, comment); err != nil {
+				return err
+			}
+		}
+		if then != nil {
+			if err := then(tx); err != nil {
+				return err
+			}
+		}
+		if comment.IsInternal {
+			return nil
+		}
+		return publish(tx, cs.Events, models.EventTicketReplied, comment)
+	})
+}
+
+// GetCommentsByTicket retrieves the comments of a ticket, oldest first.
+func (cs *DefaultCommentService) GetCommentsByTicket(ticketID uint) (*[]models.TicketComment, error) {
+	return cs.CommentDBModel.GetCommentsByTicketID(ticketID)
+}
+
+// DeleteComment deletes a comment by ID.
+func (cs *DefaultCommentService) DeleteComment(id uint) (bool, error) {
+	if err := cs.CommentDBModel.DeleteComment(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,352 @@
+// backend/services/custom_field_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCustomField      = errors.New("invalid custom field")
+	ErrInvalidCustomFieldValue = errors.New("invalid custom field value")
+)
+
+var customFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// customFieldDate is the layout of date field values.
+const customFieldDate = "2006-01-02"
+
+// CustomFieldServiceInterface provides methods for custom fields and their
+// values on tickets.
+type CustomFieldServiceInterface interface {
+	CreateDefinition(definition *models.CustomFieldDefinition) error
+	UpdateDefinition(definition *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error)
+	DeleteDefinition(id uint) (bool, error)
+	GetDefinition(id uint) (*models.CustomFieldDefinition, error)
+	GetDefinitions() (*[]models.CustomFieldDefinition, error)
+	GetForm(category, subCategory string) ([]models.CustomFieldDefinition, error)
+	Validate(ticket *models.Ticket) ([]models.TicketFieldValue, error)
+	Fill(tickets ...*models.Ticket) error
+}
+
+// DefaultCustomFieldService is the default implementation of CustomFieldService
+type DefaultCustomFieldService struct {
+	DB                 *gorm.DB
+	CustomFieldDBModel *models.CustomFieldDBModel
+	UserDBModel        *models.UserDBModel
+	AssetDBModel       *models.AssetDBModel
+}
+
+// NewDefaultCustomFieldService creates a new DefaultCustomFieldService.
+func NewDefaultCustomFieldService(customFieldDBModel *models.CustomFieldDBModel, userDBModel *models.UserDBModel, assetDBModel *models.AssetDBModel) *DefaultCustomFieldService {
+	return &DefaultCustomFieldService{
+		DB:                 customFieldDBModel.DB,
+		CustomFieldDBModel: customFieldDBModel,
+		UserDBModel:        userDBModel,
+		AssetDBModel:       assetDBModel,
+	}
+}
+
+// CreateDefinition creates a custom field definition.
+func (fs *DefaultCustomFieldService) CreateDefinition(definition *models.CustomFieldDefinition) error {
+	definition.ID = 0
+	if err := validateDefinition(definition); err != nil {
+		return err
+	}
+	if _, err := fs.CustomFieldDBModel.GetDefinitionByKey(definition.Key); err == nil {
+		return fmt.Errorf("%w: key %q is already used", ErrInvalidCustomField, definition.Key)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return fs.CustomFieldDBModel.CreateDefinition(definition)
+}
+
+// UpdateDefinition updates a custom field definition. The key and type are
+// kept, as tickets and rules refer to them.
+func (fs *DefaultCustomFieldService) UpdateDefinition(definition *models.CustomFieldDefinition) (*models.CustomFieldDefinition, error) {
+	existing, err := fs.CustomFieldDBModel.GetDefinitionByID(definition.ID)
+	if err != nil {
+		return nil, err
+	}
+	if definition.Key != "" && definition.Key != existing.Key {
+		return nil, fmt.Errorf("%w: the key cannot change", ErrInvalidCustomField)
+	}
+	if definition.Type != "" && definition.Type != existing.Type {
+		return nil, fmt.Errorf("%w: the type cannot change", ErrInvalidCustomField)
+	}
+	definition.Model = existing.Model
+	definition.Key = existing.Key
+	definition.Type = existing.Type
+	definition.CreatedAt = existing.CreatedAt
+	if err := validateDefinition(definition); err != nil {
+		return nil, err
+	}
+	if err := fs.CustomFieldDBModel.UpdateDefinition(definition); err != nil {
+		return nil, err
+	}
+	return definition, nil
+}
+
+// DeleteDefinition deletes a custom field definition and its values.
+func (fs *DefaultCustomFieldService) DeleteDefinition(id uint) (bool, error) {
+	if _, err := fs.CustomFieldDBModel.GetDefinitionByID(id); err != nil {
+		return false, err
+	}
+	err := fs.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewCustomFieldDBModel(tx).DeleteDefinition(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetDefinition retrieves a custom field definition.
+func (fs *DefaultCustomFieldService) GetDefinition(id uint) (*models.CustomFieldDefinition, error) {
+	return fs.CustomFieldDBModel.GetDefinitionByID(id)
+}
+
+// GetDefinitions retrieves every custom field definition, inactive ones
+// included.
+func (fs *DefaultCustomFieldService) GetDefinitions() (*[]models.CustomFieldDefinition, error) {
+	return fs.CustomFieldDBModel.GetDefinitions(false)
+}
+
+// GetForm retrieves the active custom fields of the tickets of a category
+// and sub-category, in form order.
+func (fs *DefaultCustomFieldService) GetForm(category, subCategory string) ([]models.CustomFieldDefinition, error) {
+	definitions, err := fs.CustomFieldDBModel.GetDefinitions(true)
+	if err != nil {
+		return nil, err
+	}
+	form := []models.CustomFieldDefinition{}
+	for _, definition := range *definitions {
+		if definition.AppliesTo(category, subCategory) {
+			form = append(form, definition)
+		}
+	}
+	return form, nil
+}
+
+// KeepFormFields returns the values that have a field in the form of the
+// category of a ticket, leaving out the others.
+func (fs *DefaultCustomFieldService) KeepFormFields(ticket *models.Ticket, values models.CustomFieldValues) (models.CustomFieldValues, error) {
+	form, err := fs.GetForm(ticket.Category.CategoryName, ticket.SubCategory.SubCategoryName)
+	if err != nil {
+		return nil, err
+	}
+	kept := models.CustomFieldValues{}
+	for _, definition := range form {
+		if value, ok := values[definition.Key]; ok {
+			kept[definition.Key] = value
+		}
+	}
+	return kept, nil
+}
+
+// Validate checks the custom fields of a ticket against the form of its
+// category and returns them as rows to store. Fields of other categories
+// are rejected, and required fields must have a value.
+func (fs *DefaultCustomFieldService) Validate(ticket *models.Ticket) ([]models.TicketFieldValue, error) {
+	form, err := fs.GetForm(ticket.Category.CategoryName, ticket.SubCategory.SubCategoryName)
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]bool, len(form))
+	for _, definition := range form {
+		known[definition.Key] = true
+	}
+	for key := range ticket.CustomFields {
+		if !known[key] {
+			return nil, fmt.Errorf("%w: %q is not a field of this category", ErrInvalidCustomFieldValue, key)
+		}
+	}
+	var rows []models.TicketFieldValue
+	for i := range form {
+		definition := &form[i]
+		values, err := fs.normalize(definition, ticket.CustomFields[definition.Key])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidCustomFieldValue, definition.Key, err)
+		}
+		if len(values) == 0 && definition.Required {
+			return nil, fmt.Errorf("%w: %s is required", ErrInvalidCustomFieldValue, definition.Key)
+		}
+		for _, value := range values {
+			rows = append(rows, models.TicketFieldValue{
+				FieldID:   definition.ID,
+				FieldKey:  definition.Key,
+				FieldType: definition.Type,
+				Value:     value,
+			})
+		}
+	}
+	return rows, nil
+}
+
+// Fill sets the custom fields of tickets from their stored values.
+func (fs *DefaultCustomFieldService) Fill(tickets ...*models.Ticket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.ID
+	}
+	values, err := fs.CustomFieldDBModel.GetTicketValues(ids)
+	if err != nil {
+		return err
+	}
+	for _, ticket := range tickets {
+		ticket.CustomFields = values[ticket.ID]
+	}
+	return nil
+}
+
+// normalize checks a value sent for a field and returns it as text, a
+// list for multi-select fields. Empty values return nothing.
+func (fs *DefaultCustomFieldService) normalize(definition *models.CustomFieldDefinition, value interface{}) ([]string, error) {
+	// Values read back from the database have their stored types rather
+	// than those of JSON.
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		value = list
+	case uint:
+		value = float64(v)
+	}
+	if definition.Type == models.FieldTypeMultiSelect {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, errors.New("expected a list of options")
+		}
+		var selected []string
+		seen := map[string]bool{}
+		for _, item := range list {
+			option, ok := item.(string)
+			if !ok || !containsString(definition.Options, option) {
+				return nil, fmt.Errorf("%v is not an option", item)
+			}
+			if !seen[option] {
+				seen[option] = true
+				selected = append(selected, option)
+			}
+		}
+		return selected, nil
+	}
+
+	switch definition.Type {
+	case models.FieldTypeNumber:
+		n, ok := value.(float64)
+		if !ok {
+			return nil, errors.New("expected a number")
+		}
+		if definition.Min != nil && n < *definition.Min || definition.Max != nil && n > *definition.Max {
+			return nil, errors.New("out of range")
+		}
+		return []string{strconv.FormatFloat(n, 'f', -1, 64)}, nil
+	case models.FieldTypeUser, models.FieldTypeAsset:
+		n, ok := value.(float64)
+		if !ok || n < 1 || n != math.Trunc(n) {
+			return nil, errors.New("expected an ID")
+		}
+		id := uint(n)
+		var err error
+		if definition.Type == models.FieldTypeUser {
+			_, err = fs.UserDBModel.GetUserByID(id)
+		} else {
+			_, err = fs.AssetDBModel.GetAssetByID(id)
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("unknown %s %d", definition.Type, id)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []string{strconv.FormatUint(uint64(id), 10)}, nil
+	}
+
+	text, ok := value.(string)
+	if !ok {
+		return nil, errors.New("expected text")
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, nil
+	}
+	switch definition.Type {
+	case models.FieldTypeDate:
+		if _, err := time.Parse(customFieldDate, text); err != nil {
+			return nil, errors.New("expected a date as YYYY-MM-DD")
+		}
+	case models.FieldTypeDropdown:
+		if !containsString(definition.Options, text) {
+			return nil, fmt.Errorf("%q is not an option", text)
+		}
+	default:
+		if definition.MaxLength > 0 && len([]rune(text)) > definition.MaxLength {
+			return nil, fmt.Errorf("longer than %d characters", definition.MaxLength)
+		}
+		if definition.Pattern != "" && !regexp.MustCompile(definition.Pattern).MatchString(text) {
+			return nil, errors.New("does not match the expected format")
+		}
+	}
+	return []string{text}, nil
+}
+
+func validateDefinition(definition *models.CustomFieldDefinition) error {
+	if !customFieldKey.MatchString(definition.Key) {
+		return fmt.Errorf("%w: key must be lower case letters, digits and underscores", ErrInvalidCustomField)
+	}
+	if strings.TrimSpace(definition.Label) == "" {
+		return fmt.Errorf("%w: a label is required", ErrInvalidCustomField)
+	}
+	if !containsString(models.FieldTypes, definition.Type) {
+		return fmt.Errorf("%w: type must be one of %s", ErrInvalidCustomField, strings.Join(models.FieldTypes, ", "))
+	}
+	if definition.SubCategory != "" && definition.Category == "" {
+		return fmt.Errorf("%w: a sub-category needs its category", ErrInvalidCustomField)
+	}
+	switch definition.Type {
+	case models.FieldTypeDropdown, models.FieldTypeMultiSelect:
+		if len(definition.Options) == 0 {
+			return fmt.Errorf("%w: options are required", ErrInvalidCustomField)
+		}
+	default:
+		definition.Options = nil
+	}
+	if definition.Pattern != "" {
+		if definition.Type != models.FieldTypeText {
+			return fmt.Errorf("%w: only text fields have a pattern", ErrInvalidCustomField)
+		}
+		if _, err := regexp.Compile(definition.Pattern); err != nil {
+			return fmt.Errorf("%w: invalid pattern: %v", ErrInvalidCustomField, err)
+		}
+	}
+	if definition.Min != nil && definition.Max != nil && *definition.Min > *definition.Max {
+		return fmt.Errorf("%w: min is greater than max", ErrInvalidCustomField)
+	}
+	return nil
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
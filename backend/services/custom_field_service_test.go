@@ -0,0 +1,66 @@
+package services
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestCategoryChangeRevalidatesCustomFields(t *testing.T) {
+	db := openTestDB(t, &models.CustomFieldDefinition{})
+	definitions := []models.CustomFieldDefinition{
+		{Key: "serial", Type: models.FieldTypeText, Category: "Hardware", Required: true, Active: true},
+		{Key: "os", Type: models.FieldTypeDropdown, Category: "Software", Options: []string{"Windows", "Linux"}, Required: true, Active: true},
+		{Key: "asset_tag", Type: models.FieldTypeText, Active: true},
+	}
+	if err := db.Create(&definitions).Error; err != nil {
+		t.Fatal(err)
+	}
+	fs := NewDefaultCustomFieldService(models.NewCustomFieldDBModel(db), models.NewUserDBModel(db), models.NewAssetDBModel(db))
+	stored := models.CustomFieldValues{"serial": "SN1", "asset_tag": "T9"}
+
+	tests := []struct {
+		name        string
+		category    string
+		subCategory string
+		kept        models.CustomFieldValues
+		valid       bool
+	}{
+		{"same category", "Hardware", "", stored, true},
+		{"sub-category of the same category", "Hardware", "Laptop", stored, true},
+		{"category requiring other fields", "Software", "", models.CustomFieldValues{"asset_tag": "T9"}, false},
+		{"category without own fields", "Network", "", models.CustomFieldValues{"asset_tag": "T9"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ticket := &models.Ticket{}
+			ticket.Category.CategoryName = tt.category
+			ticket.SubCategory.SubCategoryName = tt.subCategory
+			kept, err := fs.KeepFormFields(ticket, stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(kept, tt.kept) {
+				t.Fatalf("kept %v, want %v", kept, tt.kept)
+			}
+			ticket.CustomFields = kept
+			_, err = fs.Validate(ticket)
+			if valid := err == nil; valid != tt.valid {
+				t.Fatalf("Validate = %v, want valid %v", err, tt.valid)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidCustomFieldValue) {
+				t.Fatalf("Validate = %v, want a rejected change", err)
+			}
+		})
+	}
+
+	previous := &models.Ticket{}
+	previous.Category.CategoryName = "Hardware"
+	moved := *previous
+	moved.SubCategory.SubCategoryName = "Laptop"
+	if categoryChanged(previous, previous) || !categoryChanged(previous, &moved) {
+		t.Fatal("categoryChanged does not follow the sub-category")
+	}
+}
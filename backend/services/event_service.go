@@ -0,0 +1,61 @@
+// backend/services/event_service.go
+
+package services
+
+import (
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+// EventServiceInterface provides methods for inspecting the event outbox.
+type EventServiceInterface interface {
+	GetEvents(status string, limit int) (*[]models.OutboxEvent, error)
+	RetryEvent(id uint) (*models.OutboxEvent, error)
+}
+
+// DefaultEventService is the default implementation of EventService
+type DefaultEventService struct {
+	DB            *gorm.DB
+	OutboxDBModel *models.OutboxDBModel
+	Bus           *events.Bus
+}
+
+// NewDefaultEventService creates a new DefaultEventService.
+func NewDefaultEventService(bus *events.Bus) *DefaultEventService {
+	return &DefaultEventService{
+		OutboxDBModel: bus.Outbox,
+		Bus:           bus,
+	}
+}
+
+// RegisterSubscribers subscribes the notification and webhook services to
+// the domain events they react to. Either may be nil.
+func (es *DefaultEventService) RegisterSubscribers(notifications *DefaultNotificationService, webhooks *DefaultWebhookService) {
+	if notifications != nil {
+		es.Bus.Subscribe("notifications", "ticket.*", notifications.HandleEvent)
+	}
+	if webhooks != nil {
+		es.Bus.Subscribe("webhooks", "*", webhooks.HandleEvent)
+	}
+}
+
+// RegisterRules subscribes the automation rules to the ticket events.
+func (es *DefaultEventService) RegisterRules(rules *DefaultRuleService) {
+	es.Bus.Subscribe("rules", "ticket.*", rules.HandleEvent)
+}
+
+// RegisterSurveys sends the satisfaction surveys when tickets are resolved.
+func (es *DefaultEventService) RegisterSurveys(surveys *DefaultSurveyService) {
+	es.Bus.Subscribe("surveys", models.EventTicketResolved, surveys.HandleEvent)
+}
+
+// GetEvents retrieves the most recent events, optionally only those in a status.
+func (es *DefaultEventService) GetEvents(status string, limit int) (*[]models.OutboxEvent, error) {
+	return es.OutboxDBModel.GetEvents(status, limit)
+}
+
+// RetryEvent queues an event again, typically one that failed.
+func (es *DefaultEventService) RetryEvent(id uint) (*models.OutboxEvent, error) {
+	return es.Bus.Retry(id)
+}
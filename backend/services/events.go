@@ -0,0 +1,27 @@
+// backend/services/events.go
+
+package services
+
+import (
+	"github.com/shuttlersit/service-desk/backend/events"
+	"gorm.io/gorm"
+)
+
+var _ EventPublisher = (*events.Bus)(nil)
+
+// EventPublisher records the domain events of the changes services make,
+// so that notifications, webhooks and other subscribers can react to them.
+// Services publish with the transaction of the change, so the event is
+// stored if and only if the change is committed. It is implemented by
+// events.Bus.
+type EventPublisher interface {
+	Publish(tx *gorm.DB, event string, payload interface{}) error
+}
+
+// publish records an event with p if one is configured.
+func publish(tx *gorm.DB, p EventPublisher, event string, payload interface{}) error {
+	if p == nil {
+		return nil
+	}
+	return p.Publish(tx, event, payload)
+}
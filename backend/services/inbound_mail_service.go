@@ -0,0 +1,252 @@
+// backend/services/inbound_mail_service.go
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/inbound"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+// InboundMailConfig configures the email ingestion.
+type InboundMailConfig struct {
+	// OwnAddresses are the help desk's own mailboxes. Mail sent from them is
+	// dropped so notifications bouncing back cannot loop.
+	OwnAddresses []string
+	// WebhookToken authenticates the raw MIME webhook.
+	WebhookToken string
+}
+
+// InboundMailServiceInterface provides methods for turning email into tickets.
+type InboundMailServiceInterface interface {
+	ProcessMessage(ctx context.Context, source string, raw []byte) (*models.InboundEmail, error)
+	GetInboundEmails(limit int) (*[]models.InboundEmail, error)
+}
+
+// DefaultInboundMailService is the default implementation of InboundMailService
+type DefaultInboundMailService struct {
+	DB                  *gorm.DB
+	InboundEmailDBModel *models.InboundEmailDBModel
+	UserDBModel         *models.UserDBModel
+	AgentDBModel        *models.AgentDBModel
+	TicketDBModel       *models.TicketDBModel
+	TicketService       *DefaultTicketingService
+	CommentService      *DefaultCommentService
+	AttachmentService   *DefaultAttachmentService
+	Config              InboundMailConfig
+}
+
+// NewDefaultInboundMailService creates a new DefaultInboundMailService.
+func NewDefaultInboundMailService(inboundEmailDBModel *models.InboundEmailDBModel, userDBModel *models.UserDBModel, agentDBModel *models.AgentDBModel, ticketService *DefaultTicketingService, commentService *DefaultCommentService, attachmentService *DefaultAttachmentService, config InboundMailConfig) *DefaultInboundMailService {
+	return &DefaultInboundMailService{
+		InboundEmailDBModel: inboundEmailDBModel,
+		UserDBModel:         userDBModel,
+		AgentDBModel:        agentDBModel,
+		TicketDBModel:       ticketService.TicketDBModel,
+		TicketService:       ticketService,
+		CommentService:      commentService,
+		AttachmentService:   attachmentService,
+		Config:              config,
+	}
+}
+
+// Handler adapts ProcessMessage for use with an inbound.Source.
+func (ms *DefaultInboundMailService) Handler(source string) inbound.Handler {
+	return func(ctx context.Context, raw []byte) error {
+		_, err := ms.ProcessMessage(ctx, source, raw)
+		return err
+	}
+}
+
+// ProcessMessage turns a raw RFC 5322 message into a ticket, or into a
+// comment when the subject, a plus-addressed recipient or the thread
+// headers reference a ticket the sender may reply to.
+// Messages are recorded by Message-ID, so processing the same message again
+// returns the earlier record. Messages that can never be processed, such as
+// mail from unknown senders, are recorded as rejected and return no error so
+// sources do not retry them.
+func (ms *DefaultInboundMailService) ProcessMessage(ctx context.Context, source string, raw []byte) (*models.InboundEmail, error) {
+	record := &models.InboundEmail{
+		Source:     source,
+		ReceivedAt: time.Now(),
+	}
+
+	msg, err := inbound.Parse(bytes.NewReader(raw))
+	if err != nil {
+		record.MessageID = inbound.RawMessageID(raw)
+		return ms.reject(record, "unparseable message: "+err.Error())
+	}
+	record.MessageID = msg.MessageID
+	if record.MessageID == "" {
+		record.MessageID = inbound.RawMessageID(raw)
+	}
+	record.FromEmail = strings.ToLower(msg.From.Address)
+	record.Subject = msg.Subject
+
+	existing, err := ms.InboundEmailDBModel.GetInboundEmailByMessageID(record.MessageID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if msg.AutoSubmitted {
+		return ms.reject(record, "auto-submitted message")
+	}
+	if ms.isOwnAddress(record.FromEmail) {
+		return ms.reject(record, "message sent by the help desk itself")
+	}
+
+	if ticketID, ok := inbound.TicketReferenceFromMessage(msg); ok {
+		if ticket, err := ms.TicketDBModel.GetTicketByID(ticketID); err == nil {
+			comment, err := ms.replyAsComment(ticket, msg, record)
+			if err != nil {
+				return nil, err
+			}
+			if comment != nil {
+				ms.storeAttachments(ctx, ticket.ID, msg, comment.UserID)
+				return record, nil
+			}
+		}
+	}
+
+	user, err := ms.UserDBModel.GetUserByEmail(record.FromEmail)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ms.reject(record, "sender is not a registered user")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ticket := &models.Ticket{
+		Subject:     msg.Subject,
+		Description: msg.TextBody,
+		UserID:      *user,
+	}
+	if ticket.Subject == "" {
+		ticket.Subject = "(no subject)"
+	}
+	// The record is written with the ticket so a failure after the ticket
+	// exists cannot leave it unrecorded and open a second ticket on retry.
+	record.Status = models.InboundStatusTicketCreated
+	err = ms.TicketService.CreateTicketWith(ticket, func(tx *gorm.DB, pending bool) error {
+		record.TicketID = ticket.ID
+		return models.NewInboundEmailDBModel(tx).CreateInboundEmail(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	ms.storeAttachments(ctx, ticket.ID, msg, user.ID)
+	return record, nil
+}
+
+// replyAsComment appends a reply to a ticket when it comes from the
+// requester or from an agent, writing the record of the message with the
+// comment so that a redelivery cannot add it twice. It returns nil when the
+// sender may not reply to this ticket, in which case the message opens a
+// new ticket instead.
+func (ms *DefaultInboundMailService) replyAsComment(ticket *models.Ticket, msg *inbound.Message, record *models.InboundEmail) (*models.TicketComment, error) {
+	from := record.FromEmail
+	comment := &models.TicketComment{
+		TicketID:  ticket.ID,
+		Body:      inbound.StripQuotedReply(msg.TextBody),
+		Source:    models.CommentSourceEmail,
+		MessageID: msg.MessageID,
+	}
+	if comment.Body == "" {
+		comment.Body = strings.TrimSpace(msg.TextBody)
+	}
+
+	if agent, err := ms.AgentDBModel.GetAgentByEmail(from); err == nil {
+		comment.AgentID = agent.ID
+	} else if strings.EqualFold(ticket.UserID.Email, from) {
+		comment.UserID = ticket.UserID.ID
+	} else {
+		return nil, nil
+	}
+
+	record.TicketID = ticket.ID
+	record.Status = models.InboundStatusCommentAdded
+	err := ms.CommentService.CreateCommentWith(comment, func(tx *gorm.DB) error {
+		record.CommentID = comment.ID
+		return models.NewInboundEmailDBModel(tx).CreateInboundEmail(record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// storeAttachments saves the files of a message against a ticket. A file
+// that cannot be stored, for example because of a blocked extension, does
+// not prevent the rest of the message from being processed.
+func (ms *DefaultInboundMailService) storeAttachments(ctx context.Context, ticketID uint, msg *inbound.Message, uploadedBy uint) {
+	if ms.AttachmentService == nil {
+		return
+	}
+	for _, a := range msg.Attachments {
+		_, _, err := ms.AttachmentService.UploadAttachment(ctx, ticketID, &AttachmentUpload{
+			FileName:   a.FileName,
+			Reader:     bytes.NewReader(a.Data),
+			UploadedBy: uploadedBy,
+		})
+		if err != nil {
+			log.Printf("inbound email %s: attachment %q: %v", msg.MessageID, a.FileName, err)
+		}
+	}
+}
+
+// reject records a message that will never be processed. A message that was
+// already recorded, for example an unparseable message delivered again,
+// returns the earlier record.
+func (ms *DefaultInboundMailService) reject(record *models.InboundEmail, reason string) (*models.InboundEmail, error) {
+	existing, err := ms.InboundEmailDBModel.GetInboundEmailByMessageID(record.MessageID)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	record.Status = models.InboundStatusRejected
+	record.Error = reason
+	if err := ms.InboundEmailDBModel.CreateInboundEmail(record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// isOwnAddress reports whether address is one of the help desk mailboxes,
+// including their plus-addressed variants.
+func (ms *DefaultInboundMailService) isOwnAddress(address string) bool {
+	address = stripPlusTag(address)
+	for _, own := range ms.Config.OwnAddresses {
+		if strings.EqualFold(stripPlusTag(own), address) {
+			return true
+		}
+	}
+	return false
+}
+
+func stripPlusTag(address string) string {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return address
+	}
+	if plus := strings.Index(address[:at], "+"); plus >= 0 {
+		return address[:plus] + address[at:]
+	}
+	return address
+}
+
+// GetInboundEmails retrieves the most recently processed messages.
+func (ms *DefaultInboundMailService) GetInboundEmails(limit int) (*[]models.InboundEmail, error) {
+	return ms.InboundEmailDBModel.GetInboundEmails(limit)
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestRejectedMessagesAreRecordedOnce(t *testing.T) {
+	db := openTestDB(t, &models.InboundEmail{})
+	ms := &DefaultInboundMailService{InboundEmailDBModel: models.NewInboundEmailDBModel(db)}
+
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"unparseable message", "not a mail message"},
+		{"auto-submitted message", "From: robot@example.com\r\nMessage-Id: <auto@example.com>\r\nAuto-Submitted: auto-replied\r\nSubject: Out of office\r\n\r\nAway.\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, err := ms.ProcessMessage(context.Background(), "test", []byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			again, err := ms.ProcessMessage(context.Background(), "test", []byte(tt.raw))
+			if err != nil {
+				t.Fatalf("redelivery: %v", err)
+			}
+			if first.Status != models.InboundStatusRejected || again.ID != first.ID {
+				t.Fatalf("got %s record %d then %d, want one rejected record", first.Status, first.ID, again.ID)
+			}
+		})
+	}
+
+	var count int64
+	if err := db.Model(&models.InboundEmail{}).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != int64(len(tests)) {
+		t.Fatalf("recorded %d messages, want %d", count, len(tests))
+	}
+}
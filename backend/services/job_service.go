@@ -0,0 +1,90 @@
+// backend/services/job_service.go
+
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/jobs"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrJobNotRetryable = errors.New("only failed or cancelled jobs can be retried")
+	ErrJobFinished     = errors.New("job has already finished")
+)
+
+// JobServiceInterface provides methods for administering background jobs.
+type JobServiceInterface interface {
+	GetJobs(status, kind string, limit int) (*[]models.Job, error)
+	GetJobByID(id uint) (*models.Job, error)
+	RetryJob(id uint) (*models.Job, error)
+	CancelJob(id uint) (*models.Job, error)
+	GetSchedules() (*[]models.JobSchedule, error)
+}
+
+// DefaultJobService is the default implementation of JobService
+type DefaultJobService struct {
+	DB         *gorm.DB
+	JobDBModel *models.JobDBModel
+	Runner     *jobs.Runner
+}
+
+// NewDefaultJobService creates a new DefaultJobService.
+func NewDefaultJobService(runner *jobs.Runner) *DefaultJobService {
+	return &DefaultJobService{
+		JobDBModel: runner.Jobs,
+		Runner:     runner,
+	}
+}
+
+// GetJobs retrieves the most recent jobs, optionally filtered by status and kind.
+func (js *DefaultJobService) GetJobs(status, kind string, limit int) (*[]models.Job, error) {
+	return js.JobDBModel.GetJobs(status, kind, limit)
+}
+
+// GetJobByID retrieves a job by its ID.
+func (js *DefaultJobService) GetJobByID(id uint) (*models.Job, error) {
+	return js.JobDBModel.GetJobByID(id)
+}
+
+// RetryJob queues a failed or cancelled job again with a fresh set of attempts.
+func (js *DefaultJobService) RetryJob(id uint) (*models.Job, error) {
+	job, err := js.JobDBModel.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusFailed && job.Status != models.JobStatusCancelled {
+		return nil, ErrJobNotRetryable
+	}
+	job.Status = models.JobStatusPending
+	job.Attempts = 0
+	job.RunAt = time.Now()
+	job.CancelRequested = false
+	job.LastError = ""
+	job.StartedAt = nil
+	job.FinishedAt = nil
+	if err := js.JobDBModel.UpdateJob(job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// CancelJob cancels a pending job, or asks the worker running a job to stop it.
+func (js *DefaultJobService) CancelJob(id uint) (*models.Job, error) {
+	job, err := js.JobDBModel.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if job.Status != models.JobStatusPending && job.Status != models.JobStatusRunning {
+		return nil, ErrJobFinished
+	}
+	return js.JobDBModel.RequestCancel(id)
+}
+
+// GetSchedules retrieves the recurring jobs.
+func (js *DefaultJobService) GetSchedules() (*[]models.JobSchedule, error) {
+	return js.JobDBModel.GetSchedules()
+}
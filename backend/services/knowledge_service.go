@@ -0,0 +1,647 @@
+// backend/services/knowledge_service.go
+
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidArticle = errors.New("invalid knowledge article")
+	ErrArticleStatus  = errors.New("knowledge article cannot do this in its status")
+)
+
+// ArticleDraft is the content and settings of a knowledge article as an
+// author writes them. Changing the title, summary, body or visibility adds
+// a version.
+type ArticleDraft struct {
+	Title       string `json:"title"`
+	Summary     string `json:"summary"`
+	Body        string `json:"body"`
+	Category    string `json:"category"`
+	SubCategory string `json:"sub_category"`
+	Visibility  string `json:"visibility"`
+	ChangeNote  string `json:"change_note"`
+}
+
+// ArticleVoter identifies who votes on a knowledge article: a signed in
+// agent or user, or otherwise the address the vote comes from.
+type ArticleVoter struct {
+	UserID  uint
+	AgentID uint
+	Address string
+}
+
+// key is the VoterKey of the voter. Addresses are hashed so that they are
+// not stored.
+func (v ArticleVoter) key() string {
+	if v.AgentID != 0 {
+		return fmt.Sprintf("agent:%d", v.AgentID)
+	}
+	if v.UserID != 0 {
+		return fmt.Sprintf("user:%d", v.UserID)
+	}
+	sum := sha256.Sum256([]byte(v.Address))
+	return "address:" + hex.EncodeToString(sum[:16])
+}
+
+// KnowledgeServiceInterface provides methods for the knowledge base.
+type KnowledgeServiceInterface interface {
+	CreateArticle(agentID uint, draft *ArticleDraft) (*models.KnowledgeArticle, error)
+	UpdateArticle(agentID, id uint, draft *ArticleDraft) (*models.KnowledgeArticle, error)
+	DeleteArticle(ctx context.Context, id uint) (bool, error)
+	GetArticle(id uint) (*models.KnowledgeArticle, error)
+	GetArticles(filter models.ArticleFilter) (*[]models.KnowledgeArticle, error)
+	GetVersions(id uint) (*[]models.ArticleVersion, error)
+	GetVersion(id uint, version int) (*models.ArticleVersion, error)
+	SubmitArticle(id uint) (*models.KnowledgeArticle, error)
+	PublishArticle(agentID, id uint) (*models.KnowledgeArticle, error)
+	RejectArticle(id uint) (*models.KnowledgeArticle, error)
+	ArchiveArticle(id uint) (*models.KnowledgeArticle, error)
+	GetPublicArticle(id uint) (*models.KnowledgeArticle, error)
+	GetPublicArticles(filter models.ArticleFilter) (*[]models.KnowledgeArticle, error)
+	Vote(id uint, voter ArticleVoter, helpful, publicOnly bool) (*models.KnowledgeArticle, error)
+	UploadAttachment(ctx context.Context, id uint, upload *AttachmentUpload) (*models.ArticleAttachment, bool, error)
+	DeleteAttachment(ctx context.Context, id, attachmentID uint) error
+	OpenAttachment(ctx context.Context, attachmentID uint, expires, signature string) (*models.ArticleAttachment, io.ReadCloser, error)
+	LinkTicket(agentID, id, ticketID uint, resolved bool) (*models.KnowledgeArticle, error)
+	UnlinkTicket(agentID, id, ticketID uint) (*models.KnowledgeArticle, error)
+	GetTicketArticles(ticketID uint) (*[]models.KnowledgeArticle, error)
+	SuggestArticles(ticketID uint) (*[]models.KnowledgeArticle, error)
+}
+
+// DefaultKnowledgeService is the default implementation of KnowledgeService
+type DefaultKnowledgeService struct {
+	DB               *gorm.DB
+	KnowledgeDBModel *models.KnowledgeDBModel
+	AgentDBModel     *models.AgentDBModel
+	TicketService    *DefaultTicketingService
+	// Attachments stores, scans and signs the files of articles the same
+	// way as those of tickets, with the same limits.
+	Attachments *DefaultAttachmentService
+}
+
+// NewDefaultKnowledgeService creates a new DefaultKnowledgeService.
+func NewDefaultKnowledgeService(knowledgeDBModel *models.KnowledgeDBModel, agentDBModel *models.AgentDBModel, ticketService *DefaultTicketingService, attachments *DefaultAttachmentService) *DefaultKnowledgeService {
+	return &DefaultKnowledgeService{
+		DB:               knowledgeDBModel.DB,
+		KnowledgeDBModel: knowledgeDBModel,
+		AgentDBModel:     agentDBModel,
+		TicketService:    ticketService,
+		Attachments:      attachments,
+	}
+}
+
+// actor names an agent in ticket history.
+func (ks *DefaultKnowledgeService) actor(agentID uint) string {
+	if agent, err := ks.AgentDBModel.GetAgentByID(agentID); err == nil && agent.AgentEmail != "" {
+		return agent.AgentEmail
+	}
+	return fmt.Sprintf("agent:%d", agentID)
+}
+
+// CreateArticle creates a draft article with its first version.
+func (ks *DefaultKnowledgeService) CreateArticle(agentID uint, draft *ArticleDraft) (*models.KnowledgeArticle, error) {
+	if draft.Visibility == "" {
+		draft.Visibility = models.ArticleVisibilityAgents
+	}
+	if err := ks.validateDraft(draft); err != nil {
+		return nil, err
+	}
+	article := &models.KnowledgeArticle{
+		Title:          draft.Title,
+		Summary:        draft.Summary,
+		Body:           draft.Body,
+		Category:       draft.Category,
+		SubCategory:    draft.SubCategory,
+		Visibility:     draft.Visibility,
+		Status:         models.ArticleStatusDraft,
+		CurrentVersion: 1,
+		AuthorID:       agentID,
+	}
+	err := ks.DB.Transaction(func(tx *gorm.DB) error {
+		articles := models.NewKnowledgeDBModel(tx)
+		if err := articles.CreateArticle(article); err != nil {
+			return err
+		}
+		return articles.CreateVersion(newArticleVersion(article.ID, 1, agentID, draft))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return article, nil
+}
+
+// UpdateArticle changes the category of an article and, when its content
+// or visibility changed, adds a version. A new version is a draft: until it
+// is published readers keep seeing the published version, and only they.
+func (ks *DefaultKnowledgeService) UpdateArticle(agentID, id uint, draft *ArticleDraft) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if draft.Visibility == "" {
+		draft.Visibility = article.Visibility
+	}
+	if err := ks.validateDraft(draft); err != nil {
+		return nil, err
+	}
+	latest, err := ks.KnowledgeDBModel.GetVersion(article.ID, article.CurrentVersion)
+	if err != nil {
+		return nil, err
+	}
+	article.Category, article.SubCategory = draft.Category, draft.SubCategory
+	changed := latest.Title != draft.Title || latest.Summary != draft.Summary || latest.Body != draft.Body ||
+		versionVisibility(article, latest) != draft.Visibility
+	if changed {
+		article.CurrentVersion++
+		article.Status = models.ArticleStatusDraft
+		if !article.Published() {
+			article.Title, article.Summary, article.Body = draft.Title, draft.Summary, draft.Body
+			article.Visibility = draft.Visibility
+		}
+	}
+	err = ks.DB.Transaction(func(tx *gorm.DB) error {
+		articles := models.NewKnowledgeDBModel(tx)
+		if changed {
+			if err := articles.CreateVersion(newArticleVersion(article.ID, article.CurrentVersion, agentID, draft)); err != nil {
+				return err
+			}
+		}
+		return articles.UpdateArticle(article)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ks.GetArticle(article.ID)
+}
+
+// DeleteArticle deletes an article with its versions, attachments, links
+// to tickets and votes.
+func (ks *DefaultKnowledgeService) DeleteArticle(ctx context.Context, id uint) (bool, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return false, err
+	}
+	err = ks.DB.Transaction(func(tx *gorm.DB) error {
+		articles := models.NewKnowledgeDBModel(tx)
+		for _, attachment := range article.Attachments {
+			if err := articles.DeleteAttachment(attachment.ID); err != nil {
+				return err
+			}
+		}
+		return articles.DeleteArticle(article.ID)
+	})
+	if err != nil {
+		return false, err
+	}
+	for _, attachment := range article.Attachments {
+		if err := ks.Attachments.releaseBlob(ctx, attachment.StorageKey); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// GetArticle retrieves an article with its attachments, its tickets and
+// the draft of its next version, if any.
+func (ks *DefaultKnowledgeService) GetArticle(id uint) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if article.Published() && article.CurrentVersion != article.PublishedVersion {
+		if article.Draft, err = ks.KnowledgeDBModel.GetVersion(article.ID, article.CurrentVersion); err != nil {
+			return nil, err
+		}
+	}
+	ks.signAttachments(article)
+	return article, nil
+}
+
+// GetArticles retrieves the articles matching a filter, whatever their
+// status and visibility.
+func (ks *DefaultKnowledgeService) GetArticles(filter models.ArticleFilter) (*[]models.KnowledgeArticle, error) {
+	if filter.Status != "" && !containsString(models.ArticleStatuses, filter.Status) {
+		return nil, fmt.Errorf("%w: status must be one of %s", ErrInvalidArticle, strings.Join(models.ArticleStatuses, ", "))
+	}
+	filter.Query = strings.TrimSpace(filter.Query)
+	return ks.KnowledgeDBModel.GetArticles(filter)
+}
+
+// GetVersions retrieves the versions of an article, the latest first.
+func (ks *DefaultKnowledgeService) GetVersions(id uint) (*[]models.ArticleVersion, error) {
+	if _, err := ks.KnowledgeDBModel.GetArticleByID(id); err != nil {
+		return nil, err
+	}
+	return ks.KnowledgeDBModel.GetVersions(id)
+}
+
+// GetVersion retrieves a version of an article.
+func (ks *DefaultKnowledgeService) GetVersion(id uint, version int) (*models.ArticleVersion, error) {
+	return ks.KnowledgeDBModel.GetVersion(id, version)
+}
+
+// SubmitArticle submits the latest version of a draft or archived article
+// for review.
+func (ks *DefaultKnowledgeService) SubmitArticle(id uint) (*models.KnowledgeArticle, error) {
+	return ks.transition(id, []string{models.ArticleStatusDraft, models.ArticleStatusArchived}, models.ArticleStatusReview, nil)
+}
+
+// PublishArticle publishes the latest version of an article once it has
+// been reviewed. Its content replaces that of the published version.
+func (ks *DefaultKnowledgeService) PublishArticle(agentID, id uint) (*models.KnowledgeArticle, error) {
+	return ks.transition(id, []string{models.ArticleStatusReview}, models.ArticleStatusPublished, func(article *models.KnowledgeArticle) error {
+		version, err := ks.KnowledgeDBModel.GetVersion(article.ID, article.CurrentVersion)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		article.Title, article.Summary, article.Body = version.Title, version.Summary, version.Body
+		article.Visibility = versionVisibility(article, version)
+		article.PublishedVersion = version.Version
+		article.PublishedAt = &now
+		article.PublishedBy = agentID
+		return nil
+	})
+}
+
+// RejectArticle sends the latest version of an article in review back to
+// draft.
+func (ks *DefaultKnowledgeService) RejectArticle(id uint) (*models.KnowledgeArticle, error) {
+	return ks.transition(id, []string{models.ArticleStatusReview}, models.ArticleStatusDraft, nil)
+}
+
+// ArchiveArticle withdraws an article. It is no longer published until it
+// goes through review again.
+func (ks *DefaultKnowledgeService) ArchiveArticle(id uint) (*models.KnowledgeArticle, error) {
+	from := []string{models.ArticleStatusDraft, models.ArticleStatusReview, models.ArticleStatusPublished}
+	return ks.transition(id, from, models.ArticleStatusArchived, func(article *models.KnowledgeArticle) error {
+		article.PublishedVersion = 0
+		article.PublishedAt, article.PublishedBy = nil, 0
+		return nil
+	})
+}
+
+// transition moves an article in one of the from statuses to status.
+func (ks *DefaultKnowledgeService) transition(id uint, from []string, status string, apply func(article *models.KnowledgeArticle) error) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !containsString(from, article.Status) {
+		return nil, fmt.Errorf("%w: a %s article cannot become %s", ErrArticleStatus, article.Status, status)
+	}
+	article.Status = status
+	if apply != nil {
+		if err := apply(article); err != nil {
+			return nil, err
+		}
+	}
+	if err := ks.KnowledgeDBModel.UpdateArticle(article); err != nil {
+		return nil, err
+	}
+	return ks.GetArticle(article.ID)
+}
+
+// GetPublicArticle retrieves a published public article as readers see
+// it, without its draft or tickets.
+func (ks *DefaultKnowledgeService) GetPublicArticle(id uint) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !article.Published() || article.Visibility != models.ArticleVisibilityPublic {
+		return nil, gorm.ErrRecordNotFound
+	}
+	article.Tickets = nil
+	ks.signAttachments(article)
+	return article, nil
+}
+
+// GetPublicArticles retrieves the published public articles matching a
+// filter.
+func (ks *DefaultKnowledgeService) GetPublicArticles(filter models.ArticleFilter) (*[]models.KnowledgeArticle, error) {
+	filter.Status = ""
+	filter.PublicOnly = true
+	filter.Query = strings.TrimSpace(filter.Query)
+	return ks.KnowledgeDBModel.GetArticles(filter)
+}
+
+// Vote records whether a reader found a published article helpful. A
+// reader has one vote per article; voting again changes it. With
+// publicOnly only public articles can be voted on.
+func (ks *DefaultKnowledgeService) Vote(id uint, voter ArticleVoter, helpful, publicOnly bool) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if publicOnly && article.Visibility != models.ArticleVisibilityPublic {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if !article.Published() {
+		return nil, fmt.Errorf("%w: only published articles can be voted on", ErrArticleStatus)
+	}
+	if voter.UserID == 0 && voter.Address == "" {
+		return nil, fmt.Errorf("%w: the voter is unknown", ErrInvalidArticle)
+	}
+	err = ks.DB.Transaction(func(tx *gorm.DB) error {
+		articles := models.NewKnowledgeDBModel(tx)
+		err := articles.SaveVote(&models.ArticleVote{ArticleID: article.ID, VoterKey: voter.key(), UserID: voter.UserID, Helpful: helpful})
+		if err != nil {
+			return err
+		}
+		if article.HelpfulCount, article.NotHelpfulCount, err = articles.CountVotes(article.ID); err != nil {
+			return err
+		}
+		return tx.Model(article).UpdateColumns(map[string]interface{}{
+			"helpful_count":     article.HelpfulCount,
+			"not_helpful_count": article.NotHelpfulCount,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	if publicOnly {
+		article.Tickets = nil
+	}
+	ks.signAttachments(article)
+	return article, nil
+}
+
+// UploadAttachment attaches a file to an article, subject to the limits
+// and scanning of ticket attachments. Uploading the same file to the same
+// article twice returns the existing record and false.
+func (ks *DefaultKnowledgeService) UploadAttachment(ctx context.Context, id uint, upload *AttachmentUpload) (*models.ArticleAttachment, bool, error) {
+	if _, err := ks.KnowledgeDBModel.GetArticleByID(id); err != nil {
+		return nil, false, err
+	}
+	tmp, err := ks.Attachments.spool(upload.FileName, upload.Reader)
+	if err != nil {
+		return nil, false, err
+	}
+	defer tmp.Close()
+
+	existing, err := ks.KnowledgeDBModel.GetAttachmentByChecksum(id, tmp.Checksum)
+	if err == nil {
+		ks.withURL(existing, time.Now().Add(ks.Attachments.Config.LinkTTL))
+		return existing, false, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, err
+	}
+
+	key, scanStatus, scanResult, err := ks.Attachments.store(ctx, tmp)
+	if err != nil {
+		return nil, false, err
+	}
+	scannedAt := time.Now()
+	attachment := &models.ArticleAttachment{
+		ArticleID:   id,
+		Type:        mediaKind(tmp.ContentType),
+		FileName:    sanitizeFileName(upload.FileName),
+		ContentType: tmp.ContentType,
+		Size:        tmp.Size,
+		Checksum:    tmp.Checksum,
+		StorageKey:  key,
+		UploadedBy:  upload.UploadedBy,
+		ScanStatus:  scanStatus,
+		ScanResult:  scanResult,
+		ScannedAt:   &scannedAt,
+	}
+	if err := ks.KnowledgeDBModel.CreateAttachment(attachment); err != nil {
+		return nil, false, err
+	}
+	ks.withURL(attachment, time.Now().Add(ks.Attachments.Config.LinkTTL))
+	return attachment, true, nil
+}
+
+// DeleteAttachment removes an attachment from an article. The stored blob
+// is only deleted once nothing else refers to the same content.
+func (ks *DefaultKnowledgeService) DeleteAttachment(ctx context.Context, id, attachmentID uint) error {
+	attachment, err := ks.KnowledgeDBModel.GetAttachmentByID(attachmentID)
+	if err != nil {
+		return err
+	}
+	if attachment.ArticleID != id {
+		return gorm.ErrRecordNotFound
+	}
+	if err := ks.KnowledgeDBModel.DeleteAttachment(attachment.ID); err != nil {
+		return err
+	}
+	return ks.Attachments.releaseBlob(ctx, attachment.StorageKey)
+}
+
+// OpenAttachment verifies a signed download link and opens the stored
+// file of an article attachment. The caller must close the returned
+// reader.
+func (ks *DefaultKnowledgeService) OpenAttachment(ctx context.Context, attachmentID uint, expires, signature string) (*models.ArticleAttachment, io.ReadCloser, error) {
+	if err := ks.Attachments.Signer.Verify(articleAttachmentPath(attachmentID), expires, signature); err != nil {
+		return nil, nil, err
+	}
+	attachment, err := ks.KnowledgeDBModel.GetAttachmentByID(attachmentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !attachment.Available() {
+		return nil, nil, ErrAttachmentQuarantined
+	}
+	body, err := ks.Attachments.Store.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return attachment, body, nil
+}
+
+// signAttachments fills in fresh download links for the attachments of an
+// article.
+func (ks *DefaultKnowledgeService) signAttachments(article *models.KnowledgeArticle) {
+	expires := time.Now().Add(ks.Attachments.Config.LinkTTL)
+	for i := range article.Attachments {
+		ks.withURL(&article.Attachments[i], expires)
+	}
+}
+
+// withURL sets the signed, expiring download link of an article
+// attachment that may be served.
+func (ks *DefaultKnowledgeService) withURL(attachment *models.ArticleAttachment, expires time.Time) {
+	if attachment.StorageKey == "" || !attachment.Available() {
+		return
+	}
+	attachment.URL = ks.Attachments.Config.BaseURL + ks.Attachments.Signer.Sign(articleAttachmentPath(attachment.ID), expires)
+}
+
+// LinkTicket links a ticket with a published article that applies to it.
+// With resolved the article is recorded as having resolved the issue;
+// linking an article again changes that.
+func (ks *DefaultKnowledgeService) LinkTicket(agentID, id, ticketID uint, resolved bool) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !article.Published() {
+		return nil, fmt.Errorf("%w: only published articles can be linked with tickets", ErrArticleStatus)
+	}
+	found, err := ks.KnowledgeDBModel.TicketExists(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: ticket %d not found", ErrInvalidArticle, ticketID)
+	}
+	detail := fmt.Sprintf("Linked with knowledge article #%d: %s", article.ID, article.Title)
+	if resolved {
+		detail = fmt.Sprintf("Resolved with knowledge article #%d: %s", article.ID, article.Title)
+	}
+	err = ks.DB.Transaction(func(tx *gorm.DB) error {
+		changed, err := models.NewKnowledgeDBModel(tx).LinkTicket(&models.ArticleTicket{
+			ArticleID: article.ID,
+			TicketID:  ticketID,
+			LinkedBy:  agentID,
+			Resolved:  resolved,
+		})
+		if err != nil || !changed {
+			return err
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticketID,
+			Action:   models.HistoryArticleLinked,
+			Detail:   detail,
+			Actor:    ks.actor(agentID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ks.GetArticle(article.ID)
+}
+
+// UnlinkTicket removes the link between a ticket and an article.
+func (ks *DefaultKnowledgeService) UnlinkTicket(agentID, id, ticketID uint) (*models.KnowledgeArticle, error) {
+	article, err := ks.KnowledgeDBModel.GetArticleByID(id)
+	if err != nil {
+		return nil, err
+	}
+	err = ks.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewKnowledgeDBModel(tx).UnlinkTicket(article.ID, ticketID); err != nil {
+			return err
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticketID,
+			Action:   models.HistoryArticleUnlinked,
+			Detail:   fmt.Sprintf("Unlinked from knowledge article #%d: %s", article.ID, article.Title),
+			Actor:    ks.actor(agentID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ks.GetArticle(article.ID)
+}
+
+// GetTicketArticles retrieves the articles linked with a ticket, each with
+// only its link to that ticket.
+func (ks *DefaultKnowledgeService) GetTicketArticles(ticketID uint) (*[]models.KnowledgeArticle, error) {
+	return ks.KnowledgeDBModel.GetTicketArticles(ticketID)
+}
+
+// SuggestArticles retrieves the published articles in the category of a
+// ticket, those of its sub-category first, to help the agent working on
+// it.
+func (ks *DefaultKnowledgeService) SuggestArticles(ticketID uint) (*[]models.KnowledgeArticle, error) {
+	ticket, err := ks.TicketService.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	suggestions := []models.KnowledgeArticle{}
+	category := ticket.Category.CategoryName
+	if category == "" {
+		return &suggestions, nil
+	}
+	articles, err := ks.KnowledgeDBModel.GetArticles(models.ArticleFilter{Category: category})
+	if err != nil {
+		return nil, err
+	}
+	var others []models.KnowledgeArticle
+	for _, article := range *articles {
+		switch {
+		case !article.Published():
+		case ticket.SubCategory.SubCategoryName != "" && strings.EqualFold(article.SubCategory, ticket.SubCategory.SubCategoryName):
+			suggestions = append(suggestions, article)
+		default:
+			others = append(others, article)
+		}
+	}
+	suggestions = append(suggestions, others...)
+	return &suggestions, nil
+}
+
+// validateDraft checks and normalises the content and settings of an
+// article.
+func (ks *DefaultKnowledgeService) validateDraft(draft *ArticleDraft) error {
+	draft.Title = strings.TrimSpace(draft.Title)
+	draft.Summary = strings.TrimSpace(draft.Summary)
+	draft.Category = strings.TrimSpace(draft.Category)
+	draft.SubCategory = strings.TrimSpace(draft.SubCategory)
+	draft.ChangeNote = strings.TrimSpace(draft.ChangeNote)
+	if draft.Title == "" {
+		return fmt.Errorf("%w: a title is required", ErrInvalidArticle)
+	}
+	if strings.TrimSpace(draft.Body) == "" {
+		return fmt.Errorf("%w: a body is required", ErrInvalidArticle)
+	}
+	if !containsString(models.ArticleVisibilities, draft.Visibility) {
+		return fmt.Errorf("%w: visibility must be one of %s", ErrInvalidArticle, strings.Join(models.ArticleVisibilities, ", "))
+	}
+	if draft.SubCategory != "" && draft.Category == "" {
+		return fmt.Errorf("%w: a sub-category needs a category", ErrInvalidArticle)
+	}
+	if ks.TicketService != nil && ks.TicketService.Lookups != nil && draft.Category != "" {
+		probe := &models.Ticket{}
+		probe.Category.CategoryName = draft.Category
+		probe.SubCategory.SubCategoryName = draft.SubCategory
+		if err := ks.TicketService.Lookups.ValidateTicket(nil, probe); err != nil {
+			if errors.Is(err, ErrInvalidTicketReference) {
+				return fmt.Errorf("%w: %v", ErrInvalidArticle, err)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func newArticleVersion(articleID uint, version int, agentID uint, draft *ArticleDraft) *models.ArticleVersion {
+	return &models.ArticleVersion{
+		ArticleID:  articleID,
+		Version:    version,
+		Title:      draft.Title,
+		Summary:    draft.Summary,
+		Body:       draft.Body,
+		Visibility: draft.Visibility,
+		ChangeNote: draft.ChangeNote,
+		EditedBy:   agentID,
+	}
+}
+
+// versionVisibility is the visibility of a version of an article. Versions
+// saved before visibility was versioned have that of the article.
+func versionVisibility(article *models.KnowledgeArticle, version *models.ArticleVersion) string {
+	if version.Visibility == "" {
+		return article.Visibility
+	}
+	return version.Visibility
+}
+
+func articleAttachmentPath(id uint) string {
+	return fmt.Sprintf("/kb/attachments/%d/download", id)
+}
@@ -0,0 +1,56 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+func TestVisibilityChangeOfPublishedArticleNeedsReview(t *testing.T) {
+	db := openTestDB(t, &models.KnowledgeArticle{}, &models.ArticleVersion{}, &models.ArticleAttachment{}, &models.ArticleTicket{})
+	ks := NewDefaultKnowledgeService(models.NewKnowledgeDBModel(db), models.NewAgentDBModel(db), nil, &DefaultAttachmentService{})
+
+	draft := ArticleDraft{Title: "Reset a password", Body: "Use the self-service portal."}
+	article, err := ks.CreateArticle(1, &draft)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.SubmitArticle(article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.PublishArticle(2, article.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	public := draft
+	public.Visibility = models.ArticleVisibilityPublic
+	article, err = ks.UpdateArticle(1, article.ID, &public)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if article.Visibility != models.ArticleVisibilityAgents || article.CurrentVersion != 2 || article.Status != models.ArticleStatusDraft {
+		t.Fatalf("article = %s, version %d, %s; want agents, version 2, draft", article.Visibility, article.CurrentVersion, article.Status)
+	}
+	if article.Draft == nil || article.Draft.Visibility != models.ArticleVisibilityPublic {
+		t.Fatalf("draft = %+v, want a public version", article.Draft)
+	}
+	if _, err := ks.GetPublicArticle(article.ID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Fatalf("GetPublicArticle before review = %v, want not found", err)
+	}
+
+	if _, err := ks.SubmitArticle(article.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.PublishArticle(2, article.ID); err != nil {
+		t.Fatal(err)
+	}
+	article, err = ks.GetPublicArticle(article.ID)
+	if err != nil {
+		t.Fatalf("GetPublicArticle after review = %v", err)
+	}
+	if article.Visibility != models.ArticleVisibilityPublic || article.PublishedVersion != 2 {
+		t.Fatalf("article = %s, published version %d; want public, version 2", article.Visibility, article.PublishedVersion)
+	}
+}
@@ -0,0 +1,615 @@
+// backend/services/lookup_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidLookup          = errors.New("invalid lookup value")
+	ErrLookupInUse            = errors.New("lookup value in use")
+	ErrInvalidTicketReference = errors.New("invalid ticket reference")
+)
+
+// LookupServiceInterface provides methods for the categories,
+// sub-categories, priorities, statuses and SLAs tickets refer to.
+type LookupServiceInterface interface {
+	CreateCategory(category *models.Category) error
+	UpdateCategory(category *models.Category) (*models.Category, error)
+	DeleteCategory(id int) (bool, error)
+	GetCategories() (*[]models.Category, error)
+	GetCategoryTree() ([]models.CategoryNode, error)
+	CreateSubCategory(subCategory *models.SubCategory) error
+	UpdateSubCategory(subCategory *models.SubCategory) (*models.SubCategory, error)
+	DeleteSubCategory(id int) (bool, error)
+	GetSubCategories() (*[]models.SubCategory, error)
+	CreatePriority(priority *models.Priority) error
+	UpdatePriority(priority *models.Priority) (*models.Priority, error)
+	DeletePriority(id int) (bool, error)
+	GetPriorities() (*[]models.Priority, error)
+	CreateStatus(status *models.Status) error
+	UpdateStatus(status *models.Status) (*models.Status, error)
+	DeleteStatus(id int) (bool, error)
+	GetStatuses() (*[]models.Status, error)
+	CreateSla(sla *models.Sla) error
+	UpdateSla(sla *models.Sla) (*models.Sla, error)
+	DeleteSla(id int) (bool, error)
+	GetSlas() (*[]models.Sla, error)
+	ValidateTicket(previous, ticket *models.Ticket) error
+}
+
+// DefaultLookupService is the default implementation of LookupService
+type DefaultLookupService struct {
+	DB            *gorm.DB
+	LookupDBModel *models.LookupDBModel
+}
+
+// NewDefaultLookupService creates a new DefaultLookupService.
+func NewDefaultLookupService(lookupDBModel *models.LookupDBModel) *DefaultLookupService {
+	return &DefaultLookupService{
+		DB:            lookupDBModel.DB,
+		LookupDBModel: lookupDBModel,
+	}
+}
+
+// CreateCategory creates a category.
+func (ls *DefaultLookupService) CreateCategory(category *models.Category) error {
+	category.ID = 0
+	category.CategoryName = strings.TrimSpace(category.CategoryName)
+	if err := ls.validateCategory(category); err != nil {
+		return err
+	}
+	return ls.LookupDBModel.CreateCategory(category)
+}
+
+// UpdateCategory updates a category. A new name is carried over to the
+// tickets, custom fields and ticket policies of the category.
+func (ls *DefaultLookupService) UpdateCategory(category *models.Category) (*models.Category, error) {
+	existing, err := ls.LookupDBModel.GetCategoryByID(category.ID)
+	if err != nil {
+		return nil, err
+	}
+	category.CategoryName = strings.TrimSpace(category.CategoryName)
+	if err := ls.validateCategory(category); err != nil {
+		return nil, err
+	}
+	previousName := existing.CategoryName
+	existing.CategoryName = category.CategoryName
+	existing.Retired = category.Retired
+	err = ls.DB.Transaction(func(tx *gorm.DB) error {
+		lookupDB := models.NewLookupDBModel(tx)
+		if err := lookupDB.UpdateCategory(existing); err != nil {
+			return err
+		}
+		if previousName != existing.CategoryName {
+			return lookupDB.RenameCategory(previousName, existing.CategoryName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteCategory deletes a category with its sub-categories. A category
+// that tickets still have is retired instead, which DeleteCategory reports
+// by returning true.
+func (ls *DefaultLookupService) DeleteCategory(id int) (bool, error) {
+	category, err := ls.LookupDBModel.GetCategoryByID(id)
+	if err != nil {
+		return false, err
+	}
+	count, err := ls.LookupDBModel.CountTickets("category_name", category.CategoryName)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		category.Retired = true
+		return true, ls.LookupDBModel.UpdateCategory(category)
+	}
+	return false, ls.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewLookupDBModel(tx).DeleteCategory(id)
+	})
+}
+
+// GetCategories retrieves every category, retired ones included.
+func (ls *DefaultLookupService) GetCategories() (*[]models.Category, error) {
+	return ls.LookupDBModel.GetAllCategories()
+}
+
+// GetCategoryTree retrieves the categories that can be chosen for a ticket
+// with their sub-categories.
+func (ls *DefaultLookupService) GetCategoryTree() ([]models.CategoryNode, error) {
+	categories, err := ls.LookupDBModel.GetAllCategories()
+	if err != nil {
+		return nil, err
+	}
+	subCategories, err := ls.LookupDBModel.GetAllSubCategories()
+	if err != nil {
+		return nil, err
+	}
+	byCategory := map[int][]models.SubCategory{}
+	for _, subCategory := range *subCategories {
+		if !subCategory.Retired {
+			byCategory[subCategory.CategoryID] = append(byCategory[subCategory.CategoryID], subCategory)
+		}
+	}
+	tree := []models.CategoryNode{}
+	for _, category := range *categories {
+		if category.Retired {
+			continue
+		}
+		node := models.CategoryNode{
+			CategoryID:    category.ID,
+			CategoryName:  category.CategoryName,
+			SubCategories: byCategory[category.ID],
+		}
+		if node.SubCategories == nil {
+			node.SubCategories = []models.SubCategory{}
+		}
+		tree = append(tree, node)
+	}
+	return tree, nil
+}
+
+// CreateSubCategory creates a sub-category in a category that is not
+// retired.
+func (ls *DefaultLookupService) CreateSubCategory(subCategory *models.SubCategory) error {
+	subCategory.SubCategoryName = strings.TrimSpace(subCategory.SubCategoryName)
+	category, err := ls.LookupDBModel.GetCategoryByID(subCategory.CategoryID)
+	if err != nil {
+		return notFoundAs(err, fmt.Errorf("%w: unknown category %d", ErrInvalidLookup, subCategory.CategoryID))
+	}
+	if category.Retired {
+		return fmt.Errorf("%w: category %q is retired", ErrInvalidLookup, category.CategoryName)
+	}
+	subCategory.SubCategoryID = 0
+	if err := ls.validateSubCategory(subCategory); err != nil {
+		return err
+	}
+	return ls.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewLookupDBModel(tx).CreateSubCategory(subCategory)
+	})
+}
+
+// UpdateSubCategory updates a sub-category. It stays in its category, and
+// a new name is carried over to its tickets and custom fields.
+func (ls *DefaultLookupService) UpdateSubCategory(subCategory *models.SubCategory) (*models.SubCategory, error) {
+	existing, err := ls.LookupDBModel.GetSubCategoryByID(subCategory.SubCategoryID)
+	if err != nil {
+		return nil, err
+	}
+	if subCategory.CategoryID != 0 && subCategory.CategoryID != existing.CategoryID {
+		return nil, fmt.Errorf("%w: a sub-category cannot move to another category", ErrInvalidLookup)
+	}
+	category, err := ls.LookupDBModel.GetCategoryByID(existing.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+	subCategory.CategoryID = existing.CategoryID
+	subCategory.SubCategoryName = strings.TrimSpace(subCategory.SubCategoryName)
+	if err := ls.validateSubCategory(subCategory); err != nil {
+		return nil, err
+	}
+	previousName := existing.SubCategoryName
+	existing.SubCategoryName = subCategory.SubCategoryName
+	existing.Retired = subCategory.Retired
+	err = ls.DB.Transaction(func(tx *gorm.DB) error {
+		lookupDB := models.NewLookupDBModel(tx)
+		if err := lookupDB.UpdateSubCategory(existing); err != nil {
+			return err
+		}
+		if previousName != existing.SubCategoryName {
+			return lookupDB.RenameSubCategory(category.CategoryName, previousName, existing.SubCategoryName)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteSubCategory deletes a sub-category, or retires it when tickets
+// still have it, which DeleteSubCategory reports by returning true.
+func (ls *DefaultLookupService) DeleteSubCategory(id int) (bool, error) {
+	subCategory, err := ls.LookupDBModel.GetSubCategoryByID(id)
+	if err != nil {
+		return false, err
+	}
+	category, err := ls.LookupDBModel.GetCategoryByID(subCategory.CategoryID)
+	if err != nil {
+		return false, err
+	}
+	count, err := ls.LookupDBModel.CountSubCategoryTickets(category.CategoryName, subCategory.SubCategoryName)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		subCategory.Retired = true
+		return true, ls.LookupDBModel.UpdateSubCategory(subCategory)
+	}
+	return false, ls.LookupDBModel.DeleteSubCategory(id)
+}
+
+// GetSubCategories retrieves every sub-category, retired ones included.
+func (ls *DefaultLookupService) GetSubCategories() (*[]models.SubCategory, error) {
+	return ls.LookupDBModel.GetAllSubCategories()
+}
+
+// CreatePriority creates a priority.
+func (ls *DefaultLookupService) CreatePriority(priority *models.Priority) error {
+	priority.Name = strings.TrimSpace(priority.Name)
+	priority.PriorityID = 0
+	if err := ls.validatePriority(priority); err != nil {
+		return err
+	}
+	return ls.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewLookupDBModel(tx).CreatePriority(priority)
+	})
+}
+
+// UpdatePriority updates a priority. A new name is carried over to its
+// tickets.
+func (ls *DefaultLookupService) UpdatePriority(priority *models.Priority) (*models.Priority, error) {
+	existing, err := ls.LookupDBModel.GetPriorityByID(priority.PriorityID)
+	if err != nil {
+		return nil, err
+	}
+	priority.Name = strings.TrimSpace(priority.Name)
+	if err := ls.validatePriority(priority); err != nil {
+		return nil, err
+	}
+	renamed := existing.Name != priority.Name
+	existing.Name = priority.Name
+	existing.FirstResponse = priority.FirstResponse
+	existing.Colour = priority.Colour
+	err = ls.DB.Transaction(func(tx *gorm.DB) error {
+		lookupDB := models.NewLookupDBModel(tx)
+		if err := lookupDB.UpdatePriority(existing); err != nil {
+			return err
+		}
+		if renamed {
+			return lookupDB.RenamePriority(existing.PriorityID, existing.Name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeletePriority deletes a priority that no ticket, SLA or cell of the
+// priority matrix refers to.
+func (ls *DefaultLookupService) DeletePriority(id int) (bool, error) {
+	if _, err := ls.LookupDBModel.GetPriorityByID(id); err != nil {
+		return false, err
+	}
+	count, err := ls.LookupDBModel.CountTickets("priority_id", id)
+	if err != nil {
+		return false, err
+	}
+	uses, err := ls.LookupDBModel.CountPriorityUses(id)
+	if err != nil {
+		return false, err
+	}
+	if count+uses > 0 {
+		return false, fmt.Errorf("%w: the priority has tickets, an SLA or cells of the priority matrix", ErrLookupInUse)
+	}
+	if err := ls.LookupDBModel.DeletePriority(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetPriorities retrieves every priority.
+func (ls *DefaultLookupService) GetPriorities() (*[]models.Priority, error) {
+	return ls.LookupDBModel.GetPriorities()
+}
+
+// CreateStatus creates a status.
+func (ls *DefaultLookupService) CreateStatus(status *models.Status) error {
+	status.StatusName = strings.TrimSpace(status.StatusName)
+	status.StatusID = 0
+	if err := ls.validateStatus(status); err != nil {
+		return err
+	}
+	return ls.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewLookupDBModel(tx).CreateStatus(status)
+	})
+}
+
+// UpdateStatus updates a status. A new name is carried over to its
+// tickets; the resolved and closed statuses keep theirs.
+func (ls *DefaultLookupService) UpdateStatus(status *models.Status) (*models.Status, error) {
+	existing, err := ls.LookupDBModel.GetStatusByID(status.StatusID)
+	if err != nil {
+		return nil, err
+	}
+	status.StatusName = strings.TrimSpace(status.StatusName)
+	if err := ls.validateStatus(status); err != nil {
+		return nil, err
+	}
+	if existing.StatusName == status.StatusName {
+		return existing, nil
+	}
+	if existing.IsResolved() {
+		return nil, fmt.Errorf("%w: status %q cannot be renamed", ErrInvalidLookup, existing.StatusName)
+	}
+	previousName := existing.StatusName
+	existing.StatusName = status.StatusName
+	err = ls.DB.Transaction(func(tx *gorm.DB) error {
+		lookupDB := models.NewLookupDBModel(tx)
+		if err := lookupDB.UpdateStatus(existing); err != nil {
+			return err
+		}
+		return lookupDB.RenameStatus(previousName, existing.StatusName)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteStatus deletes a status that no ticket has. The resolved and
+// closed statuses cannot be deleted.
+func (ls *DefaultLookupService) DeleteStatus(id int) (bool, error) {
+	status, err := ls.LookupDBModel.GetStatusByID(id)
+	if err != nil {
+		return false, err
+	}
+	if status.IsResolved() {
+		return false, fmt.Errorf("%w: status %q cannot be deleted", ErrInvalidLookup, status.StatusName)
+	}
+	count, err := ls.LookupDBModel.CountTickets("status_name", status.StatusName)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, fmt.Errorf("%w: tickets have status %q", ErrLookupInUse, status.StatusName)
+	}
+	if err := ls.LookupDBModel.DeleteStatus(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetStatuses retrieves every status.
+func (ls *DefaultLookupService) GetStatuses() (*[]models.Status, error) {
+	return ls.LookupDBModel.GetStatuses()
+}
+
+// CreateSla creates the SLA of a priority.
+func (ls *DefaultLookupService) CreateSla(sla *models.Sla) error {
+	sla.SlaName = strings.TrimSpace(sla.SlaName)
+	sla.SlaID = 0
+	if err := ls.validateSla(sla); err != nil {
+		return err
+	}
+	return ls.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewLookupDBModel(tx).CreateSla(sla)
+	})
+}
+
+// UpdateSla updates an SLA.
+func (ls *DefaultLookupService) UpdateSla(sla *models.Sla) (*models.Sla, error) {
+	existing, err := ls.LookupDBModel.GetSlaByID(sla.SlaID)
+	if err != nil {
+		return nil, err
+	}
+	sla.SlaName = strings.TrimSpace(sla.SlaName)
+	if err := ls.validateSla(sla); err != nil {
+		return nil, err
+	}
+	existing.SlaName = sla.SlaName
+	existing.PriorityID = sla.PriorityID
+	existing.SatisfactionID = sla.SatisfactionID
+	existing.PolicyID = sla.PolicyID
+	if err := ls.LookupDBModel.UpdateSla(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteSla deletes an SLA that no ticket has.
+func (ls *DefaultLookupService) DeleteSla(id int) (bool, error) {
+	if _, err := ls.LookupDBModel.GetSlaByID(id); err != nil {
+		return false, err
+	}
+	count, err := ls.LookupDBModel.CountTickets("sla_id", id)
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return false, fmt.Errorf("%w: tickets have the SLA", ErrLookupInUse)
+	}
+	if err := ls.LookupDBModel.DeleteSla(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetSlas retrieves every SLA.
+func (ls *DefaultLookupService) GetSlas() (*[]models.Sla, error) {
+	return ls.LookupDBModel.GetAllSla()
+}
+
+// ValidateTicket checks that the category, sub-category, priority and
+// status of a ticket exist, given by ID or by name, and completes them. The
+// sub-category must belong to the category, and a retired category or
+// sub-category is only accepted on a ticket that already has it. previous
+// is the ticket before an update, nil on creation. A name changed since
+// previous wins over the ID it kept, so that setting the name of a loaded
+// ticket is enough.
+func (ls *DefaultLookupService) ValidateTicket(previous, ticket *models.Ticket) error {
+	var before models.Ticket
+	if previous != nil {
+		before = *previous
+	}
+	var category *models.Category
+	if ticket.Category.ID != 0 || ticket.Category.CategoryName != "" {
+		var err error
+		if byID(ticket.Category.ID != 0, ticket.Category.CategoryName, before.Category.CategoryName, previous != nil) {
+			category, err = ls.LookupDBModel.GetCategoryByID(ticket.Category.ID)
+		} else {
+			category, err = ls.LookupDBModel.GetCategoryByName(ticket.Category.CategoryName)
+		}
+		if err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown category", ErrInvalidTicketReference))
+		}
+		if category.Retired && (previous == nil || previous.Category.CategoryName != category.CategoryName) {
+			return fmt.Errorf("%w: category %q is retired", ErrInvalidTicketReference, category.CategoryName)
+		}
+		ticket.Category.ID = category.ID
+		ticket.Category.CategoryName = category.CategoryName
+	}
+
+	if ticket.SubCategory.SubCategoryID != 0 || ticket.SubCategory.SubCategoryName != "" {
+		if category == nil {
+			return fmt.Errorf("%w: a sub-category needs its category", ErrInvalidTicketReference)
+		}
+		var subCategory *models.SubCategory
+		var err error
+		if byID(ticket.SubCategory.SubCategoryID != 0, ticket.SubCategory.SubCategoryName, before.SubCategory.SubCategoryName, previous != nil) {
+			subCategory, err = ls.LookupDBModel.GetSubCategoryByID(ticket.SubCategory.SubCategoryID)
+		} else {
+			subCategory, err = ls.LookupDBModel.GetSubCategoryByName(category.ID, ticket.SubCategory.SubCategoryName)
+		}
+		if err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown sub-category", ErrInvalidTicketReference))
+		}
+		if subCategory.CategoryID != category.ID {
+			return fmt.Errorf("%w: sub-category %q is not in category %q", ErrInvalidTicketReference, subCategory.SubCategoryName, category.CategoryName)
+		}
+		kept := previous != nil && previous.Category.CategoryName == category.CategoryName &&
+			previous.SubCategory.SubCategoryName == subCategory.SubCategoryName
+		if subCategory.Retired && !kept {
+			return fmt.Errorf("%w: sub-category %q is retired", ErrInvalidTicketReference, subCategory.SubCategoryName)
+		}
+		ticket.SubCategory.SubCategoryID = subCategory.SubCategoryID
+		ticket.SubCategory.SubCategoryName = subCategory.SubCategoryName
+		ticket.SubCategory.CategoryID = subCategory.CategoryID
+	}
+
+	if ticket.Priority.PriorityID != 0 || ticket.Priority.Name != "" {
+		var priority *models.Priority
+		var err error
+		if byID(ticket.Priority.PriorityID != 0, ticket.Priority.Name, before.Priority.Name, previous != nil) {
+			priority, err = ls.LookupDBModel.GetPriorityByID(ticket.Priority.PriorityID)
+		} else {
+			priority, err = ls.LookupDBModel.GetPriorityByName(ticket.Priority.Name)
+		}
+		if err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown priority", ErrInvalidTicketReference))
+		}
+		ticket.Priority.PriorityID = priority.PriorityID
+		ticket.Priority.Name = priority.Name
+	}
+
+	if ticket.Status.StatusID != 0 || ticket.Status.StatusName != "" {
+		var status *models.Status
+		var err error
+		if byID(ticket.Status.StatusID != 0, ticket.Status.StatusName, before.Status.StatusName, previous != nil) {
+			status, err = ls.LookupDBModel.GetStatusByID(ticket.Status.StatusID)
+		} else {
+			status, err = ls.LookupDBModel.GetStatusByName(ticket.Status.StatusName)
+		}
+		if err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown status", ErrInvalidTicketReference))
+		}
+		ticket.Status.StatusID = status.StatusID
+		ticket.Status.StatusName = status.StatusName
+	}
+	return nil
+}
+
+// byID tells whether a reference of a ticket is looked up by its ID rather
+// than its name: when it has one, unless its name changed on an update.
+func byID(hasID bool, name, previousName string, update bool) bool {
+	return hasID && !(update && name != "" && name != previousName)
+}
+
+func (ls *DefaultLookupService) validateCategory(category *models.Category) error {
+	if category.CategoryName == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidLookup)
+	}
+	return uniqueName("category", category.CategoryName, category.ID, func() (int, error) {
+		found, err := ls.LookupDBModel.GetCategoryByName(category.CategoryName)
+		return found.ID, err
+	})
+}
+
+func (ls *DefaultLookupService) validateSubCategory(subCategory *models.SubCategory) error {
+	if subCategory.SubCategoryName == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidLookup)
+	}
+	return uniqueName("sub-category", subCategory.SubCategoryName, subCategory.SubCategoryID, func() (int, error) {
+		found, err := ls.LookupDBModel.GetSubCategoryByName(subCategory.CategoryID, subCategory.SubCategoryName)
+		return found.SubCategoryID, err
+	})
+}
+
+func (ls *DefaultLookupService) validatePriority(priority *models.Priority) error {
+	if priority.Name == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidLookup)
+	}
+	if priority.FirstResponse < 0 {
+		return fmt.Errorf("%w: first response cannot be negative", ErrInvalidLookup)
+	}
+	return uniqueName("priority", priority.Name, priority.PriorityID, func() (int, error) {
+		found, err := ls.LookupDBModel.GetPriorityByName(priority.Name)
+		return found.PriorityID, err
+	})
+}
+
+func (ls *DefaultLookupService) validateStatus(status *models.Status) error {
+	if status.StatusName == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidLookup)
+	}
+	return uniqueName("status", status.StatusName, status.StatusID, func() (int, error) {
+		found, err := ls.LookupDBModel.GetStatusByName(status.StatusName)
+		return found.StatusID, err
+	})
+}
+
+// validateSla checks an SLA, which needs a priority without another SLA.
+func (ls *DefaultLookupService) validateSla(sla *models.Sla) error {
+	if sla.SlaName == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidLookup)
+	}
+	if _, err := ls.LookupDBModel.GetPriorityByID(sla.PriorityID); err != nil {
+		return notFoundAs(err, fmt.Errorf("%w: unknown priority %d", ErrInvalidLookup, sla.PriorityID))
+	}
+	existing, err := ls.LookupDBModel.GetSlaByPriorityID(sla.PriorityID)
+	if err == nil && existing.SlaID != sla.SlaID {
+		return fmt.Errorf("%w: the priority already has SLA %q", ErrInvalidLookup, existing.SlaName)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	return nil
+}
+
+// uniqueName checks that no other value than the one with ID id has name,
+// find returning the ID of the one that has.
+func uniqueName(what, name string, id int, find func() (int, error)) error {
+	found, err := find()
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if found != id {
+		return fmt.Errorf("%w: %s %q already exists", ErrInvalidLookup, what, name)
+	}
+	return nil
+}
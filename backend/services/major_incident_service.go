@@ -0,0 +1,491 @@
+// backend/services/major_incident_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidMajorIncident = errors.New("invalid major incident")
+
+// MajorIncidentBroadcast is a status update to broadcast. An empty status
+// keeps the current one.
+type MajorIncidentBroadcast struct {
+	Status  string `json:"status"`
+	Message string `json:"message"`
+}
+
+// SiteStatus is the public status of a site: its active major incidents.
+type SiteStatus struct {
+	Site      string               `json:"site"`
+	Incidents []PublicIncidentInfo `json:"incidents"`
+}
+
+// PublicIncidentInfo is what the public status page shows of a major
+// incident, without its tickets or who handles it.
+type PublicIncidentInfo struct {
+	ID         uint      `json:"major_incident_id"`
+	Title      string    `json:"title"`
+	Summary    string    `json:"summary"`
+	Status     string    `json:"status"`
+	LastUpdate string    `json:"last_update,omitempty"`
+	StartedAt  time.Time `json:"started_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// MajorIncidentServiceInterface provides methods for major incidents.
+type MajorIncidentServiceInterface interface {
+	Declare(agentID uint, incident *models.MajorIncident) error
+	UpdateIncident(incident *models.MajorIncident) (*models.MajorIncident, error)
+	GetIncident(id uint) (*models.MajorIncident, error)
+	GetIncidents(activeOnly bool) (*[]models.MajorIncident, error)
+	GetTicketIncident(ticketID uint) (*models.MajorIncident, error)
+	LinkTickets(agentID, incidentID uint, ticketIDs []uint) (*models.MajorIncident, error)
+	UnlinkTicket(agentID, incidentID, ticketID uint) (*models.MajorIncident, error)
+	Broadcast(agentID, incidentID uint, broadcast *MajorIncidentBroadcast) (*models.MajorIncidentUpdate, error)
+	GetStatus(site string) ([]SiteStatus, error)
+	HandleEvent(ctx context.Context, e events.Event) error
+}
+
+// DefaultMajorIncidentService is the default implementation of
+// MajorIncidentService
+type DefaultMajorIncidentService struct {
+	DB                   *gorm.DB
+	MajorIncidentDBModel *models.MajorIncidentDBModel
+	AgentDBModel         *models.AgentDBModel
+	TicketService        *DefaultTicketingService
+	Notifications        *DefaultNotificationService
+	Events               EventPublisher
+}
+
+// NewDefaultMajorIncidentService creates a new DefaultMajorIncidentService.
+func NewDefaultMajorIncidentService(majorIncidentDBModel *models.MajorIncidentDBModel, agentDBModel *models.AgentDBModel, ticketService *DefaultTicketingService, notifications *DefaultNotificationService) *DefaultMajorIncidentService {
+	return &DefaultMajorIncidentService{
+		DB:                   majorIncidentDBModel.DB,
+		MajorIncidentDBModel: majorIncidentDBModel,
+		AgentDBModel:         agentDBModel,
+		TicketService:        ticketService,
+		Notifications:        notifications,
+	}
+}
+
+// actor names an agent in ticket history.
+func (ms *DefaultMajorIncidentService) actor(agentID uint) string {
+	if agent, err := ms.AgentDBModel.GetAgentByID(agentID); err == nil && agent.AgentEmail != "" {
+		return agent.AgentEmail
+	}
+	return fmt.Sprintf("agent:%d", agentID)
+}
+
+// Declare declares a ticket a major incident. The title and site default
+// to those of the ticket, which is linked as the first of its tickets so
+// its requester is kept updated too.
+func (ms *DefaultMajorIncidentService) Declare(agentID uint, incident *models.MajorIncident) error {
+	ticket, err := ms.TicketService.TicketDBModel.GetTicketByID(incident.TicketID)
+	if err != nil {
+		return notFoundAs(err, fmt.Errorf("%w: unknown ticket %d", ErrInvalidMajorIncident, incident.TicketID))
+	}
+	if existing, err := ms.MajorIncidentDBModel.GetTicketIncident(ticket.ID); err == nil && existing.Status != models.MajorIncidentResolved {
+		return fmt.Errorf("%w: ticket %d is already part of major incident #%d", ErrInvalidMajorIncident, ticket.ID, existing.ID)
+	} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	incident.ID = 0
+	incident.DeclaredBy = agentID
+	incident.ResolvedAt = nil
+	incident.Tickets, incident.Updates = nil, nil
+	if strings.TrimSpace(incident.Title) == "" {
+		incident.Title = ticket.Subject
+	}
+	if strings.TrimSpace(incident.Site) == "" {
+		incident.Site = ticket.Site
+	}
+	if incident.Status == "" {
+		incident.Status = models.MajorIncidentInvestigating
+	}
+	if err := validateMajorIncident(incident); err != nil {
+		return err
+	}
+	if incident.Status == models.MajorIncidentResolved {
+		return fmt.Errorf("%w: a major incident is declared active", ErrInvalidMajorIncident)
+	}
+	return ms.DB.Transaction(func(tx *gorm.DB) error {
+		incidents := models.NewMajorIncidentDBModel(tx)
+		if err := incidents.CreateIncident(incident); err != nil {
+			return err
+		}
+		_, err := incidents.LinkTicket(&models.MajorIncidentTicket{
+			MajorIncidentID: incident.ID,
+			TicketID:        ticket.ID,
+			UserID:          ticket.UserID.ID,
+			LinkedBy:        agentID,
+		})
+		if err != nil {
+			return err
+		}
+		err = models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryMajorIncident,
+			Detail:   fmt.Sprintf("declared major incident #%d: %s", incident.ID, incident.Title),
+			Actor:    ms.actor(agentID),
+		})
+		if err != nil {
+			return err
+		}
+		return publish(tx, ms.Events, models.EventMajorIncidentDeclared, incident)
+	})
+}
+
+// UpdateIncident updates the description and matching of a major
+// incident. Its status changes through broadcasts.
+func (ms *DefaultMajorIncidentService) UpdateIncident(incident *models.MajorIncident) (*models.MajorIncident, error) {
+	existing, err := ms.MajorIncidentDBModel.GetIncidentByID(incident.ID)
+	if err != nil {
+		return nil, err
+	}
+	incident.Model = existing.Model
+	incident.CreatedAt = existing.CreatedAt
+	incident.TicketID = existing.TicketID
+	incident.Status = existing.Status
+	incident.DeclaredBy = existing.DeclaredBy
+	incident.ResolvedAt = existing.ResolvedAt
+	if err := validateMajorIncident(incident); err != nil {
+		return nil, err
+	}
+	if err := ms.MajorIncidentDBModel.UpdateIncident(incident); err != nil {
+		return nil, err
+	}
+	return ms.MajorIncidentDBModel.GetIncidentByID(incident.ID)
+}
+
+// GetIncident retrieves a major incident with its tickets and updates.
+func (ms *DefaultMajorIncidentService) GetIncident(id uint) (*models.MajorIncident, error) {
+	return ms.MajorIncidentDBModel.GetIncidentByID(id)
+}
+
+// GetIncidents retrieves the major incidents, optionally only the active
+// ones.
+func (ms *DefaultMajorIncidentService) GetIncidents(activeOnly bool) (*[]models.MajorIncident, error) {
+	return ms.MajorIncidentDBModel.GetIncidents(activeOnly)
+}
+
+// GetTicketIncident retrieves the major incident of a ticket.
+func (ms *DefaultMajorIncidentService) GetTicketIncident(ticketID uint) (*models.MajorIncident, error) {
+	return ms.MajorIncidentDBModel.GetTicketIncident(ticketID)
+}
+
+// LinkTickets links tickets with an active major incident by hand.
+func (ms *DefaultMajorIncidentService) LinkTickets(agentID, incidentID uint, ticketIDs []uint) (*models.MajorIncident, error) {
+	incident, err := ms.MajorIncidentDBModel.GetIncidentByID(incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status == models.MajorIncidentResolved {
+		return nil, fmt.Errorf("%w: the major incident is resolved", ErrInvalidMajorIncident)
+	}
+	if len(ticketIDs) == 0 {
+		return nil, fmt.Errorf("%w: ticket_ids are required", ErrInvalidMajorIncident)
+	}
+	tickets := make([]*models.Ticket, 0, len(ticketIDs))
+	for _, id := range ticketIDs {
+		ticket, err := ms.TicketService.TicketDBModel.GetTicketByID(id)
+		if err != nil {
+			return nil, notFoundAs(err, fmt.Errorf("%w: unknown ticket %d", ErrInvalidMajorIncident, id))
+		}
+		tickets = append(tickets, ticket)
+	}
+	actor := ms.actor(agentID)
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		for _, ticket := range tickets {
+			if err := ms.link(tx, incident, ticket, agentID, actor); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ms.MajorIncidentDBModel.GetIncidentByID(incidentID)
+}
+
+// UnlinkTicket removes a ticket from a major incident. The master ticket
+// stays linked.
+func (ms *DefaultMajorIncidentService) UnlinkTicket(agentID, incidentID, ticketID uint) (*models.MajorIncident, error) {
+	incident, err := ms.MajorIncidentDBModel.GetIncidentByID(incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if ticketID == incident.TicketID {
+		return nil, fmt.Errorf("%w: the master ticket cannot be unlinked", ErrInvalidMajorIncident)
+	}
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewMajorIncidentDBModel(tx).UnlinkTicket(incidentID, ticketID); err != nil {
+			return err
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticketID,
+			Action:   models.HistoryMajorIncident,
+			Detail:   fmt.Sprintf("unlinked from major incident #%d", incidentID),
+			Actor:    ms.actor(agentID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ms.MajorIncidentDBModel.GetIncidentByID(incidentID)
+}
+
+// Broadcast posts a status update of an active major incident and emails
+// it to the requesters of its tickets, once per requester. Broadcasting
+// the resolved status resolves the incident and stops linking tickets.
+func (ms *DefaultMajorIncidentService) Broadcast(agentID, incidentID uint, broadcast *MajorIncidentBroadcast) (*models.MajorIncidentUpdate, error) {
+	incident, err := ms.MajorIncidentDBModel.GetIncidentByID(incidentID)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status == models.MajorIncidentResolved {
+		return nil, fmt.Errorf("%w: the major incident is resolved", ErrInvalidMajorIncident)
+	}
+	message := strings.TrimSpace(broadcast.Message)
+	if message == "" {
+		return nil, fmt.Errorf("%w: a message is required", ErrInvalidMajorIncident)
+	}
+	status := broadcast.Status
+	if status == "" {
+		status = incident.Status
+	}
+	if !containsString(models.MajorIncidentStatuses, status) {
+		return nil, fmt.Errorf("%w: status must be one of %s", ErrInvalidMajorIncident, strings.Join(models.MajorIncidentStatuses, ", "))
+	}
+
+	// Each requester hears once, about their first ticket.
+	recipients := map[uint]uint{}
+	var order []uint
+	for _, link := range incident.Tickets {
+		if link.UserID == 0 {
+			continue
+		}
+		if _, ok := recipients[link.UserID]; !ok {
+			recipients[link.UserID] = link.TicketID
+			order = append(order, link.UserID)
+		}
+	}
+	update := &models.MajorIncidentUpdate{
+		MajorIncidentID: incident.ID,
+		Status:          status,
+		Message:         message,
+		PostedBy:        agentID,
+		Recipients:      len(order),
+	}
+	incident.Status = status
+	event := models.EventMajorIncidentUpdated
+	if status == models.MajorIncidentResolved {
+		now := time.Now()
+		incident.ResolvedAt = &now
+		event = models.EventMajorIncidentResolved
+	}
+	actor := ms.actor(agentID)
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		incidents := models.NewMajorIncidentDBModel(tx)
+		if err := incidents.CreateUpdate(update); err != nil {
+			return err
+		}
+		if err := incidents.UpdateIncident(incident); err != nil {
+			return err
+		}
+		history := models.NewTicketHistoryDBModel(tx)
+		for _, link := range incident.Tickets {
+			err := history.CreateEntry(&models.TicketHistory{
+				TicketID: link.TicketID,
+				Action:   models.HistoryMajorIncident,
+				Detail:   fmt.Sprintf("major incident #%d %s: %s", incident.ID, status, message),
+				Actor:    actor,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return publish(tx, ms.Events, event, update)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if ms.Notifications != nil {
+		for _, userID := range order {
+			if err := ms.Notifications.NotifyMajorIncident(incident, update, userID, recipients[userID]); err != nil {
+				log.Printf("major incident %d: failed to notify user %d: %v", incident.ID, userID, err)
+			}
+		}
+	}
+	return update, nil
+}
+
+// GetStatus retrieves the active major incidents by site, optionally only
+// those of one site, for the public status page.
+func (ms *DefaultMajorIncidentService) GetStatus(site string) ([]SiteStatus, error) {
+	incidents, err := ms.MajorIncidentDBModel.GetActiveIncidentsBySite(strings.TrimSpace(site))
+	if err != nil {
+		return nil, err
+	}
+	sites := []SiteStatus{}
+	for _, incident := range *incidents {
+		info := PublicIncidentInfo{
+			ID:        incident.ID,
+			Title:     incident.Title,
+			Summary:   incident.Summary,
+			Status:    incident.Status,
+			StartedAt: incident.CreatedAt,
+			UpdatedAt: incident.UpdatedAt,
+		}
+		if len(incident.Updates) > 0 {
+			info.LastUpdate = incident.Updates[0].Message
+		}
+		if n := len(sites); n > 0 && strings.EqualFold(sites[n-1].Site, incident.Site) {
+			sites[n-1].Incidents = append(sites[n-1].Incidents, info)
+			continue
+		}
+		sites = append(sites, SiteStatus{Site: incident.Site, Incidents: []PublicIncidentInfo{info}})
+	}
+	return sites, nil
+}
+
+// HandleEvent links a new ticket with the latest active major incident it
+// matches and sends its requester the latest update. It is registered on
+// the event bus for ticket.created; a redelivered event does not link the
+// ticket twice.
+func (ms *DefaultMajorIncidentService) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Name != models.EventTicketCreated {
+		return nil
+	}
+	var ticket models.Ticket
+	if err := e.Decode(&ticket); err != nil {
+		return err
+	}
+	if ticket.ID == 0 {
+		return nil
+	}
+	incidents, err := ms.MajorIncidentDBModel.GetIncidents(true)
+	if err != nil {
+		return err
+	}
+	for i := range *incidents {
+		incident := &(*incidents)[i]
+		if !matchesMajorIncident(incident, &ticket) {
+			continue
+		}
+		var linked bool
+		err := ms.DB.Transaction(func(tx *gorm.DB) error {
+			created, err := models.NewMajorIncidentDBModel(tx).LinkTicket(&models.MajorIncidentTicket{
+				MajorIncidentID: incident.ID,
+				TicketID:        ticket.ID,
+				UserID:          ticket.UserID.ID,
+				Automatic:       true,
+			})
+			if err != nil || !created {
+				return err
+			}
+			linked = true
+			return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+				TicketID: ticket.ID,
+				Action:   models.HistoryMajorIncident,
+				Detail:   fmt.Sprintf("linked to major incident #%d: %s", incident.ID, incident.Title),
+				Actor:    models.ActorSystem,
+			})
+		})
+		if err != nil {
+			return err
+		}
+		if linked && ms.Notifications != nil && ticket.UserID.ID != 0 && len(incident.Updates) > 0 {
+			return ms.Notifications.NotifyMajorIncident(incident, &incident.Updates[0], ticket.UserID.ID, ticket.ID)
+		}
+		return nil
+	}
+	return nil
+}
+
+// link links a ticket with a major incident within tx unless it already is.
+func (ms *DefaultMajorIncidentService) link(tx *gorm.DB, incident *models.MajorIncident, ticket *models.Ticket, agentID uint, actor string) error {
+	linked, err := models.NewMajorIncidentDBModel(tx).LinkTicket(&models.MajorIncidentTicket{
+		MajorIncidentID: incident.ID,
+		TicketID:        ticket.ID,
+		UserID:          ticket.UserID.ID,
+		LinkedBy:        agentID,
+	})
+	if err != nil || !linked {
+		return err
+	}
+	return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+		TicketID: ticket.ID,
+		Action:   models.HistoryMajorIncident,
+		Detail:   fmt.Sprintf("linked to major incident #%d: %s", incident.ID, incident.Title),
+		Actor:    actor,
+	})
+}
+
+// matchesMajorIncident reports whether a new ticket belongs to a major
+// incident. It has to match the category or one of the keywords of the
+// incident, and its site when the incident has one; an incident with
+// neither a category nor keywords is only linked to by hand.
+func matchesMajorIncident(incident *models.MajorIncident, ticket *models.Ticket) bool {
+	if ticket.ID == incident.TicketID || (incident.Category == "" && len(incident.Keywords) == 0) {
+		return false
+	}
+	if incident.Site != "" && !strings.EqualFold(incident.Site, ticket.Site) {
+		return false
+	}
+	if incident.Category != "" {
+		if !strings.EqualFold(incident.Category, ticket.Category.CategoryName) {
+			return false
+		}
+		if incident.SubCategory != "" && !strings.EqualFold(incident.SubCategory, ticket.SubCategory.SubCategoryName) {
+			return false
+		}
+	}
+	if len(incident.Keywords) == 0 {
+		return true
+	}
+	text := strings.ToLower(ticket.Subject + " " + ticket.Description)
+	for _, keyword := range incident.Keywords {
+		if strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateMajorIncident checks a major incident and normalises its
+// keywords.
+func validateMajorIncident(incident *models.MajorIncident) error {
+	incident.Title = strings.TrimSpace(incident.Title)
+	if incident.Title == "" {
+		return fmt.Errorf("%w: a title is required", ErrInvalidMajorIncident)
+	}
+	if !containsString(models.MajorIncidentStatuses, incident.Status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidMajorIncident, strings.Join(models.MajorIncidentStatuses, ", "))
+	}
+	incident.Site = strings.TrimSpace(incident.Site)
+	incident.Category = strings.TrimSpace(incident.Category)
+	incident.SubCategory = strings.TrimSpace(incident.SubCategory)
+	if incident.SubCategory != "" && incident.Category == "" {
+		return fmt.Errorf("%w: a sub-category needs a category", ErrInvalidMajorIncident)
+	}
+	keywords := []string{}
+	for _, keyword := range incident.Keywords {
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword != "" && !containsString(keywords, keyword) {
+			keywords = append(keywords, keyword)
+		}
+	}
+	incident.Keywords = keywords
+	return nil
+}
@@ -0,0 +1,825 @@
+// backend/services/notification_service.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/notify"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrUnknownNotificationEvent   = errors.New("unknown notification event")
+	ErrUnknownNotificationChannel = errors.New("unknown notification channel")
+	ErrNoNotificationSender       = errors.New("no notification sender configured")
+	ErrInvalidReceiptToken        = errors.New("invalid delivery receipt token")
+)
+
+// NotificationConfig configures outgoing notifications.
+type NotificationConfig struct {
+	// From is the sender of notification email, e.g.
+	// "Service Desk <support@example.com>".
+	From string
+	// ReplyAddress is the mailbox read by the inbound mail ingestion. Replies
+	// are routed to the ticket through its plus-addressed variant.
+	ReplyAddress string
+	// PortalURL is the base URL of the web portal used for ticket links.
+	PortalURL string
+	// MaxAttempts is the number of delivery attempts before a notification
+	// is marked as failed.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every failed attempt.
+	RetryBackoff time.Duration
+	// BatchSize bounds the notifications delivered per run.
+	BatchSize int
+	// UrgentPriorities are the priority names whose assignment is also sent
+	// to the agent by text message.
+	UrgentPriorities []string
+	// ReceiptToken authenticates delivery receipts posted by messaging gateways.
+	ReceiptToken string
+}
+
+// DefaultNotificationConfig returns the default notification settings.
+func DefaultNotificationConfig() NotificationConfig {
+	return NotificationConfig{
+		MaxAttempts:      8,
+		RetryBackoff:     time.Minute,
+		BatchSize:        50,
+		UrgentPriorities: []string{"P1", "Critical"},
+	}
+}
+
+// maxRetryBackoff caps the delay between two delivery attempts.
+const maxRetryBackoff = 6 * time.Hour
+
+// staleSendingAfter is how long a notification may stay claimed before
+// another worker assumes the sender crashed and retries it.
+const staleSendingAfter = 10 * time.Minute
+
+// NotificationServiceInterface provides methods for notifying requesters and agents.
+type NotificationServiceInterface interface {
+	NotifySurvey(tx *gorm.DB, ticket *models.Ticket, surveyURL string, ratings []SurveyRating) error
+	NotifyMention(mention *models.Mention) error
+	NotifyTicket(event string, ticket *models.Ticket, comment *models.TicketComment) error
+	HandleEvent(ctx context.Context, e events.Event) error
+	DeliverPending(ctx context.Context) (int, error)
+	GetNotificationsByTicket(ticketID uint) (*[]models.Notification, error)
+	RecordReceipt(channel, providerMessageID, status, detail string) (*models.Notification, error)
+	GetReceipts(notificationID uint) (*[]models.NotificationReceipt, error)
+	GetPreferences(recipientType string, recipientID uint) (*[]models.NotificationPreference, error)
+	UpdatePreferences(recipientType string, recipientID uint, preferences []models.NotificationPreference) (*[]models.NotificationPreference, error)
+}
+
+// DefaultNotificationService is the default implementation of NotificationService
+type DefaultNotificationService struct {
+	DB                  *gorm.DB
+	NotificationDBModel *models.NotificationDBModel
+	UserDBModel         *models.UserDBModel
+	AgentDBModel        *models.AgentDBModel
+	TicketDBModel       *models.TicketDBModel
+	WatcherDBModel      *models.WatcherDBModel
+	Templates           *notify.Templates
+	Sender              notify.EmailSender
+	// Channels are the messaging channels by name, tried in the order they
+	// were registered.
+	Channels     map[string]notify.MessagingChannel
+	channelOrder []string
+	// RateLimits throttle deliveries per channel.
+	RateLimits map[string]*notify.RateLimiter
+	Config     NotificationConfig
+}
+
+// NewDefaultNotificationService creates a new DefaultNotificationService.
+func NewDefaultNotificationService(notificationDBModel *models.NotificationDBModel, userDBModel *models.UserDBModel, agentDBModel *models.AgentDBModel, ticketDBModel *models.TicketDBModel, templates *notify.Templates, sender notify.EmailSender, config NotificationConfig) *DefaultNotificationService {
+	if templates == nil {
+		templates = notify.DefaultTemplates()
+	}
+	defaults := DefaultNotificationConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.UrgentPriorities == nil {
+		config.UrgentPriorities = defaults.UrgentPriorities
+	}
+	return &DefaultNotificationService{
+		NotificationDBModel: notificationDBModel,
+		UserDBModel:         userDBModel,
+		AgentDBModel:        agentDBModel,
+		TicketDBModel:       ticketDBModel,
+		WatcherDBModel:      models.NewWatcherDBModel(notificationDBModel.DB),
+		Templates:           templates,
+		Sender:              sender,
+		Channels:            map[string]notify.MessagingChannel{},
+		RateLimits:          map[string]*notify.RateLimiter{},
+		Config:              config,
+	}
+}
+
+// RegisterChannel adds a messaging channel such as SMS or WhatsApp. A
+// recipient gets text messages on the first registered channel they have
+// not opted out of, so registering WhatsApp before SMS makes WhatsApp the
+// default and SMS the fallback. It is meant to be called during start-up.
+func (ns *DefaultNotificationService) RegisterChannel(channel notify.MessagingChannel) {
+	if _, ok := ns.Channels[channel.Name()]; !ok {
+		ns.channelOrder = append(ns.channelOrder, channel.Name())
+	}
+	ns.Channels[channel.Name()] = channel
+}
+
+// SetRateLimit limits the deliveries of a channel, including "email", to
+// limit per period. Notifications over the limit wait in the outbox.
+func (ns *DefaultNotificationService) SetRateLimit(channel string, limit int, period time.Duration) {
+	ns.RateLimits[channel] = notify.NewRateLimiter(limit, period)
+}
+
+// notificationRecipient is a user or agent a notification is addressed to.
+type notificationRecipient struct {
+	Type  string
+	ID    uint
+	Name  string
+	Email string
+	Phone string
+	// Cc are the addresses copied on email to the recipient.
+	Cc []string
+}
+
+// notificationData is what the notification templates are rendered with.
+type notificationData struct {
+	Recipient notificationRecipient
+	ForAgent  bool
+	Ticket    *models.Ticket
+	User      models.Users
+	Agent     models.Agents
+	Comment   *models.TicketComment
+	TicketURL string
+	// SurveyURL and Ratings are only set for survey emails.
+	SurveyURL string
+	Ratings   []SurveyRating
+	// Approval, ApproveURL and RejectURL are only set for approval emails,
+	// and Change for those of change requests.
+	Approval   *models.Approval
+	ApproveURL string
+	RejectURL  string
+	Change     *models.ChangeRequest
+	// MajorIncident and Update are only set for major incident broadcasts.
+	MajorIncident *models.MajorIncident
+	Update        *models.MajorIncidentUpdate
+}
+
+// SurveyRating is one rating link of a survey email.
+type SurveyRating struct {
+	Name  string
+	Emoji string
+	URL   string
+}
+
+// NotifyTicket queues the notifications of a ticket event for delivery:
+//
+//   - ticket.created goes to the requester and, if any, the assigned agent
+//   - ticket.assigned goes to the assigned agent and the requester
+//   - ticket.replied goes to whoever did not write the comment
+//   - ticket.resolved, ticket.status_changed and ticket.reminder go to the
+//     requester
+//   - ticket.mentioned goes to the mentioned agent
+//
+// The user and agent watchers of the ticket also get ticket.assigned,
+// ticket.replied, ticket.resolved and ticket.status_changed, except for
+// their own replies, and its CC addresses are copied on the replies of
+// agents to the requester.
+//
+// Besides email, the assignment of an urgent ticket is sent to the agent and
+// status changes are sent to the requester by text message when a messaging
+// channel is registered and the recipient has a phone number. Recipients
+// who opted out of the event on a channel are skipped.
+func (ns *DefaultNotificationService) NotifyTicket(event string, ticket *models.Ticket, comment *models.TicketComment) error {
+	if !ns.Templates.Has(event) {
+		return ErrUnknownNotificationEvent
+	}
+	user, agent := ns.participants(ticket)
+	requester := notificationRecipient{Type: models.RecipientUser, ID: user.ID, Name: strings.TrimSpace(user.FirstName + " " + user.LastName), Email: user.Email, Phone: user.Phone}
+	assignee := notificationRecipient{Type: models.RecipientAgent, ID: agent.ID, Name: strings.TrimSpace(agent.FirstName + " " + agent.LastName), Email: agent.AgentEmail, Phone: agent.Phone}
+
+	var recipients []notificationRecipient
+	switch event {
+	case models.EventTicketCreated:
+		recipients = []notificationRecipient{requester, assignee}
+	case models.EventTicketAssigned:
+		recipients = []notificationRecipient{assignee, requester}
+	case models.EventTicketReplied:
+		if comment == nil || comment.IsInternal {
+			return nil
+		}
+		if comment.AgentID != 0 {
+			recipients = []notificationRecipient{requester}
+			if author, err := ns.AgentDBModel.GetAgentByID(comment.AgentID); err == nil {
+				agent = *author
+			}
+		} else {
+			recipients = []notificationRecipient{assignee}
+		}
+	case models.EventTicketResolved, models.EventTicketStatusChanged, models.EventTicketReminder:
+		recipients = []notificationRecipient{requester}
+	}
+	if watched[event] {
+		watchers, cc, err := ns.watchers(ticket.ID, comment)
+		if err != nil {
+			return err
+		}
+		if event == models.EventTicketReplied && comment.AgentID != 0 && len(recipients) > 0 {
+			recipients[0].Cc = cc
+		}
+		recipients = uniqueRecipients(append(recipients, watchers...))
+	}
+
+	for _, recipient := range recipients {
+		if recipient.ID == 0 {
+			continue
+		}
+		data := notificationData{
+			Recipient: recipient,
+			ForAgent:  recipient.Type == models.RecipientAgent,
+			Ticket:    ticket,
+			User:      user,
+			Agent:     agent,
+			Comment:   comment,
+			TicketURL: ns.ticketURL(ticket.ID),
+		}
+		rendered, err := ns.Templates.Render(event, data)
+		if err != nil {
+			return err
+		}
+		if recipient.Email != "" && ns.Templates.HasEmail(event) {
+			if err := ns.enqueueEmail(event, recipient, ticket.ID, rendered); err != nil {
+				return err
+			}
+		}
+		if recipient.Phone != "" && ns.Templates.HasShort(event) && ns.wantsText(event, recipient, ticket) {
+			if err := ns.enqueueText(event, recipient, ticket.ID, rendered); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HandleEvent queues the notifications of a domain event. It is registered
+// on the event bus for the ticket events.
+func (ns *DefaultNotificationService) HandleEvent(ctx context.Context, e events.Event) error {
+	switch e.Name {
+	case models.EventTicketCreated, models.EventTicketAssigned, models.EventTicketResolved, models.EventTicketStatusChanged, models.EventTicketReminder:
+		var ticket models.Ticket
+		if err := e.Decode(&ticket); err != nil {
+			return err
+		}
+		return ns.NotifyTicket(e.Name, &ticket, nil)
+	case models.EventTicketMentioned:
+		var mention models.Mention
+		if err := e.Decode(&mention); err != nil {
+			return err
+		}
+		return ns.NotifyMention(&mention)
+	case models.EventTicketReplied:
+		var comment models.TicketComment
+		if err := e.Decode(&comment); err != nil {
+			return err
+		}
+		ticket, err := ns.TicketDBModel.GetTicketByID(comment.TicketID)
+		if err != nil {
+			return err
+		}
+		return ns.NotifyTicket(e.Name, ticket, &comment)
+	}
+	return nil
+}
+
+// NotifySurvey queues the satisfaction survey of a resolved ticket for its
+// requester. It is called by the survey service rather than from an event,
+// because the rating links carry the survey token. The email is queued in
+// tx, the transaction creating the survey.
+func (ns *DefaultNotificationService) NotifySurvey(tx *gorm.DB, ticket *models.Ticket, surveyURL string, ratings []SurveyRating) error {
+	if !ns.Templates.HasEmail(models.EventTicketSurvey) {
+		return ErrUnknownNotificationEvent
+	}
+	ns = ns.inTx(tx)
+	user, agent := ns.participants(ticket)
+	if user.ID == 0 || user.Email == "" {
+		return nil
+	}
+	requester := notificationRecipient{Type: models.RecipientUser, ID: user.ID, Name: strings.TrimSpace(user.FirstName + " " + user.LastName), Email: user.Email, Phone: user.Phone}
+	rendered, err := ns.Templates.Render(models.EventTicketSurvey, notificationData{
+		Recipient: requester,
+		Ticket:    ticket,
+		User:      user,
+		Agent:     agent,
+		TicketURL: ns.ticketURL(ticket.ID),
+		SurveyURL: surveyURL,
+		Ratings:   ratings,
+	})
+	if err != nil {
+		return err
+	}
+	return ns.enqueueEmail(models.EventTicketSurvey, requester, ticket.ID, rendered)
+}
+
+// NotifyApproval queues the email asking an approver to approve or reject
+// a ticket, with the signed links that record the decision.
+func (ns *DefaultNotificationService) NotifyApproval(approval *models.Approval, ticket *models.Ticket, approveURL, rejectURL string) error {
+	if !ns.Templates.HasEmail(models.EventTicketApproval) {
+		return ErrUnknownNotificationEvent
+	}
+	if approval.ApproverEmail == "" {
+		return nil
+	}
+	user, agent := ns.participants(ticket)
+	approver := notificationRecipient{Type: models.RecipientUser, ID: approval.ApproverID, Name: approval.ApproverName, Email: approval.ApproverEmail}
+	rendered, err := ns.Templates.Render(models.EventTicketApproval, notificationData{
+		Recipient:  approver,
+		Ticket:     ticket,
+		User:       user,
+		Agent:      agent,
+		TicketURL:  ns.ticketURL(ticket.ID),
+		Approval:   approval,
+		ApproveURL: approveURL,
+		RejectURL:  rejectURL,
+	})
+	if err != nil {
+		return err
+	}
+	return ns.enqueueEmail(models.EventTicketApproval, approver, ticket.ID, rendered)
+}
+
+// NotifyChangeApproval queues the email asking an approver to approve or
+// reject a change request, with the signed links that record the decision.
+func (ns *DefaultNotificationService) NotifyChangeApproval(approval *models.Approval, change *models.ChangeRequest, approveURL, rejectURL string) error {
+	if !ns.Templates.HasEmail(models.EventChangeApproval) {
+		return ErrUnknownNotificationEvent
+	}
+	if approval.ApproverEmail == "" {
+		return nil
+	}
+	approver := notificationRecipient{Type: models.RecipientUser, ID: approval.ApproverID, Name: approval.ApproverName, Email: approval.ApproverEmail}
+	rendered, err := ns.Templates.Render(models.EventChangeApproval, notificationData{
+		Recipient:  approver,
+		Approval:   approval,
+		ApproveURL: approveURL,
+		RejectURL:  rejectURL,
+		Change:     change,
+	})
+	if err != nil {
+		return err
+	}
+	return ns.enqueueEmail(models.EventChangeApproval, approver, 0, rendered)
+}
+
+// NotifyMajorIncident queues the email broadcasting a status update of a
+// major incident to the requester of one of its tickets.
+func (ns *DefaultNotificationService) NotifyMajorIncident(incident *models.MajorIncident, update *models.MajorIncidentUpdate, userID, ticketID uint) error {
+	if !ns.Templates.HasEmail(models.EventMajorIncidentBroadcast) {
+		return ErrUnknownNotificationEvent
+	}
+	user, err := ns.UserDBModel.GetUserByID(userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if user.Email == "" {
+		return nil
+	}
+	requester := notificationRecipient{Type: models.RecipientUser, ID: user.ID, Name: strings.TrimSpace(user.FirstName + " " + user.LastName), Email: user.Email, Phone: user.Phone}
+	rendered, err := ns.Templates.Render(models.EventMajorIncidentBroadcast, notificationData{
+		Recipient:     requester,
+		User:          *user,
+		TicketURL:     ns.ticketURL(ticketID),
+		MajorIncident: incident,
+		Update:        update,
+	})
+	if err != nil {
+		return err
+	}
+	return ns.enqueueEmail(models.EventMajorIncidentBroadcast, requester, ticketID, rendered)
+}
+
+// watched are the events the watchers of a ticket are notified of.
+var watched = map[string]bool{
+	models.EventTicketAssigned:      true,
+	models.EventTicketReplied:       true,
+	models.EventTicketResolved:      true,
+	models.EventTicketStatusChanged: true,
+}
+
+// watchers returns the user and agent watchers of a ticket, leaving out the
+// author of comment, and its CC addresses.
+func (ns *DefaultNotificationService) watchers(ticketID uint, comment *models.TicketComment) ([]notificationRecipient, []string, error) {
+	if ns.WatcherDBModel == nil {
+		return nil, nil, nil
+	}
+	watchers, err := ns.WatcherDBModel.GetWatchers(ticketID)
+	if err != nil {
+		return nil, nil, err
+	}
+	var recipients []notificationRecipient
+	var cc []string
+	for _, watcher := range *watchers {
+		switch watcher.WatcherType {
+		case models.WatcherCC:
+			cc = append(cc, watcher.Email)
+		case models.RecipientAgent:
+			if comment != nil && comment.AgentID == watcher.RecipientID {
+				continue
+			}
+			if agent, err := ns.AgentDBModel.GetAgentByID(watcher.RecipientID); err == nil {
+				recipients = append(recipients, agentRecipient(*agent))
+			}
+		case models.RecipientUser:
+			if comment != nil && comment.AgentID == 0 && comment.UserID == watcher.RecipientID {
+				continue
+			}
+			if user, err := ns.UserDBModel.GetUserByID(watcher.RecipientID); err == nil {
+				recipients = append(recipients, notificationRecipient{Type: models.RecipientUser, ID: user.ID, Name: strings.TrimSpace(user.FirstName + " " + user.LastName), Email: user.Email, Phone: user.Phone})
+			}
+		}
+	}
+	return recipients, cc, nil
+}
+
+// uniqueRecipients drops the recipients listed before, keeping the first.
+func uniqueRecipients(recipients []notificationRecipient) []notificationRecipient {
+	seen := map[string]bool{}
+	var unique []notificationRecipient
+	for _, recipient := range recipients {
+		key := fmt.Sprintf("%s:%d", recipient.Type, recipient.ID)
+		if recipient.ID != 0 && seen[key] {
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, recipient)
+	}
+	return unique
+}
+
+func splitAddresses(list string) []string {
+	var addresses []string
+	for _, address := range strings.Split(list, ",") {
+		if address = strings.TrimSpace(address); address != "" {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// NotifyMention queues the notification of an agent mentioned in a comment.
+func (ns *DefaultNotificationService) NotifyMention(mention *models.Mention) error {
+	if !ns.Templates.HasEmail(models.EventTicketMentioned) {
+		return ErrUnknownNotificationEvent
+	}
+	if mention.AgentID == 0 || mention.AgentID == mention.AuthorAgentID {
+		return nil
+	}
+	ticket, err := ns.TicketDBModel.GetTicketByID(mention.TicketID)
+	if err != nil {
+		return err
+	}
+	mentioned, err := ns.AgentDBModel.GetAgentByID(mention.AgentID)
+	if err != nil {
+		return err
+	}
+	// Agent is the author of the comment, as for ticket.replied.
+	var author models.Agents
+	if mention.AuthorAgentID != 0 {
+		if a, err := ns.AgentDBModel.GetAgentByID(mention.AuthorAgentID); err == nil {
+			author = *a
+		}
+	}
+	user, _ := ns.participants(ticket)
+	recipient := agentRecipient(*mentioned)
+	if recipient.Email == "" {
+		return nil
+	}
+	rendered, err := ns.Templates.Render(models.EventTicketMentioned, notificationData{
+		Recipient: recipient,
+		ForAgent:  true,
+		Ticket:    ticket,
+		User:      user,
+		Agent:     author,
+		Comment:   &models.TicketComment{ID: mention.CommentID, IsInternal: mention.IsInternal},
+		TicketURL: ns.ticketURL(ticket.ID),
+	})
+	if err != nil {
+		return err
+	}
+	return ns.enqueueEmail(models.EventTicketMentioned, recipient, ticket.ID, rendered)
+}
+
+// wantsText reports whether an event is worth a text message to a recipient.
+func (ns *DefaultNotificationService) wantsText(event string, recipient notificationRecipient, ticket *models.Ticket) bool {
+	if recipient.Type == models.RecipientAgent {
+		return event == models.EventTicketAssigned && ns.isUrgent(ticket)
+	}
+	return event == models.EventTicketStatusChanged || event == models.EventTicketResolved
+}
+
+func (ns *DefaultNotificationService) isUrgent(ticket *models.Ticket) bool {
+	for _, name := range ns.Config.UrgentPriorities {
+		if strings.EqualFold(ticket.Priority.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// participants returns the current requester and assigned agent of a
+// ticket, falling back to the copies embedded in the ticket.
+func (ns *DefaultNotificationService) participants(ticket *models.Ticket) (models.Users, models.Agents) {
+	user, agent := ticket.UserID, ticket.AgentID
+	if user.ID != 0 {
+		if u, err := ns.UserDBModel.GetUserByID(user.ID); err == nil {
+			user = *u
+		}
+	}
+	if agent.ID != 0 {
+		if a, err := ns.AgentDBModel.GetAgentByID(agent.ID); err == nil {
+			agent = *a
+		}
+	}
+	return user, agent
+}
+
+// enqueueEmail stores an email notification in the outbox unless the
+// recipient opted out of it.
+func (ns *DefaultNotificationService) enqueueEmail(event string, recipient notificationRecipient, ticketID uint, rendered *notify.Rendered) error {
+	optedOut, err := ns.NotificationDBModel.IsOptedOut(recipient.Type, recipient.ID, event, models.ChannelEmail)
+	if err != nil || optedOut {
+		return err
+	}
+	thread := notify.ThreadID(ns.Config.From, ticketID)
+	headers, err := json.Marshal(map[string]string{
+		"Message-Id":  notify.NewMessageID(ns.Config.From, fmt.Sprintf("ticket-%d", ticketID)),
+		"In-Reply-To": thread,
+		"References":  thread,
+	})
+	if err != nil {
+		return err
+	}
+	notification := &models.Notification{
+		Event:         event,
+		Channel:       models.ChannelEmail,
+		RecipientType: recipient.Type,
+		RecipientID:   recipient.ID,
+		Recipient:     recipient.Email,
+		Cc:            strings.Join(recipient.Cc, ", "),
+		TicketID:      ticketID,
+		Subject:       rendered.Subject,
+		TextBody:      rendered.Text,
+		HTMLBody:      rendered.HTML,
+		Headers:       string(headers),
+		Status:        models.NotificationStatusPending,
+		NextAttemptAt: time.Now(),
+	}
+	if ns.Config.ReplyAddress != "" && ticketID != 0 {
+		notification.ReplyTo = notify.ReplyAddress(ns.Config.ReplyAddress, ticketID)
+	}
+	return ns.NotificationDBModel.CreateNotification(notification)
+}
+
+// inTx returns a copy of the service reading and queuing within tx.
+func (ns *DefaultNotificationService) inTx(tx *gorm.DB) *DefaultNotificationService {
+	scoped := *ns
+	scoped.DB = tx
+	scoped.NotificationDBModel = models.NewNotificationDBModel(tx)
+	scoped.UserDBModel = models.NewUserDBModel(tx)
+	scoped.AgentDBModel = models.NewAgentDBModel(tx)
+	scoped.TicketDBModel = models.NewTicketDBModel(tx)
+	if ns.WatcherDBModel != nil {
+		scoped.WatcherDBModel = models.NewWatcherDBModel(tx)
+	}
+	return &scoped
+}
+
+// enqueueText stores a text message in the outbox, on the first messaging
+// channel the recipient has not opted out of.
+func (ns *DefaultNotificationService) enqueueText(event string, recipient notificationRecipient, ticketID uint, rendered *notify.Rendered) error {
+	for _, channel := range ns.channelOrder {
+		optedOut, err := ns.NotificationDBModel.IsOptedOut(recipient.Type, recipient.ID, event, channel)
+		if err != nil {
+			return err
+		}
+		if optedOut {
+			continue
+		}
+		return ns.NotificationDBModel.CreateNotification(&models.Notification{
+			Event:         event,
+			Channel:       channel,
+			RecipientType: recipient.Type,
+			RecipientID:   recipient.ID,
+			Recipient:     recipient.Phone,
+			TicketID:      ticketID,
+			TextBody:      rendered.Short,
+			Status:        models.NotificationStatusPending,
+			NextAttemptAt: time.Now(),
+		})
+	}
+	return nil
+}
+
+func (ns *DefaultNotificationService) ticketURL(ticketID uint) string {
+	return fmt.Sprintf("%s/tickets/%d", strings.TrimRight(ns.Config.PortalURL, "/"), ticketID)
+}
+
+// DeliverPending sends the notifications that are due and returns how many
+// were delivered. Failed deliveries are retried with exponential backoff
+// until MaxAttempts is reached, unless the failure is permanent.
+// Notifications over the rate limit of their channel are postponed.
+func (ns *DefaultNotificationService) DeliverPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	due, err := ns.NotificationDBModel.GetDueNotifications(now, now.Add(-staleSendingAfter), ns.Config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	sent := 0
+	for i := range *due {
+		if err := ctx.Err(); err != nil {
+			return sent, err
+		}
+		notification := &(*due)[i]
+		claimed, err := ns.NotificationDBModel.ClaimNotification(notification.ID, now.Add(-staleSendingAfter))
+		if err != nil {
+			return sent, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := ns.deliver(ctx, notification); err != nil {
+			return sent, err
+		}
+		if notification.Status == models.NotificationStatusSent {
+			sent++
+		}
+	}
+	return sent, nil
+}
+
+// deliver sends one claimed notification and records the outcome.
+func (ns *DefaultNotificationService) deliver(ctx context.Context, notification *models.Notification) error {
+	if limiter := ns.RateLimits[notification.Channel]; limiter != nil {
+		if wait := limiter.Reserve(); wait > 0 {
+			notification.Status = models.NotificationStatusPending
+			notification.NextAttemptAt = time.Now().Add(wait)
+			return ns.NotificationDBModel.UpdateNotification(notification)
+		}
+	}
+
+	notification.Attempts++
+	var err error
+	if notification.Channel == models.ChannelEmail {
+		err = ns.sendEmail(ctx, notification)
+	} else if channel, ok := ns.Channels[notification.Channel]; ok {
+		notification.ProviderMessageID, err = channel.SendText(ctx, &notify.TextMessage{
+			To:   notification.Recipient,
+			Body: notification.TextBody,
+		})
+	} else {
+		err = &notify.PermanentError{Err: fmt.Errorf("channel %q is not configured", notification.Channel)}
+	}
+
+	if err != nil {
+		notification.LastError = err.Error()
+		if notify.IsPermanent(err) || notification.Attempts >= ns.Config.MaxAttempts {
+			notification.Status = models.NotificationStatusFailed
+		} else {
+			notification.Status = models.NotificationStatusPending
+			notification.NextAttemptAt = time.Now().Add(backoff(ns.Config.RetryBackoff, notification.Attempts))
+		}
+		log.Printf("notification %d: attempt %d failed: %v", notification.ID, notification.Attempts, err)
+	} else {
+		sentAt := time.Now()
+		notification.Status = models.NotificationStatusSent
+		notification.SentAt = &sentAt
+		notification.LastError = ""
+	}
+	return ns.NotificationDBModel.UpdateNotification(notification)
+}
+
+func (ns *DefaultNotificationService) sendEmail(ctx context.Context, notification *models.Notification) error {
+	if ns.Sender == nil {
+		return ErrNoNotificationSender
+	}
+	email := &notify.Email{
+		From:    ns.Config.From,
+		To:      []string{notification.Recipient},
+		Cc:      splitAddresses(notification.Cc),
+		ReplyTo: notification.ReplyTo,
+		Subject: notification.Subject,
+		Text:    notification.TextBody,
+		HTML:    notification.HTMLBody,
+	}
+	if notification.Headers != "" {
+		if err := json.Unmarshal([]byte(notification.Headers), &email.Headers); err != nil {
+			log.Printf("notification %d: invalid headers: %v", notification.ID, err)
+		}
+	}
+	return ns.Sender.SendEmail(ctx, email)
+}
+
+// Run delivers due notifications every interval until ctx is cancelled.
+func (ns *DefaultNotificationService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := ns.DeliverPending(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("notifications: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetNotificationsByTicket retrieves the notifications sent about a ticket.
+func (ns *DefaultNotificationService) GetNotificationsByTicket(ticketID uint) (*[]models.Notification, error) {
+	return ns.NotificationDBModel.GetNotificationsByTicketID(ticketID)
+}
+
+// RecordReceipt stores a delivery receipt reported by the gateway of a
+// messaging channel and updates the delivery status of the notification.
+func (ns *DefaultNotificationService) RecordReceipt(channel, providerMessageID, status, detail string) (*models.Notification, error) {
+	notification, err := ns.NotificationDBModel.GetNotificationByProviderMessageID(channel, providerMessageID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	status = strings.ToLower(strings.TrimSpace(status))
+	receipt := &models.NotificationReceipt{
+		NotificationID: notification.ID,
+		Status:         status,
+		Detail:         detail,
+		ReceivedAt:     now,
+	}
+	if err := ns.NotificationDBModel.CreateReceipt(receipt); err != nil {
+		return nil, err
+	}
+
+	notification.DeliveryStatus = status
+	switch status {
+	case models.DeliveryStatusDelivered, models.DeliveryStatusRead:
+		if notification.DeliveredAt == nil {
+			notification.DeliveredAt = &now
+		}
+	case models.DeliveryStatusUndelivered, models.DeliveryStatusFailed:
+		notification.LastError = detail
+	}
+	if err := ns.NotificationDBModel.UpdateNotification(notification); err != nil {
+		return nil, err
+	}
+	return notification, nil
+}
+
+// GetReceipts retrieves the delivery receipts of a notification.
+func (ns *DefaultNotificationService) GetReceipts(notificationID uint) (*[]models.NotificationReceipt, error) {
+	return ns.NotificationDBModel.GetReceiptsByNotificationID(notificationID)
+}
+
+// GetPreferences retrieves the notification preferences of a user or agent.
+func (ns *DefaultNotificationService) GetPreferences(recipientType string, recipientID uint) (*[]models.NotificationPreference, error) {
+	return ns.NotificationDBModel.GetPreferences(recipientType, recipientID)
+}
+
+// UpdatePreferences saves the given preferences of a user or agent and
+// returns all of their preferences.
+func (ns *DefaultNotificationService) UpdatePreferences(recipientType string, recipientID uint, preferences []models.NotificationPreference) (*[]models.NotificationPreference, error) {
+	for _, p := range preferences {
+		if !ns.Templates.Has(p.Event) {
+			return nil, ErrUnknownNotificationEvent
+		}
+		if _, ok := ns.Channels[p.Channel]; !ok && p.Channel != models.ChannelEmail {
+			return nil, ErrUnknownNotificationChannel
+		}
+	}
+	for i := range preferences {
+		preferences[i].RecipientType = recipientType
+		preferences[i].RecipientID = recipientID
+		if err := ns.NotificationDBModel.SavePreference(&preferences[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ns.NotificationDBModel.GetPreferences(recipientType, recipientID)
+}
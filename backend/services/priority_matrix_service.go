@@ -0,0 +1,408 @@
+// backend/services/priority_matrix_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidPriorityMatrix = errors.New("invalid priority matrix")
+	ErrInvalidImpactUrgency  = errors.New("invalid impact or urgency")
+	ErrInvalidOverride       = errors.New("invalid priority override")
+	ErrPriorityFromMatrix    = errors.New("the priority follows the priority matrix")
+)
+
+// PriorityMatrix is the configuration of the priority matrix: the impacts,
+// the urgencies and the priority of each combination.
+type PriorityMatrix struct {
+	Impacts   []models.Impact             `json:"impacts"`
+	Urgencies []models.Urgency            `json:"urgencies"`
+	Cells     []models.PriorityMatrixCell `json:"cells"`
+}
+
+// PriorityMatrixServiceInterface provides methods for deriving the priority
+// of tickets from their impact and urgency.
+type PriorityMatrixServiceInterface interface {
+	CreateImpact(impact *models.Impact) error
+	UpdateImpact(impact *models.Impact) (*models.Impact, error)
+	DeleteImpact(id uint) (bool, error)
+	GetImpacts() (*[]models.Impact, error)
+	CreateUrgency(urgency *models.Urgency) error
+	UpdateUrgency(urgency *models.Urgency) (*models.Urgency, error)
+	DeleteUrgency(id uint) (bool, error)
+	GetUrgencies() (*[]models.Urgency, error)
+	GetMatrix() (*PriorityMatrix, error)
+	SetCells(cells []models.PriorityMatrixCell) (*PriorityMatrix, error)
+	Apply(previous, ticket *models.Ticket) error
+	OverridePriority(ticketID, agentID uint, priorityID int, justification string) (*models.Ticket, error)
+}
+
+// DefaultPriorityMatrixService is the default implementation of PriorityMatrixService
+type DefaultPriorityMatrixService struct {
+	DB                    *gorm.DB
+	PriorityMatrixDBModel *models.PriorityMatrixDBModel
+	TicketDBModel         *models.TicketDBModel
+	TicketService         *DefaultTicketingService
+	AgentDBModel          *models.AgentDBModel
+}
+
+// NewDefaultPriorityMatrixService creates a new DefaultPriorityMatrixService.
+func NewDefaultPriorityMatrixService(priorityMatrixDBModel *models.PriorityMatrixDBModel, ticketService *DefaultTicketingService, agentDBModel *models.AgentDBModel) *DefaultPriorityMatrixService {
+	return &DefaultPriorityMatrixService{
+		DB:                    priorityMatrixDBModel.DB,
+		PriorityMatrixDBModel: priorityMatrixDBModel,
+		TicketDBModel:         ticketService.TicketDBModel,
+		TicketService:         ticketService,
+		AgentDBModel:          agentDBModel,
+	}
+}
+
+// CreateImpact creates an impact. Making it the default unsets the
+// previous default.
+func (ms *DefaultPriorityMatrixService) CreateImpact(impact *models.Impact) error {
+	impact.ID = 0
+	if err := validateMatrixLevel(impact.Name, impact.Rank); err != nil {
+		return err
+	}
+	return ms.DB.Transaction(func(tx *gorm.DB) error {
+		matrixDB := models.NewPriorityMatrixDBModel(tx)
+		if err := matrixDB.CreateImpact(impact); err != nil {
+			return err
+		}
+		if impact.IsDefault {
+			return matrixDB.ClearDefaultImpact(impact.ID)
+		}
+		return nil
+	})
+}
+
+// UpdateImpact updates an impact.
+func (ms *DefaultPriorityMatrixService) UpdateImpact(impact *models.Impact) (*models.Impact, error) {
+	existing, err := ms.PriorityMatrixDBModel.GetImpactByID(impact.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMatrixLevel(impact.Name, impact.Rank); err != nil {
+		return nil, err
+	}
+	existing.Name = impact.Name
+	existing.Description = impact.Description
+	existing.Rank = impact.Rank
+	existing.IsDefault = impact.IsDefault
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		matrixDB := models.NewPriorityMatrixDBModel(tx)
+		if err := matrixDB.UpdateImpact(existing); err != nil {
+			return err
+		}
+		if existing.IsDefault {
+			return matrixDB.ClearDefaultImpact(existing.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteImpact deletes an impact and its cells of the matrix. Tickets keep
+// the priority they have.
+func (ms *DefaultPriorityMatrixService) DeleteImpact(id uint) (bool, error) {
+	if _, err := ms.PriorityMatrixDBModel.GetImpactByID(id); err != nil {
+		return false, err
+	}
+	err := ms.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewPriorityMatrixDBModel(tx).DeleteImpact(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetImpacts retrieves the impacts.
+func (ms *DefaultPriorityMatrixService) GetImpacts() (*[]models.Impact, error) {
+	return ms.PriorityMatrixDBModel.GetImpacts()
+}
+
+// CreateUrgency creates an urgency. Making it the default unsets the
+// previous default.
+func (ms *DefaultPriorityMatrixService) CreateUrgency(urgency *models.Urgency) error {
+	urgency.ID = 0
+	if err := validateMatrixLevel(urgency.Name, urgency.Rank); err != nil {
+		return err
+	}
+	return ms.DB.Transaction(func(tx *gorm.DB) error {
+		matrixDB := models.NewPriorityMatrixDBModel(tx)
+		if err := matrixDB.CreateUrgency(urgency); err != nil {
+			return err
+		}
+		if urgency.IsDefault {
+			return matrixDB.ClearDefaultUrgency(urgency.ID)
+		}
+		return nil
+	})
+}
+
+// UpdateUrgency updates an urgency.
+func (ms *DefaultPriorityMatrixService) UpdateUrgency(urgency *models.Urgency) (*models.Urgency, error) {
+	existing, err := ms.PriorityMatrixDBModel.GetUrgencyByID(urgency.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateMatrixLevel(urgency.Name, urgency.Rank); err != nil {
+		return nil, err
+	}
+	existing.Name = urgency.Name
+	existing.Description = urgency.Description
+	existing.Rank = urgency.Rank
+	existing.IsDefault = urgency.IsDefault
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		matrixDB := models.NewPriorityMatrixDBModel(tx)
+		if err := matrixDB.UpdateUrgency(existing); err != nil {
+			return err
+		}
+		if existing.IsDefault {
+			return matrixDB.ClearDefaultUrgency(existing.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteUrgency deletes an urgency and its cells of the matrix. Tickets
+// keep the priority they have.
+func (ms *DefaultPriorityMatrixService) DeleteUrgency(id uint) (bool, error) {
+	if _, err := ms.PriorityMatrixDBModel.GetUrgencyByID(id); err != nil {
+		return false, err
+	}
+	err := ms.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewPriorityMatrixDBModel(tx).DeleteUrgency(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetUrgencies retrieves the urgencies.
+func (ms *DefaultPriorityMatrixService) GetUrgencies() (*[]models.Urgency, error) {
+	return ms.PriorityMatrixDBModel.GetUrgencies()
+}
+
+// GetMatrix retrieves the impacts, urgencies and cells of the matrix.
+func (ms *DefaultPriorityMatrixService) GetMatrix() (*PriorityMatrix, error) {
+	impacts, err := ms.PriorityMatrixDBModel.GetImpacts()
+	if err != nil {
+		return nil, err
+	}
+	urgencies, err := ms.PriorityMatrixDBModel.GetUrgencies()
+	if err != nil {
+		return nil, err
+	}
+	cells, err := ms.PriorityMatrixDBModel.GetCells()
+	if err != nil {
+		return nil, err
+	}
+	return &PriorityMatrix{Impacts: *impacts, Urgencies: *urgencies, Cells: *cells}, nil
+}
+
+// SetCells sets the priority of combinations of impact and urgency. The
+// other cells are left as they are. Either every cell is set or none is.
+func (ms *DefaultPriorityMatrixService) SetCells(cells []models.PriorityMatrixCell) (*PriorityMatrix, error) {
+	if len(cells) == 0 {
+		return nil, fmt.Errorf("%w: no cells", ErrInvalidPriorityMatrix)
+	}
+	err := ms.DB.Transaction(func(tx *gorm.DB) error {
+		matrixDB := models.NewPriorityMatrixDBModel(tx)
+		for i := range cells {
+			cell := &cells[i]
+			if _, err := matrixDB.GetImpactByID(cell.ImpactID); err != nil {
+				return notFoundAs(err, fmt.Errorf("%w: unknown impact %d", ErrInvalidPriorityMatrix, cell.ImpactID))
+			}
+			if _, err := matrixDB.GetUrgencyByID(cell.UrgencyID); err != nil {
+				return notFoundAs(err, fmt.Errorf("%w: unknown urgency %d", ErrInvalidPriorityMatrix, cell.UrgencyID))
+			}
+			if _, err := matrixDB.GetPriorityByID(cell.PriorityID); err != nil {
+				return notFoundAs(err, fmt.Errorf("%w: unknown priority %d", ErrInvalidPriorityMatrix, cell.PriorityID))
+			}
+			if err := matrixDB.SetCell(cell); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ms.GetMatrix()
+}
+
+// Apply sets the priority and SLA of a ticket from the matrix. previous is
+// the ticket before an update and nil on creation; a ticket without impact
+// or urgency keeps those of previous, or gets the defaults. The priority an
+// agent chose instead of the matrix is kept until the impact or urgency
+// changes. Tickets are left as they are while the matrix has no cell for
+// them.
+func (ms *DefaultPriorityMatrixService) Apply(previous, ticket *models.Ticket) error {
+	_, err := ms.apply(ms.PriorityMatrixDBModel, previous, ticket)
+	return err
+}
+
+// apply applies the matrix like Apply, reading it through matrixDB, and
+// tells whether the matrix has a cell for the ticket, which then takes its
+// priority from the matrix or an override.
+func (ms *DefaultPriorityMatrixService) apply(matrixDB *models.PriorityMatrixDBModel, previous, ticket *models.Ticket) (bool, error) {
+	if previous != nil {
+		if ticket.ImpactID == 0 {
+			ticket.ImpactID = previous.ImpactID
+		}
+		if ticket.UrgencyID == 0 {
+			ticket.UrgencyID = previous.UrgencyID
+		}
+	}
+	if ticket.ImpactID == 0 {
+		if impact, err := matrixDB.GetDefaultImpact(); err == nil {
+			ticket.ImpactID = impact.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+	}
+	if ticket.UrgencyID == 0 {
+		if urgency, err := matrixDB.GetDefaultUrgency(); err == nil {
+			ticket.UrgencyID = urgency.ID
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+	}
+	if ticket.ImpactID == 0 || ticket.UrgencyID == 0 {
+		return false, nil
+	}
+	if previous == nil || previous.ImpactID != ticket.ImpactID {
+		if _, err := matrixDB.GetImpactByID(ticket.ImpactID); err != nil {
+			return false, notFoundAs(err, fmt.Errorf("%w: unknown impact %d", ErrInvalidImpactUrgency, ticket.ImpactID))
+		}
+	}
+	if previous == nil || previous.UrgencyID != ticket.UrgencyID {
+		if _, err := matrixDB.GetUrgencyByID(ticket.UrgencyID); err != nil {
+			return false, notFoundAs(err, fmt.Errorf("%w: unknown urgency %d", ErrInvalidImpactUrgency, ticket.UrgencyID))
+		}
+	}
+
+	if previous != nil && previous.PriorityOverriddenBy != 0 &&
+		previous.ImpactID == ticket.ImpactID && previous.UrgencyID == ticket.UrgencyID {
+		setPriority(ticket, &previous.Priority, &previous.SLA)
+		ticket.PriorityOverriddenBy = previous.PriorityOverriddenBy
+		ticket.PriorityJustification = previous.PriorityJustification
+		return true, nil
+	}
+	ticket.PriorityOverriddenBy = 0
+	ticket.PriorityJustification = ""
+
+	cell, err := matrixDB.GetCell(ticket.ImpactID, ticket.UrgencyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, ms.derive(matrixDB, ticket, cell.PriorityID)
+}
+
+// OverridePriority sets the priority of a ticket instead of the matrix, for
+// the reason the agent gives. The SLA follows the priority.
+func (ms *DefaultPriorityMatrixService) OverridePriority(ticketID, agentID uint, priorityID int, justification string) (*models.Ticket, error) {
+	justification = strings.TrimSpace(justification)
+	if justification == "" {
+		return nil, fmt.Errorf("%w: a justification is required", ErrInvalidOverride)
+	}
+	agent, err := ms.AgentDBModel.GetAgentByID(agentID)
+	if err != nil {
+		return nil, notFoundAs(err, fmt.Errorf("%w: only agents override the priority", ErrInvalidOverride))
+	}
+	ticket, err := ms.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	previous := *ticket
+	err = ms.DB.Transaction(func(tx *gorm.DB) error {
+		if err := ms.derive(models.NewPriorityMatrixDBModel(tx), ticket, priorityID); err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown priority %d", ErrInvalidOverride, priorityID))
+		}
+		ticket.PriorityOverriddenBy = agent.ID
+		ticket.PriorityJustification = justification
+		if err := ms.TicketService.updateTicketTx(tx, &previous, ticket, nil, false); err != nil {
+			return err
+		}
+		err := models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryPriorityOverridden,
+			Detail:   fmt.Sprintf("priority set to %s: %s", ticket.Priority.Name, justification),
+			Actor:    agent.AgentEmail,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// derive sets the priority of a ticket and the SLA of that priority, or no
+// SLA when it has none.
+func (ms *DefaultPriorityMatrixService) derive(matrixDB *models.PriorityMatrixDBModel, ticket *models.Ticket, priorityID int) error {
+	priority, err := matrixDB.GetPriorityByID(priorityID)
+	if err != nil {
+		return err
+	}
+	sla, err := matrixDB.GetSlaByPriorityID(priority.PriorityID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		sla, err = &models.Sla{}, nil
+	}
+	if err != nil {
+		return err
+	}
+	setPriority(ticket, priority, sla)
+	return nil
+}
+
+// setPriority copies a priority and SLA into a ticket, leaving out their
+// own record fields.
+func setPriority(ticket *models.Ticket, priority *models.Priority, sla *models.Sla) {
+	ticket.Priority.PriorityID = priority.PriorityID
+	ticket.Priority.Name = priority.Name
+	ticket.Priority.FirstResponse = priority.FirstResponse
+	ticket.Priority.Colour = priority.Colour
+	ticket.SLA.SlaID = sla.SlaID
+	ticket.SLA.SlaName = sla.SlaName
+	ticket.SLA.PriorityID = sla.PriorityID
+	ticket.SLA.SatisfactionID = sla.SatisfactionID
+	ticket.SLA.PolicyID = sla.PolicyID
+}
+
+// notFoundAs replaces gorm.ErrRecordNotFound with replacement.
+func notFoundAs(err, replacement error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return replacement
+	}
+	return err
+}
+
+func validateMatrixLevel(name string, rank int) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidPriorityMatrix)
+	}
+	if rank < 1 {
+		return fmt.Errorf("%w: rank must be 1 or more", ErrInvalidPriorityMatrix)
+	}
+	return nil
+}
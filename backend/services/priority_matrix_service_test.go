@@ -0,0 +1,62 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+func TestUpdatesKeepThePriorityOfTheMatrix(t *testing.T) {
+	db := openTestDB(t, &models.Impact{}, &models.Urgency{}, &models.PriorityMatrixCell{}, &models.Priority{}, &models.Sla{})
+	fixtures := []interface{}{
+		&models.Impact{ID: 1, Name: "High", Rank: 1},
+		&models.Urgency{ID: 1, Name: "High", Rank: 1},
+		&models.Urgency{ID: 2, Name: "Low", Rank: 2},
+		&models.Priority{PriorityID: 1, Name: "Critical"},
+		&models.Sla{SlaID: 1, SlaName: "4 hours", PriorityID: 1},
+		&models.PriorityMatrixCell{ImpactID: 1, UrgencyID: 1, PriorityID: 1},
+	}
+	for _, fixture := range fixtures {
+		if err := db.Create(fixture).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	ticketService := NewDefaultTicketingService(models.NewTicketDBModel(db))
+	ticketService.PriorityMatrix = NewDefaultPriorityMatrixService(models.NewPriorityMatrixDBModel(db), ticketService, models.NewAgentDBModel(db))
+
+	ticket := func(urgencyID uint, priority string, overriddenBy uint) models.Ticket {
+		ticket := models.Ticket{ID: 1, ImpactID: 1, UrgencyID: urgencyID, PriorityOverriddenBy: overriddenBy}
+		ticket.Priority.Name = priority
+		return ticket
+	}
+	tests := []struct {
+		name     string
+		previous models.Ticket
+		priority string
+		rejected bool
+		want     string
+	}{
+		{"another priority", ticket(1, "Critical", 0), "Low", true, ""},
+		{"another priority than the override", ticket(1, "Medium", 5), "Low", true, ""},
+		{"priority unchanged", ticket(1, "Medium", 0), "Medium", false, "Critical"},
+		{"override unchanged", ticket(1, "Medium", 5), "Medium", false, "Medium"},
+		{"no cell for the ticket", ticket(2, "Critical", 0), "Low", false, "Low"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := tt.previous
+			updated.Priority.Name = tt.priority
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return ticketService.UpdateTicketTx(tx, &tt.previous, &updated, nil)
+			})
+			if rejected := errors.Is(err, ErrPriorityFromMatrix); rejected != tt.rejected {
+				t.Fatalf("rejected = %v, want %v: %v", rejected, tt.rejected, err)
+			}
+			if !tt.rejected && updated.Priority.Name != tt.want {
+				t.Fatalf("priority = %q, want %q", updated.Priority.Name, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+// backend/services/problem_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidProblem = errors.New("invalid problem")
+
+// ProblemResolution resolves a problem, and with ResolveIncidents its
+// incidents too.
+type ProblemResolution struct {
+	Resolution       string `json:"resolution"`
+	ResolveIncidents bool   `json:"resolve_incidents"`
+}
+
+// ResolvedProblem is the outcome of resolving a problem: the incidents it
+// resolved, and those it failed to resolve with the reason. Incidents that
+// were already resolved are in neither.
+type ResolvedProblem struct {
+	Problem  *models.Problem `json:"problem"`
+	Resolved []uint          `json:"resolved"`
+	Failed   map[uint]string `json:"failed,omitempty"`
+}
+
+// ProblemServiceInterface provides methods for problem management.
+type ProblemServiceInterface interface {
+	CreateProblem(agentID uint, problem *models.Problem) error
+	UpdateProblem(problem *models.Problem) (*models.Problem, error)
+	DeleteProblem(id uint) (bool, error)
+	GetProblem(id uint) (*models.Problem, error)
+	GetProblems(status string) (*[]models.Problem, error)
+	GetTicketProblems(ticketID uint) (*[]models.Problem, error)
+	LinkIncidents(agentID, problemID uint, ticketIDs []uint) (*models.Problem, error)
+	UnlinkIncident(agentID, problemID, ticketID uint) (*models.Problem, error)
+	Resolve(agentID, problemID uint, resolution *ProblemResolution) (*ResolvedProblem, error)
+}
+
+// DefaultProblemService is the default implementation of ProblemService
+type DefaultProblemService struct {
+	DB             *gorm.DB
+	ProblemDBModel *models.ProblemDBModel
+	AgentDBModel   *models.AgentDBModel
+	TicketService  *DefaultTicketingService
+}
+
+// NewDefaultProblemService creates a new DefaultProblemService.
+func NewDefaultProblemService(problemDBModel *models.ProblemDBModel, agentDBModel *models.AgentDBModel, ticketService *DefaultTicketingService) *DefaultProblemService {
+	return &DefaultProblemService{
+		DB:             problemDBModel.DB,
+		ProblemDBModel: problemDBModel,
+		AgentDBModel:   agentDBModel,
+		TicketService:  ticketService,
+	}
+}
+
+// actor names an agent in ticket history.
+func (ps *DefaultProblemService) actor(agentID uint) string {
+	if agent, err := ps.AgentDBModel.GetAgentByID(agentID); err == nil && agent.AgentEmail != "" {
+		return agent.AgentEmail
+	}
+	return fmt.Sprintf("agent:%d", agentID)
+}
+
+// CreateProblem records a problem raised by an agent.
+func (ps *DefaultProblemService) CreateProblem(agentID uint, problem *models.Problem) error {
+	problem.ID = 0
+	problem.CreatedBy = agentID
+	problem.Resolution, problem.ResolvedAt, problem.ResolvedBy = "", nil, 0
+	problem.KnownErrorAt = nil
+	if problem.Status == "" {
+		problem.Status = models.ProblemStatusOpen
+	}
+	if err := ps.validateProblem(problem, nil); err != nil {
+		return err
+	}
+	if problem.Status == models.ProblemStatusKnownError {
+		now := time.Now()
+		problem.KnownErrorAt = &now
+	}
+	if err := ps.ProblemDBModel.CreateProblem(problem); err != nil {
+		return err
+	}
+	problem.Incidents = []models.ProblemIncident{}
+	return nil
+}
+
+// UpdateProblem updates the analysis and status of a problem. A problem is
+// resolved with Resolve; setting any other status reopens it.
+func (ps *DefaultProblemService) UpdateProblem(problem *models.Problem) (*models.Problem, error) {
+	existing, err := ps.ProblemDBModel.GetProblemByID(problem.ID)
+	if err != nil {
+		return nil, err
+	}
+	if problem.Status == "" {
+		problem.Status = existing.Status
+	}
+	if err := ps.validateProblem(problem, existing); err != nil {
+		return nil, err
+	}
+	problem.Model = existing.Model
+	problem.CreatedAt = existing.CreatedAt
+	problem.CreatedBy = existing.CreatedBy
+	problem.KnownErrorAt = existing.KnownErrorAt
+	problem.Resolution, problem.ResolvedAt, problem.ResolvedBy = existing.Resolution, existing.ResolvedAt, existing.ResolvedBy
+	if problem.Status != models.ProblemStatusResolved && problem.Status != models.ProblemStatusClosed {
+		problem.ResolvedAt, problem.ResolvedBy = nil, 0
+	}
+	if problem.Status == models.ProblemStatusKnownError && problem.KnownErrorAt == nil {
+		now := time.Now()
+		problem.KnownErrorAt = &now
+	}
+	if err := ps.ProblemDBModel.UpdateProblem(problem); err != nil {
+		return nil, err
+	}
+	return ps.ProblemDBModel.GetProblemByID(problem.ID)
+}
+
+// DeleteProblem deletes a problem and its links to incidents.
+func (ps *DefaultProblemService) DeleteProblem(id uint) (bool, error) {
+	if _, err := ps.ProblemDBModel.GetProblemByID(id); err != nil {
+		return false, err
+	}
+	err := ps.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewProblemDBModel(tx).DeleteProblem(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetProblem retrieves a problem with its incidents.
+func (ps *DefaultProblemService) GetProblem(id uint) (*models.Problem, error) {
+	return ps.ProblemDBModel.GetProblemByID(id)
+}
+
+// GetProblems retrieves the problems, optionally only those with a status,
+// such as the known errors.
+func (ps *DefaultProblemService) GetProblems(status string) (*[]models.Problem, error) {
+	if status != "" && !containsString(models.ProblemStatuses, status) {
+		return nil, fmt.Errorf("%w: status must be one of %s", ErrInvalidProblem, strings.Join(models.ProblemStatuses, ", "))
+	}
+	return ps.ProblemDBModel.GetProblems(status)
+}
+
+// GetTicketProblems retrieves the problems a ticket is an incident of.
+func (ps *DefaultProblemService) GetTicketProblems(ticketID uint) (*[]models.Problem, error) {
+	return ps.ProblemDBModel.GetTicketProblems(ticketID)
+}
+
+// LinkIncidents links incident tickets with a problem. Tickets already
+// linked are left as they are.
+func (ps *DefaultProblemService) LinkIncidents(agentID, problemID uint, ticketIDs []uint) (*models.Problem, error) {
+	problem, err := ps.ProblemDBModel.GetProblemByID(problemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ticketIDs) == 0 {
+		return nil, fmt.Errorf("%w: at least one ticket is required", ErrInvalidProblem)
+	}
+	existing, err := ps.ProblemDBModel.GetExistingTicketIDs(ticketIDs)
+	if err != nil {
+		return nil, err
+	}
+	found := map[uint]bool{}
+	for _, id := range existing {
+		found[id] = true
+	}
+	for _, id := range ticketIDs {
+		if !found[id] {
+			return nil, fmt.Errorf("%w: ticket %d not found", ErrInvalidProblem, id)
+		}
+	}
+	actor := ps.actor(agentID)
+	err = ps.DB.Transaction(func(tx *gorm.DB) error {
+		problems := models.NewProblemDBModel(tx)
+		history := models.NewTicketHistoryDBModel(tx)
+		for _, id := range existing {
+			linked, err := problems.LinkIncident(problem.ID, id, agentID)
+			if err != nil {
+				return err
+			}
+			if !linked {
+				continue
+			}
+			err = history.CreateEntry(&models.TicketHistory{
+				TicketID: id,
+				Action:   models.HistoryProblemLinked,
+				Detail:   fmt.Sprintf("Linked with problem #%d: %s", problem.ID, problem.Title),
+				Actor:    actor,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ps.ProblemDBModel.GetProblemByID(problem.ID)
+}
+
+// UnlinkIncident removes an incident ticket from a problem.
+func (ps *DefaultProblemService) UnlinkIncident(agentID, problemID, ticketID uint) (*models.Problem, error) {
+	problem, err := ps.ProblemDBModel.GetProblemByID(problemID)
+	if err != nil {
+		return nil, err
+	}
+	err = ps.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewProblemDBModel(tx).UnlinkIncident(problem.ID, ticketID); err != nil {
+			return err
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticketID,
+			Action:   models.HistoryProblemUnlinked,
+			Detail:   fmt.Sprintf("Unlinked from problem #%d: %s", problem.ID, problem.Title),
+			Actor:    ps.actor(agentID),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ps.ProblemDBModel.GetProblemByID(problem.ID)
+}
+
+// Resolve records the fix of a problem. With ResolveIncidents its linked
+// incidents that are still open are resolved too, each in its own
+// transaction with an internal note giving the resolution, so that one
+// incident failing does not hold back the others. Resolving a resolved
+// problem again with ResolveIncidents retries the incidents left open,
+// keeping its resolution.
+func (ps *DefaultProblemService) Resolve(agentID, problemID uint, resolution *ProblemResolution) (*ResolvedProblem, error) {
+	problem, err := ps.ProblemDBModel.GetProblemByID(problemID)
+	if err != nil {
+		return nil, err
+	}
+	retry := problem.Status == models.ProblemStatusResolved && resolution.ResolveIncidents
+	if !retry {
+		if problem.Status == models.ProblemStatusResolved || problem.Status == models.ProblemStatusClosed {
+			return nil, fmt.Errorf("%w: the problem is already %s", ErrInvalidProblem, problem.Status)
+		}
+		text := strings.TrimSpace(resolution.Resolution)
+		if text == "" {
+			return nil, fmt.Errorf("%w: a resolution is required", ErrInvalidProblem)
+		}
+		now := time.Now()
+		problem.Status = models.ProblemStatusResolved
+		problem.Resolution = text
+		problem.ResolvedAt = &now
+		problem.ResolvedBy = agentID
+		if err := ps.ProblemDBModel.UpdateProblem(problem); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ResolvedProblem{Problem: problem, Resolved: []uint{}}
+	if !resolution.ResolveIncidents {
+		return result, nil
+	}
+	actor := ps.actor(agentID)
+	for _, incident := range problem.Incidents {
+		resolved, err := ps.resolveIncident(problem, incident.TicketID, agentID, actor)
+		if err != nil {
+			if result.Failed == nil {
+				result.Failed = map[uint]string{}
+			}
+			result.Failed[incident.TicketID] = err.Error()
+			continue
+		}
+		if resolved {
+			result.Resolved = append(result.Resolved, incident.TicketID)
+		}
+	}
+	return result, nil
+}
+
+// resolveIncident resolves an incident of a resolved problem unless it is
+// already resolved, and reports whether it did. The incident, its note and
+// its history entry are written in one transaction.
+func (ps *DefaultProblemService) resolveIncident(problem *models.Problem, ticketID, agentID uint, actor string) (bool, error) {
+	resolved := false
+	err := ps.DB.Transaction(func(tx *gorm.DB) error {
+		ticket, err := models.NewTicketDBModel(tx).GetTicketByID(ticketID)
+		if err != nil || ticket.Status.IsResolved() {
+			return err
+		}
+		previous := *ticket
+		ticket.Status = models.Status{StatusName: models.StatusResolved}
+		if err := ps.TicketService.UpdateTicketTx(tx, &previous, ticket, nil); err != nil {
+			return err
+		}
+		err = models.NewCommentDBModel(tx).CreateComment(&models.TicketComment{
+			TicketID:   ticketID,
+			AgentID:    agentID,
+			Body:       fmt.Sprintf("Resolved with problem #%d: %s", problem.ID, problem.Resolution),
+			IsInternal: true,
+			Source:     models.CommentSourceWeb,
+		})
+		if err != nil {
+			return err
+		}
+		err = models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticketID,
+			Action:   models.HistoryProblemResolved,
+			Detail:   fmt.Sprintf("Resolved with problem #%d", problem.ID),
+			Actor:    actor,
+		})
+		resolved = err == nil
+		return err
+	})
+	return resolved, err
+}
+
+// validateProblem checks a new problem, or a change to existing.
+func (ps *DefaultProblemService) validateProblem(problem, existing *models.Problem) error {
+	problem.Title = strings.TrimSpace(problem.Title)
+	if problem.Title == "" {
+		return fmt.Errorf("%w: a title is required", ErrInvalidProblem)
+	}
+	if !containsString(models.ProblemStatuses, problem.Status) {
+		return fmt.Errorf("%w: status must be one of %s", ErrInvalidProblem, strings.Join(models.ProblemStatuses, ", "))
+	}
+	if problem.Status == models.ProblemStatusResolved && (existing == nil || existing.Status != models.ProblemStatusResolved) {
+		return fmt.Errorf("%w: a problem is resolved with its resolution", ErrInvalidProblem)
+	}
+	if problem.Status == models.ProblemStatusKnownError && strings.TrimSpace(problem.Workaround) == "" {
+		return fmt.Errorf("%w: a known error needs a workaround", ErrInvalidProblem)
+	}
+	if problem.OwnerID != 0 {
+		if _, err := ps.AgentDBModel.GetAgentByID(problem.OwnerID); err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown owner %d", ErrInvalidProblem, problem.OwnerID))
+		}
+	}
+	return nil
+}
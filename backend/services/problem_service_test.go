@@ -0,0 +1,47 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestResolveRetriesIncidentsOfResolvedProblem(t *testing.T) {
+	db := openTestDB(t, &models.Problem{}, &models.ProblemIncident{}, &models.Agents{}, &models.TicketComment{}, &models.TicketHistory{})
+	// Incident 7 does not exist, so it cannot be resolved.
+	problem := &models.Problem{Title: "Mail outage", Status: models.ProblemStatusKnownError, Incidents: []models.ProblemIncident{{TicketID: 7}}}
+	if err := db.Create(problem).Error; err != nil {
+		t.Fatal(err)
+	}
+	service := NewDefaultProblemService(models.NewProblemDBModel(db), models.NewAgentDBModel(db), NewDefaultTicketingService(models.NewTicketDBModel(db)))
+
+	result, err := service.Resolve(1, problem.ID, &ProblemResolution{Resolution: "Replaced the relay", ResolveIncidents: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Problem.Status != models.ProblemStatusResolved || len(result.Resolved) != 0 || result.Failed[7] == "" {
+		t.Fatalf("result = %+v", result)
+	}
+	for _, table := range []interface{}{&models.TicketComment{}, &models.TicketHistory{}} {
+		var count int64
+		if err := db.Model(table).Count(&count).Error; err != nil {
+			t.Fatal(err)
+		}
+		if count != 0 {
+			t.Fatalf("%d %T written for an incident that was not resolved", count, table)
+		}
+	}
+
+	result, err = service.Resolve(1, problem.ID, &ProblemResolution{Resolution: "Something else", ResolveIncidents: true})
+	if err != nil {
+		t.Fatalf("retrying the incidents: %v", err)
+	}
+	if result.Problem.Resolution != "Replaced the relay" || result.Failed[7] == "" {
+		t.Fatalf("retry = %+v", result)
+	}
+
+	if _, err := service.Resolve(1, problem.ID, &ProblemResolution{Resolution: "Again"}); !errors.Is(err, ErrInvalidProblem) {
+		t.Fatalf("resolving a resolved problem: err = %v, want ErrInvalidProblem", err)
+	}
+}
@@ -0,0 +1,581 @@
+// backend/services/rule_service.go
+
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/notify"
+	"github.com/shuttlersit/service-desk/backend/rules"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrNotificationsDisabled = errors.New("notifications are not configured")
+	ErrWebhooksDisabled      = errors.New("webhooks are not configured")
+	ErrUnknownRecipient      = errors.New("unknown notification recipient")
+)
+
+// RuleServiceInterface provides methods for managing and running automation rules.
+type RuleServiceInterface interface {
+	CreateRule(rule *models.Rule) error
+	UpdateRule(rule *models.Rule) (*models.Rule, error)
+	GetRuleByID(id uint) (*models.Rule, error)
+	GetAllRules() (*[]models.Rule, error)
+	DeleteRule(id uint) (bool, error)
+	DryRun(rule *models.Rule, ticketID uint, event string) (*RuleEvaluation, error)
+	HandleEvent(ctx context.Context, e events.Event) error
+	GetExecutions(ruleID, ticketID uint, limit int) (*[]models.RuleExecution, error)
+}
+
+// DefaultRuleService is the default implementation of RuleService
+type DefaultRuleService struct {
+	DB            *gorm.DB
+	RuleDBModel   *models.RuleDBModel
+	TicketDBModel *models.TicketDBModel
+	TicketService *DefaultTicketingService
+	AgentDBModel  *models.AgentDBModel
+	Notifications *DefaultNotificationService
+	Webhooks      *DefaultWebhookService
+	Events        EventPublisher
+}
+
+// NewDefaultRuleService creates a new DefaultRuleService.
+func NewDefaultRuleService(ruleDBModel *models.RuleDBModel, ticketService *DefaultTicketingService, agentDBModel *models.AgentDBModel) *DefaultRuleService {
+	return &DefaultRuleService{
+		RuleDBModel:   ruleDBModel,
+		TicketDBModel: ticketService.TicketDBModel,
+		TicketService: ticketService,
+		AgentDBModel:  agentDBModel,
+	}
+}
+
+// CreateRule validates and creates a new rule.
+func (rs *DefaultRuleService) CreateRule(rule *models.Rule) error {
+	if rule.Match == "" {
+		rule.Match = models.RuleMatchAll
+	}
+	if err := rules.Validate(rule); err != nil {
+		return err
+	}
+	return rs.RuleDBModel.CreateRule(rule)
+}
+
+// UpdateRule validates and updates an existing rule.
+func (rs *DefaultRuleService) UpdateRule(rule *models.Rule) (*models.Rule, error) {
+	existing, err := rs.RuleDBModel.GetRuleByID(rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rule.Match == "" {
+		rule.Match = models.RuleMatchAll
+	}
+	if err := rules.Validate(rule); err != nil {
+		return nil, err
+	}
+	rule.CreatedBy = existing.CreatedBy
+	rule.CreatedAt = existing.CreatedAt
+	if err := rs.RuleDBModel.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRuleByID retrieves a rule by its ID.
+func (rs *DefaultRuleService) GetRuleByID(id uint) (*models.Rule, error) {
+	return rs.RuleDBModel.GetRuleByID(id)
+}
+
+// GetAllRules retrieves all rules in the order they run.
+func (rs *DefaultRuleService) GetAllRules() (*[]models.Rule, error) {
+	return rs.RuleDBModel.GetAllRules()
+}
+
+// DeleteRule deletes a rule by ID.
+func (rs *DefaultRuleService) DeleteRule(id uint) (bool, error) {
+	status := false
+	if _, err := rs.RuleDBModel.GetRuleByID(id); err != nil {
+		return status, err
+	}
+	if err := rs.RuleDBModel.DeleteRule(id); err != nil {
+		return status, err
+	}
+	status = true
+	return status, nil
+}
+
+// GetExecutions retrieves the execution log, optionally only that of a rule
+// or of a ticket.
+func (rs *DefaultRuleService) GetExecutions(ruleID, ticketID uint, limit int) (*[]models.RuleExecution, error) {
+	return rs.RuleDBModel.GetExecutions(ruleID, ticketID, limit)
+}
+
+// ActionResult describes what an action did, or would do in a dry run.
+type ActionResult struct {
+	Type    string `json:"type"`
+	Detail  string `json:"detail"`
+	Changed bool   `json:"changed"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RuleEvaluation is the outcome of a rule on a ticket. Skipped is set when
+// the rule matched but was held back by loop protection.
+type RuleEvaluation struct {
+	RuleID     uint                    `json:"rule_id"`
+	RuleName   string                  `json:"rule_name"`
+	Event      string                  `json:"event"`
+	Matched    bool                    `json:"matched"`
+	Skipped    bool                    `json:"skipped,omitempty"`
+	Conditions []rules.ConditionResult `json:"conditions"`
+	Actions    []ActionResult          `json:"actions,omitempty"`
+}
+
+// rulePlan collects the effects of the rules fired by one event, so they
+// can be carried out together once every rule has been evaluated.
+type rulePlan struct {
+	ticketChanged bool
+	tags          []string
+	comments      []models.TicketComment
+	emails        []plannedEmail
+	webhooks      []plannedWebhook
+	fired         []uint
+	// changedBy are the rules whose actions changed the ticket.
+	changedBy []uint
+}
+
+type plannedEmail struct {
+	ruleID    uint
+	recipient notificationRecipient
+	rendered  notify.Rendered
+}
+
+type plannedWebhook struct {
+	ruleID    uint
+	ruleName  string
+	webhookID uint
+}
+
+// DryRun evaluates a rule against an existing ticket as if event had just
+// happened, without changing anything. The rule does not need to be saved.
+// With an empty event the first event of the rule is used.
+func (rs *DefaultRuleService) DryRun(rule *models.Rule, ticketID uint, event string) (*RuleEvaluation, error) {
+	if rule.Match == "" {
+		rule.Match = models.RuleMatchAll
+	}
+	if err := rules.Validate(rule); err != nil {
+		return nil, err
+	}
+	if event == "" {
+		event = rule.Events[0]
+	}
+	ticket, err := rs.loadTicket(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	var plan rulePlan
+	evaluations := rs.run(ticket, event, []models.Rule{*rule}, nil, &plan)
+	return &evaluations[0], nil
+}
+
+func (rs *DefaultRuleService) loadTicket(ticketID uint) (*models.Ticket, error) {
+	ticket, err := rs.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return nil, err
+	}
+	if ticket.Tags, err = rs.TicketDBModel.GetTicketTags(ticketID); err != nil {
+		return nil, err
+	}
+	values, err := models.NewCustomFieldDBModel(rs.TicketDBModel.DB).GetTicketValues([]uint{ticketID})
+	if err != nil {
+		return nil, err
+	}
+	ticket.CustomFields = values[ticketID]
+	return ticket, nil
+}
+
+// run evaluates rules in order against ticket and applies the actions of
+// those that match to ticket and plan. Rules in applied are not run again.
+func (rs *DefaultRuleService) run(ticket *models.Ticket, event string, candidates []models.Rule, applied map[uint]bool, plan *rulePlan) []RuleEvaluation {
+	var evaluations []RuleEvaluation
+	for i := range candidates {
+		rule := &candidates[i]
+		matched, conditions := rules.Evaluate(rule, rules.TicketFacts(ticket, event))
+		evaluation := RuleEvaluation{
+			RuleID:     rule.ID,
+			RuleName:   rule.Name,
+			Event:      event,
+			Matched:    matched,
+			Conditions: conditions,
+		}
+		if matched && applied[rule.ID] {
+			evaluation.Skipped = true
+		} else if matched {
+			evaluation.Actions = rs.apply(ticket, rule, plan)
+			plan.fired = append(plan.fired, rule.ID)
+		}
+		evaluations = append(evaluations, evaluation)
+		if matched && !evaluation.Skipped && rule.StopProcessing {
+			break
+		}
+	}
+	return evaluations
+}
+
+// apply carries out the actions of a rule on ticket, in order. Field
+// changes are made to ticket; everything else is added to plan.
+func (rs *DefaultRuleService) apply(ticket *models.Ticket, rule *models.Rule, plan *rulePlan) []ActionResult {
+	results := make([]ActionResult, 0, len(rule.Actions))
+	for _, action := range rule.Actions {
+		result := ActionResult{Type: action.Type}
+		var err error
+		switch action.Type {
+		case models.ActionSetField:
+			var previous string
+			previous, result.Changed, err = rules.SetField(ticket, action.Field, action.Value)
+			result.Detail = fmt.Sprintf("%s: %q -> %q", action.Field, previous, action.Value)
+		case models.ActionAssign:
+			result.Detail, result.Changed, err = rs.assign(ticket, action)
+		case models.ActionAddTag:
+			result.Detail = "tag " + action.Value
+			result.Changed = !hasTag(ticket, action.Value)
+			if result.Changed {
+				ticket.Tags = append(ticket.Tags, models.Tags{TicketID: ticket.ID, TagName: action.Value})
+				plan.tags = append(plan.tags, action.Value)
+			}
+		case models.ActionAddComment:
+			var body string
+			if body, err = rules.Render(action.Body, ticket); err == nil {
+				plan.comments = append(plan.comments, models.TicketComment{
+					TicketID:   ticket.ID,
+					Body:       body,
+					IsInternal: action.Internal,
+					Source:     models.CommentSourceRule,
+				})
+				result.Detail, result.Changed = body, true
+			}
+		case models.ActionNotify:
+			var email plannedEmail
+			if email, err = rs.planEmail(ticket, rule, action); err == nil {
+				plan.emails = append(plan.emails, email)
+				result.Detail, result.Changed = "email to "+email.recipient.Email, true
+			}
+		case models.ActionCallWebhook:
+			plan.webhooks = append(plan.webhooks, plannedWebhook{ruleID: rule.ID, ruleName: rule.Name, webhookID: action.WebhookID})
+			result.Detail, result.Changed = fmt.Sprintf("webhook %d", action.WebhookID), true
+		}
+		if err != nil {
+			result.Error = err.Error()
+			result.Changed = false
+		}
+		if result.Changed && (action.Type == models.ActionSetField || action.Type == models.ActionAssign) {
+			plan.ticketChanged = true
+			plan.changedBy = append(plan.changedBy, rule.ID)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// assign applies an assign action.
+func (rs *DefaultRuleService) assign(ticket *models.Ticket, action models.RuleAction) (string, bool, error) {
+	return assignTicket(rs.AgentDBModel, ticket, action.AgentID, action.Unit)
+}
+
+// assignTicket assigns a ticket to an agent, or when agentID is zero queues
+// it for a unit, leaving it without an agent. It describes the assignment
+// and reports false when the ticket was already assigned that way.
+func assignTicket(agentDBModel *models.AgentDBModel, ticket *models.Ticket, agentID uint, unit string) (string, bool, error) {
+	if agentID == 0 {
+		detail := "queued for unit " + unit
+		if ticket.AgentID.ID == 0 && strings.EqualFold(ticket.AgentID.Unit.UnitName, unit) {
+			return detail, false, nil
+		}
+		ticket.AgentID = models.Agents{Unit: models.Unit{UnitName: unit}}
+		return detail, true, nil
+	}
+	agent, err := agentDBModel.GetAgentByID(agentID)
+	if err != nil {
+		return "", false, err
+	}
+	detail := fmt.Sprintf("assigned to %s %s", agent.FirstName, agent.LastName)
+	if ticket.AgentID.ID == agent.ID {
+		return detail, false, nil
+	}
+	ticket.AgentID = *agent
+	return detail, true, nil
+}
+
+func hasTag(ticket *models.Ticket, name string) bool {
+	for _, tag := range ticket.Tags {
+		if strings.EqualFold(tag.TagName, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// planEmail renders a notify action for its recipient.
+func (rs *DefaultRuleService) planEmail(ticket *models.Ticket, rule *models.Rule, action models.RuleAction) (plannedEmail, error) {
+	if rs.Notifications == nil {
+		return plannedEmail{}, ErrNotificationsDisabled
+	}
+	recipient, err := rs.recipient(ticket, action.To)
+	if err != nil {
+		return plannedEmail{}, err
+	}
+	subject, err := rules.Render(action.Subject, ticket)
+	if err != nil {
+		return plannedEmail{}, err
+	}
+	body, err := rules.Render(action.Body, ticket)
+	if err != nil {
+		return plannedEmail{}, err
+	}
+	return plannedEmail{ruleID: rule.ID, recipient: recipient, rendered: notify.Rendered{Subject: subject, Text: body}}, nil
+}
+
+// recipient resolves the To of a notify action.
+func (rs *DefaultRuleService) recipient(ticket *models.Ticket, to string) (notificationRecipient, error) {
+	user, agent := rs.Notifications.participants(ticket)
+	var recipient notificationRecipient
+	switch {
+	case to == "requester":
+		recipient = notificationRecipient{Type: models.RecipientUser, ID: user.ID, Name: strings.TrimSpace(user.FirstName + " " + user.LastName), Email: user.Email}
+	case to == "assignee":
+		recipient = agentRecipient(agent)
+	case to == "supervisor":
+		if agent.SupervisorID == 0 {
+			return recipient, fmt.Errorf("%w: the assignee has no supervisor", ErrUnknownRecipient)
+		}
+		supervisor, err := rs.AgentDBModel.GetAgentByID(uint(agent.SupervisorID))
+		if err != nil {
+			return recipient, err
+		}
+		recipient = agentRecipient(*supervisor)
+	case strings.HasPrefix(to, "agent:"):
+		id, err := strconv.ParseUint(strings.TrimPrefix(to, "agent:"), 10, 64)
+		if err != nil {
+			return recipient, fmt.Errorf("%w: %s", ErrUnknownRecipient, to)
+		}
+		a, err := rs.AgentDBModel.GetAgentByID(uint(id))
+		if err != nil {
+			return recipient, err
+		}
+		recipient = agentRecipient(*a)
+	case strings.Contains(to, "@"):
+		recipient = notificationRecipient{Email: to}
+	default:
+		return recipient, fmt.Errorf("%w: %s", ErrUnknownRecipient, to)
+	}
+	if recipient.Email == "" {
+		return recipient, fmt.Errorf("%w: %s has no email address", ErrUnknownRecipient, to)
+	}
+	return recipient, nil
+}
+
+func agentRecipient(agent models.Agents) notificationRecipient {
+	return notificationRecipient{Type: models.RecipientAgent, ID: agent.ID, Name: strings.TrimSpace(agent.FirstName + " " + agent.LastName), Email: agent.AgentEmail}
+}
+
+// ruleEventRef is the part of a ticket or comment event payload the rules
+// need. AppliedRules is only present on events caused by rules.
+type ruleEventRef struct {
+	TicketID     uint   `json:"ticket_id"`
+	AppliedRules []uint `json:"applied_rules"`
+}
+
+// HandleEvent runs the active rules triggered by a ticket event. It is
+// registered on the event bus.
+//
+// Changes made by rules are saved in one transaction and publish events of
+// their own, which may trigger further rules. To stop rules from triggering
+// each other forever, those events carry the rules already applied in the
+// chain, and a rule never runs twice in one chain. A rule also runs at most
+// once per event, even when the bus delivers the event again.
+func (rs *DefaultRuleService) HandleEvent(ctx context.Context, e events.Event) error {
+	if !strings.HasPrefix(e.Name, "ticket.") || e.Name == models.EventTicketDeleted {
+		return nil
+	}
+	var ref ruleEventRef
+	if err := e.Decode(&ref); err != nil {
+		return err
+	}
+	if ref.TicketID == 0 {
+		return nil
+	}
+	active, err := rs.RuleDBModel.GetActiveRules()
+	if err != nil {
+		return err
+	}
+	var candidates []models.Rule
+	for _, rule := range *active {
+		if !rule.Triggers(e.Name) {
+			continue
+		}
+		done, err := rs.RuleDBModel.HasExecution(rule.ID, e.ID)
+		if err != nil {
+			return err
+		}
+		if !done {
+			candidates = append(candidates, rule)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	ticket, err := rs.loadTicket(ref.TicketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	previous := *ticket
+
+	applied := map[uint]bool{}
+	for _, id := range ref.AppliedRules {
+		applied[id] = true
+	}
+	var plan rulePlan
+	evaluations := rs.run(ticket, e.Name, candidates, applied, &plan)
+	chain := append(append([]uint{}, ref.AppliedRules...), plan.fired...)
+
+	executions := map[uint]*models.RuleExecution{}
+	var ordered []*models.RuleExecution
+	for _, evaluation := range evaluations {
+		if !evaluation.Matched {
+			continue
+		}
+		execution := &models.RuleExecution{
+			RuleID:   evaluation.RuleID,
+			RuleName: evaluation.RuleName,
+			TicketID: ticket.ID,
+			Event:    e.Name,
+			EventID:  e.ID,
+			Status:   models.RuleExecutionApplied,
+		}
+		if evaluation.Skipped {
+			execution.Status = models.RuleExecutionSkipped
+			execution.Error = "not run again in the same chain of automated changes"
+		}
+		var failures []string
+		for _, result := range evaluation.Actions {
+			if result.Error != "" {
+				failures = append(failures, fmt.Sprintf("%s: %s", result.Type, result.Error))
+			}
+		}
+		if len(failures) > 0 {
+			execution.Status = models.RuleExecutionFailed
+			execution.Error = strings.Join(failures, "; ")
+		}
+		actions, err := json.Marshal(evaluation.Actions)
+		if err != nil {
+			return err
+		}
+		execution.Actions = string(actions)
+		executions[execution.RuleID] = execution
+		ordered = append(ordered, execution)
+	}
+
+	err = rs.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if plan.ticketChanged {
+			saved := *ticket
+			saved.Tags = nil
+			err := rs.TicketService.UpdateTicketTx(tx, &previous, &saved, func(t *models.Ticket) interface{} {
+				return models.AutomatedTicket{Ticket: *t, AppliedRules: chain}
+			})
+			// A change the ticket does not accept fails the rules that
+			// made it; their other actions still apply.
+			if rejectedTicketChange(err) {
+				for _, ruleID := range plan.changedBy {
+					if execution, ok := executions[ruleID]; ok {
+						execution.Status = models.RuleExecutionFailed
+						execution.Error = strings.TrimPrefix(execution.Error+"; "+err.Error(), "; ")
+					}
+				}
+			} else if err != nil {
+				return err
+			}
+		}
+		for _, tag := range plan.tags {
+			if _, err := models.NewTicketDBModel(tx).AddTag(ticket.ID, tag); err != nil {
+				return err
+			}
+		}
+		for i := range plan.comments {
+			comment := &plan.comments[i]
+			if err := models.NewCommentDBModel(tx).CreateComment(comment); err != nil {
+				return err
+			}
+			if !comment.IsInternal {
+				if err := publish(tx, rs.Events, models.EventTicketReplied, models.AutomatedComment{TicketComment: *comment, AppliedRules: chain}); err != nil {
+					return err
+				}
+			}
+		}
+		for _, execution := range ordered {
+			if err := models.NewRuleDBModel(tx).CreateExecution(execution); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// Emails and webhook calls go to their own outboxes once the changes are
+	// committed. A failure is recorded on the execution rather than retried,
+	// as retrying the event would not run the rule again.
+	failed := func(ruleID uint, err error) {
+		execution, ok := executions[ruleID]
+		if !ok {
+			return
+		}
+		log.Printf("rules: rule %d on ticket %d: %v", ruleID, ticket.ID, err)
+		execution.Status = models.RuleExecutionFailed
+		execution.Error = strings.TrimPrefix(execution.Error+"; "+err.Error(), "; ")
+		if err := rs.RuleDBModel.UpdateExecution(execution); err != nil {
+			log.Printf("rules: %v", err)
+		}
+	}
+	for _, email := range plan.emails {
+		if err := rs.Notifications.enqueueEmail(models.EventRuleNotification, email.recipient, ticket.ID, &email.rendered); err != nil {
+			failed(email.ruleID, err)
+		}
+	}
+	for _, call := range plan.webhooks {
+		var err error
+		if rs.Webhooks == nil {
+			err = ErrWebhooksDisabled
+		} else {
+			payload := ticketEventPayload(ticket)
+			err = rs.Webhooks.EnqueueTo(call.webhookID, models.EventRuleTriggered, ruleWebhookPayload{
+				RuleID:   call.ruleID,
+				RuleName: call.ruleName,
+				Event:    e.Name,
+				Ticket:   &payload,
+			})
+		}
+		if err != nil {
+			failed(call.ruleID, err)
+		}
+	}
+	return nil
+}
+
+// ruleWebhookPayload is the data of the rule.triggered webhook.
+type ruleWebhookPayload struct {
+	RuleID   uint           `json:"rule_id"`
+	RuleName string         `json:"rule_name"`
+	Event    string         `json:"event"`
+	Ticket   *models.Ticket `json:"ticket"`
+}
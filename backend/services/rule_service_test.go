@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/rules"
+)
+
+func priorityRule(id uint, value string, stop bool) models.Rule {
+	rule := models.Rule{
+		Name:           "Set priority " + value,
+		Events:         []string{models.EventTicketUpdated},
+		Match:          models.RuleMatchAll,
+		Conditions:     []models.RuleCondition{{Field: rules.FieldSite, Operator: models.OperatorEquals, Value: "HQ"}},
+		Actions:        []models.RuleAction{{Type: models.ActionSetField, Field: rules.FieldPriority, Value: value}},
+		Active:         true,
+		StopProcessing: stop,
+	}
+	rule.ID = id
+	return rule
+}
+
+func TestRunSkipsRulesAlreadyInTheChain(t *testing.T) {
+	rs := &DefaultRuleService{}
+	ticket := &models.Ticket{Site: "HQ"}
+	ticket.Priority.Name = "P3"
+	candidates := []models.Rule{priorityRule(1, "P2", false), priorityRule(2, "P1", false)}
+
+	var plan rulePlan
+	evaluations := rs.run(ticket, models.EventTicketUpdated, candidates, map[uint]bool{2: true}, &plan)
+	if len(evaluations) != 2 || !evaluations[0].Matched || evaluations[0].Skipped || !evaluations[1].Matched || !evaluations[1].Skipped {
+		t.Fatalf("evaluations = %+v, want rule 1 applied and rule 2 skipped", evaluations)
+	}
+	if len(evaluations[1].Actions) != 0 || ticket.Priority.Name != "P2" {
+		t.Fatalf("skipped rule acted: priority %q", ticket.Priority.Name)
+	}
+	if len(plan.fired) != 1 || plan.fired[0] != 1 || !plan.ticketChanged || len(plan.changedBy) != 1 {
+		t.Fatalf("plan = %+v, want only rule 1 fired and changing the ticket", plan)
+	}
+}
+
+func TestRunStopsAfterStopProcessing(t *testing.T) {
+	rs := &DefaultRuleService{}
+	ticket := &models.Ticket{Site: "HQ"}
+	candidates := []models.Rule{priorityRule(1, "P2", true), priorityRule(2, "P1", false)}
+
+	var plan rulePlan
+	evaluations := rs.run(ticket, models.EventTicketUpdated, candidates, nil, &plan)
+	if len(evaluations) != 1 || ticket.Priority.Name != "P2" {
+		t.Fatalf("%d rules evaluated, priority %q; want only the first", len(evaluations), ticket.Priority.Name)
+	}
+
+	// A stopping rule held back by loop protection does not stop the others.
+	ticket.Priority.Name = ""
+	plan = rulePlan{}
+	evaluations = rs.run(ticket, models.EventTicketUpdated, candidates, map[uint]bool{1: true}, &plan)
+	if len(evaluations) != 2 || ticket.Priority.Name != "P1" {
+		t.Fatalf("%d rules evaluated, priority %q; want both and P1", len(evaluations), ticket.Priority.Name)
+	}
+}
+
+func TestHandleEventRunsARuleOncePerEvent(t *testing.T) {
+	db := openTestDB(t, &models.Rule{}, &models.RuleExecution{})
+	rs := &DefaultRuleService{RuleDBModel: models.NewRuleDBModel(db)}
+	rule := priorityRule(0, "P1", false)
+	if err := rs.RuleDBModel.CreateRule(&rule); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.RuleDBModel.CreateExecution(&models.RuleExecution{RuleID: rule.ID, TicketID: 5, Event: models.EventTicketUpdated, EventID: "evt-1", Status: models.RuleExecutionApplied}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The redelivered event finds the rule already run and loads nothing.
+	e := events.Event{ID: "evt-1", Name: models.EventTicketUpdated, Payload: []byte(`{"ticket_id":5}`)}
+	if err := rs.HandleEvent(context.Background(), e); err != nil {
+		t.Fatalf("HandleEvent = %v", err)
+	}
+	executions, err := rs.GetExecutions(rule.ID, 0, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*executions) != 1 {
+		t.Fatalf("%d executions, want the first one only", len(*executions))
+	}
+
+	// Events the rules do not handle are ignored.
+	for _, name := range []string{models.EventTicketDeleted, "change.created"} {
+		if err := rs.HandleEvent(context.Background(), events.Event{ID: "evt-2", Name: name, Payload: []byte(`{"ticket_id":5}`)}); err != nil {
+			t.Fatalf("HandleEvent(%s) = %v", name, err)
+		}
+	}
+}
+
+func TestDryRunValidatesTheRule(t *testing.T) {
+	rs := &DefaultRuleService{}
+	rule := priorityRule(0, "P1", false)
+	rule.Actions[0].Field = rules.FieldSubject
+	if _, err := rs.DryRun(&rule, 1, ""); !errors.Is(err, rules.ErrInvalidRule) {
+		t.Fatalf("DryRun = %v, want ErrInvalidRule", err)
+	}
+}
@@ -0,0 +1,472 @@
+// backend/services/service_catalog_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	ErrInvalidCatalogItem    = errors.New("invalid catalog item")
+	ErrInvalidServiceRequest = errors.New("invalid service request")
+	ErrInvalidTaskUpdate     = errors.New("invalid task update")
+)
+
+// ServiceRequest is what a requester fills in to request a catalog item.
+// The subject defaults to the name of the item.
+type ServiceRequest struct {
+	Subject      string                   `json:"subject"`
+	Description  string                   `json:"description"`
+	Site         string                   `json:"site"`
+	CustomFields models.CustomFieldValues `json:"custom_fields"`
+}
+
+// RequestForm is a catalog item with the fields of its request form.
+type RequestForm struct {
+	Item   *models.CatalogItem            `json:"item"`
+	Fields []models.CustomFieldDefinition `json:"fields"`
+}
+
+// TaskUpdate changes a fulfilment task. Empty values are left as they are.
+type TaskUpdate struct {
+	Status  string `json:"status"`
+	AgentID uint   `json:"agent_id"`
+	Note    string `json:"note"`
+}
+
+// ServiceCatalogServiceInterface provides methods for the service catalog,
+// its requests and their fulfilment tasks.
+type ServiceCatalogServiceInterface interface {
+	CreateItem(item *models.CatalogItem) error
+	UpdateItem(item *models.CatalogItem) (*models.CatalogItem, error)
+	DeleteItem(id uint) (bool, error)
+	GetItem(id uint) (*models.CatalogItem, error)
+	GetItems(activeOnly bool) (*[]models.CatalogItem, error)
+	GetRequestForm(id uint) (*RequestForm, error)
+	Submit(userID, itemID uint, request *ServiceRequest) (*models.Ticket, error)
+	GetTicketTasks(ticketID uint) (*[]models.FulfilmentTask, error)
+	GetUnitTasks(unitName string, openOnly bool) (*[]models.FulfilmentTask, error)
+	UpdateTask(agentID, taskID uint, update *TaskUpdate) (*models.FulfilmentTask, error)
+}
+
+// DefaultServiceCatalogService is the default implementation of ServiceCatalogService
+type DefaultServiceCatalogService struct {
+	DB                    *gorm.DB
+	ServiceCatalogDBModel *models.ServiceCatalogDBModel
+	UserDBModel           *models.UserDBModel
+	AgentDBModel          *models.AgentDBModel
+	TicketService         *DefaultTicketingService
+}
+
+// NewDefaultServiceCatalogService creates a new DefaultServiceCatalogService.
+func NewDefaultServiceCatalogService(serviceCatalogDBModel *models.ServiceCatalogDBModel, userDBModel *models.UserDBModel, agentDBModel *models.AgentDBModel, ticketService *DefaultTicketingService) *DefaultServiceCatalogService {
+	return &DefaultServiceCatalogService{
+		DB:                    serviceCatalogDBModel.DB,
+		ServiceCatalogDBModel: serviceCatalogDBModel,
+		UserDBModel:           userDBModel,
+		AgentDBModel:          agentDBModel,
+		TicketService:         ticketService,
+	}
+}
+
+// CreateItem creates a catalog item with its task templates.
+func (cs *DefaultServiceCatalogService) CreateItem(item *models.CatalogItem) error {
+	item.ID = 0
+	if err := cs.validateItem(item); err != nil {
+		return err
+	}
+	if err := cs.ServiceCatalogDBModel.CreateItem(item); err != nil {
+		return err
+	}
+	return cs.fillPolicies(item)
+}
+
+// UpdateItem updates a catalog item and replaces its task templates. The
+// tasks of requests already made are not changed.
+func (cs *DefaultServiceCatalogService) UpdateItem(item *models.CatalogItem) (*models.CatalogItem, error) {
+	existing, err := cs.ServiceCatalogDBModel.GetItemByID(item.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.validateItem(item); err != nil {
+		return nil, err
+	}
+	item.Model = existing.Model
+	item.CreatedAt = existing.CreatedAt
+	err = cs.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewServiceCatalogDBModel(tx).UpdateItem(item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cs.GetItem(item.ID)
+}
+
+// DeleteItem deletes a catalog item and its task templates.
+func (cs *DefaultServiceCatalogService) DeleteItem(id uint) (bool, error) {
+	if _, err := cs.ServiceCatalogDBModel.GetItemByID(id); err != nil {
+		return false, err
+	}
+	err := cs.DB.Transaction(func(tx *gorm.DB) error {
+		return models.NewServiceCatalogDBModel(tx).DeleteItem(id)
+	})
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetItem retrieves a catalog item with its tasks and policies.
+func (cs *DefaultServiceCatalogService) GetItem(id uint) (*models.CatalogItem, error) {
+	item, err := cs.ServiceCatalogDBModel.GetItemByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := cs.fillPolicies(item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetItems retrieves the catalog items with their tasks and policies,
+// optionally only the active ones.
+func (cs *DefaultServiceCatalogService) GetItems(activeOnly bool) (*[]models.CatalogItem, error) {
+	items, err := cs.ServiceCatalogDBModel.GetItems(activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *items {
+		if err := cs.fillPolicies(&(*items)[i]); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// GetRequestForm retrieves an active catalog item with the custom fields
+// of its category, which requesters fill in.
+func (cs *DefaultServiceCatalogService) GetRequestForm(id uint) (*RequestForm, error) {
+	item, err := cs.GetItem(id)
+	if err != nil {
+		return nil, err
+	}
+	if !item.Active {
+		return nil, gorm.ErrRecordNotFound
+	}
+	form := &RequestForm{Item: item, Fields: []models.CustomFieldDefinition{}}
+	if cs.TicketService.CustomFields != nil {
+		if form.Fields, err = cs.TicketService.CustomFields.GetForm(item.Category, item.SubCategory); err != nil {
+			return nil, err
+		}
+	}
+	return form, nil
+}
+
+// Submit requests a catalog item for a user. It creates the ticket of the
+// request, due after the delivery time of the item, and its fulfilment
+// tasks from the templates of the item. The tasks of a request awaiting
+// approval are only created once it is approved.
+func (cs *DefaultServiceCatalogService) Submit(userID, itemID uint, request *ServiceRequest) (*models.Ticket, error) {
+	item, err := cs.ServiceCatalogDBModel.GetItemByID(itemID)
+	if err != nil {
+		return nil, err
+	}
+	if !item.Active {
+		return nil, fmt.Errorf("%w: %q cannot be requested", ErrInvalidServiceRequest, item.Name)
+	}
+	user, err := cs.UserDBModel.GetUserByID(userID)
+	if err != nil {
+		return nil, notFoundAs(err, fmt.Errorf("%w: unknown requester", ErrInvalidServiceRequest))
+	}
+
+	now := time.Now()
+	ticket := &models.Ticket{
+		Subject:       strings.TrimSpace(request.Subject),
+		Description:   request.Description,
+		Site:          request.Site,
+		UserID:        *user,
+		CustomFields:  request.CustomFields,
+		CatalogItemID: item.ID,
+	}
+	if ticket.Subject == "" {
+		ticket.Subject = item.Name
+	}
+	ticket.Category.CategoryName = item.Category
+	ticket.SubCategory.SubCategoryName = item.SubCategory
+	if item.DeliveryHours > 0 {
+		ticket.DueAt = now.Add(time.Duration(item.DeliveryHours) * time.Hour)
+	}
+	err = cs.TicketService.CreateTicketWith(ticket, func(tx *gorm.DB, pending bool) error {
+		if pending {
+			return nil
+		}
+		return cs.createTasks(models.NewServiceCatalogDBModel(tx), item, ticket.ID, now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ticket, nil
+}
+
+// HandleEvent creates the fulfilment tasks of a service request once it is
+// approved. It is registered on the event bus for ticket.approved; a
+// redelivered event does not create the tasks twice.
+func (cs *DefaultServiceCatalogService) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Name != models.EventTicketApproved {
+		return nil
+	}
+	var request models.ApprovalRequest
+	if err := e.Decode(&request); err != nil {
+		return err
+	}
+	if request.TicketID == 0 {
+		return nil
+	}
+	ticket, err := cs.TicketService.TicketDBModel.GetTicketByID(request.TicketID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	if ticket.CatalogItemID == 0 {
+		return nil
+	}
+	tasks, err := cs.ServiceCatalogDBModel.GetTicketTasks(ticket.ID)
+	if err != nil || len(*tasks) > 0 {
+		return err
+	}
+	item, err := cs.ServiceCatalogDBModel.GetItemByID(ticket.CatalogItemID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		return err
+	}
+	return cs.createTasks(cs.ServiceCatalogDBModel, item, ticket.ID, time.Now())
+}
+
+// createTasks creates the fulfilment tasks of a request from the templates
+// of its item, due from now.
+func (cs *DefaultServiceCatalogService) createTasks(catalog *models.ServiceCatalogDBModel, item *models.CatalogItem, ticketID uint, now time.Time) error {
+	tasks := make([]models.FulfilmentTask, len(item.Tasks))
+	for i, template := range item.Tasks {
+		tasks[i] = models.FulfilmentTask{
+			TicketID:      ticketID,
+			CatalogItemID: item.ID,
+			Title:         template.Title,
+			Description:   template.Description,
+			UnitID:        template.UnitID,
+			UnitName:      template.UnitName,
+			Status:        models.TaskStatusOpen,
+			Position:      template.Position,
+		}
+		if template.DueHours > 0 {
+			due := now.Add(time.Duration(template.DueHours) * time.Hour)
+			tasks[i].DueAt = &due
+		} else if item.DeliveryHours > 0 {
+			due := now.Add(time.Duration(item.DeliveryHours) * time.Hour)
+			tasks[i].DueAt = &due
+		}
+	}
+	return catalog.CreateTasks(tasks)
+}
+
+// GetTicketTasks retrieves the fulfilment tasks of a ticket.
+func (cs *DefaultServiceCatalogService) GetTicketTasks(ticketID uint) (*[]models.FulfilmentTask, error) {
+	return cs.ServiceCatalogDBModel.GetTicketTasks(ticketID)
+}
+
+// GetUnitTasks retrieves the fulfilment tasks of a unit, optionally only
+// those not finished.
+func (cs *DefaultServiceCatalogService) GetUnitTasks(unitName string, openOnly bool) (*[]models.FulfilmentTask, error) {
+	return cs.ServiceCatalogDBModel.GetUnitTasks(unitName, openOnly)
+}
+
+// UpdateTask changes the status, assignee or note of a fulfilment task on
+// behalf of an agent. A task is assigned to an agent of its unit, and a
+// finished task no longer changes. Once all the tasks of a request are
+// finished, at least one of them done, its ticket is resolved.
+func (cs *DefaultServiceCatalogService) UpdateTask(agentID, taskID uint, update *TaskUpdate) (*models.FulfilmentTask, error) {
+	task, err := cs.ServiceCatalogDBModel.GetTaskByID(taskID)
+	if err != nil {
+		return nil, err
+	}
+	agent, err := cs.AgentDBModel.GetAgentByID(agentID)
+	if err != nil {
+		return nil, notFoundAs(err, fmt.Errorf("%w: unknown agent", ErrInvalidTaskUpdate))
+	}
+	if task.IsFinished() {
+		return nil, fmt.Errorf("%w: the task is already %s", ErrInvalidTaskUpdate, task.Status)
+	}
+	if update.Status != "" && !containsString(models.TaskStatuses, update.Status) {
+		return nil, fmt.Errorf("%w: status must be one of %s", ErrInvalidTaskUpdate, strings.Join(models.TaskStatuses, ", "))
+	}
+	if update.AgentID != 0 {
+		assignee, err := cs.AgentDBModel.GetAgentByID(update.AgentID)
+		if err != nil {
+			return nil, notFoundAs(err, fmt.Errorf("%w: unknown agent %d", ErrInvalidTaskUpdate, update.AgentID))
+		}
+		if task.UnitName != "" && !strings.EqualFold(assignee.Unit.UnitName, task.UnitName) {
+			return nil, fmt.Errorf("%w: %s is not in unit %s", ErrInvalidTaskUpdate, assignee.AgentEmail, task.UnitName)
+		}
+		task.AgentID = assignee.ID
+	}
+	if update.Note != "" {
+		task.Note = update.Note
+	}
+	if update.Status != "" {
+		task.Status = update.Status
+	}
+	if task.IsFinished() {
+		now := time.Now()
+		task.CompletedAt = &now
+		task.CompletedBy = agent.ID
+	}
+	err = cs.DB.Transaction(func(tx *gorm.DB) error {
+		// The tasks of the request stay locked until the task is saved, so
+		// that of two agents finishing its last tasks at once the second
+		// sees the first one finished and resolves the ticket.
+		tasks, err := models.NewServiceCatalogDBModel(tx.Clauses(clause.Locking{Strength: "UPDATE"})).GetTicketTasks(task.TicketID)
+		if err != nil {
+			return err
+		}
+		for i := range *tasks {
+			if (*tasks)[i].ID != task.ID {
+				continue
+			}
+			if (*tasks)[i].IsFinished() {
+				return fmt.Errorf("%w: the task is already %s", ErrInvalidTaskUpdate, (*tasks)[i].Status)
+			}
+			(*tasks)[i] = *task
+		}
+		if err := models.NewServiceCatalogDBModel(tx).UpdateTask(task); err != nil {
+			return err
+		}
+		err = models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: task.TicketID,
+			Action:   models.HistoryTaskUpdated,
+			Detail:   fmt.Sprintf("%s: %s", task.Title, task.Status),
+			Actor:    agent.AgentEmail,
+		})
+		if err != nil || !task.IsFinished() {
+			return err
+		}
+		return cs.resolveWhenFulfilled(tx, task.TicketID, *tasks)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// resolveWhenFulfilled resolves the ticket of a request within tx once all
+// its tasks are finished and one of them is done. A request whose tasks
+// were all cancelled is left to an agent.
+func (cs *DefaultServiceCatalogService) resolveWhenFulfilled(tx *gorm.DB, ticketID uint, tasks []models.FulfilmentTask) error {
+	done := false
+	for _, task := range tasks {
+		if !task.IsFinished() {
+			return nil
+		}
+		done = done || task.Status == models.TaskStatusDone
+	}
+	if !done {
+		return nil
+	}
+	ticket, err := models.NewTicketDBModel(tx).GetTicketByID(ticketID)
+	if err != nil {
+		return err
+	}
+	if ticket.Status.IsResolved() {
+		return nil
+	}
+	previous := *ticket
+	ticket.Status = models.Status{StatusName: models.StatusResolved}
+	err = cs.TicketService.UpdateTicketTx(tx, &previous, ticket, nil)
+	// A ticket that does not accept the resolution is left to an agent.
+	if rejectedTicketChange(err) {
+		return nil
+	}
+	return err
+}
+
+// fillPolicies sets the policies of a catalog item from their IDs.
+func (cs *DefaultServiceCatalogService) fillPolicies(item *models.CatalogItem) error {
+	policies, err := cs.ServiceCatalogDBModel.GetPolicies(item.PolicyIDs)
+	if err != nil {
+		return err
+	}
+	item.Policies = policies
+	return nil
+}
+
+// validateItem checks a catalog item and completes the unit names of its
+// task templates.
+func (cs *DefaultServiceCatalogService) validateItem(item *models.CatalogItem) error {
+	item.Name = strings.TrimSpace(item.Name)
+	if item.Name == "" {
+		return fmt.Errorf("%w: a name is required", ErrInvalidCatalogItem)
+	}
+	if item.DeliveryHours < 0 {
+		return fmt.Errorf("%w: delivery hours cannot be negative", ErrInvalidCatalogItem)
+	}
+	if item.SubCategory != "" && item.Category == "" {
+		return fmt.Errorf("%w: a sub-category needs its category", ErrInvalidCatalogItem)
+	}
+	if cs.TicketService.Lookups != nil && item.Category != "" {
+		probe := &models.Ticket{}
+		probe.Category.CategoryName = item.Category
+		probe.SubCategory.SubCategoryName = item.SubCategory
+		if err := cs.TicketService.Lookups.ValidateTicket(nil, probe); err != nil {
+			if errors.Is(err, ErrInvalidTicketReference) {
+				return fmt.Errorf("%w: %v", ErrInvalidCatalogItem, err)
+			}
+			return err
+		}
+	}
+
+	policyIDs := []int{}
+	seen := map[int]bool{}
+	for _, id := range item.PolicyIDs {
+		if !seen[id] {
+			seen[id] = true
+			policyIDs = append(policyIDs, id)
+		}
+	}
+	item.PolicyIDs = policyIDs
+	policies, err := cs.ServiceCatalogDBModel.GetPolicies(item.PolicyIDs)
+	if err != nil {
+		return err
+	}
+	if len(policies) != len(item.PolicyIDs) {
+		return fmt.Errorf("%w: unknown policy", ErrInvalidCatalogItem)
+	}
+
+	for i := range item.Tasks {
+		template := &item.Tasks[i]
+		template.ID = 0
+		template.Title = strings.TrimSpace(template.Title)
+		if template.Title == "" {
+			return fmt.Errorf("%w: every task needs a title", ErrInvalidCatalogItem)
+		}
+		if template.DueHours < 0 {
+			return fmt.Errorf("%w: due hours cannot be negative", ErrInvalidCatalogItem)
+		}
+		unit, err := cs.AgentDBModel.GetUnitByID(template.UnitID)
+		if err != nil {
+			return notFoundAs(err, fmt.Errorf("%w: unknown unit %d", ErrInvalidCatalogItem, template.UnitID))
+		}
+		template.UnitName = unit.UnitName
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestUpdateTaskLeavesOpenRequestsAlone(t *testing.T) {
+	db := openTestDB(t, &models.Agents{}, &models.FulfilmentTask{}, &models.TicketHistory{})
+	agent := &models.Agents{AgentEmail: "dana@example.com"}
+	if err := db.Create(agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	tasks := []models.FulfilmentTask{
+		{TicketID: 7, Title: "Order laptop", Status: models.TaskStatusOpen, Position: 1},
+		{TicketID: 7, Title: "Set up laptop", Status: models.TaskStatusOpen, Position: 2},
+	}
+	if err := db.Create(&tasks).Error; err != nil {
+		t.Fatal(err)
+	}
+	// The ticket is never loaded while a task of it is open.
+	service := NewDefaultServiceCatalogService(models.NewServiceCatalogDBModel(db), models.NewUserDBModel(db), models.NewAgentDBModel(db), NewDefaultTicketingService(models.NewTicketDBModel(db)))
+
+	task, err := service.UpdateTask(agent.ID, tasks[0].ID, &TaskUpdate{Status: models.TaskStatusDone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task.CompletedBy != agent.ID || task.CompletedAt == nil {
+		t.Fatalf("task = %+v, want completed by agent %d", task, agent.ID)
+	}
+	var history []models.TicketHistory
+	if err := db.Find(&history).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].TicketID != 7 || history[0].Actor != agent.AgentEmail {
+		t.Fatalf("history = %+v", history)
+	}
+
+	if _, err := service.UpdateTask(agent.ID, tasks[0].ID, &TaskUpdate{Note: "again"}); !errors.Is(err, ErrInvalidTaskUpdate) {
+		t.Fatalf("updating a finished task: err = %v, want ErrInvalidTaskUpdate", err)
+	}
+}
@@ -0,0 +1,306 @@
+// backend/services/survey_service.go
+
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrSurveyExpired   = errors.New("survey has expired")
+	ErrInvalidRating   = errors.New("rating must be one of the satisfaction levels")
+	ErrInvalidGrouping = errors.New("group_by must be agent, unit or category")
+)
+
+// SurveyConfig configures the satisfaction surveys.
+type SurveyConfig struct {
+	// SurveyURL is the base URL of the survey links; the token is appended
+	// to it. It defaults to PortalURL/surveys.
+	SurveyURL string
+	// TokenTTL is how long a survey can be answered.
+	TokenTTL time.Duration
+	// SatisfiedRank is the lowest rank that counts as satisfied.
+	SatisfiedRank int
+	// ReopenAtOrBelowRank reopens the ticket when it is rated at or below
+	// this rank. Zero never reopens.
+	ReopenAtOrBelowRank int
+	// ReopenStatus is the status of a reopened ticket.
+	ReopenStatus string
+}
+
+// DefaultSurveyConfig returns the default survey settings.
+func DefaultSurveyConfig() SurveyConfig {
+	return SurveyConfig{
+		TokenTTL:      14 * 24 * time.Hour,
+		SatisfiedRank: 4,
+		ReopenStatus:  "Open",
+	}
+}
+
+// defaultSatisfactionLevels are created when no level is configured.
+var defaultSatisfactionLevels = []models.Satisfaction{
+	{SatisfactionID: 1, Name: "Very dissatisfied", Rank: 1, Emoji: "😠"},
+	{SatisfactionID: 2, Name: "Dissatisfied", Rank: 2, Emoji: "🙁"},
+	{SatisfactionID: 3, Name: "Neutral", Rank: 3, Emoji: "😐"},
+	{SatisfactionID: 4, Name: "Satisfied", Rank: 4, Emoji: "🙂"},
+	{SatisfactionID: 5, Name: "Very satisfied", Rank: 5, Emoji: "😀"},
+}
+
+// SurveyServiceInterface provides methods for customer satisfaction surveys.
+type SurveyServiceInterface interface {
+	GetLevels() (*[]models.Satisfaction, error)
+	GetSurvey(token string) (*models.SatisfactionSurvey, error)
+	Respond(token string, satisfactionID int, feedback string) (*models.SatisfactionSurvey, error)
+	GetSurveysByTicket(ticketID uint) (*[]models.SatisfactionSurvey, error)
+	GetStats(groupBy string, from, to time.Time) (*[]models.SurveyStats, error)
+}
+
+// DefaultSurveyService is the default implementation of SurveyService
+type DefaultSurveyService struct {
+	DB                   *gorm.DB
+	SurveyDBModel        *models.SurveyDBModel
+	TicketDBModel        *models.TicketDBModel
+	TicketService        *DefaultTicketingService
+	TicketHistoryDBModel *models.TicketHistoryDBModel
+	Notifications        *DefaultNotificationService
+	Config               SurveyConfig
+}
+
+// NewDefaultSurveyService creates a new DefaultSurveyService.
+func NewDefaultSurveyService(surveyDBModel *models.SurveyDBModel, ticketService *DefaultTicketingService, ticketHistoryDBModel *models.TicketHistoryDBModel, notifications *DefaultNotificationService, config SurveyConfig) *DefaultSurveyService {
+	defaults := DefaultSurveyConfig()
+	if config.TokenTTL <= 0 {
+		config.TokenTTL = defaults.TokenTTL
+	}
+	if config.SatisfiedRank <= 0 {
+		config.SatisfiedRank = defaults.SatisfiedRank
+	}
+	if config.ReopenStatus == "" {
+		config.ReopenStatus = defaults.ReopenStatus
+	}
+	if config.SurveyURL == "" && notifications != nil {
+		config.SurveyURL = strings.TrimRight(notifications.Config.PortalURL, "/") + "/surveys"
+	}
+	return &DefaultSurveyService{
+		SurveyDBModel:        surveyDBModel,
+		TicketDBModel:        ticketService.TicketDBModel,
+		TicketService:        ticketService,
+		TicketHistoryDBModel: ticketHistoryDBModel,
+		Notifications:        notifications,
+		Config:               config,
+	}
+}
+
+func hashSurveyToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// EnsureLevels creates the default satisfaction levels, from very
+// dissatisfied (1) to very satisfied (5), when none exist.
+func (ss *DefaultSurveyService) EnsureLevels() error {
+	levels, err := ss.SurveyDBModel.GetSatisfactions()
+	if err != nil || len(*levels) > 0 {
+		return err
+	}
+	for _, level := range defaultSatisfactionLevels {
+		level := level
+		if err := ss.SurveyDBModel.CreateSatisfaction(&level); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetLevels retrieves the satisfaction levels a survey can be rated with.
+func (ss *DefaultSurveyService) GetLevels() (*[]models.Satisfaction, error) {
+	return ss.SurveyDBModel.GetSatisfactions()
+}
+
+// HandleEvent sends a survey to the requester of a resolved ticket. It is
+// registered on the event bus for ticket.resolved; a redelivered event does
+// not send a second survey, and tickets closed by a ticket policy get none.
+func (ss *DefaultSurveyService) HandleEvent(ctx context.Context, e events.Event) error {
+	if e.Name != models.EventTicketResolved {
+		return nil
+	}
+	var closed models.AutoClosedTicket
+	if err := e.Decode(&closed); err != nil {
+		return err
+	}
+	ticket := closed.Ticket
+	if ticket.ID == 0 || ticket.UserID.ID == 0 || closed.AutoClosed {
+		return nil
+	}
+	sent, err := ss.SurveyDBModel.HasSurveyForEvent(e.ID)
+	if err != nil || sent {
+		return err
+	}
+	levels, err := ss.SurveyDBModel.GetSatisfactions()
+	if err != nil {
+		return err
+	}
+	if len(*levels) == 0 {
+		return nil
+	}
+
+	token := randomHex(24)
+	survey := &models.SatisfactionSurvey{
+		TicketID:     ticket.ID,
+		EventID:      e.ID,
+		UserID:       ticket.UserID.ID,
+		AgentID:      ticket.AgentID.ID,
+		AgentName:    strings.TrimSpace(ticket.AgentID.FirstName + " " + ticket.AgentID.LastName),
+		UnitName:     ticket.AgentID.Unit.UnitName,
+		CategoryName: ticket.Category.CategoryName,
+		TokenHash:    hashSurveyToken(token),
+		ExpiresAt:    time.Now().Add(ss.Config.TokenTTL),
+	}
+	surveyURL := strings.TrimRight(ss.Config.SurveyURL, "/") + "/" + token
+	ratings := make([]SurveyRating, 0, len(*levels))
+	for i := len(*levels) - 1; i >= 0; i-- {
+		level := (*levels)[i]
+		ratings = append(ratings, SurveyRating{
+			Name:  level.Name,
+			Emoji: level.Emoji,
+			URL:   fmt.Sprintf("%s?rating=%d", surveyURL, level.SatisfactionID),
+		})
+	}
+	// The survey is only kept when its email could be queued, so that a
+	// failed attempt is retried by the bus.
+	return ss.SurveyDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewSurveyDBModel(tx).CreateSurvey(survey); err != nil {
+			return err
+		}
+		if ss.Notifications == nil {
+			return nil
+		}
+		return ss.Notifications.NotifySurvey(tx, &ticket, surveyURL, ratings)
+	})
+}
+
+// GetSurvey retrieves the survey of a token.
+func (ss *DefaultSurveyService) GetSurvey(token string) (*models.SatisfactionSurvey, error) {
+	return ss.SurveyDBModel.GetSurveyByTokenHash(hashSurveyToken(token))
+}
+
+// Respond records the rating and feedback of a survey. The rating can be
+// changed until the survey expires; a zero satisfactionID keeps the current
+// rating and only updates the feedback. A rating at or below
+// ReopenAtOrBelowRank reopens the ticket, once per survey.
+func (ss *DefaultSurveyService) Respond(token string, satisfactionID int, feedback string) (*models.SatisfactionSurvey, error) {
+	survey, err := ss.GetSurvey(token)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(survey.ExpiresAt) {
+		return nil, ErrSurveyExpired
+	}
+	if satisfactionID == 0 && survey.SatisfactionID == 0 {
+		return nil, ErrInvalidRating
+	}
+	if satisfactionID != 0 {
+		level, err := ss.SurveyDBModel.GetSatisfactionByID(satisfactionID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidRating
+		}
+		if err != nil {
+			return nil, err
+		}
+		survey.SatisfactionID = level.SatisfactionID
+		survey.Rating = level.Name
+		survey.Emoji = level.Emoji
+		survey.Rank = level.Rank
+	}
+	if feedback = strings.TrimSpace(feedback); feedback != "" {
+		survey.Feedback = feedback
+	}
+	now := time.Now()
+	survey.RespondedAt = &now
+
+	if survey.Reopened || ss.Config.ReopenAtOrBelowRank <= 0 || survey.Rank > ss.Config.ReopenAtOrBelowRank {
+		if err := ss.SurveyDBModel.UpdateSurvey(survey); err != nil {
+			return nil, err
+		}
+		return survey, nil
+	}
+	if err := ss.reopen(survey); err != nil {
+		return nil, err
+	}
+	return survey, nil
+}
+
+// reopen saves a negative rating and reopens its ticket if it is still
+// resolved or closed.
+func (ss *DefaultSurveyService) reopen(survey *models.SatisfactionSurvey) error {
+	ticket, err := ss.TicketDBModel.GetTicketByID(survey.TicketID)
+	if err != nil {
+		return err
+	}
+	return ss.SurveyDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if ticket.Status.IsResolved() {
+			previous := *ticket
+			ticket.Status.StatusName = ss.Config.ReopenStatus
+			err := ss.TicketService.UpdateTicketTx(tx, &previous, ticket, nil)
+			// A ticket that cannot be reopened, held by its approval, keeps
+			// its status; the rating is recorded all the same.
+			if rejectedTicketChange(err) {
+				log.Printf("survey %d: ticket %d not reopened: %v", survey.ID, ticket.ID, err)
+				return models.NewSurveyDBModel(tx).UpdateSurvey(survey)
+			}
+			if err != nil {
+				return err
+			}
+			err = models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+				TicketID: ticket.ID,
+				Action:   models.HistoryReopened,
+				Detail:   fmt.Sprintf("Reopened after a %q satisfaction rating", survey.Rating),
+				Actor:    models.ActorSystem,
+			})
+			if err != nil {
+				return err
+			}
+			survey.Reopened = true
+		}
+		return models.NewSurveyDBModel(tx).UpdateSurvey(survey)
+	})
+}
+
+// GetSurveysByTicket retrieves the surveys sent for a ticket.
+func (ss *DefaultSurveyService) GetSurveysByTicket(ticketID uint) (*[]models.SatisfactionSurvey, error) {
+	return ss.SurveyDBModel.GetSurveysByTicketID(ticketID)
+}
+
+// GetStats aggregates the surveys answered between from and to per agent,
+// unit or category. CSAT is the percentage of satisfied responses.
+func (ss *DefaultSurveyService) GetStats(groupBy string, from, to time.Time) (*[]models.SurveyStats, error) {
+	switch groupBy {
+	case models.SurveyGroupAgent, models.SurveyGroupUnit, models.SurveyGroupCategory:
+	default:
+		return nil, ErrInvalidGrouping
+	}
+	stats, err := ss.SurveyDBModel.GetSurveyStats(groupBy, ss.Config.SatisfiedRank, from, to)
+	if err != nil {
+		return nil, err
+	}
+	for i := range *stats {
+		s := &(*stats)[i]
+		s.AverageRank = math.Round(s.AverageRank*100) / 100
+		if s.Responses > 0 {
+			s.CSAT = math.Round(float64(s.Satisfied)*1000/float64(s.Responses)) / 10
+		}
+	}
+	return stats, nil
+}
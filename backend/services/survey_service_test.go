@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestPolicyClosuresSendNoSurvey(t *testing.T) {
+	db := openTestDB(t, &models.Satisfaction{}, &models.SatisfactionSurvey{})
+	if err := db.Create(&models.Satisfaction{SatisfactionID: 5, Name: "Very satisfied", Rank: 5}).Error; err != nil {
+		t.Fatal(err)
+	}
+	ss := NewDefaultSurveyService(models.NewSurveyDBModel(db), NewDefaultTicketingService(models.NewTicketDBModel(db)), models.NewTicketHistoryDBModel(db), nil, SurveyConfig{SurveyURL: "https://desk/surveys"})
+
+	ticket := models.Ticket{ID: 42}
+	ticket.UserID.ID = 7
+	ticket.Status.StatusName = models.StatusClosed
+	tests := []struct {
+		name    string
+		eventID string
+		payload interface{}
+		surveys int64
+	}{
+		{"closed by a ticket policy", "evt-1", models.AutoClosedTicket{Ticket: ticket, AutoClosed: true}, 0},
+		{"resolved by an agent", "evt-2", ticket, 1},
+		{"redelivered resolution", "evt-2", ticket, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := events.Event{ID: tt.eventID, Name: models.EventTicketResolved, Payload: payload}
+			if err := ss.HandleEvent(context.Background(), e); err != nil {
+				t.Fatal(err)
+			}
+			var count int64
+			if err := db.Model(&models.SatisfactionSurvey{}).Count(&count).Error; err != nil {
+				t.Fatal(err)
+			}
+			if count != tt.surveys {
+				t.Fatalf("%d surveys, want %d", count, tt.surveys)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+// backend/services/ticket_history_service.go
+
+package services
+
+import (
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+// TicketHistoryServiceInterface provides methods for reading ticket history.
+type TicketHistoryServiceInterface interface {
+	GetHistory(ticketID uint) (*[]models.TicketHistory, error)
+}
+
+// DefaultTicketHistoryService is the default implementation of TicketHistoryService
+type DefaultTicketHistoryService struct {
+	DB                   *gorm.DB
+	TicketHistoryDBModel *models.TicketHistoryDBModel
+}
+
+// NewDefaultTicketHistoryService creates a new DefaultTicketHistoryService.
+func NewDefaultTicketHistoryService(ticketHistoryDBModel *models.TicketHistoryDBModel) *DefaultTicketHistoryService {
+	return &DefaultTicketHistoryService{
+		TicketHistoryDBModel: ticketHistoryDBModel,
+	}
+}
+
+// GetHistory retrieves the history of a ticket, oldest first.
+func (hs *DefaultTicketHistoryService) GetHistory(ticketID uint) (*[]models.TicketHistory, error) {
+	return hs.TicketHistoryDBModel.GetHistoryByTicketID(ticketID)
+}
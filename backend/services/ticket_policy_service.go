@@ -0,0 +1,280 @@
+// backend/services/ticket_policy_service.go
+
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/calendar"
+	"github.com/shuttlersit/service-desk/backend/jobs"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTicketPolicy = errors.New("policy delays must not be negative, and a pending ticket must be reminded before it is closed")
+
+// TicketPolicyConfig configures the time-based ticket automations.
+type TicketPolicyConfig struct {
+	// Schedule is how often the policies run, in jobs.ParseSchedule syntax.
+	Schedule string
+	// BatchSize bounds the tickets loaded at once.
+	BatchSize int
+	// Calendar tells which days count as business days.
+	Calendar calendar.Calendar
+}
+
+// DefaultTicketPolicyConfig returns the default policy settings.
+func DefaultTicketPolicyConfig() TicketPolicyConfig {
+	return TicketPolicyConfig{
+		Schedule:  "@every 15m",
+		BatchSize: 200,
+		Calendar:  calendar.Default(),
+	}
+}
+
+// JobTicketPolicies is the job kind that runs the ticket policies.
+const JobTicketPolicies = "ticket.policies"
+
+// TicketPolicyServiceInterface provides methods for the time-based ticket automations.
+type TicketPolicyServiceInterface interface {
+	CreatePolicy(policy *models.TicketPolicy) error
+	UpdatePolicy(policy *models.TicketPolicy) (*models.TicketPolicy, error)
+	GetPolicyByID(id uint) (*models.TicketPolicy, error)
+	GetAllPolicies() (*[]models.TicketPolicy, error)
+	DeletePolicy(id uint) (bool, error)
+	RunPolicies(ctx context.Context, now time.Time) (int, error)
+}
+
+// DefaultTicketPolicyService is the default implementation of TicketPolicyService
+type DefaultTicketPolicyService struct {
+	DB                   *gorm.DB
+	TicketPolicyDBModel  *models.TicketPolicyDBModel
+	TicketDBModel        *models.TicketDBModel
+	TicketService        *DefaultTicketingService
+	TicketHistoryDBModel *models.TicketHistoryDBModel
+	Events               EventPublisher
+	Config               TicketPolicyConfig
+}
+
+// NewDefaultTicketPolicyService creates a new DefaultTicketPolicyService.
+func NewDefaultTicketPolicyService(ticketPolicyDBModel *models.TicketPolicyDBModel, ticketService *DefaultTicketingService, ticketHistoryDBModel *models.TicketHistoryDBModel, config TicketPolicyConfig) *DefaultTicketPolicyService {
+	defaults := DefaultTicketPolicyConfig()
+	if config.Schedule == "" {
+		config.Schedule = defaults.Schedule
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Calendar.Weekend == nil {
+		config.Calendar.Weekend = defaults.Calendar.Weekend
+	}
+	return &DefaultTicketPolicyService{
+		TicketPolicyDBModel:  ticketPolicyDBModel,
+		TicketDBModel:        ticketService.TicketDBModel,
+		TicketService:        ticketService,
+		TicketHistoryDBModel: ticketHistoryDBModel,
+		Config:               config,
+	}
+}
+
+// RegisterJobs registers the policy run with the job runner and schedules it.
+func (ts *DefaultTicketPolicyService) RegisterJobs(runner *jobs.Runner) error {
+	runner.Register(JobTicketPolicies, func(ctx context.Context, job *models.Job) error {
+		_, err := ts.RunPolicies(ctx, time.Now())
+		return err
+	})
+	return runner.Schedule("ticket-policies", ts.Config.Schedule, JobTicketPolicies, nil)
+}
+
+func validatePolicy(policy *models.TicketPolicy) error {
+	if policy.RemindAfterDays < 0 || policy.CloseAfterDays < 0 || policy.CloseResolvedAfterDays < 0 {
+		return ErrInvalidTicketPolicy
+	}
+	if policy.RemindAfterDays > 0 && policy.CloseAfterDays > 0 && policy.CloseAfterDays <= policy.RemindAfterDays {
+		return ErrInvalidTicketPolicy
+	}
+	if policy.PendingStatus == "" {
+		policy.PendingStatus = models.StatusPending
+	}
+	return nil
+}
+
+// CreatePolicy validates and creates a new policy.
+func (ts *DefaultTicketPolicyService) CreatePolicy(policy *models.TicketPolicy) error {
+	if err := validatePolicy(policy); err != nil {
+		return err
+	}
+	return ts.TicketPolicyDBModel.CreatePolicy(policy)
+}
+
+// UpdatePolicy validates and updates an existing policy.
+func (ts *DefaultTicketPolicyService) UpdatePolicy(policy *models.TicketPolicy) (*models.TicketPolicy, error) {
+	existing, err := ts.TicketPolicyDBModel.GetPolicyByID(policy.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validatePolicy(policy); err != nil {
+		return nil, err
+	}
+	policy.CreatedAt = existing.CreatedAt
+	if err := ts.TicketPolicyDBModel.UpdatePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+// GetPolicyByID retrieves a policy by its ID.
+func (ts *DefaultTicketPolicyService) GetPolicyByID(id uint) (*models.TicketPolicy, error) {
+	return ts.TicketPolicyDBModel.GetPolicyByID(id)
+}
+
+// GetAllPolicies retrieves all policies.
+func (ts *DefaultTicketPolicyService) GetAllPolicies() (*[]models.TicketPolicy, error) {
+	return ts.TicketPolicyDBModel.GetAllPolicies()
+}
+
+// DeletePolicy deletes a policy by ID.
+func (ts *DefaultTicketPolicyService) DeletePolicy(id uint) (bool, error) {
+	status := false
+	if _, err := ts.TicketPolicyDBModel.GetPolicyByID(id); err != nil {
+		return status, err
+	}
+	if err := ts.TicketPolicyDBModel.DeletePolicy(id); err != nil {
+		return status, err
+	}
+	status = true
+	return status, nil
+}
+
+// RunPolicies applies the active policies to the tickets as of now and
+// returns how many tickets were reminded or closed:
+//
+//   - a pending ticket is closed CloseAfterDays business days after its
+//     last update, and before that its requester is reminded once
+//     RemindAfterDays business days after the last update
+//   - a resolved ticket is closed CloseResolvedAfterDays business days
+//     after its last update
+//
+// Every reminder and closure is recorded in the ticket history. Reminding
+// does not update the ticket, so the closing delay still counts from the
+// last real change.
+func (ts *DefaultTicketPolicyService) RunPolicies(ctx context.Context, now time.Time) (int, error) {
+	active, err := ts.TicketPolicyDBModel.GetActivePolicies()
+	if err != nil {
+		return 0, err
+	}
+	if len(*active) == 0 {
+		return 0, nil
+	}
+	byCategory := map[string]*models.TicketPolicy{}
+	statuses := []string{models.StatusResolved}
+	for i := range *active {
+		policy := &(*active)[i]
+		byCategory[strings.ToLower(policy.CategoryName)] = policy
+		statuses = append(statuses, policy.PendingStatus)
+	}
+
+	// No delay is shorter than a day, so younger tickets need no look.
+	updatedBefore := now.AddDate(0, 0, -1)
+	acted := 0
+	var afterID uint
+	for {
+		batch, err := ts.TicketDBModel.GetStaleTickets(statuses, updatedBefore, afterID, ts.Config.BatchSize)
+		if err != nil {
+			return acted, err
+		}
+		for i := range *batch {
+			if err := ctx.Err(); err != nil {
+				return acted, err
+			}
+			ticket := &(*batch)[i]
+			afterID = ticket.ID
+			policy, ok := byCategory[strings.ToLower(ticket.Category.CategoryName)]
+			if !ok {
+				if policy, ok = byCategory[""]; !ok {
+					continue
+				}
+			}
+			done, err := ts.apply(policy, ticket, now)
+			if rejectedTicketChange(err) {
+				log.Printf("ticket policies: ticket %d: %v", ticket.ID, err)
+				continue
+			}
+			if err != nil {
+				return acted, err
+			}
+			if done {
+				acted++
+			}
+		}
+		if len(*batch) < ts.Config.BatchSize {
+			return acted, nil
+		}
+	}
+}
+
+// apply runs one policy on one ticket and reports whether it did anything.
+func (ts *DefaultTicketPolicyService) apply(policy *models.TicketPolicy, ticket *models.Ticket, now time.Time) (bool, error) {
+	cal := ts.Config.Calendar
+	due := func(days int) bool {
+		return days > 0 && !now.Before(cal.AddBusinessDays(ticket.UpdatedAt, days))
+	}
+	status := ticket.Status.StatusName
+	switch {
+	case strings.EqualFold(status, models.StatusResolved):
+		if due(policy.CloseResolvedAfterDays) {
+			return true, ts.close(ticket, fmt.Sprintf("resolved for %d business days", policy.CloseResolvedAfterDays))
+		}
+	case strings.EqualFold(status, policy.PendingStatus):
+		if due(policy.CloseAfterDays) {
+			return true, ts.close(ticket, fmt.Sprintf("no reply from the requester for %d business days", policy.CloseAfterDays))
+		}
+		if due(policy.RemindAfterDays) {
+			reminded, err := ts.TicketHistoryDBModel.HasEntrySince(ticket.ID, models.HistoryReminderSent, ticket.UpdatedAt)
+			if err != nil || reminded {
+				return false, err
+			}
+			return true, ts.remind(ticket, policy.RemindAfterDays)
+		}
+	}
+	return false, nil
+}
+
+func (ts *DefaultTicketPolicyService) close(ticket *models.Ticket, reason string) error {
+	previous := *ticket
+	ticket.Status.StatusName = models.StatusClosed
+	return ts.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		err := ts.TicketService.UpdateTicketTx(tx, &previous, ticket, func(t *models.Ticket) interface{} {
+			return models.AutoClosedTicket{Ticket: *t, AutoClosed: true}
+		})
+		if err != nil {
+			return err
+		}
+		return models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryAutoClosed,
+			Detail:   "Closed automatically: " + reason,
+			Actor:    models.ActorSystem,
+		})
+	})
+}
+
+func (ts *DefaultTicketPolicyService) remind(ticket *models.Ticket, days int) error {
+	return ts.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		err := models.NewTicketHistoryDBModel(tx).CreateEntry(&models.TicketHistory{
+			TicketID: ticket.ID,
+			Action:   models.HistoryReminderSent,
+			Detail:   fmt.Sprintf("Requester reminded after %d business days pending", days),
+			Actor:    models.ActorSystem,
+		})
+		if err != nil {
+			return err
+		}
+		return publish(tx, ts.Events, models.EventTicketReminder, ticketEventPayload(ticket))
+	})
+}
@@ -3,6 +3,10 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"log"
+
 	"github.com/shuttlersit/service-desk/backend/models"
 	"gorm.io/gorm"
 )
@@ -18,8 +22,24 @@ type TicketingServiceInterface interface {
 
 // DefaultAdvertisementService is the default implementation of AdvertisementService
 type DefaultTicketingService struct {
-	DB            *gorm.DB
-	TicketDBModel *models.TicketDBModel
+	DB                *gorm.DB
+	TicketDBModel     *models.TicketDBModel
+	AttachmentService *DefaultAttachmentService
+	Events            EventPublisher
+	// TimeEntryDBModel, when set, fills in the time spent on tickets.
+	TimeEntryDBModel *models.TimeEntryDBModel
+	// PriorityMatrix, when set, derives the priority and SLA of tickets
+	// from their impact and urgency.
+	PriorityMatrix *DefaultPriorityMatrixService
+	// CustomFields, when set, validates and stores the custom fields of
+	// tickets.
+	CustomFields *DefaultCustomFieldService
+	// Lookups, when set, checks the category, sub-category, priority and
+	// status of tickets.
+	Lookups *DefaultLookupService
+	// Approvals, when set, starts the approval chain of new tickets and
+	// holds their status until the approval completes.
+	Approvals *DefaultApprovalService
 	// Add any dependencies or data needed for the service
 }
 
@@ -36,15 +56,85 @@ func (ps *DefaultTicketingService) GetAllTickets() (*[]models.Ticket, error) {
 	if err != nil {
 		return nil, err
 	}
+	list := make([]*models.Ticket, len(*tickets))
+	for i := range *tickets {
+		list[i] = &(*tickets)[i]
+	}
+	if err := ps.fillTimeSpent(list...); err != nil {
+		return nil, err
+	}
+	if ps.CustomFields != nil {
+		if err := ps.CustomFields.Fill(list...); err != nil {
+			return nil, err
+		}
+	}
 	return tickets, nil
 }
 
-// CreateTicket creates a new Ticket.
+// CreateTicket creates a new Ticket with its custom fields, once its
+// category and other lookups are checked. With a priority matrix, the
+// priority given is replaced by the one of its impact and urgency. The
+// approval chain that applies to the ticket, if any, is started and its
+// first approvers are emailed.
 func (ps *DefaultTicketingService) CreateTicket(ticket *models.Ticket) error {
-	err := ps.TicketDBModel.CreateTicket(ticket)
+	return ps.CreateTicketWith(ticket, nil)
+}
+
+// CreateTicketWith creates a ticket like CreateTicket, calling then within
+// the transaction creating it so that what belongs to the ticket is created
+// along with it. pending tells whether the ticket awaits approval.
+func (ps *DefaultTicketingService) CreateTicketWith(ticket *models.Ticket, then func(tx *gorm.DB, pending bool) error) error {
+	if ps.Lookups != nil {
+		if err := ps.Lookups.ValidateTicket(nil, ticket); err != nil {
+			return err
+		}
+	}
+	// Only OverridePriority overrides the priority.
+	ticket.PriorityOverriddenBy = 0
+	ticket.PriorityJustification = ""
+	if ps.PriorityMatrix != nil {
+		if err := ps.PriorityMatrix.Apply(nil, ticket); err != nil {
+			return err
+		}
+	}
+	var fields []models.TicketFieldValue
+	if ps.CustomFields != nil {
+		var err error
+		if fields, err = ps.CustomFields.Validate(ticket); err != nil {
+			return err
+		}
+	}
+	var approval *models.ApprovalRequest
+	err := ps.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewTicketDBModel(tx).CreateTicket(ticket); err != nil {
+			return err
+		}
+		if ps.CustomFields != nil {
+			if err := models.NewCustomFieldDBModel(tx).SetTicketValues(ticket.ID, fields); err != nil {
+				return err
+			}
+		}
+		if ps.Approvals != nil {
+			var err error
+			if approval, err = ps.Approvals.Start(tx, ticket); err != nil {
+				return err
+			}
+		}
+		if then != nil {
+			if err := then(tx, approval != nil); err != nil {
+				return err
+			}
+		}
+		return publish(tx, ps.Events, models.EventTicketCreated, ticketEventPayload(ticket))
+	})
 	if err != nil {
 		return err
 	}
+	if approval != nil {
+		if err := ps.Approvals.Notify(approval, ticket); err != nil {
+			log.Printf("ticket %d: failed to notify approvers: %v", ticket.ID, err)
+		}
+	}
 	return nil
 }
 
@@ -54,22 +144,195 @@ func (ps *DefaultTicketingService) GetTicketByID(id uint) (*models.Ticket, error
 	if err != nil {
 		return nil, err
 	}
+	if ps.AttachmentService != nil {
+		ps.AttachmentService.SignURLs(ticket.MediaAttachments)
+	}
+	if err := ps.fillTimeSpent(ticket); err != nil {
+		return nil, err
+	}
+	if ps.CustomFields != nil {
+		if err := ps.CustomFields.Fill(ticket); err != nil {
+			return nil, err
+		}
+	}
 	return ticket, nil
 }
 
-// UpdateTicket updates an existing Ticket.
+// fillTimeSpent sets the time spent on tickets.
+func (ps *DefaultTicketingService) fillTimeSpent(tickets ...*models.Ticket) error {
+	if ps.TimeEntryDBModel == nil || len(tickets) == 0 {
+		return nil
+	}
+	ids := make([]uint, len(tickets))
+	for i, ticket := range tickets {
+		ids[i] = ticket.ID
+	}
+	totals, err := ps.TimeEntryDBModel.GetTicketTotals(ids)
+	if err != nil {
+		return err
+	}
+	for _, ticket := range tickets {
+		spent := totals[ticket.ID]
+		ticket.TimeSpent = &spent
+	}
+	return nil
+}
+
+// UpdateTicket updates an existing Ticket. Besides ticket.updated it
+// publishes ticket.assigned when the agent changes, and ticket.resolved or
+// ticket.status_changed when the status changes. With a priority matrix,
+// the priority follows the impact and urgency unless an agent overrode it;
+// the override itself is only changed by OverridePriority. The custom
+// fields are replaced when given and kept otherwise. The status of a ticket
+// awaiting approval does not change.
 func (ps *DefaultTicketingService) UpdateTicket(ticket *models.Ticket) (*models.Ticket, error) {
-	err := ps.TicketDBModel.UpdateTicket(ticket)
+	previous, err := ps.TicketDBModel.GetTicketByID(ticket.ID)
+	if err != nil {
+		return nil, err
+	}
+	// Only OverridePriority overrides the priority.
+	ticket.PriorityOverriddenBy = previous.PriorityOverriddenBy
+	ticket.PriorityJustification = previous.PriorityJustification
+	err = ps.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		return ps.UpdateTicketTx(tx, previous, ticket, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
 	return ticket, nil
 }
 
+// UpdateTicketTx saves a change to a ticket within tx, from previous, its
+// state before the change. It is the one path of every ticket update: the
+// lookups are checked, the status of a ticket awaiting approval does not
+// change, the custom fields are replaced when given and checked against the
+// new form when the category changes, and the events of the change are
+// published. Their payload is the ticket as ticketEventPayload
+// makes it, or what payload makes of that when set. The tags of the ticket
+// are saved with it as given. With a priority matrix, the priority of a
+// ticket the matrix has a cell for follows it or an override, and a change
+// to another priority is refused; OverridePriority changes it instead.
+func (ps *DefaultTicketingService) UpdateTicketTx(tx *gorm.DB, previous, ticket *models.Ticket, payload func(*models.Ticket) interface{}) error {
+	return ps.updateTicketTx(tx, previous, ticket, payload, true)
+}
+
+// updateTicketTx is UpdateTicketTx, applying the priority matrix only when
+// matrix is set.
+func (ps *DefaultTicketingService) updateTicketTx(tx *gorm.DB, previous, ticket *models.Ticket, payload func(*models.Ticket) interface{}, matrix bool) error {
+	if ps.PriorityMatrix != nil && matrix {
+		requested := ticket.Priority.Name
+		governed, err := ps.PriorityMatrix.apply(models.NewPriorityMatrixDBModel(tx), previous, ticket)
+		if err != nil {
+			return err
+		}
+		if governed && previous != nil && requested != "" && requested != previous.Priority.Name && requested != ticket.Priority.Name {
+			return fmt.Errorf("%w: override it to set %q", ErrPriorityFromMatrix, requested)
+		}
+	}
+	if ps.Lookups != nil {
+		if err := ps.Lookups.ValidateTicket(previous, ticket); err != nil {
+			return err
+		}
+	}
+	if ps.Approvals != nil && previous != nil && ticket.Status.StatusName != "" && ticket.Status.StatusName != previous.Status.StatusName {
+		pending, err := models.NewApprovalDBModel(tx).HasPendingRequest(ticket.ID)
+		if err != nil {
+			return err
+		}
+		if pending {
+			return fmt.Errorf("%w: its status cannot change", ErrApprovalPending)
+		}
+	}
+	var fields []models.TicketFieldValue
+	setFields := ps.CustomFields != nil && (ticket.CustomFields != nil || categoryChanged(previous, ticket))
+	if setFields {
+		// A ticket moved to another category keeps the stored values its
+		// new form has a field for, and must satisfy that form.
+		if ticket.CustomFields == nil {
+			stored, err := models.NewCustomFieldDBModel(tx).GetTicketValues([]uint{ticket.ID})
+			if err != nil {
+				return err
+			}
+			if ticket.CustomFields, err = ps.CustomFields.KeepFormFields(ticket, stored[ticket.ID]); err != nil {
+				return err
+			}
+		}
+		var err error
+		if fields, err = ps.CustomFields.Validate(ticket); err != nil {
+			return err
+		}
+	}
+	if err := models.NewTicketDBModel(tx).UpdateTicket(ticket); err != nil {
+		return err
+	}
+	if setFields {
+		if err := models.NewCustomFieldDBModel(tx).SetTicketValues(ticket.ID, fields); err != nil {
+			return err
+		}
+	}
+	sanitized := ticketEventPayload(ticket)
+	var body interface{} = sanitized
+	if payload != nil {
+		body = payload(&sanitized)
+	}
+	for _, event := range ticketUpdateEvents(previous, ticket) {
+		if err := publish(tx, ps.Events, event, body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ticketEventPayload is the ticket as published to other systems, without
+// the login credentials of its requester and agent.
+func ticketEventPayload(ticket *models.Ticket) models.Ticket {
+	payload := *ticket
+	payload.UserID = userEventPayload(&ticket.UserID)
+	payload.AgentID.Credentials = models.AgentLoginCredentials{}
+	return payload
+}
+
+// categoryChanged tells whether an update moves a ticket to another
+// category or sub-category.
+func categoryChanged(previous, ticket *models.Ticket) bool {
+	return previous != nil && (previous.Category.CategoryName != ticket.Category.CategoryName ||
+		previous.SubCategory.SubCategoryName != ticket.SubCategory.SubCategoryName)
+}
+
+// rejectedTicketChange tells whether UpdateTicketTx refused a change to a
+// ticket, leaving it as it was.
+func rejectedTicketChange(err error) bool {
+	return errors.Is(err, ErrInvalidTicketReference) || errors.Is(err, ErrApprovalPending) || errors.Is(err, ErrInvalidCustomFieldValue) ||
+		errors.Is(err, ErrPriorityFromMatrix)
+}
+
+// ticketUpdateEvents lists the events of a change from previous to ticket.
+// previous may be nil when it could not be loaded.
+func ticketUpdateEvents(previous, ticket *models.Ticket) []string {
+	events := []string{models.EventTicketUpdated}
+	if previous == nil {
+		return events
+	}
+	if ticket.AgentID.ID != 0 && ticket.AgentID.ID != previous.AgentID.ID {
+		events = append(events, models.EventTicketAssigned)
+	}
+	if ticket.Status.IsResolved() && !previous.Status.IsResolved() {
+		events = append(events, models.EventTicketResolved)
+	} else if ticket.Status.StatusName != "" && ticket.Status.StatusName != previous.Status.StatusName {
+		events = append(events, models.EventTicketStatusChanged)
+	}
+	return events
+}
+
 // DeleteTicket deletes an ticket by ID.
 func (ps *DefaultTicketingService) DeleteTicket(ticketID uint) (bool, error) {
 	status := false
-	err := ps.TicketDBModel.DeleteTicket(ticketID)
+	err := ps.TicketDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewTicketDBModel(tx).DeleteTicket(ticketID); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventTicketDeleted, models.TicketRef{TicketID: ticketID})
+	})
 	if err != nil {
 		return status, err
 	}
@@ -0,0 +1,43 @@
+package services
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestTicketPayloadsCarryNoCredentials(t *testing.T) {
+	ticket := &models.Ticket{ID: 1, Subject: "Printer jammed"}
+	ticket.UserID.ID = 2
+	ticket.UserID.Credentials = models.UsersLoginCredentials{Username: "requester-login", Password: "user-secret-hash"}
+	ticket.AgentID.ID = 3
+	ticket.AgentID.Credentials = models.AgentLoginCredentials{Username: "agent-login", Password: "agent-secret-hash"}
+
+	payload := ticketEventPayload(ticket)
+	tests := []struct {
+		name    string
+		payload interface{}
+	}{
+		{"ticket event", payload},
+		{"automated ticket event", models.AutomatedTicket{Ticket: payload, AppliedRules: []uint{1}}},
+		{"rule webhook", ruleWebhookPayload{RuleID: 1, Event: models.EventTicketUpdated, Ticket: &payload}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.payload)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, secret := range []string{"user-secret-hash", "agent-secret-hash", "requester-login", "agent-login"} {
+				if strings.Contains(string(body), secret) {
+					t.Fatalf("payload carries %s: %s", secret, body)
+				}
+			}
+		})
+	}
+	if ticket.UserID.Credentials.Password == "" || ticket.AgentID.Credentials.Password == "" {
+		t.Fatal("the ticket itself lost its credentials")
+	}
+}
@@ -0,0 +1,263 @@
+// backend/services/time_tracking_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTimeEntry = errors.New("invalid time entry")
+	ErrTimerRunning     = errors.New("a timer is already running on this ticket")
+	ErrNoTimerRunning   = errors.New("no timer is running on this ticket")
+	ErrInvalidTimeGroup = errors.New("group_by must be agent, unit, department or category")
+)
+
+// MaxEntryMinutes bounds the duration of a time entry logged directly.
+const MaxEntryMinutes = 24 * 60
+
+// TimerOptions are the details of the work measured by a timer, given
+// when it is started or stopped. Billable is left unchanged when nil.
+type TimerOptions struct {
+	ActivityType string `json:"activity_type"`
+	Billable     *bool  `json:"billable"`
+	Note         string `json:"note"`
+}
+
+// TimeTrackingServiceInterface provides methods for logging work on tickets.
+type TimeTrackingServiceInterface interface {
+	LogTime(agentID, ticketID uint, entry *models.TimeEntry) error
+	UpdateEntry(ticketID uint, entry *models.TimeEntry) (*models.TimeEntry, error)
+	DeleteEntry(ticketID, id uint) (bool, error)
+	GetEntries(ticketID uint) (*[]models.TimeEntry, error)
+	StartTimer(agentID, ticketID uint, options TimerOptions) (*models.TimeEntry, error)
+	StopTimer(agentID, ticketID uint, options TimerOptions) (*models.TimeEntry, error)
+	GetReport(groupBy string, from, to time.Time) (*[]models.TimeReport, error)
+}
+
+// DefaultTimeTrackingService is the default implementation of TimeTrackingService
+type DefaultTimeTrackingService struct {
+	DB               *gorm.DB
+	TimeEntryDBModel *models.TimeEntryDBModel
+	TicketDBModel    *models.TicketDBModel
+	AgentDBModel     *models.AgentDBModel
+}
+
+// NewDefaultTimeTrackingService creates a new DefaultTimeTrackingService.
+func NewDefaultTimeTrackingService(timeEntryDBModel *models.TimeEntryDBModel, ticketDBModel *models.TicketDBModel, agentDBModel *models.AgentDBModel) *DefaultTimeTrackingService {
+	return &DefaultTimeTrackingService{
+		DB:               timeEntryDBModel.DB,
+		TimeEntryDBModel: timeEntryDBModel,
+		TicketDBModel:    ticketDBModel,
+		AgentDBModel:     agentDBModel,
+	}
+}
+
+// LogTime records work an agent did on a ticket. The work is dated now
+// unless WorkedAt is set.
+func (ts *DefaultTimeTrackingService) LogTime(agentID, ticketID uint, entry *models.TimeEntry) error {
+	entry.ID = 0
+	entry.Running = false
+	entry.StoppedAt = nil
+	if entry.WorkedAt.IsZero() {
+		entry.WorkedAt = time.Now()
+	}
+	if err := validateTimeEntry(entry); err != nil {
+		return err
+	}
+	if err := ts.attribute(agentID, ticketID, entry); err != nil {
+		return err
+	}
+	return ts.TimeEntryDBModel.CreateTimeEntry(entry)
+}
+
+// UpdateEntry changes the activity type, duration, billable flag, note and
+// date of a stopped time entry. Who logged it and for what is kept.
+func (ts *DefaultTimeTrackingService) UpdateEntry(ticketID uint, entry *models.TimeEntry) (*models.TimeEntry, error) {
+	existing, err := ts.entry(ticketID, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing.Running {
+		return nil, fmt.Errorf("%w: stop the timer first", ErrInvalidTimeEntry)
+	}
+	existing.ActivityType = entry.ActivityType
+	existing.Minutes = entry.Minutes
+	existing.Billable = entry.Billable
+	existing.Note = entry.Note
+	if !entry.WorkedAt.IsZero() {
+		existing.WorkedAt = entry.WorkedAt
+	}
+	if err := validateTimeEntry(existing); err != nil {
+		return nil, err
+	}
+	if err := ts.TimeEntryDBModel.UpdateTimeEntry(existing); err != nil {
+		return nil, err
+	}
+	return existing, nil
+}
+
+// DeleteEntry deletes a time entry of a ticket, running timers included.
+func (ts *DefaultTimeTrackingService) DeleteEntry(ticketID, id uint) (bool, error) {
+	if _, err := ts.entry(ticketID, id); err != nil {
+		return false, err
+	}
+	if err := ts.TimeEntryDBModel.DeleteTimeEntry(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetEntries retrieves the time entries of a ticket.
+func (ts *DefaultTimeTrackingService) GetEntries(ticketID uint) (*[]models.TimeEntry, error) {
+	if _, err := ts.TicketDBModel.GetTicketByID(ticketID); err != nil {
+		return nil, err
+	}
+	return ts.TimeEntryDBModel.GetTimeEntriesByTicket(ticketID)
+}
+
+// StartTimer starts measuring the work of an agent on a ticket. An agent
+// runs at most one timer per ticket.
+func (ts *DefaultTimeTrackingService) StartTimer(agentID, ticketID uint, options TimerOptions) (*models.TimeEntry, error) {
+	if _, err := ts.TimeEntryDBModel.GetRunningTimer(agentID, ticketID); err == nil {
+		return nil, ErrTimerRunning
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	entry := &models.TimeEntry{
+		ActivityType: options.ActivityType,
+		Note:         options.Note,
+		WorkedAt:     time.Now(),
+		Running:      true,
+	}
+	if options.Billable != nil {
+		entry.Billable = *options.Billable
+	}
+	if entry.ActivityType == "" {
+		entry.ActivityType = models.ActivityOther
+	}
+	if !isActivityType(entry.ActivityType) {
+		return nil, fmt.Errorf("%w: unknown activity_type %q", ErrInvalidTimeEntry, entry.ActivityType)
+	}
+	if err := ts.attribute(agentID, ticketID, entry); err != nil {
+		return nil, err
+	}
+	if err := ts.TimeEntryDBModel.CreateTimeEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// StopTimer stops the running timer of an agent on a ticket and records the
+// elapsed time, rounded up to the minute. The options, when set, replace
+// those given when the timer was started.
+func (ts *DefaultTimeTrackingService) StopTimer(agentID, ticketID uint, options TimerOptions) (*models.TimeEntry, error) {
+	entry, err := ts.TimeEntryDBModel.GetRunningTimer(agentID, ticketID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNoTimerRunning
+	}
+	if err != nil {
+		return nil, err
+	}
+	if options.ActivityType != "" {
+		if !isActivityType(options.ActivityType) {
+			return nil, fmt.Errorf("%w: unknown activity_type %q", ErrInvalidTimeEntry, options.ActivityType)
+		}
+		entry.ActivityType = options.ActivityType
+	}
+	if options.Billable != nil {
+		entry.Billable = *options.Billable
+	}
+	if options.Note != "" {
+		entry.Note = options.Note
+	}
+	now := time.Now()
+	entry.Minutes = int(math.Ceil(now.Sub(entry.WorkedAt).Minutes()))
+	if entry.Minutes < 1 {
+		entry.Minutes = 1
+	}
+	entry.Running = false
+	entry.StoppedAt = &now
+	if err := ts.TimeEntryDBModel.UpdateTimeEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// GetReport sums the time worked between from and to by agent, unit,
+// department or category.
+func (ts *DefaultTimeTrackingService) GetReport(groupBy string, from, to time.Time) (*[]models.TimeReport, error) {
+	switch groupBy {
+	case models.TimeGroupAgent, models.TimeGroupUnit, models.TimeGroupDepartment, models.TimeGroupCategory:
+	default:
+		return nil, ErrInvalidTimeGroup
+	}
+	return ts.TimeEntryDBModel.GetTimeReport(groupBy, from, to)
+}
+
+// attribute sets the agent and ticket of an entry, with the names the
+// reports group by.
+func (ts *DefaultTimeTrackingService) attribute(agentID, ticketID uint, entry *models.TimeEntry) error {
+	ticket, err := ts.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return err
+	}
+	agent, err := ts.AgentDBModel.GetAgentByID(agentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("%w: only agents log time", ErrInvalidTimeEntry)
+	}
+	if err != nil {
+		return err
+	}
+	entry.TicketID = ticket.ID
+	entry.AgentID = agent.ID
+	entry.AgentName = strings.TrimSpace(agent.FirstName + " " + agent.LastName)
+	entry.UnitName = agent.Unit.UnitName
+	entry.DepartmentName = ticket.UserID.Department.DepartmentName
+	entry.CategoryName = ticket.Category.CategoryName
+	return nil
+}
+
+// entry retrieves a time entry of a ticket.
+func (ts *DefaultTimeTrackingService) entry(ticketID, id uint) (*models.TimeEntry, error) {
+	entry, err := ts.TimeEntryDBModel.GetTimeEntryByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if entry.TicketID != ticketID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return entry, nil
+}
+
+func validateTimeEntry(entry *models.TimeEntry) error {
+	if entry.ActivityType == "" {
+		entry.ActivityType = models.ActivityOther
+	}
+	if !isActivityType(entry.ActivityType) {
+		return fmt.Errorf("%w: unknown activity_type %q", ErrInvalidTimeEntry, entry.ActivityType)
+	}
+	if entry.Minutes < 1 || entry.Minutes > MaxEntryMinutes {
+		return fmt.Errorf("%w: minutes must be between 1 and %d", ErrInvalidTimeEntry, MaxEntryMinutes)
+	}
+	if entry.WorkedAt.After(time.Now().Add(time.Minute)) {
+		return fmt.Errorf("%w: worked_at is in the future", ErrInvalidTimeEntry)
+	}
+	return nil
+}
+
+func isActivityType(activityType string) bool {
+	for _, t := range models.ActivityTypes {
+		if t == activityType {
+			return true
+		}
+	}
+	return false
+}
@@ -20,6 +20,7 @@ type UserServiceInterface interface {
 type DefaultUserService struct {
 	DB          *gorm.DB
 	UserDBModel *models.UserDBModel
+	Events      EventPublisher
 	// Add any dependencies or data needed for the service
 }
 
@@ -41,11 +42,12 @@ func (ps *DefaultUserService) GetAllUsers() (*[]models.Users, error) {
 
 // CreateUser creates a new user.
 func (ps *DefaultUserService) CreateUser(user *models.Users) error {
-	err := ps.UserDBModel.CreateUser(user)
-	if err != nil {
-		return err
-	}
-	return nil
+	return ps.UserDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewUserDBModel(tx).CreateUser(user); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventUserCreated, userEventPayload(user))
+	})
 }
 
 // CreateUser creates a new user.
@@ -59,7 +61,12 @@ func (ps *DefaultUserService) GetUserByID(id uint) (*models.Users, error) {
 
 // UpdateUser updates an existing users.
 func (ps *DefaultUserService) UpdateUser(user *models.Users) (*models.Users, error) {
-	err := ps.UserDBModel.UpdateUser(user)
+	err := ps.UserDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewUserDBModel(tx).UpdateUser(user); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventUserUpdated, userEventPayload(user))
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -69,10 +76,23 @@ func (ps *DefaultUserService) UpdateUser(user *models.Users) (*models.Users, err
 // DeleteUser deletes an user by ID.
 func (ps *DefaultUserService) DeleteUser(userID uint) (bool, error) {
 	status := false
-	err := ps.UserDBModel.DeleteUser(userID)
+	err := ps.UserDBModel.DB.Transaction(func(tx *gorm.DB) error {
+		if err := models.NewUserDBModel(tx).DeleteUser(userID); err != nil {
+			return err
+		}
+		return publish(tx, ps.Events, models.EventUserDeleted, models.UserRef{UserID: userID})
+	})
 	if err != nil {
 		return status, err
 	}
 	status = true
 	return status, nil
 }
+
+// userEventPayload is the user as published to other systems, without
+// login credentials.
+func userEventPayload(user *models.Users) models.Users {
+	payload := *user
+	payload.Credentials = models.UsersLoginCredentials{}
+	return payload
+}
@@ -0,0 +1,194 @@
+// backend/services/watcher_service.go
+
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWatcher   = errors.New("invalid watcher")
+	ErrWatcherForbidden = errors.New("only the requester or agents add watchers")
+)
+
+// mentionPattern matches @mentions: the part of an agent's email address
+// before the @, or the whole address. An @ inside a word, as in an email
+// address written in the text, is not a mention.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.%+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// Mentions returns the handles mentioned in a comment body, once each, in
+// the order they first appear.
+func Mentions(body string) []string {
+	seen := map[string]bool{}
+	var handles []string
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		// A sentence may end right after a mention.
+		handle := strings.ToLower(strings.TrimRight(match[1], "."))
+		if handle == "" || seen[handle] {
+			continue
+		}
+		seen[handle] = true
+		handles = append(handles, handle)
+	}
+	return handles
+}
+
+// WatcherServiceInterface provides methods for the watchers of tickets.
+type WatcherServiceInterface interface {
+	AddWatcher(ticketID uint, watcher *models.TicketWatcher, userID, agentID uint) (bool, error)
+	RemoveWatcher(ticketID, watcherID uint) (bool, error)
+	GetWatchers(ticketID uint) (*[]models.TicketWatcher, error)
+	ProcessMentions(tx *gorm.DB, comment *models.TicketComment) ([]uint, error)
+}
+
+// DefaultWatcherService is the default implementation of WatcherService
+type DefaultWatcherService struct {
+	DB             *gorm.DB
+	WatcherDBModel *models.WatcherDBModel
+	TicketDBModel  *models.TicketDBModel
+	AgentDBModel   *models.AgentDBModel
+	UserDBModel    *models.UserDBModel
+	Events         EventPublisher
+}
+
+// NewDefaultWatcherService creates a new DefaultWatcherService.
+func NewDefaultWatcherService(watcherDBModel *models.WatcherDBModel, ticketDBModel *models.TicketDBModel, agentDBModel *models.AgentDBModel, userDBModel *models.UserDBModel) *DefaultWatcherService {
+	return &DefaultWatcherService{
+		DB:             watcherDBModel.DB,
+		WatcherDBModel: watcherDBModel,
+		TicketDBModel:  ticketDBModel,
+		AgentDBModel:   agentDBModel,
+		UserDBModel:    userDBModel,
+	}
+}
+
+// AddWatcher adds a user, an agent or a CC address to the watchers of a
+// ticket, on behalf of the agent agentID or else the user userID, who must
+// be its requester. It reports false when they already watch it.
+func (ws *DefaultWatcherService) AddWatcher(ticketID uint, watcher *models.TicketWatcher, userID, agentID uint) (bool, error) {
+	ticket, err := ws.TicketDBModel.GetTicketByID(ticketID)
+	if err != nil {
+		return false, err
+	}
+	watcher.ID = 0
+	watcher.TicketID = ticketID
+	switch {
+	case agentID != 0:
+		watcher.AddedBy, watcher.AddedByType = agentID, models.RecipientAgent
+	case userID != 0 && userID == ticket.UserID.ID:
+		watcher.AddedBy, watcher.AddedByType = userID, models.RecipientUser
+	default:
+		return false, ErrWatcherForbidden
+	}
+	if watcher.Source == "" {
+		watcher.Source = models.WatcherSourceManual
+	}
+	switch watcher.WatcherType {
+	case models.RecipientUser:
+		if _, err := ws.UserDBModel.GetUserByID(watcher.RecipientID); err != nil {
+			return false, fmt.Errorf("%w: unknown user %d", ErrInvalidWatcher, watcher.RecipientID)
+		}
+		watcher.Email = ""
+	case models.RecipientAgent:
+		if _, err := ws.AgentDBModel.GetAgentByID(watcher.RecipientID); err != nil {
+			return false, fmt.Errorf("%w: unknown agent %d", ErrInvalidWatcher, watcher.RecipientID)
+		}
+		watcher.Email = ""
+	case models.WatcherCC:
+		address, err := mail.ParseAddress(watcher.Email)
+		if err != nil {
+			return false, fmt.Errorf("%w: invalid email address %q", ErrInvalidWatcher, watcher.Email)
+		}
+		watcher.RecipientID = 0
+		watcher.Email = strings.ToLower(address.Address)
+	default:
+		return false, fmt.Errorf("%w: watcher_type must be user, agent or cc", ErrInvalidWatcher)
+	}
+	return ws.WatcherDBModel.AddWatcher(watcher)
+}
+
+// RemoveWatcher removes a watcher from a ticket.
+func (ws *DefaultWatcherService) RemoveWatcher(ticketID, watcherID uint) (bool, error) {
+	watcher, err := ws.WatcherDBModel.GetWatcherByID(watcherID)
+	if err != nil {
+		return false, err
+	}
+	if watcher.TicketID != ticketID {
+		return false, gorm.ErrRecordNotFound
+	}
+	if err := ws.WatcherDBModel.RemoveWatcher(watcherID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// GetWatchers retrieves the watchers of a ticket.
+func (ws *DefaultWatcherService) GetWatchers(ticketID uint) (*[]models.TicketWatcher, error) {
+	if _, err := ws.TicketDBModel.GetTicketByID(ticketID); err != nil {
+		return nil, err
+	}
+	return ws.WatcherDBModel.GetWatchers(ticketID)
+}
+
+// ProcessMentions adds the agents mentioned in a new comment to the
+// watchers of its ticket and publishes ticket.mentioned for each, within
+// the transaction that created the comment. Only agents mention: the
+// comments of requesters are ignored, so that they cannot pull agents into
+// their tickets. Handles that match no agent are ignored, as are agents
+// mentioning themselves. It returns the mentioned agents.
+func (ws *DefaultWatcherService) ProcessMentions(tx *gorm.DB, comment *models.TicketComment) ([]uint, error) {
+	if comment.AgentID == 0 {
+		return nil, nil
+	}
+	handles := Mentions(comment.Body)
+	if len(handles) == 0 {
+		return nil, nil
+	}
+	agentDBModel := models.NewAgentDBModel(tx)
+	watcherDBModel := models.NewWatcherDBModel(tx)
+	var mentioned []uint
+	seen := map[uint]bool{}
+	for _, handle := range handles {
+		agent, err := agentDBModel.GetAgentByHandle(handle)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if agent.ID == comment.AgentID || seen[agent.ID] {
+			continue
+		}
+		seen[agent.ID] = true
+		watcher := models.TicketWatcher{
+			TicketID:    comment.TicketID,
+			WatcherType: models.RecipientAgent,
+			RecipientID: agent.ID,
+			Source:      models.WatcherSourceMention,
+			AddedBy:     comment.AgentID,
+			AddedByType: models.RecipientAgent,
+		}
+		if _, err := watcherDBModel.AddWatcher(&watcher); err != nil {
+			return nil, err
+		}
+		mention := models.Mention{
+			TicketID:      comment.TicketID,
+			CommentID:     comment.ID,
+			AgentID:       agent.ID,
+			AuthorAgentID: comment.AgentID,
+			IsInternal:    comment.IsInternal,
+		}
+		if err := publish(tx, ws.Events, models.EventTicketMentioned, mention); err != nil {
+			return nil, err
+		}
+		mentioned = append(mentioned, agent.ID)
+	}
+	return mentioned, nil
+}
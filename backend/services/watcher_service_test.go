@@ -0,0 +1,42 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/shuttlersit/service-desk/backend/models"
+)
+
+func TestOnlyAgentCommentsMention(t *testing.T) {
+	db := openTestDB(t, &models.Agents{}, &models.TicketWatcher{})
+	agent := &models.Agents{AgentEmail: "dana@example.com"}
+	if err := db.Create(agent).Error; err != nil {
+		t.Fatal(err)
+	}
+	ws := NewDefaultWatcherService(models.NewWatcherDBModel(db), nil, models.NewAgentDBModel(db), nil)
+
+	requester := &models.TicketComment{ID: 1, TicketID: 7, UserID: 3, Body: "Hi @dana, please look"}
+	mentioned, err := ws.ProcessMentions(db, requester)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mentioned) != 0 {
+		t.Fatalf("requester comment mentioned %v, want none", mentioned)
+	}
+
+	colleague := &models.TicketComment{ID: 2, TicketID: 7, AgentID: 9, Body: "@dana can you take this?"}
+	mentioned, err = ws.ProcessMentions(db, colleague)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mentioned) != 1 || mentioned[0] != agent.ID {
+		t.Fatalf("agent comment mentioned %v, want [%d]", mentioned, agent.ID)
+	}
+
+	var watchers []models.TicketWatcher
+	if err := db.Find(&watchers).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(watchers) != 1 || watchers[0].AddedBy != 9 {
+		t.Fatalf("watchers = %+v, want the one added by agent 9", watchers)
+	}
+}
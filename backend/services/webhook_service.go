@@ -0,0 +1,434 @@
+// backend/services/webhook_service.go
+
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shuttlersit/service-desk/backend/events"
+	"github.com/shuttlersit/service-desk/backend/models"
+	"github.com/shuttlersit/service-desk/backend/webhook"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidWebhookURL   = errors.New("webhook URL must be an absolute http or https URL")
+	ErrUnknownWebhookEvent = errors.New("unknown webhook event")
+	ErrNoWebhookEvents     = errors.New("webhook must subscribe to at least one event")
+	ErrWebhookInactive     = errors.New("webhook is not active")
+)
+
+// WebhookConfig configures the delivery of outgoing webhooks.
+type WebhookConfig struct {
+	// MaxAttempts is the number of delivery attempts before a delivery is
+	// marked as failed.
+	MaxAttempts int
+	// RetryBackoff is the delay before the first retry. It doubles with
+	// every failed attempt.
+	RetryBackoff time.Duration
+	// BatchSize bounds the deliveries sent per run.
+	BatchSize int
+	// Timeout bounds each request.
+	Timeout time.Duration
+}
+
+// DefaultWebhookConfig returns the default webhook settings.
+func DefaultWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		MaxAttempts:  10,
+		RetryBackoff: 30 * time.Second,
+		BatchSize:    50,
+		Timeout:      10 * time.Second,
+	}
+}
+
+// maxResponseBody bounds the part of a response kept in the delivery log.
+const maxResponseBody = 2048
+
+// WebhookServiceInterface provides methods for managing and delivering webhooks.
+type WebhookServiceInterface interface {
+	CreateSubscription(subscription *models.WebhookSubscription) error
+	UpdateSubscription(subscription *models.WebhookSubscription) (*models.WebhookSubscription, error)
+	GetSubscriptionByID(id uint) (*models.WebhookSubscription, error)
+	GetAllSubscriptions() (*[]models.WebhookSubscription, error)
+	DeleteSubscription(id uint) (bool, error)
+	Enqueue(event string, payload interface{}) error
+	HandleEvent(ctx context.Context, e events.Event) error
+	DeliverPending(ctx context.Context) (int, error)
+	GetDeliveries(subscriptionID uint, limit int) (*[]models.WebhookDelivery, error)
+	ReplayDelivery(subscriptionID, deliveryID uint) (*models.WebhookDelivery, error)
+}
+
+// DefaultWebhookService is the default implementation of WebhookService
+type DefaultWebhookService struct {
+	DB             *gorm.DB
+	WebhookDBModel *models.WebhookDBModel
+	Client         *http.Client
+	Config         WebhookConfig
+}
+
+// NewDefaultWebhookService creates a new DefaultWebhookService.
+func NewDefaultWebhookService(webhookDBModel *models.WebhookDBModel, config WebhookConfig) *DefaultWebhookService {
+	defaults := DefaultWebhookConfig()
+	if config.MaxAttempts <= 0 {
+		config.MaxAttempts = defaults.MaxAttempts
+	}
+	if config.RetryBackoff <= 0 {
+		config.RetryBackoff = defaults.RetryBackoff
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaults.BatchSize
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaults.Timeout
+	}
+	return &DefaultWebhookService{
+		WebhookDBModel: webhookDBModel,
+		Client:         &http.Client{Timeout: config.Timeout},
+		Config:         config,
+	}
+}
+
+// CreateSubscription validates and stores a subscription. A secret is
+// generated when none is given; it is only returned by this call.
+func (ws *DefaultWebhookService) CreateSubscription(subscription *models.WebhookSubscription) error {
+	if err := validateSubscription(subscription); err != nil {
+		return err
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = randomHex(32)
+	}
+	return ws.WebhookDBModel.CreateSubscription(subscription)
+}
+
+// UpdateSubscription updates a subscription, keeping its secret unless a
+// new one is given.
+func (ws *DefaultWebhookService) UpdateSubscription(subscription *models.WebhookSubscription) (*models.WebhookSubscription, error) {
+	existing, err := ws.WebhookDBModel.GetSubscriptionByID(subscription.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	if subscription.Secret == "" {
+		subscription.Secret = existing.Secret
+	}
+	subscription.Model = existing.Model
+	subscription.CreatedBy = existing.CreatedBy
+	subscription.CreatedAt = existing.CreatedAt
+	if err := ws.WebhookDBModel.UpdateSubscription(subscription); err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+func validateSubscription(subscription *models.WebhookSubscription) error {
+	u, err := url.Parse(subscription.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ErrInvalidWebhookURL
+	}
+	if len(subscription.Events) == 0 {
+		return ErrNoWebhookEvents
+	}
+	for _, e := range subscription.Events {
+		if e != models.WildcardEvent && !models.IsKnownEvent(e) {
+			return fmt.Errorf("%w: %s", ErrUnknownWebhookEvent, e)
+		}
+	}
+	return nil
+}
+
+// GetSubscriptionByID retrieves a subscription without its secret.
+func (ws *DefaultWebhookService) GetSubscriptionByID(id uint) (*models.WebhookSubscription, error) {
+	subscription, err := ws.WebhookDBModel.GetSubscriptionByID(id)
+	if err != nil {
+		return nil, err
+	}
+	subscription.Secret = ""
+	return subscription, nil
+}
+
+// GetAllSubscriptions retrieves all subscriptions without their secrets.
+func (ws *DefaultWebhookService) GetAllSubscriptions() (*[]models.WebhookSubscription, error) {
+	subscriptions, err := ws.WebhookDBModel.GetAllSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	for i := range *subscriptions {
+		(*subscriptions)[i].Secret = ""
+	}
+	return subscriptions, nil
+}
+
+// DeleteSubscription deletes a subscription by ID.
+func (ws *DefaultWebhookService) DeleteSubscription(id uint) (bool, error) {
+	if err := ws.WebhookDBModel.DeleteSubscription(id); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// HandleEvent queues a domain event for every active subscription that
+// wants it. It is registered on the event bus; an event delivered again by
+// the bus is not queued twice.
+func (ws *DefaultWebhookService) HandleEvent(ctx context.Context, e events.Event) error {
+	return ws.enqueue(webhook.Envelope{
+		ID:         e.ID,
+		Event:      e.Name,
+		OccurredAt: e.OccurredAt,
+		Data:       e.Payload,
+	})
+}
+
+// Enqueue queues an event that did not come from the event bus for every
+// active subscription that wants it.
+func (ws *DefaultWebhookService) Enqueue(event string, payload interface{}) error {
+	return ws.enqueue(webhook.Envelope{
+		ID:         randomHex(16),
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       payload,
+	})
+}
+
+// EnqueueTo queues an event for one active subscription, whatever events it
+// subscribes to. Automation rules use it to call a chosen webhook.
+func (ws *DefaultWebhookService) EnqueueTo(subscriptionID uint, event string, payload interface{}) error {
+	subscription, err := ws.WebhookDBModel.GetSubscriptionByID(subscriptionID)
+	if err != nil {
+		return err
+	}
+	if !subscription.Active {
+		return ErrWebhookInactive
+	}
+	envelope := webhook.Envelope{
+		ID:         randomHex(16),
+		Event:      event,
+		OccurredAt: time.Now().UTC(),
+		Data:       payload,
+	}
+	body, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return ws.WebhookDBModel.CreateDelivery(&models.WebhookDelivery{
+		SubscriptionID: subscription.ID,
+		EventID:        envelope.ID,
+		Event:          event,
+		Payload:        string(body),
+		Status:         models.WebhookStatusPending,
+		NextAttemptAt:  time.Now(),
+	})
+}
+
+func (ws *DefaultWebhookService) enqueue(envelope webhook.Envelope) error {
+	subscriptions, err := ws.WebhookDBModel.GetActiveSubscriptions()
+	if err != nil {
+		return err
+	}
+	event := envelope.Event
+	var body []byte
+	for _, subscription := range *subscriptions {
+		if !subscription.Subscribes(event) {
+			continue
+		}
+		queued, err := ws.WebhookDBModel.HasDelivery(subscription.ID, envelope.ID)
+		if err != nil {
+			return err
+		}
+		if queued {
+			continue
+		}
+		if body == nil {
+			if body, err = json.Marshal(envelope); err != nil {
+				return err
+			}
+		}
+		err = ws.WebhookDBModel.CreateDelivery(&models.WebhookDelivery{
+			SubscriptionID: subscription.ID,
+			EventID:        envelope.ID,
+			Event:          event,
+			Payload:        string(body),
+			Status:         models.WebhookStatusPending,
+			NextAttemptAt:  time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeliverPending sends the deliveries that are due and returns how many
+// succeeded. Failed deliveries are retried with exponential backoff until
+// MaxAttempts is reached.
+func (ws *DefaultWebhookService) DeliverPending(ctx context.Context) (int, error) {
+	now := time.Now()
+	staleBefore := now.Add(-2 * ws.Config.Timeout)
+	due, err := ws.WebhookDBModel.GetDueDeliveries(now, staleBefore, ws.Config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	delivered := 0
+	for i := range *due {
+		if err := ctx.Err(); err != nil {
+			return delivered, err
+		}
+		delivery := &(*due)[i]
+		claimed, err := ws.WebhookDBModel.ClaimDelivery(delivery.ID, staleBefore)
+		if err != nil {
+			return delivered, err
+		}
+		if !claimed {
+			continue
+		}
+		if err := ws.deliver(ctx, delivery); err != nil {
+			return delivered, err
+		}
+		if delivery.Status == models.WebhookStatusDelivered {
+			delivered++
+		}
+	}
+	return delivered, nil
+}
+
+// deliver sends one claimed delivery and records the outcome.
+func (ws *DefaultWebhookService) deliver(ctx context.Context, delivery *models.WebhookDelivery) error {
+	delivery.Attempts++
+	subscription, err := ws.WebhookDBModel.GetSubscriptionByID(delivery.SubscriptionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		delivery.Status = models.WebhookStatusFailed
+		delivery.LastError = "subscription deleted"
+		return ws.WebhookDBModel.UpdateDelivery(delivery)
+	}
+	if err != nil {
+		return err
+	}
+
+	status, body, err := ws.post(ctx, subscription, delivery)
+	delivery.ResponseStatus = status
+	delivery.ResponseBody = body
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("endpoint returned %d", status)
+	}
+	if err != nil {
+		delivery.LastError = err.Error()
+		if delivery.Attempts >= ws.Config.MaxAttempts {
+			delivery.Status = models.WebhookStatusFailed
+		} else {
+			delivery.Status = models.WebhookStatusPending
+			delivery.NextAttemptAt = time.Now().Add(backoff(ws.Config.RetryBackoff, delivery.Attempts))
+		}
+	} else {
+		now := time.Now()
+		delivery.Status = models.WebhookStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	}
+	return ws.WebhookDBModel.UpdateDelivery(delivery)
+}
+
+// post sends the signed payload and returns the response status and the
+// start of the response body.
+func (ws *DefaultWebhookService) post(ctx context.Context, subscription *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "service-desk-webhooks")
+	req.Header.Set(webhook.HeaderEvent, delivery.Event)
+	req.Header.Set(webhook.HeaderDelivery, delivery.EventID)
+	req.Header.Set(webhook.HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(webhook.HeaderSignature, webhook.Sign(subscription.Secret, timestamp, body))
+
+	resp, err := ws.Client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<20))
+	return resp.StatusCode, string(respBody), nil
+}
+
+// Run delivers due webhooks every interval until ctx is cancelled.
+func (ws *DefaultWebhookService) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if _, err := ws.DeliverPending(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("webhooks: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetDeliveries retrieves the most recent deliveries of a subscription.
+func (ws *DefaultWebhookService) GetDeliveries(subscriptionID uint, limit int) (*[]models.WebhookDelivery, error) {
+	return ws.WebhookDBModel.GetDeliveriesBySubscriptionID(subscriptionID, limit)
+}
+
+// ReplayDelivery queues a delivery again as a new entry of the log. The
+// event keeps its ID so receivers can recognise it.
+func (ws *DefaultWebhookService) ReplayDelivery(subscriptionID, deliveryID uint) (*models.WebhookDelivery, error) {
+	original, err := ws.WebhookDBModel.GetDeliveryByID(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	if original.SubscriptionID != subscriptionID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	replay := &models.WebhookDelivery{
+		SubscriptionID: original.SubscriptionID,
+		EventID:        original.EventID,
+		Event:          original.Event,
+		Payload:        original.Payload,
+		Status:         models.WebhookStatusPending,
+		NextAttemptAt:  time.Now(),
+		ReplayOf:       original.ID,
+	}
+	if err := ws.WebhookDBModel.CreateDelivery(replay); err != nil {
+		return nil, err
+	}
+	return replay, nil
+}
+
+// backoff returns base doubled for every attempt after the first, capped
+// at maxRetryBackoff.
+func backoff(base time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryBackoff {
+		delay = maxRetryBackoff
+	}
+	return delay
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,21 @@
+// backend/storage/blob.go
+
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrBlobNotFound is returned when a key does not exist in the store.
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore is the interface implemented by attachment storage backends.
+// Keys are slash separated paths relative to the root of the store.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+	Exists(ctx context.Context, key string) (bool, error)
+}
@@ -0,0 +1,99 @@
+// backend/storage/local.go
+
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalStore keeps blobs as plain files below a root directory.
+type LocalStore struct {
+	Root string
+}
+
+// NewLocalStore creates a new LocalStore, creating the root directory if needed.
+func NewLocalStore(root string) (*LocalStore, error) {
+	if err := os.MkdirAll(root, 0o750); err != nil {
+		return nil, err
+	}
+	return &LocalStore{
+		Root: root,
+	}, nil
+}
+
+// path resolves a key to a file path and refuses keys escaping the root.
+func (ls *LocalStore) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(ls.Root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes a blob to disk. The file is written to a temporary name first
+// so readers never observe a partially written blob.
+func (ls *LocalStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error {
+	p, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0o750); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+// Get opens a blob for reading.
+func (ls *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	p, err := ls.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if os.IsNotExist(err) {
+		return nil, ErrBlobNotFound
+	}
+	return f, err
+}
+
+// Delete removes a blob. Deleting a missing blob is not an error.
+func (ls *LocalStore) Delete(ctx context.Context, key string) error {
+	p, err := ls.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// Exists reports whether a blob is present.
+func (ls *LocalStore) Exists(ctx context.Context, key string) (bool, error) {
+	p, err := ls.path(key)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(p)
+	if os.IsNotExist(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestS3SignatureV4(t *testing.T) {
+	at := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name          string
+		config        S3Config
+		method        string
+		key           string
+		url           string
+		authorization string
+	}{
+		{
+			name: "virtual hosted",
+			config: S3Config{
+				Endpoint:  "https://s3.eu-west-1.amazonaws.com",
+				Region:    "eu-west-1",
+				Bucket:    "attachments",
+				AccessKey: "AKIDEXAMPLE",
+				SecretKey: "wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY",
+			},
+			method: http.MethodPut,
+			key:    "tickets/42/report final.pdf",
+			url:    "https://attachments.s3.eu-west-1.amazonaws.com/tickets/42/report%20final.pdf",
+			authorization: "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20240115/eu-west-1/s3/aws4_request, " +
+				"SignedHeaders=host;x-amz-content-sha256;x-amz-date, " +
+				"Signature=cfe0d70715d12e6139e97d37671b8340d172414facc46db7a723efd902c84613",
+		},
+		{
+			name: "path style with default region",
+			config: S3Config{
+				Endpoint:  "http://minio.local:9000",
+				Bucket:    "attachments",
+				AccessKey: "minio",
+				SecretKey: "minio-secret",
+				PathStyle: true,
+			},
+			method: http.MethodGet,
+			key:    "/a+b.txt",
+			url:    "http://minio.local:9000/attachments/a%2Bb.txt",
+			authorization: "AWS4-HMAC-SHA256 Credential=minio/20240115/us-east-1/s3/aws4_request, " +
+				"SignedHeaders=host;x-amz-content-sha256;x-amz-date, " +
+				"Signature=1a95ab62cf70355a3288e82068055eb7560e57828e175cdcadf4ff65b1d686e2",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewS3Store(tt.config)
+			s.now = func() time.Time { return at }
+			req, err := s.newRequest(context.Background(), tt.method, tt.key, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := req.URL.String(); got != tt.url {
+				t.Fatalf("url = %s, want %s", got, tt.url)
+			}
+			s.sign(req)
+			if got := req.Header.Get("X-Amz-Date"); got != "20240115T100000Z" {
+				t.Errorf("X-Amz-Date = %s", got)
+			}
+			if got := req.Header.Get("Authorization"); got != tt.authorization {
+				t.Errorf("Authorization = %s\nwant %s", got, tt.authorization)
+			}
+		})
+	}
+}
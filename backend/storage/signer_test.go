@@ -0,0 +1,40 @@
+package storage
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestURLSigner(t *testing.T) {
+	signer := NewURLSigner("secret")
+	signed, err := url.Parse(signer.Sign("/attachments/7/download", time.Now().Add(time.Hour)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := signed.Query()
+	expired, _ := url.Parse(signer.Sign("/attachments/7/download", time.Now().Add(-time.Minute)))
+
+	tests := []struct {
+		name      string
+		signer    *URLSigner
+		path      string
+		expires   string
+		signature string
+		want      error
+	}{
+		{"valid link", signer, signed.Path, q.Get("expires"), q.Get("signature"), nil},
+		{"other path", signer, "/attachments/8/download", q.Get("expires"), q.Get("signature"), ErrInvalidSignature},
+		{"extended expiry", signer, signed.Path, "9999999999", q.Get("signature"), ErrInvalidSignature},
+		{"other secret", NewURLSigner("other"), signed.Path, q.Get("expires"), q.Get("signature"), ErrInvalidSignature},
+		{"missing signature", signer, signed.Path, q.Get("expires"), "", ErrInvalidSignature},
+		{"expired link", signer, expired.Path, expired.Query().Get("expires"), expired.Query().Get("signature"), ErrLinkExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.signer.Verify(tt.path, tt.expires, tt.signature); err != tt.want {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package webhook
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	// echo -n '1700000000.{"id":"evt-1"}' | openssl dgst -sha256 -hmac secret
+	want := "sha256=9345f9374bb95cbb3d927d2cc0decf6b672b5da8a5fd2fb364206dea648910b0"
+	if got := Sign("secret", 1700000000, []byte(`{"id":"evt-1"}`)); got != want {
+		t.Fatalf("Sign = %s, want %s", got, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":"evt-1","event":"ticket.created"}`)
+	now := time.Now().Unix()
+	ts := strconv.FormatInt(now, 10)
+	old := strconv.FormatInt(now-600, 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		timestamp string
+		body      []byte
+		want      error
+	}{
+		{"valid", "secret", Sign("secret", now, body), ts, body, nil},
+		{"padded headers", "secret", " " + Sign("secret", now, body) + " ", " " + ts, body, nil},
+		{"other secret", "other", Sign("secret", now, body), ts, body, ErrInvalidSignature},
+		{"tampered body", "secret", Sign("secret", now, body), ts, []byte(`{"id":"evt-2"}`), ErrInvalidSignature},
+		{"timestamp not signed", "secret", Sign("secret", now-1, body), ts, body, ErrInvalidSignature},
+		{"missing signature", "secret", "", ts, body, ErrInvalidSignature},
+		{"malformed timestamp", "secret", Sign("secret", now, body), "yesterday", body, ErrInvalidSignature},
+		{"replayed", "secret", Sign("secret", now-600, body), old, body, ErrStaleTimestamp},
+		{"from the future", "secret", Sign("secret", now+600, body), strconv.FormatInt(now+600, 10), body, ErrStaleTimestamp},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := Verify(tt.secret, tt.signature, tt.timestamp, tt.body, 5*time.Minute); err != tt.want {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}